@@ -0,0 +1,196 @@
+package analysis
+
+import (
+	"math"
+	"testing"
+
+	"github.com/antha-lang/antha/antha/AnthaStandardLibrary/Packages/doe"
+)
+
+// surface is a quadratic response in coded units of factors A and B, whose
+// levels are 0, 5 and 10
+func surface(a, b float64) float64 {
+	return 10 + 2*a - 3*b + 1.5*a*b - 4*a*a - 2*b*b
+}
+
+func surfaceRuns(noise []float64) []doe.Run {
+	factors := []doe.DOEPair{
+		doe.Pair("A", []interface{}{0.0, 5.0, 10.0}),
+		doe.Pair("B", []interface{}{0.0, 5.0, 10.0}),
+		doe.Pair("Buffer", []interface{}{"PBS"}),
+	}
+	runs := doe.AllCombinations(factors)
+	// replicate the centre point
+	for range noise {
+		centre := doe.Copy(runs[4])
+		centre.RunNumber = len(runs) + 1
+		runs = append(runs, centre)
+	}
+
+	for i, run := range runs {
+		a, _ := run.GetFactorValue("A")
+		b, _ := run.GetFactorValue("B")
+		y := surface(a.(float64)/5-1, b.(float64)/5-1)
+		if i >= 9 {
+			y += noise[i-9]
+		}
+		runs[i] = doe.AddNewResponseFieldandValue(run, "Yield", y)
+	}
+	return runs
+}
+
+func near(a, b float64) bool {
+	return math.Abs(a-b) < 1e-9
+}
+
+func TestFitQuadratic(t *testing.T) {
+	runs := surfaceRuns([]float64{0.1, -0.1, 0.05})
+	fit, err := FitModel(runs, "Yield", doe.Quadratic)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := map[string]float64{"A": 2, "B": -3, "A*B": 1.5, "A^2": -4, "B^2": -2}
+	if len(fit.Terms) != 6 {
+		t.Fatalf("expected 6 terms, got %v", fit.Terms)
+	}
+	for i, term := range fit.Terms {
+		if e, found := expected[term]; found && math.Abs(fit.Coefficients[i]-e) > 0.05 {
+			t.Errorf("term %s: expected coefficient %g, got %g", term, e, fit.Coefficients[i])
+		}
+		if term != "Intercept" && fit.PValues[i] > 0.01 {
+			t.Errorf("term %s: expected significant p value, got %g", term, fit.PValues[i])
+		}
+	}
+
+	if fit.RSquared < 0.99 {
+		t.Errorf("expected R squared near 1, got %g", fit.RSquared)
+	}
+
+	sources := make(map[string]ANOVARow)
+	for _, row := range fit.ANOVA {
+		sources[row.Source] = row
+	}
+	for _, source := range []string{ModelSource, ResidualSource, LackOfFitSource, PureErrorSource, TotalSource} {
+		if _, found := sources[source]; !found {
+			t.Errorf("no %s row in ANOVA table", source)
+		}
+	}
+	if row := sources[ResidualSource]; row.DF != 12-6 {
+		t.Errorf("expected 6 residual degrees of freedom, got %d", row.DF)
+	}
+	if lof, pe, res := sources[LackOfFitSource], sources[PureErrorSource], sources[ResidualSource]; lof.DF+pe.DF != res.DF || !near(lof.SumOfSquares+pe.SumOfSquares, res.SumOfSquares) {
+		t.Errorf("lack of fit %v and pure error %v do not sum to residual %v", lof, pe, res)
+	}
+	if row := sources[ModelSource]; !near(row.SumOfSquares+sources[ResidualSource].SumOfSquares, sources[TotalSource].SumOfSquares) {
+		t.Errorf("model and residual sums of squares do not sum to total")
+	}
+}
+
+func TestFitLinear(t *testing.T) {
+	runs := surfaceRuns(nil)
+	fit, err := FitModel(runs, "Yield", doe.Linear)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(fit.Terms) != 3 {
+		t.Fatalf("expected 3 terms, got %v", fit.Terms)
+	}
+	if !near(fit.Coefficients[1], 2) || !near(fit.Coefficients[2], -3) {
+		t.Errorf("expected main effects 2 and -3, got %v", fit.Coefficients)
+	}
+	// without replicates there is no pure error
+	for _, row := range fit.ANOVA {
+		if row.Source == LackOfFitSource || row.Source == PureErrorSource {
+			t.Errorf("unexpected %s row in ANOVA table", row.Source)
+		}
+	}
+
+	if _, err := FitModel(runs[:5], "Yield", doe.Quadratic); err == nil {
+		t.Error("expected error for fewer runs than terms")
+	}
+	if _, err := FitModel(runs, "Titre", doe.Linear); err == nil {
+		t.Error("expected error for missing response")
+	}
+}
+
+func TestOptimum(t *testing.T) {
+	fit, err := FitModel(surfaceRuns(nil), "Yield", doe.Quadratic)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	best, err := fit.Optimum(Maximise)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the stationary point of the surface, which is inside the bounds
+	b := -2.625 / 3.71875
+	a := (2 + 1.5*b) / 8
+	if len(best.Factordescriptors) != 3 || best.Setpoints[2] != "PBS" {
+		t.Errorf("expected factors A, B and Buffer, got %v", best.Factordescriptors)
+	} else if !near(best.Setpoints[0].(float64), 5*(a+1)) || !near(best.Setpoints[1].(float64), 5*(b+1)) {
+		t.Errorf("expected optimum at %g, %g, got %v", 5*(a+1), 5*(b+1), best.Setpoints)
+	}
+	if y, err := best.GetResponseValue("Yield"); err != nil || !near(y.(float64), surface(a, b)) {
+		t.Errorf("expected yield %g, got %v (%v)", surface(a, b), y, err)
+	}
+
+	worst, err := fit.Optimum(Minimise)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if y, _ := worst.GetResponseValue("Yield"); !near(y.(float64), surface(-1, 1)) {
+		t.Errorf("expected minimum %g, got %v at %v", surface(-1, 1), y, worst.Setpoints)
+	}
+}
+
+func TestTables(t *testing.T) {
+	runs := surfaceRuns([]float64{0.1, -0.1})
+	fit, err := FitModel(runs, "Yield", doe.Interaction)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	coefficients, err := fit.CoefficientTable()
+	if err != nil {
+		t.Fatal(err)
+	} else if coefficients.Size() != len(fit.Terms) {
+		t.Errorf("expected %d coefficients, got %d", len(fit.Terms), coefficients.Size())
+	}
+
+	anova, err := fit.ANOVATable()
+	if err != nil {
+		t.Fatal(err)
+	} else if anova.Size() != len(fit.ANOVA) {
+		t.Errorf("expected %d ANOVA rows, got %d", len(fit.ANOVA), anova.Size())
+	}
+
+	runTable, err := RunTable(runs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	predictions, err := fit.PredictionTable()
+	if err != nil {
+		t.Fatal(err)
+	}
+	joined, err := runTable.Join().On(RunNumberColumn).Inner(predictions, RunNumberColumn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if joined, err = joined.Cache(); err != nil {
+		t.Fatal(err)
+	}
+	if joined.Size() != len(runs) {
+		t.Errorf("expected %d joined rows, got %d", len(runs), joined.Size())
+	}
+	for _, col := range []string{"A", "Buffer", "Yield", "Yield Predicted", "Yield Residual"} {
+		found := false
+		for _, c := range joined.Schema().Columns {
+			found = found || string(c.Name) == col
+		}
+		if !found {
+			t.Errorf("no column %s in %v", col, joined.Schema().Columns)
+		}
+	}
+}
@@ -0,0 +1,364 @@
+// Part of the Antha language
+// Copyright (C) 2018 The Antha authors. All rights reserved.
+//
+// This program is free software; you can redistribute it and/or
+// modify it under the terms of the GNU General Public License
+// as published by the Free Software Foundation; either version 2
+// of the License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program; if not, write to the Free Software
+// Foundation, Inc., 51 Franklin Street, Fifth Floor, Boston, MA  02110-1301, USA.
+//
+// For more information relating to the software or licensing issues please
+// contact license@antha-lang.org or write to the Antha team c/o
+// Synthace Ltd. The London Bioscience Innovation Centre
+// 2 Royal College St, London NW1 0NH UK
+
+// Package analysis fits response surface models to the results of designed
+// experiments.
+//
+// Models are fitted by least squares to the coded factor values of the runs,
+// see doe.Coding, so coefficients are the change in the response between the
+// centre and the highest level of each factor. Levels of non-numeric factors
+// are treated as ordered in the same way as by the design generators.
+package analysis
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+
+	"github.com/antha-lang/antha/antha/AnthaStandardLibrary/Packages/doe"
+	"gonum.org/v1/gonum/mat"
+	"gonum.org/v1/gonum/stat/distuv"
+)
+
+// Fit is a least squares fit of a model to a response of a set of runs
+type Fit struct {
+	Response string
+	Model    doe.Model
+
+	// Factors are the factors of the runs with the levels found in the runs,
+	// in the order of the runs' factor descriptors. Factors with a single
+	// level are fixed and not part of the model.
+	Factors []doe.DOEPair
+	// Codings are the codings of the factors which are not fixed
+	Codings []doe.Coding
+
+	// Terms, Coefficients, StdErrors, TValues and PValues describe each term
+	// of the model in the order given by doe.Model.Terms. Standard errors,
+	// t values and p values are NaN if there are no residual degrees of
+	// freedom.
+	Terms        []string
+	Coefficients []float64
+	StdErrors    []float64
+	TValues      []float64
+	PValues      []float64
+
+	// RunNumbers, Observed and Fitted give the observed and fitted response
+	// of each run
+	RunNumbers []int
+	Observed   []float64
+	Fitted     []float64
+
+	RSquared         float64
+	AdjustedRSquared float64
+
+	// ANOVA is the analysis of variance of the fit, see ANOVARow
+	ANOVA []ANOVARow
+
+	coded [][]float64 // coded factor values of each run
+}
+
+// ANOVARow is a row of an analysis of variance table. MeanSquare, F and P are
+// NaN where they do not apply.
+type ANOVARow struct {
+	Source       string
+	DF           int
+	SumOfSquares float64
+	MeanSquare   float64
+	F            float64
+	P            float64
+}
+
+// Sources of variation in the ANOVA table. The residual is divided into lack
+// of fit and pure error if any runs are replicated.
+const (
+	ModelSource     = "Model"
+	ResidualSource  = "Residual"
+	LackOfFitSource = "Lack of fit"
+	PureErrorSource = "Pure error"
+	TotalSource     = "Total"
+)
+
+// responseValue converts the value of a response to a float64
+func responseValue(value interface{}) (float64, error) {
+	switch v := value.(type) {
+	case float64:
+		return v, nil
+	case float32:
+		return float64(v), nil
+	case int:
+		return float64(v), nil
+	case int64:
+		return float64(v), nil
+	case int32:
+		return float64(v), nil
+	case string:
+		return strconv.ParseFloat(v, 64)
+	case interface {
+		RawValue() float64
+	}:
+		return v.RawValue(), nil
+	}
+	return 0, fmt.Errorf("%v of type %T is not a number", value, value)
+}
+
+// runFactors returns the factors of the runs with the distinct levels found
+// in the runs
+func runFactors(runs []doe.Run) ([]doe.DOEPair, error) {
+	var factors []doe.DOEPair
+	for _, name := range runs[0].Factordescriptors {
+		pair, err := doe.MakePair(runs, name)
+		if err != nil {
+			return nil, err
+		}
+		if pair, err = doe.RemoveDuplicateLevels(pair); err != nil {
+			return nil, err
+		}
+		factors = append(factors, pair)
+	}
+	return factors, nil
+}
+
+// code returns the coded values of the non-fixed factors of the run
+func (f *Fit) code(run doe.Run) ([]float64, error) {
+	x := make([]float64, 0, len(f.Codings))
+	for _, c := range f.Codings {
+		level, err := run.GetFactorValue(c.Pair.Factor)
+		if err != nil {
+			return nil, err
+		}
+		v, err := c.Code(level)
+		if err != nil {
+			return nil, err
+		}
+		x = append(x, v)
+	}
+	return x, nil
+}
+
+// FitModel fits the model to the named response of the runs by least squares.
+// All runs must have the same factors as the first run and a numeric value
+// for the response.
+func FitModel(runs []doe.Run, response string, model doe.Model) (*Fit, error) {
+	if len(runs) == 0 {
+		return nil, fmt.Errorf("cannot fit %s model: no runs", model)
+	}
+
+	factors, err := runFactors(runs)
+	if err != nil {
+		return nil, err
+	}
+
+	f := &Fit{
+		Response: response,
+		Model:    model,
+		Factors:  factors,
+	}
+	var names []string
+	for _, factor := range factors {
+		if doe.IsFixedFactor(factor) {
+			continue
+		}
+		c, err := doe.NewCoding(factor)
+		if err != nil {
+			return nil, err
+		}
+		f.Codings = append(f.Codings, c)
+		names = append(names, factor.Factor)
+	}
+	f.Terms = model.Terms(names)
+	f.coded = make([][]float64, len(runs))
+
+	n, p := len(runs), len(f.Terms)
+	if n < p {
+		return nil, fmt.Errorf("%s model of %d factors has %d terms, at least as many runs are required, found %d", model, len(names), p, n)
+	}
+
+	x := make([][]float64, n)
+	for i, run := range runs {
+		if f.coded[i], err = f.code(run); err != nil {
+			return nil, fmt.Errorf("run %d: %s", run.RunNumber, err)
+		}
+		x[i] = model.Expand(f.coded[i])
+
+		value, err := run.GetResponseValue(response)
+		if err != nil {
+			return nil, fmt.Errorf("run %d: %s", run.RunNumber, err)
+		}
+		y, err := responseValue(value)
+		if err != nil {
+			return nil, fmt.Errorf("run %d: response %s: %s", run.RunNumber, response, err)
+		}
+		f.RunNumbers = append(f.RunNumbers, run.RunNumber)
+		f.Observed = append(f.Observed, y)
+	}
+
+	xtx := mat.NewDense(p, p, nil)
+	xty := make([]float64, p)
+	for i := range x {
+		for j := 0; j < p; j++ {
+			xty[j] += x[i][j] * f.Observed[i]
+			for k := 0; k < p; k++ {
+				xtx.Set(j, k, xtx.At(j, k)+x[i][j]*x[i][k])
+			}
+		}
+	}
+	var inv mat.Dense
+	if err := inv.Inverse(xtx); err != nil {
+		return nil, fmt.Errorf("the runs cannot estimate all terms of the %s model: %s", model, err)
+	}
+
+	f.Coefficients = make([]float64, p)
+	for j := range f.Coefficients {
+		for k := 0; k < p; k++ {
+			f.Coefficients[j] += inv.At(j, k) * xty[k]
+		}
+	}
+
+	var mean float64
+	for _, y := range f.Observed {
+		mean += y / float64(n)
+	}
+	var sse, sst float64
+	f.Fitted = make([]float64, n)
+	for i := range x {
+		f.Fitted[i] = dot(x[i], f.Coefficients)
+		sse += (f.Observed[i] - f.Fitted[i]) * (f.Observed[i] - f.Fitted[i])
+		sst += (f.Observed[i] - mean) * (f.Observed[i] - mean)
+	}
+
+	dfModel, dfResidual := p-1, n-p
+	mse := math.NaN()
+	if dfResidual > 0 {
+		mse = sse / float64(dfResidual)
+	}
+
+	f.StdErrors = make([]float64, p)
+	f.TValues = make([]float64, p)
+	f.PValues = make([]float64, p)
+	for j := range f.Coefficients {
+		f.StdErrors[j] = math.Sqrt(mse * inv.At(j, j))
+		f.TValues[j] = f.Coefficients[j] / f.StdErrors[j]
+		f.PValues[j] = math.NaN()
+		if dfResidual > 0 {
+			f.PValues[j] = 2 * distuv.StudentsT{Mu: 0, Sigma: 1, Nu: float64(dfResidual)}.Survival(math.Abs(f.TValues[j]))
+		}
+	}
+
+	f.RSquared = 1 - sse/sst
+	f.AdjustedRSquared = 1 - mse/(sst/float64(n-1))
+
+	f.ANOVA = append(f.ANOVA, newANOVARow(ModelSource, dfModel, sst-sse, dfResidual, mse))
+	f.ANOVA = append(f.ANOVA, newANOVARow(ResidualSource, dfResidual, sse, 0, math.NaN()))
+	if lof, pe, ok := lackOfFit(f.coded, f.Observed, sse, dfResidual); ok {
+		f.ANOVA = append(f.ANOVA, lof, pe)
+	}
+	f.ANOVA = append(f.ANOVA, ANOVARow{
+		Source:       TotalSource,
+		DF:           n - 1,
+		SumOfSquares: sst,
+		MeanSquare:   math.NaN(),
+		F:            math.NaN(),
+		P:            math.NaN(),
+	})
+
+	return f, nil
+}
+
+// newANOVARow returns the row of the ANOVA table for a source of variation,
+// testing it against the error mean square with errDF degrees of freedom if
+// errDF is positive
+func newANOVARow(source string, df int, ss float64, errDF int, errMS float64) ANOVARow {
+	row := ANOVARow{
+		Source:       source,
+		DF:           df,
+		SumOfSquares: ss,
+		MeanSquare:   math.NaN(),
+		F:            math.NaN(),
+		P:            math.NaN(),
+	}
+	if df > 0 {
+		row.MeanSquare = ss / float64(df)
+	}
+	if df > 0 && errDF > 0 {
+		row.F = row.MeanSquare / errMS
+		row.P = distuv.F{D1: float64(df), D2: float64(errDF)}.Survival(row.F)
+	}
+	return row
+}
+
+// lackOfFit returns the lack of fit and pure error rows of the ANOVA table,
+// which divide the residual sum of squares sse. The pure error is the
+// variation between runs with the same factor levels. ok is false unless
+// there are replicated runs and residual degrees of freedom left for lack of
+// fit.
+func lackOfFit(coded [][]float64, observed []float64, sse float64, dfResidual int) (lof, pe ANOVARow, ok bool) {
+	groups := make(map[string][]int)
+	var keys []string
+	for i, x := range coded {
+		key := fmt.Sprint(x)
+		if _, seen := groups[key]; !seen {
+			keys = append(keys, key)
+		}
+		groups[key] = append(groups[key], i)
+	}
+
+	dfPE := len(coded) - len(keys)
+	if dfPE <= 0 || dfResidual-dfPE <= 0 {
+		return lof, pe, false
+	}
+
+	var sspe float64
+	for _, key := range keys {
+		var mean float64
+		for _, i := range groups[key] {
+			mean += observed[i] / float64(len(groups[key]))
+		}
+		for _, i := range groups[key] {
+			sspe += (observed[i] - mean) * (observed[i] - mean)
+		}
+	}
+
+	pe = newANOVARow(PureErrorSource, dfPE, sspe, 0, math.NaN())
+	lof = newANOVARow(LackOfFitSource, dfResidual-dfPE, sse-sspe, dfPE, pe.MeanSquare)
+	return lof, pe, true
+}
+
+func dot(a, b []float64) float64 {
+	var s float64
+	for i := range a {
+		s += a[i] * b[i]
+	}
+	return s
+}
+
+// Predict returns the predicted response for the factor levels of the run
+func (f *Fit) Predict(run doe.Run) (float64, error) {
+	x, err := f.code(run)
+	if err != nil {
+		return 0, err
+	}
+	return f.predictCoded(x), nil
+}
+
+func (f *Fit) predictCoded(x []float64) float64 {
+	return dot(f.Model.Expand(x), f.Coefficients)
+}
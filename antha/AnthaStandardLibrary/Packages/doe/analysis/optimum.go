@@ -0,0 +1,157 @@
+// Part of the Antha language
+// Copyright (C) 2018 The Antha authors. All rights reserved.
+//
+// This program is free software; you can redistribute it and/or
+// modify it under the terms of the GNU General Public License
+// as published by the Free Software Foundation; either version 2
+// of the License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program; if not, write to the Free Software
+// Foundation, Inc., 51 Franklin Street, Fifth Floor, Boston, MA  02110-1301, USA.
+//
+// For more information relating to the software or licensing issues please
+// contact license@antha-lang.org or write to the Antha team c/o
+// Synthace Ltd. The London Bioscience Innovation Centre
+// 2 Royal College St, London NW1 0NH UK
+
+package analysis
+
+import (
+	"math"
+
+	"github.com/antha-lang/antha/antha/AnthaStandardLibrary/Packages/doe"
+)
+
+// Goal is the direction in which to optimise a response
+type Goal int
+
+const (
+	// Maximise the response
+	Maximise Goal = iota
+	// Minimise the response
+	Minimise
+)
+
+// maxSweeps limits the number of passes over the factors made when searching
+// for an optimum from each starting point
+const maxSweeps = 100
+
+// Optimum returns the factor levels within the bounds of the runs which give
+// the best predicted response, as a run with the predicted response as its
+// only response value. Numeric factors may take any value between their
+// lowest and highest level, other factors only their levels. Fixed factors
+// are set to their level.
+//
+// The search maximises one factor at a time, which is exact for each factor
+// since the models are at most quadratic in any one factor, starting from the
+// centre and from each run of the fit.
+func (f *Fit) Optimum(goal Goal) (doe.Run, error) {
+	sign := 1.0
+	if goal == Minimise {
+		sign = -1.0
+	}
+	objective := func(x []float64) float64 {
+		return sign * f.predictCoded(x)
+	}
+
+	// coded values of the levels of non-numeric factors
+	discrete := make([][]float64, len(f.Codings))
+	for i, c := range f.Codings {
+		if c.Numeric {
+			continue
+		}
+		for _, level := range c.Pair.Levels {
+			v, err := c.Code(level)
+			if err != nil {
+				return doe.Run{}, err
+			}
+			discrete[i] = append(discrete[i], v)
+		}
+	}
+
+	centre := make([]float64, len(f.Codings))
+	for i := range centre {
+		if discrete[i] != nil {
+			centre[i] = discrete[i][0]
+		}
+	}
+	starts := [][]float64{centre}
+	for _, x := range f.coded {
+		starts = append(starts, append([]float64{}, x...))
+	}
+
+	var best []float64
+	bestValue := math.Inf(-1)
+	for _, x := range starts {
+		for sweep := 0; sweep < maxSweeps; sweep++ {
+			var moved float64
+			for i := range x {
+				v := bestCoordinate(objective, x, i, discrete[i])
+				moved = math.Max(moved, math.Abs(v-x[i]))
+				x[i] = v
+			}
+			if moved < 1e-12 {
+				break
+			}
+		}
+		if value := objective(x); value > bestValue {
+			best, bestValue = x, value
+		}
+	}
+
+	var run doe.Run
+	j := 0
+	for _, factor := range f.Factors {
+		if doe.IsFixedFactor(factor) {
+			run = doe.AddNewFactorFieldandValue(run, factor.Factor, factor.Levels[0])
+		} else if len(factor.Levels) > 1 {
+			run = doe.AddNewFactorFieldandValue(run, factor.Factor, f.Codings[j].Decode(best[j]))
+			j++
+		}
+	}
+	run = doe.AddNewResponseFieldandValue(run, f.Response, sign*bestValue)
+	return run, nil
+}
+
+// bestCoordinate returns the value of x[i] which maximises the objective
+// with all other coordinates held constant, choosing from the given values
+// if there are any and from the range -1 to 1 otherwise
+func bestCoordinate(objective func([]float64) float64, x []float64, i int, values []float64) float64 {
+	old := x[i]
+	defer func() { x[i] = old }()
+
+	at := func(v float64) float64 {
+		x[i] = v
+		return objective(x)
+	}
+
+	candidates := values
+	if candidates == nil {
+		candidates = []float64{-1, 1}
+		// the objective is a quadratic a*t^2 + b*t + c in x[i]
+		c := at(0)
+		a := (at(1)+at(-1))/2 - c
+		b := (at(1) - at(-1)) / 2
+		if a < 0 {
+			if t := -b / (2 * a); t > -1 && t < 1 {
+				candidates = append(candidates, t)
+			}
+		}
+	}
+
+	// the maximum is one of the candidates, which need not include the current
+	// value
+	best, bestValue := candidates[0], at(candidates[0])
+	for _, v := range candidates[1:] {
+		if value := at(v); value > bestValue {
+			best, bestValue = v, value
+		}
+	}
+	return best
+}
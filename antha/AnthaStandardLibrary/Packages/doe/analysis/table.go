@@ -0,0 +1,226 @@
+// Part of the Antha language
+// Copyright (C) 2018 The Antha authors. All rights reserved.
+//
+// This program is free software; you can redistribute it and/or
+// modify it under the terms of the GNU General Public License
+// as published by the Free Software Foundation; either version 2
+// of the License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program; if not, write to the Free Software
+// Foundation, Inc., 51 Franklin Street, Fifth Floor, Boston, MA  02110-1301, USA.
+//
+// For more information relating to the software or licensing issues please
+// contact license@antha-lang.org or write to the Antha team c/o
+// Synthace Ltd. The London Bioscience Innovation Centre
+// 2 Royal College St, London NW1 0NH UK
+
+package analysis
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+
+	"github.com/antha-lang/antha/antha/AnthaStandardLibrary/Packages/doe"
+	"github.com/antha-lang/antha/antha/anthalib/data"
+)
+
+// RunNumberColumn is the column of run numbers in tables of runs and
+// predictions, on which they can be joined
+const RunNumberColumn data.ColumnName = "RunNumber"
+
+var (
+	intType     = reflect.TypeOf(0)
+	float64Type = reflect.TypeOf(0.0)
+	stringType  = reflect.TypeOf("")
+)
+
+// nullable returns nil for NaN so that it is null in a table
+func nullable(v float64) interface{} {
+	if math.IsNaN(v) {
+		return nil
+	}
+	return v
+}
+
+// CoefficientTable returns a table with a row for each term of the model,
+// with columns Term, Coefficient, StdError, T and P
+func (f *Fit) CoefficientTable() (*data.Table, error) {
+	builder, err := data.NewTableBuilder([]data.Column{
+		{Name: "Term", Type: stringType},
+		{Name: "Coefficient", Type: float64Type},
+		{Name: "StdError", Type: float64Type},
+		{Name: "T", Type: float64Type},
+		{Name: "P", Type: float64Type},
+	})
+	if err != nil {
+		return nil, err
+	}
+	for i, term := range f.Terms {
+		builder.Append([]interface{}{
+			term,
+			f.Coefficients[i],
+			nullable(f.StdErrors[i]),
+			nullable(f.TValues[i]),
+			nullable(f.PValues[i]),
+		})
+	}
+	return builder.Build(), nil
+}
+
+// ANOVATable returns the analysis of variance of the fit as a table with
+// columns Source, DF, SumOfSquares, MeanSquare, F and P
+func (f *Fit) ANOVATable() (*data.Table, error) {
+	builder, err := data.NewTableBuilder([]data.Column{
+		{Name: "Source", Type: stringType},
+		{Name: "DF", Type: intType},
+		{Name: "SumOfSquares", Type: float64Type},
+		{Name: "MeanSquare", Type: float64Type},
+		{Name: "F", Type: float64Type},
+		{Name: "P", Type: float64Type},
+	})
+	if err != nil {
+		return nil, err
+	}
+	for _, row := range f.ANOVA {
+		builder.Append([]interface{}{
+			row.Source,
+			row.DF,
+			row.SumOfSquares,
+			nullable(row.MeanSquare),
+			nullable(row.F),
+			nullable(row.P),
+		})
+	}
+	return builder.Build(), nil
+}
+
+// PredictionTable returns a table of the fitted values and residuals of each
+// run, with columns RunNumber, "<response> Predicted" and
+// "<response> Residual". It can be joined to the table of the runs returned
+// by RunTable on RunNumber.
+func (f *Fit) PredictionTable() (*data.Table, error) {
+	residuals := make([]float64, len(f.Observed))
+	for i := range residuals {
+		residuals[i] = f.Observed[i] - f.Fitted[i]
+	}
+	runNumbers, err := data.NewSeriesFromSlice(RunNumberColumn, f.RunNumbers, nil)
+	if err != nil {
+		return nil, err
+	}
+	predicted, err := data.NewSeriesFromSlice(data.ColumnName(f.Response+" Predicted"), f.Fitted, nil)
+	if err != nil {
+		return nil, err
+	}
+	residual, err := data.NewSeriesFromSlice(data.ColumnName(f.Response+" Residual"), residuals, nil)
+	if err != nil {
+		return nil, err
+	}
+	return data.NewTable(runNumbers, predicted, residual), nil
+}
+
+// RunTable returns a table with a row for each run, with columns RunNumber,
+// StdNumber and a column for each factor and response of the first run.
+// Columns of numeric values are float64, other values are formatted as
+// strings. Values missing from a run are null.
+func RunTable(runs []doe.Run) (*data.Table, error) {
+	if len(runs) == 0 {
+		return nil, fmt.Errorf("cannot make table: no runs")
+	}
+
+	type column struct {
+		name   string
+		values []interface{}
+	}
+	var columns []column
+	for _, name := range runs[0].Factordescriptors {
+		col := column{name: name}
+		for _, run := range runs {
+			v, err := run.GetFactorValue(name)
+			if err != nil {
+				v = nil
+			}
+			col.values = append(col.values, v)
+		}
+		columns = append(columns, col)
+	}
+	for _, name := range runs[0].Responsedescriptors {
+		col := column{name: name}
+		for _, run := range runs {
+			v, err := run.GetResponseValue(name)
+			if err != nil {
+				v = nil
+			}
+			col.values = append(col.values, v)
+		}
+		columns = append(columns, col)
+	}
+
+	series := make([]*data.Series, 0, len(columns)+2)
+	runNumbers := make([]int, len(runs))
+	stdNumbers := make([]int, len(runs))
+	for i, run := range runs {
+		runNumbers[i], stdNumbers[i] = run.RunNumber, run.StdNumber
+	}
+	for _, s := range []struct {
+		name   data.ColumnName
+		values []int
+	}{{RunNumberColumn, runNumbers}, {"StdNumber", stdNumbers}} {
+		ser, err := data.NewSeriesFromSlice(s.name, s.values, nil)
+		if err != nil {
+			return nil, err
+		}
+		series = append(series, ser)
+	}
+
+	for _, col := range columns {
+		notNull := make([]bool, len(col.values))
+		floats := make([]float64, len(col.values))
+		numeric := true
+		for i, v := range col.values {
+			if v == nil {
+				continue
+			}
+			notNull[i] = true
+			if floats[i], numeric = toFloat(v); !numeric {
+				break
+			}
+		}
+
+		var ser *data.Series
+		var err error
+		if numeric {
+			ser, err = data.NewSeriesFromSlice(data.ColumnName(col.name), floats, notNull)
+		} else {
+			strs := make([]string, len(col.values))
+			for i, v := range col.values {
+				notNull[i] = v != nil
+				if v != nil {
+					strs[i] = fmt.Sprint(v)
+				}
+			}
+			ser, err = data.NewSeriesFromSlice(data.ColumnName(col.name), strs, notNull)
+		}
+		if err != nil {
+			return nil, err
+		}
+		series = append(series, ser)
+	}
+
+	return data.NewTable(series...), nil
+}
+
+// toFloat converts numbers, but not strings, to float64
+func toFloat(v interface{}) (float64, bool) {
+	if _, isString := v.(string); isString {
+		return 0, false
+	}
+	f, err := responseValue(v)
+	return f, err == nil
+}
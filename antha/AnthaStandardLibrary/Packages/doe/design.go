@@ -0,0 +1,544 @@
+// Part of the Antha language
+// Copyright (C) 2018 The Antha authors. All rights reserved.
+//
+// This program is free software; you can redistribute it and/or
+// modify it under the terms of the GNU General Public License
+// as published by the Free Software Foundation; either version 2
+// of the License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program; if not, write to the Free Software
+// Foundation, Inc., 51 Franklin Street, Fifth Floor, Boston, MA  02110-1301, USA.
+//
+// For more information relating to the software or licensing issues please
+// contact license@antha-lang.org or write to the Antha team c/o
+// Synthace Ltd. The London Bioscience Innovation Centre
+// 2 Royal College St, London NW1 0NH UK
+
+package doe
+
+import (
+	"fmt"
+	"math"
+	"math/bits"
+	"math/rand"
+	"sort"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+// The design generators below work on coded factor values between -1 and 1,
+// see Coding. Fixed factors, i.e. those with a single level, are not part of
+// the design and are set to their level in every run. Factors appear in the
+// runs in the order they are given.
+
+// codeFactors returns the codings of the factors which are not fixed
+func codeFactors(factors []DOEPair) ([]Coding, error) {
+	_, nonfixed := FixedAndNonFixed(factors)
+	codings := make([]Coding, 0, len(nonfixed))
+	for _, factor := range nonfixed {
+		c, err := NewCoding(factor)
+		if err != nil {
+			return nil, err
+		}
+		codings = append(codings, c)
+	}
+	return codings, nil
+}
+
+// runsFromDesign converts rows of coded values of the non-fixed factors into
+// runs
+func runsFromDesign(factors []DOEPair, codings []Coding, design [][]float64) []Run {
+	runs := make([]Run, 0, len(design))
+	for i, row := range design {
+		var run Run
+		j := 0
+		for _, factor := range factors {
+			if IsFixedFactor(factor) {
+				run = AddNewFactorFieldandValue(run, factor.Factor, factor.Levels[0])
+			} else if len(factor.Levels) > 1 {
+				run = AddNewFactorFieldandValue(run, factor.Factor, codings[j].Decode(row[j]))
+				j++
+			}
+		}
+		run.RunNumber = i + 1
+		run.StdNumber = i + 1
+		runs = append(runs, run)
+	}
+	return runs
+}
+
+// requireCentre returns an error unless the centre of each factor is a valid
+// level, i.e. it is numeric or has three levels
+func requireCentre(design string, codings []Coding) error {
+	for _, c := range codings {
+		if !c.Numeric && len(c.Pair.Levels) != 3 {
+			return fmt.Errorf("%s design requires numeric factors or factors with three levels, factor %s has %d non-numeric levels", design, c.Pair.Factor, len(c.Pair.Levels))
+		}
+	}
+	return nil
+}
+
+// requireNumeric returns an error unless all factors are numeric
+func requireNumeric(design string, codings []Coding) error {
+	for _, c := range codings {
+		if !c.Numeric {
+			return fmt.Errorf("%s design requires numeric factors, factor %s has non-numeric levels", design, c.Pair.Factor)
+		}
+	}
+	return nil
+}
+
+// maxGeneratorSearch limits the number of generator combinations tried for
+// each size of fractional factorial design
+const maxGeneratorSearch = 1000000
+
+// findGenerators looks for p generators, each the product of two or more of
+// the m base factors, such that the design has at least the given
+// resolution. Generators are returned as bitmasks of base factors.
+func findGenerators(m, p, resolution int) ([]uint64, bool) {
+	var candidates []uint64
+	for mask := uint64(1); mask < 1<<uint(m); mask++ {
+		if bits.OnesCount64(mask) >= 2 {
+			candidates = append(candidates, mask)
+		}
+	}
+	// prefer higher order interactions as they alias fewer effects
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return bits.OnesCount64(candidates[i]) > bits.OnesCount64(candidates[j])
+	})
+
+	generators := make([]uint64, 0, p)
+	tries := 0
+
+	// group is the defining relation of the generators chosen so far, each
+	// word is a bitmask of all m+p factors
+	var search func(start int, group []uint64) bool
+	search = func(start int, group []uint64) bool {
+		if len(generators) == p {
+			return true
+		}
+		for i := start; i < len(candidates) && len(candidates)-i >= p-len(generators); i++ {
+			if tries++; tries > maxGeneratorSearch {
+				return false
+			}
+			word := candidates[i] | 1<<uint(m+len(generators))
+			newGroup := make([]uint64, 0, 2*len(group))
+			newGroup = append(newGroup, group...)
+			ok := true
+			for _, g := range group {
+				if w := g ^ word; bits.OnesCount64(w) < resolution {
+					ok = false
+					break
+				} else {
+					newGroup = append(newGroup, w)
+				}
+			}
+			if !ok {
+				continue
+			}
+			generators = append(generators, candidates[i])
+			if search(i+1, newGroup) {
+				return true
+			}
+			generators = generators[:len(generators)-1]
+		}
+		return false
+	}
+
+	return generators, search(0, []uint64{0})
+}
+
+// fractionalDesign returns the coded design of the smallest two level
+// fractional factorial of k factors with at least the given resolution
+func fractionalDesign(k, resolution int) ([][]float64, error) {
+	if resolution < 3 {
+		return nil, fmt.Errorf("cannot make fractional factorial of resolution %d: resolution must be at least 3", resolution)
+	} else if k > 62 {
+		return nil, fmt.Errorf("cannot make fractional factorial of %d factors: at most 62 factors are supported", k)
+	}
+
+	m := 0
+	for 1<<uint(m) < k+1 {
+		m++
+	}
+	var generators []uint64
+	for ; m < k; m++ {
+		if gens, found := findGenerators(m, k-m, resolution); found {
+			generators = gens
+			break
+		}
+	}
+
+	design := make([][]float64, 1<<uint(m))
+	for r := range design {
+		row := make([]float64, k)
+		for i := 0; i < m; i++ {
+			row[i] = -1
+			if r&(1<<uint(i)) != 0 {
+				row[i] = 1
+			}
+		}
+		for j, gen := range generators {
+			row[m+j] = 1
+			for i := 0; i < m; i++ {
+				if gen&(1<<uint(i)) != 0 {
+					row[m+j] *= row[i]
+				}
+			}
+		}
+		design[r] = row
+	}
+	return design, nil
+}
+
+// FractionalFactorial returns the smallest two level fractional factorial
+// design with at least the given resolution. Resolution III designs alias
+// main effects with two factor interactions, resolution IV designs alias two
+// factor interactions with each other, and resolution V designs alias two
+// factor interactions only with three factor interactions. A full factorial is
+// returned if no smaller design has the resolution required.
+// The low and high level of each numeric factor are its lowest and highest
+// level, other factors use their first and last level.
+func FractionalFactorial(factors []DOEPair, resolution int) ([]Run, error) {
+	codings, err := codeFactors(factors)
+	if err != nil {
+		return nil, err
+	}
+	design, err := fractionalDesign(len(codings), resolution)
+	if err != nil {
+		return nil, err
+	}
+	return runsFromDesign(factors, codings, design), nil
+}
+
+// plackettBurmanGenerators are the first rows of the cyclic Plackett-Burman
+// designs which are not powers of two
+var plackettBurmanGenerators = map[int]string{
+	12: "++-+++---+-",
+	20: "++--++++-+-+----++-",
+	24: "+++++-+-++--++--+-+----",
+}
+
+// plackettBurmanMatrix returns the n runs of an n-1 factor Plackett-Burman
+// design, or nil if there is no design of that size
+func plackettBurmanMatrix(n int) [][]float64 {
+	if gen, found := plackettBurmanGenerators[n]; found {
+		design := make([][]float64, n)
+		for i := 0; i < n-1; i++ {
+			design[i] = make([]float64, n-1)
+			for j := range design[i] {
+				design[i][j] = -1
+				if gen[(j-i+n-1)%(n-1)] == '+' {
+					design[i][j] = 1
+				}
+			}
+		}
+		design[n-1] = make([]float64, n-1)
+		for j := range design[n-1] {
+			design[n-1][j] = -1
+		}
+		return design
+	}
+
+	if n < 2 || n&(n-1) != 0 {
+		return nil
+	}
+	// Sylvester's construction of a Hadamard matrix, without the column of ones
+	h := [][]float64{{1}}
+	for len(h) < n {
+		size := len(h)
+		next := make([][]float64, 2*size)
+		for i := range h {
+			next[i] = append(append([]float64{}, h[i]...), h[i]...)
+			next[i+size] = make([]float64, 0, 2*size)
+			next[i+size] = append(next[i+size], h[i]...)
+			for _, v := range h[i] {
+				next[i+size] = append(next[i+size], -v)
+			}
+		}
+		h = next
+	}
+	design := make([][]float64, n)
+	for i := range h {
+		design[i] = h[i][1:]
+	}
+	return design
+}
+
+// PlackettBurman returns a two level screening design in which all main
+// effects are estimated independently of each other, in the smallest
+// multiple of four runs greater than the number of factors for which a design
+// is available. Designs of 12, 20 and 24 runs and of powers of two are
+// available.
+// The low and high level of each numeric factor are its lowest and highest
+// level, other factors use their first and last level.
+func PlackettBurman(factors []DOEPair) ([]Run, error) {
+	codings, err := codeFactors(factors)
+	if err != nil {
+		return nil, err
+	}
+	k := len(codings)
+
+	n := 4 * (k/4 + 1)
+	matrix := plackettBurmanMatrix(n)
+	for matrix == nil {
+		n += 4
+		matrix = plackettBurmanMatrix(n)
+	}
+
+	design := make([][]float64, len(matrix))
+	for i, row := range matrix {
+		design[i] = row[:k]
+	}
+	return runsFromDesign(factors, codings, design), nil
+}
+
+// centrePoints returns n runs at the centre of the design
+func centrePoints(k, n int) [][]float64 {
+	design := make([][]float64, 0, n)
+	for i := 0; i < n; i++ {
+		design = append(design, make([]float64, k))
+	}
+	return design
+}
+
+// BoxBehnken returns a three level response surface design for three or more
+// factors. Each pair of factors is run at the four combinations of their low
+// and high levels with all other factors at their centre, followed by the
+// given number of runs with all factors at their centre. For more than five
+// factors this is larger than the published designs based on incomplete
+// blocks.
+// Numeric factors are run at their lowest, highest and mid levels; other
+// factors must have three levels.
+func BoxBehnken(factors []DOEPair, centreRuns int) ([]Run, error) {
+	codings, err := codeFactors(factors)
+	if err != nil {
+		return nil, err
+	}
+	k := len(codings)
+	if k < 3 {
+		return nil, fmt.Errorf("Box-Behnken design requires at least 3 non-fixed factors, found %d", k)
+	} else if err := requireCentre("Box-Behnken", codings); err != nil {
+		return nil, err
+	}
+
+	var design [][]float64
+	for i := 0; i < k; i++ {
+		for j := i + 1; j < k; j++ {
+			for _, a := range []float64{-1, 1} {
+				for _, b := range []float64{-1, 1} {
+					row := make([]float64, k)
+					row[i], row[j] = a, b
+					design = append(design, row)
+				}
+			}
+		}
+	}
+	design = append(design, centrePoints(k, centreRuns)...)
+
+	return runsFromDesign(factors, codings, design), nil
+}
+
+// RotatableAlpha returns the distance of the axial points from the centre of
+// a central composite design with the given number of factorial runs such
+// that the variance of predictions depends only on the distance from the
+// centre
+func RotatableAlpha(factorialRuns int) float64 {
+	return math.Pow(float64(factorialRuns), 0.25)
+}
+
+// CentralComposite returns a central composite response surface design. The
+// factorial part of the design is a full factorial or, for five or more
+// factors, a resolution V fractional factorial. It is followed by two axial
+// runs for each factor at a coded distance alpha from the centre, and the
+// given number of centre runs. An alpha of 1 gives a face centred design with
+// three levels for each factor, larger values put the axial runs outside the
+// range of the factor levels. An alpha of zero or less gives a rotatable
+// design, see RotatableAlpha.
+func CentralComposite(factors []DOEPair, alpha float64, centreRuns int) ([]Run, error) {
+	codings, err := codeFactors(factors)
+	if err != nil {
+		return nil, err
+	}
+	k := len(codings)
+	if k < 2 {
+		return nil, fmt.Errorf("central composite design requires at least 2 non-fixed factors, found %d", k)
+	}
+
+	design, err := fractionalDesign(k, 5)
+	if err != nil {
+		return nil, err
+	}
+
+	if alpha <= 0 {
+		alpha = RotatableAlpha(len(design))
+	}
+	if alpha == 1 {
+		err = requireCentre("face centred central composite", codings)
+	} else {
+		err = requireNumeric("central composite", codings)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	for i := 0; i < k; i++ {
+		for _, a := range []float64{-alpha, alpha} {
+			row := make([]float64, k)
+			row[i] = a
+			design = append(design, row)
+		}
+	}
+	design = append(design, centrePoints(k, centreRuns)...)
+
+	return runsFromDesign(factors, codings, design), nil
+}
+
+// LatinHypercube returns a space filling design of the given number of runs.
+// The range of each factor is divided into as many equal intervals as there
+// are runs, and each interval is sampled once at random. The same seed gives
+// the same design. All non-fixed factors must be numeric.
+func LatinHypercube(factors []DOEPair, runs int, seed int64) ([]Run, error) {
+	codings, err := codeFactors(factors)
+	if err != nil {
+		return nil, err
+	} else if err := requireNumeric("Latin hypercube", codings); err != nil {
+		return nil, err
+	} else if runs < 1 {
+		return nil, fmt.Errorf("Latin hypercube design requires at least 1 run, %d requested", runs)
+	}
+
+	rng := rand.New(rand.NewSource(seed))
+	design := make([][]float64, runs)
+	for i := range design {
+		design[i] = make([]float64, len(codings))
+	}
+	for j := range codings {
+		for i, interval := range rng.Perm(runs) {
+			design[i][j] = -1 + 2*(float64(interval)+rng.Float64())/float64(runs)
+		}
+	}
+
+	return runsFromDesign(factors, codings, design), nil
+}
+
+// logDetInformation returns the log of the determinant of X'X + ridge*I for
+// the selected rows of X
+func logDetInformation(x [][]float64, selected []int, ridge float64) float64 {
+	p := len(x[0])
+	m := mat.NewDense(p, p, nil)
+	for _, s := range selected {
+		for i := 0; i < p; i++ {
+			for j := 0; j < p; j++ {
+				m.Set(i, j, m.At(i, j)+x[s][i]*x[s][j])
+			}
+		}
+	}
+	for i := 0; i < p; i++ {
+		m.Set(i, i, m.At(i, i)+ridge)
+	}
+	logDet, sign := mat.LogDet(m)
+	if sign <= 0 {
+		return math.Inf(-1)
+	}
+	return logDet
+}
+
+// maxExchanges limits the number of exchanges made when searching for a
+// D-optimal design
+const maxExchanges = 1000
+
+// DOptimal selects runs from a set of candidate runs to maximise the
+// determinant of the information matrix of the given model, so that the
+// coefficients of the model are estimated as precisely as possible. If no
+// candidates are given all combinations of the factor levels are used.
+// Candidates may be selected more than once. The selection is made by
+// Fedorov's exchange algorithm starting from a random selection, the same
+// seed gives the same design.
+func DOptimal(factors []DOEPair, candidates []Run, runs int, model Model, seed int64) ([]Run, error) {
+	codings, err := codeFactors(factors)
+	if err != nil {
+		return nil, err
+	}
+	if len(candidates) == 0 {
+		_, nonfixed := FixedAndNonFixed(factors)
+		if len(nonfixed) > 0 {
+			candidates = AllCombinations(nonfixed)
+		}
+	}
+	if len(candidates) == 0 {
+		return nil, fmt.Errorf("no candidate runs for D-optimal design")
+	}
+
+	p := model.TermCount(len(codings))
+	if runs < p {
+		return nil, fmt.Errorf("%s model of %d factors has %d terms, at least as many runs are required, %d requested", model, len(codings), p, runs)
+	}
+
+	coded := make([][]float64, len(candidates))
+	x := make([][]float64, len(candidates))
+	for i, candidate := range candidates {
+		coded[i] = make([]float64, len(codings))
+		for j, c := range codings {
+			level, err := candidate.GetFactorValue(c.Pair.Factor)
+			if err != nil {
+				return nil, fmt.Errorf("candidate run %d: %s", i+1, err)
+			}
+			if coded[i][j], err = c.Code(level); err != nil {
+				return nil, fmt.Errorf("candidate run %d: %s", i+1, err)
+			}
+		}
+		x[i] = model.Expand(coded[i])
+	}
+
+	rng := rand.New(rand.NewSource(seed))
+	selected := make([]int, 0, runs)
+	for len(selected) < runs {
+		for _, i := range rng.Perm(len(candidates)) {
+			if len(selected) < runs {
+				selected = append(selected, i)
+			}
+		}
+	}
+
+	// a small ridge lets the exchange move away from singular designs
+	const ridge = 1e-6
+	current := logDetInformation(x, selected, ridge)
+	for n := 0; n < maxExchanges; n++ {
+		best, bestPos, bestCandidate := current, -1, -1
+		for pos, old := range selected {
+			for c := range candidates {
+				if c == old {
+					continue
+				}
+				selected[pos] = c
+				if v := logDetInformation(x, selected, ridge); v > best+1e-9 {
+					best, bestPos, bestCandidate = v, pos, c
+				}
+				selected[pos] = old
+			}
+		}
+		if bestPos < 0 {
+			break
+		}
+		selected[bestPos] = bestCandidate
+		current = best
+	}
+
+	if math.IsInf(logDetInformation(x, selected, 0), -1) {
+		return nil, fmt.Errorf("the candidate runs cannot estimate all terms of the %s model", model)
+	}
+
+	sort.Ints(selected)
+	design := make([][]float64, 0, runs)
+	for _, s := range selected {
+		design = append(design, coded[s])
+	}
+	return runsFromDesign(factors, codings, design), nil
+}
@@ -0,0 +1,268 @@
+package doe
+
+import (
+	"fmt"
+	"math/bits"
+	"testing"
+)
+
+func numericFactors(k int) []DOEPair {
+	var factors []DOEPair
+	for i := 0; i < k; i++ {
+		factors = append(factors, Pair(fmt.Sprintf("Factor %d", i+1), []interface{}{1.0, 5.0}))
+	}
+	return factors
+}
+
+// codedDesign returns the coded values of the non-fixed factors in each run
+func codedDesign(t *testing.T, factors []DOEPair, runs []Run) [][]float64 {
+	codings, err := codeFactors(factors)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var design [][]float64
+	for _, run := range runs {
+		var row []float64
+		for _, c := range codings {
+			level, err := run.GetFactorValue(c.Pair.Factor)
+			if err != nil {
+				t.Fatal(err)
+			}
+			v, err := c.Code(level)
+			if err != nil {
+				t.Fatal(err)
+			}
+			row = append(row, v)
+		}
+		design = append(design, row)
+	}
+	return design
+}
+
+// checkOrthogonal checks that each column of the design is balanced and
+// orthogonal to each other column
+func checkOrthogonal(t *testing.T, name string, design [][]float64) {
+	if len(design) == 0 {
+		t.Errorf("%s: empty design", name)
+		return
+	}
+	for i := range design[0] {
+		for j := i; j < len(design[0]); j++ {
+			var sum, dot float64
+			for _, row := range design {
+				sum += row[i]
+				dot += row[i] * row[j]
+			}
+			if sum != 0 {
+				t.Errorf("%s: column %d not balanced", name, i)
+			}
+			if i != j && dot != 0 {
+				t.Errorf("%s: columns %d and %d not orthogonal", name, i, j)
+			}
+		}
+	}
+}
+
+func TestFractionalFactorial(t *testing.T) {
+	tests := []struct {
+		factors, resolution, runs int
+	}{
+		{factors: 2, resolution: 3, runs: 4},
+		{factors: 3, resolution: 3, runs: 4},
+		{factors: 3, resolution: 4, runs: 8},
+		{factors: 4, resolution: 4, runs: 8},
+		{factors: 5, resolution: 3, runs: 8},
+		{factors: 5, resolution: 5, runs: 16},
+		{factors: 7, resolution: 3, runs: 8},
+		{factors: 8, resolution: 4, runs: 16},
+		{factors: 8, resolution: 5, runs: 64},
+	}
+
+	for _, test := range tests {
+		name := fmt.Sprintf("%d factors resolution %d", test.factors, test.resolution)
+		factors := numericFactors(test.factors)
+		runs, err := FractionalFactorial(factors, test.resolution)
+		if err != nil {
+			t.Errorf("%s: %s", name, err)
+			continue
+		}
+		if len(runs) != test.runs {
+			t.Errorf("%s: expected %d runs, got %d", name, test.runs, len(runs))
+		}
+		design := codedDesign(t, factors, runs)
+		checkOrthogonal(t, name, design)
+
+		// the product of the columns in any word shorter than the resolution
+		// must not be constant
+		k := test.factors
+		for word := uint64(1); word < 1<<uint(k); word++ {
+			if bits.OnesCount64(word) >= test.resolution {
+				continue
+			}
+			var sum float64
+			for _, row := range design {
+				prod := 1.0
+				for i := 0; i < k; i++ {
+					if word&(1<<uint(i)) != 0 {
+						prod *= row[i]
+					}
+				}
+				sum += prod
+			}
+			if sum == float64(len(design)) || sum == -float64(len(design)) {
+				t.Errorf("%s: word %b in defining relation", name, word)
+			}
+		}
+	}
+
+	if _, err := FractionalFactorial(numericFactors(3), 2); err == nil {
+		t.Error("expected error for resolution 2")
+	}
+}
+
+func TestPlackettBurman(t *testing.T) {
+	tests := []struct {
+		factors, runs int
+	}{
+		{factors: 3, runs: 4},
+		{factors: 7, runs: 8},
+		{factors: 11, runs: 12},
+		{factors: 15, runs: 16},
+		{factors: 19, runs: 20},
+		{factors: 23, runs: 24},
+		{factors: 25, runs: 32},
+	}
+
+	for _, test := range tests {
+		name := fmt.Sprintf("%d factors", test.factors)
+		factors := numericFactors(test.factors)
+		runs, err := PlackettBurman(factors)
+		if err != nil {
+			t.Errorf("%s: %s", name, err)
+			continue
+		}
+		if len(runs) != test.runs {
+			t.Errorf("%s: expected %d runs, got %d", name, test.runs, len(runs))
+		}
+		checkOrthogonal(t, name, codedDesign(t, factors, runs))
+	}
+}
+
+func TestFixedFactors(t *testing.T) {
+	factors := []DOEPair{
+		Pair("Temperature", []interface{}{25.0, 37.0}),
+		Pair("Buffer", []interface{}{"PBS"}),
+		Pair("Strain", []interface{}{"A", "B"}),
+		Pair("Volume", []interface{}{10, 20}),
+	}
+	runs, err := FractionalFactorial(factors, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(runs) != 4 {
+		t.Fatalf("expected 4 runs, got %d", len(runs))
+	}
+	for i, run := range runs {
+		if run.RunNumber != i+1 {
+			t.Errorf("run %d: run number %d", i+1, run.RunNumber)
+		}
+		for j, factor := range factors {
+			if run.Factordescriptors[j] != factor.Factor {
+				t.Errorf("run %d: expected factor %s, got %s", i+1, factor.Factor, run.Factordescriptors[j])
+			}
+			found := false
+			for _, level := range factor.Levels {
+				if level == run.Setpoints[j] {
+					found = true
+				}
+			}
+			if !found {
+				t.Errorf("run %d: %v is not a level of %s", i+1, run.Setpoints[j], factor.Factor)
+			}
+		}
+	}
+}
+
+func TestResponseSurface(t *testing.T) {
+	bb, err := BoxBehnken(numericFactors(3), 3)
+	if err != nil {
+		t.Fatal(err)
+	} else if len(bb) != 15 {
+		t.Errorf("Box-Behnken: expected 15 runs, got %d", len(bb))
+	}
+
+	ccd, err := CentralComposite(numericFactors(3), 0, 4)
+	if err != nil {
+		t.Fatal(err)
+	} else if len(ccd) != 8+6+4 {
+		t.Errorf("central composite: expected 18 runs, got %d", len(ccd))
+	} else if v := ccd[8].Setpoints[0].(float64); v >= 1.0 {
+		t.Errorf("central composite: expected rotatable axial point below lowest level, got %v", v)
+	}
+
+	ccf, err := CentralComposite([]DOEPair{
+		Pair("A", []interface{}{"x", "y", "z"}),
+		Pair("B", []interface{}{1, 2}),
+	}, 1, 1)
+	if err != nil {
+		t.Fatal(err)
+	} else if len(ccf) != 4+4+1 {
+		t.Errorf("face centred: expected 9 runs, got %d", len(ccf))
+	} else if ccf[8].Setpoints[0] != "y" || ccf[8].Setpoints[1] != 1.5 {
+		t.Errorf("face centred: expected centre y, 1.5, got %v", ccf[8].Setpoints)
+	}
+
+	if _, err := CentralComposite([]DOEPair{
+		Pair("A", []interface{}{"x", "y"}),
+		Pair("B", []interface{}{1, 2}),
+	}, 0, 1); err == nil {
+		t.Error("expected error for rotatable design of non-numeric factor")
+	}
+}
+
+func TestLatinHypercube(t *testing.T) {
+	factors := numericFactors(3)
+	runs, err := LatinHypercube(factors, 10, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	design := codedDesign(t, factors, runs)
+	for j := range factors {
+		strata := make(map[int]bool)
+		for _, row := range design {
+			strata[int((row[j]+1)/2*10)] = true
+		}
+		if len(strata) != 10 {
+			t.Errorf("factor %d: expected 10 strata, got %d", j, len(strata))
+		}
+	}
+}
+
+func TestDOptimal(t *testing.T) {
+	factors := []DOEPair{
+		Pair("A", []interface{}{1, 2, 3}),
+		Pair("B", []interface{}{1, 2, 3}),
+		Pair("C", []interface{}{"on"}),
+	}
+	runs, err := DOptimal(factors, nil, 8, Quadratic, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(runs) != 8 {
+		t.Errorf("expected 8 runs, got %d", len(runs))
+	}
+	for _, run := range runs {
+		if len(run.Setpoints) != 3 || run.Setpoints[2] != "on" {
+			t.Errorf("fixed factor not preserved in %v", run.Setpoints)
+		}
+	}
+
+	if _, err := DOptimal(factors, nil, 5, Quadratic, 1); err == nil {
+		t.Error("expected error for fewer runs than terms")
+	}
+
+	candidates := AllCombinations([]DOEPair{Pair("A", []interface{}{1, 2}), Pair("B", []interface{}{1, 2, 3})})
+	if _, err := DOptimal(factors, candidates, 6, Quadratic, 1); err == nil {
+		t.Error("expected error for candidates which cannot estimate the model")
+	}
+}
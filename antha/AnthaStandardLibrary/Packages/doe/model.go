@@ -0,0 +1,214 @@
+// Part of the Antha language
+// Copyright (C) 2018 The Antha authors. All rights reserved.
+//
+// This program is free software; you can redistribute it and/or
+// modify it under the terms of the GNU General Public License
+// as published by the Free Software Foundation; either version 2
+// of the License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program; if not, write to the Free Software
+// Foundation, Inc., 51 Franklin Street, Fifth Floor, Boston, MA  02110-1301, USA.
+//
+// For more information relating to the software or licensing issues please
+// contact license@antha-lang.org or write to the Antha team c/o
+// Synthace Ltd. The London Bioscience Innovation Centre
+// 2 Royal College St, London NW1 0NH UK
+
+package doe
+
+import (
+	"fmt"
+	"math"
+)
+
+// Model is the form of a polynomial model of a response in terms of the
+// coded factor values
+type Model int
+
+const (
+	// Linear models have an intercept and a main effect for each factor
+	Linear Model = iota
+	// Interaction models add all two factor interactions to a linear model
+	Interaction
+	// Quadratic models add the square of each factor to an interaction model
+	Quadratic
+)
+
+var modelNames = map[Model]string{
+	Linear:      "Linear",
+	Interaction: "Interaction",
+	Quadratic:   "Quadratic",
+}
+
+func (m Model) String() string {
+	if name, found := modelNames[m]; found {
+		return name
+	}
+	return fmt.Sprintf("Model(%d)", int(m))
+}
+
+// TermCount returns the number of terms, including the intercept, in the
+// model of the given number of factors
+func (m Model) TermCount(factors int) int {
+	n := 1 + factors
+	if m >= Interaction {
+		n += factors * (factors - 1) / 2
+	}
+	if m >= Quadratic {
+		n += factors
+	}
+	return n
+}
+
+// Terms returns the names of the terms of the model in the order used by
+// Expand, e.g. "Intercept", "A", "A*B" and "A^2"
+func (m Model) Terms(factors []string) []string {
+	terms := make([]string, 0, m.TermCount(len(factors)))
+	terms = append(terms, "Intercept")
+	terms = append(terms, factors...)
+	if m >= Interaction {
+		for i := range factors {
+			for j := i + 1; j < len(factors); j++ {
+				terms = append(terms, factors[i]+"*"+factors[j])
+			}
+		}
+	}
+	if m >= Quadratic {
+		for _, f := range factors {
+			terms = append(terms, f+"^2")
+		}
+	}
+	return terms
+}
+
+// Expand returns the values of each term of the model for the given coded
+// factor values
+func (m Model) Expand(x []float64) []float64 {
+	row := make([]float64, 0, m.TermCount(len(x)))
+	row = append(row, 1.0)
+	row = append(row, x...)
+	if m >= Interaction {
+		for i := range x {
+			for j := i + 1; j < len(x); j++ {
+				row = append(row, x[i]*x[j])
+			}
+		}
+	}
+	if m >= Quadratic {
+		for _, v := range x {
+			row = append(row, v*v)
+		}
+	}
+	return row
+}
+
+// toFloat converts numeric levels to float64
+func toFloat(level interface{}) (float64, bool) {
+	switch v := level.(type) {
+	case float64:
+		return v, true
+	case float32:
+		return float64(v), true
+	case int:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case int32:
+		return float64(v), true
+	case uint:
+		return float64(v), true
+	case uint64:
+		return float64(v), true
+	case uint32:
+		return float64(v), true
+	}
+	return 0, false
+}
+
+// Coding maps the levels of a factor onto the coded range -1 to 1. Numeric
+// factors are scaled linearly between their lowest and highest level. The
+// levels of other factors are coded in the order they are given, so a factor
+// with two levels is coded -1 for the first level and 1 for the second.
+type Coding struct {
+	Pair    DOEPair
+	Numeric bool
+	Low     float64 // lowest level of a numeric factor
+	High    float64 // highest level of a numeric factor
+}
+
+// NewCoding returns the coding of the levels of the factor
+func NewCoding(pair DOEPair) (Coding, error) {
+	if len(pair.Levels) == 0 {
+		return Coding{}, fmt.Errorf("factor %s has no levels", pair.Factor)
+	}
+	c := Coding{Pair: pair, Numeric: true, Low: math.Inf(1), High: math.Inf(-1)}
+	for _, level := range pair.Levels {
+		v, ok := toFloat(level)
+		if !ok {
+			c.Numeric = false
+			break
+		}
+		c.Low = math.Min(c.Low, v)
+		c.High = math.Max(c.High, v)
+	}
+	if !c.Numeric {
+		c.Low, c.High = 0, 0
+	}
+	return c, nil
+}
+
+// Code returns the coded value of a level of the factor
+func (c Coding) Code(level interface{}) (float64, error) {
+	if c.Numeric {
+		v, ok := toFloat(level)
+		if !ok {
+			return 0, fmt.Errorf("level %v of numeric factor %s is not a number", level, c.Pair.Factor)
+		}
+		if c.High == c.Low {
+			return 0, nil
+		}
+		return 2*(v-c.Low)/(c.High-c.Low) - 1, nil
+	}
+	for i, l := range c.Pair.Levels {
+		if fmt.Sprint(l) == fmt.Sprint(level) {
+			return c.indexToCode(i), nil
+		}
+	}
+	return 0, fmt.Errorf("%v is not a level of factor %s", level, c.Pair.Factor)
+}
+
+func (c Coding) indexToCode(i int) float64 {
+	if len(c.Pair.Levels) == 1 {
+		return 0
+	}
+	return -1 + 2*float64(i)/float64(len(c.Pair.Levels)-1)
+}
+
+// Decode returns the level corresponding to a coded value. Levels of the
+// factor are returned unchanged where they match the coded value, otherwise
+// numeric factors return a float64 and other factors the nearest level.
+func (c Coding) Decode(coded float64) interface{} {
+	if !c.Numeric {
+		best, bestDiff := 0, math.Inf(1)
+		for i := range c.Pair.Levels {
+			if d := math.Abs(c.indexToCode(i) - coded); d < bestDiff {
+				best, bestDiff = i, d
+			}
+		}
+		return c.Pair.Levels[best]
+	}
+
+	v := c.Low + (coded+1)*(c.High-c.Low)/2
+	for _, level := range c.Pair.Levels {
+		if l, _ := toFloat(level); math.Abs(l-v) <= 1e-9*math.Max(1, math.Abs(v)) {
+			return level
+		}
+	}
+	return v
+}
@@ -0,0 +1,519 @@
+// antha/AnthaStandardLibrary/Packages/enzymes/Gibsonassembly.go: Part of the Antha language
+// Copyright (C) 2018 The Antha authors. All rights reserved.
+//
+// This program is free software; you can redistribute it and/or
+// modify it under the terms of the GNU General Public License
+// as published by the Free Software Foundation; either version 2
+// of the License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program; if not, write to the Free Software
+// Foundation, Inc., 51 Franklin Street, Fifth Floor, Boston, MA  02110-1301, USA.
+//
+// For more information relating to the software or licensing issues please
+// contact license@antha-lang.org or write to the Antha team c/o
+// Synthace Ltd. The London Bioscience Innovation Centre
+// 2 Royal College St, London NW1 0NH UK
+
+package enzymes
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/antha-lang/antha/antha/AnthaStandardLibrary/Packages/sequences"
+	"github.com/antha-lang/antha/antha/AnthaStandardLibrary/Packages/sequences/oligos"
+	"github.com/antha-lang/antha/antha/anthalib/wtype"
+	"github.com/antha-lang/antha/antha/anthalib/wunit"
+)
+
+// DefaultMisprimingLength is the length of the 3' ends of each overlap which
+// is checked for mis-priming if GibsonParameters.MisprimingLength is not set.
+const DefaultMisprimingLength = 12
+
+// GibsonParameters are the settings used to design primers for a Gibson
+// (or other homology based) assembly.
+type GibsonParameters struct {
+	// OverlapLength is the length of the homologous overlap between adjacent
+	// fragments. When both fragments are amplified the overlap is split
+	// between the tails of their primers.
+	OverlapLength int
+	// Primers are the constraints, checked by oligos.CheckPrimer, on the
+	// region of each primer which anneals to its template. The melting
+	// temperatures of overlaps are also calculated as specified by Primers.
+	Primers oligos.PrimerConstraints
+	// AmplifyVector specifies whether primers are designed for the vector.
+	// Otherwise the vector is assumed to be linearised already, for instance
+	// by digestion, and the parts carry the whole of the overlaps with it.
+	AmplifyVector bool
+	// MisprimingLength is the length of the 3' ends of each overlap which
+	// must occur only once in the assembled product.
+	MisprimingLength int
+}
+
+// DefaultGibsonParameters returns parameters for 30 bp overlaps with primers
+// of 18 to 35 bases annealing between 55 and 72 ℃ by
+// oligos.BasicMeltingTemp. Since the primers must anneal at the ends of their
+// templates, secondary structures are not checked unless the limits of
+// Primers are set.
+func DefaultGibsonParameters() GibsonParameters {
+	primers := oligos.DefaultPrimerConstraints()
+	primers.MaxLength = 35
+	primers.MaxMeltingTemp = wunit.NewTemperature(72, "C")
+	primers.NearestNeighbour = false
+	primers.MinHairpinDeltaG = 0
+	primers.MinSelfDimerDeltaG = 0
+	primers.MinHeteroDimerDeltaG = 0
+	return GibsonParameters{
+		OverlapLength:    30,
+		Primers:          primers,
+		AmplifyVector:    true,
+		MisprimingLength: DefaultMisprimingLength,
+	}
+}
+
+// GibsonFragment is a template and the primers designed to amplify it with
+// tails overlapping its neighbours.
+// The MeltingTemp of each primer is that of the region annealing to the
+// template; Length and GCContent are those of the whole primer.
+// If the template was not amplified the primers are empty and the Product is
+// the template.
+type GibsonFragment struct {
+	Template wtype.DNASequence
+	Forward  oligos.Primer
+	Reverse  oligos.Primer
+	Product  wtype.DNASequence
+}
+
+// GibsonJunction is the overlap between two adjacent fragments of an
+// assembly. Mispriming lists any other sites in the assembled product at
+// which the 3' ends of the overlap could anneal.
+type GibsonJunction struct {
+	Upstream    string
+	Downstream  string
+	Overlap     string
+	MeltingTemp wunit.Temperature
+	Mispriming  []string
+}
+
+// GibsonAssembly is the result of designing a Gibson assembly.
+type GibsonAssembly struct {
+	Fragments []GibsonFragment
+	Junctions []GibsonJunction
+	Product   wtype.DNASequence
+}
+
+// Warnings returns a description of any junctions at which mis-priming is
+// predicted.
+func (a GibsonAssembly) Warnings() []string {
+	var warnings []string
+	for _, j := range a.Junctions {
+		for _, m := range j.Mispriming {
+			warnings = append(warnings, fmt.Sprintf("overlap between %s and %s: %s", j.Upstream, j.Downstream, m))
+		}
+	}
+	return warnings
+}
+
+// DesignGibsonAssembly designs primers to assemble parts, in the order
+// specified, into vector by Gibson assembly and simulates the product.
+// The parts are inserted between the end and the start of the vector
+// sequence, so a plasmid vector should be rotated such that it starts
+// immediately after the insertion site.
+// If vector has no sequence the parts are joined into a linear product, as
+// in overlap extension PCR, and no tails are added to the ends of the first
+// and last parts.
+// Features of the parts and vector are carried over to the product.
+func DesignGibsonAssembly(parts []wtype.DNASequence, vector wtype.DNASequence, parameters GibsonParameters) (assembly GibsonAssembly, err error) {
+	if len(parts) == 0 {
+		return assembly, fmt.Errorf("No parts found")
+	}
+	if parameters.OverlapLength < 2 {
+		return assembly, fmt.Errorf("overlap length %d too short for Gibson assembly", parameters.OverlapLength)
+	}
+
+	circular := vector.Seq != ""
+	var templates []wtype.DNASequence
+	if circular {
+		templates = append(templates, vector)
+	}
+	templates = append(templates, parts...)
+
+	for _, template := range templates {
+		if len(template.Seq) < parameters.OverlapLength {
+			return assembly, fmt.Errorf("%s is shorter than the overlap length %d", template.Nm, parameters.OverlapLength)
+		}
+	}
+
+	amplified := func(i int) bool {
+		return !circular || i != 0 || parameters.AmplifyVector
+	}
+
+	upstreamHalf := parameters.OverlapLength / 2
+	downstreamHalf := parameters.OverlapLength - upstreamHalf
+
+	// tails[i] are the bases added to the 5' and 3' ends of template i
+	tails := make([][2]string, len(templates))
+	for i := range templates {
+		next := (i + 1) % len(templates)
+		if !circular && next == 0 {
+			break
+		}
+		upstream, downstream := strings.ToUpper(templates[i].Seq), strings.ToUpper(templates[next].Seq)
+		switch {
+		case amplified(i) && amplified(next):
+			tails[i][1] = downstream[:downstreamHalf]
+			tails[next][0] = upstream[len(upstream)-upstreamHalf:]
+		case amplified(i):
+			tails[i][1] = downstream[:parameters.OverlapLength]
+		case amplified(next):
+			tails[next][0] = upstream[len(upstream)-parameters.OverlapLength:]
+		}
+	}
+
+	var products []wtype.DNASequence
+	for i, template := range templates {
+		fragment := GibsonFragment{
+			Template: template,
+			Product:  template,
+		}
+		if amplified(i) {
+			fragment, err = designGibsonFragment(template, tails[i][0], tails[i][1], parameters)
+			if err != nil {
+				return assembly, err
+			}
+		}
+		assembly.Fragments = append(assembly.Fragments, fragment)
+		products = append(products, fragment.Product)
+	}
+
+	// the plasmid starts at the start of the vector rather than its tail
+	product, junctions, err := simulateGibsonAssembly(products, circular, len(tails[0][0]), parameters.OverlapLength, parameters.MisprimingLength)
+	if err != nil {
+		return assembly, err
+	}
+	product.Nm = strings.Join(names(templates), "_")
+
+	for i, junction := range junctions {
+		junctions[i].MeltingTemp, err = parameters.Primers.MeltingTemp(wtype.MakeLinearDNASequence("overlap", junction.Overlap))
+		if err != nil {
+			return assembly, err
+		}
+	}
+
+	assembly.Product = product
+	assembly.Junctions = junctions
+	return assembly, nil
+}
+
+// designGibsonFragment designs primers which anneal at the ends of template
+// and add the tails specified.
+func designGibsonFragment(template wtype.DNASequence, fivePrimeTail, threePrimeTail string, parameters GibsonParameters) (fragment GibsonFragment, err error) {
+	seq := strings.ToUpper(template.Seq)
+	linear := wtype.MakeLinearDNASequence(template.Nm, seq)
+
+	forward, err := anchoredPrimer(linear, seq, false, parameters.Primers)
+	if err != nil {
+		return fragment, err
+	}
+	reverse, err := anchoredPrimer(linear, sequences.RevComp(seq), true, parameters.Primers)
+	if err != nil {
+		return fragment, err
+	}
+
+	fragment.Template = template
+	fragment.Forward = tailedPrimer(template.Nm+"_F", fivePrimeTail, forward, false)
+	fragment.Reverse = tailedPrimer(template.Nm+"_R", sequences.RevComp(threePrimeTail), reverse, true)
+
+	fragment.Product = wtype.MakeLinearDNASequence(template.Nm, fivePrimeTail+seq+threePrimeTail)
+	for _, feature := range template.Features {
+		fragment.Product.Features = append(fragment.Product.Features, shiftFeature(feature, len(fivePrimeTail), 0))
+	}
+	return fragment, nil
+}
+
+// anchoredPrimer returns the shortest primer meeting the constraints which
+// starts at the beginning of strand, since unlike oligos.FWDOligoSeq no bases
+// of the template may be skipped.
+func anchoredPrimer(template wtype.DNASequence, strand string, reverse bool, constraints oligos.PrimerConstraints) (primer oligos.Primer, err error) {
+	err = fmt.Errorf("%s too short to design primers of at least %d bases", template.Nm, constraints.MinLength)
+	for length := constraints.MinLength; length <= constraints.MaxLength && length <= len(strand); length++ {
+		if primer, err = oligos.CheckPrimer(template, strand[:length], reverse, constraints); err == nil {
+			return primer, nil
+		}
+	}
+	end := "start"
+	if reverse {
+		end = "end"
+	}
+	return primer, fmt.Errorf("no primer meeting the constraints anneals at the %s of %s:%s", end, template.Nm, err.Error())
+}
+
+// tailedPrimer adds tail to the 5' end of primer
+func tailedPrimer(name, tail string, primer oligos.Primer, reverse bool) oligos.Primer {
+	seq := tail + strings.ToUpper(primer.Sequence())
+	return oligos.Primer{
+		DNASequence: wtype.MakeSingleStrandedDNASequence(name, seq),
+		Length:      len(seq),
+		GCContent:   sequences.GCcontent(seq),
+		Reverse:     reverse,
+		MeltingTemp: primer.MeltingTemp,
+	}
+}
+
+// shiftFeature moves a feature by offset, wrapping round a circular sequence
+// of the given length if it is greater than zero.
+func shiftFeature(feature wtype.Feature, offset int, length int) wtype.Feature {
+	shift := func(position int) int {
+		position += offset
+		if length > 0 {
+			position = (position-1+length)%length + 1
+		}
+		return position
+	}
+	feature.StartPosition = shift(feature.StartPosition)
+	feature.EndPosition = shift(feature.EndPosition)
+	return feature
+}
+
+// SimulateGibsonAssembly joins linear fragments which share homologous
+// overlaps of at least minOverlap bases at their ends.
+// The order of the fragments is found from their overlaps; each fragment must
+// overlap at most one other at each end, in the orientation given.
+// If the last fragment overlaps the first the product is a plasmid.
+// Features of the fragments are carried over to the product, and each
+// junction is checked for mis-priming as described by GibsonJunction.
+func SimulateGibsonAssembly(fragments []wtype.DNASequence, minOverlap int) (product wtype.DNASequence, junctions []GibsonJunction, err error) {
+	if len(fragments) == 0 {
+		return product, junctions, fmt.Errorf("No fragments found")
+	}
+	if minOverlap < 1 {
+		return product, junctions, fmt.Errorf("minimum overlap must be at least 1, got %d", minOverlap)
+	}
+
+	n := len(fragments)
+	next := make([]int, n)
+	previous := make([]int, n)
+	overlaps := make([]int, n)
+	for i := range fragments {
+		next[i], previous[i] = -1, -1
+	}
+	for i, upstream := range fragments {
+		for j, downstream := range fragments {
+			if i == j && n > 1 {
+				continue
+			}
+			overlap := endOverlap(upstream.Seq, downstream.Seq, minOverlap)
+			if overlap == 0 {
+				continue
+			}
+			if next[i] != -1 {
+				return product, junctions, fmt.Errorf("ambiguous assembly: %s overlaps both %s and %s", upstream.Nm, fragments[next[i]].Nm, downstream.Nm)
+			}
+			if previous[j] != -1 {
+				return product, junctions, fmt.Errorf("ambiguous assembly: both %s and %s overlap %s", fragments[previous[j]].Nm, upstream.Nm, downstream.Nm)
+			}
+			next[i], previous[j], overlaps[i] = j, i, overlap
+		}
+	}
+
+	// start from the fragment with no upstream neighbour, if there is one
+	first := 0
+	circular := true
+	for i := range fragments {
+		if previous[i] == -1 {
+			first = i
+			circular = false
+			break
+		}
+	}
+
+	var order []int
+	for i := first; i != -1 && len(order) < n; i = next[i] {
+		order = append(order, i)
+		if next[i] == first {
+			break
+		}
+	}
+	if len(order) != n {
+		var unused []string
+		used := make(map[int]bool)
+		for _, i := range order {
+			used[i] = true
+		}
+		for i, fragment := range fragments {
+			if !used[i] {
+				unused = append(unused, fragment.Nm)
+			}
+		}
+		return product, junctions, fmt.Errorf("fragments %s are not joined to %s by overlaps of at least %d bp", strings.Join(unused, ", "), fragments[first].Nm, minOverlap)
+	}
+	circular = circular && next[order[n-1]] == first
+
+	ordered := make([]wtype.DNASequence, n)
+	for k, i := range order {
+		ordered[k] = fragments[i]
+	}
+	return simulateGibsonAssembly(ordered, circular, 0, minOverlap, DefaultMisprimingLength)
+}
+
+// endOverlap returns the length of the longest suffix of upstream, of at
+// least minOverlap bases, which is a prefix of downstream, or 0 if there is
+// none. Neither sequence may be entirely contained in the overlap.
+func endOverlap(upstream, downstream string, minOverlap int) int {
+	upstream, downstream = strings.ToUpper(upstream), strings.ToUpper(downstream)
+	longest := len(upstream) - 1
+	if len(downstream)-1 < longest {
+		longest = len(downstream) - 1
+	}
+	for overlap := longest; overlap >= minOverlap; overlap-- {
+		if upstream[len(upstream)-overlap:] == downstream[:overlap] {
+			return overlap
+		}
+	}
+	return 0
+}
+
+// simulateGibsonAssembly joins fragments which are already in order. A
+// circular product starts at position origin of the first fragment.
+func simulateGibsonAssembly(fragments []wtype.DNASequence, circular bool, origin int, minOverlap int, misprimingLength int) (product wtype.DNASequence, junctions []GibsonJunction, err error) {
+	if misprimingLength <= 0 {
+		misprimingLength = DefaultMisprimingLength
+	}
+
+	type junction struct {
+		upstream, downstream int
+		overlap              int
+	}
+	var joins []junction
+	for i := range fragments {
+		next := (i + 1) % len(fragments)
+		if !circular && next == 0 {
+			break
+		}
+		overlap := endOverlap(fragments[i].Seq, fragments[next].Seq, minOverlap)
+		if overlap == 0 {
+			return product, junctions, fmt.Errorf("%s and %s do not share an overlap of at least %d bp", fragments[i].Nm, fragments[next].Nm, minOverlap)
+		}
+		joins = append(joins, junction{upstream: i, downstream: next, overlap: overlap})
+	}
+
+	// offsets[i] is the position in the product of the first base of fragment i
+	offsets := make([]int, len(fragments))
+	var seq string
+	for i, fragment := range fragments {
+		offsets[i] = len(seq)
+		if i > 0 {
+			offsets[i] -= joins[i-1].overlap
+			seq += strings.ToUpper(fragment.Seq[joins[i-1].overlap:])
+		} else {
+			seq = strings.ToUpper(fragment.Seq)
+		}
+	}
+	length := 0
+	if circular {
+		// the end of the last fragment is the start of the first
+		seq = seq[:len(seq)-joins[len(joins)-1].overlap]
+		length = len(seq)
+		seq = seq[origin:] + seq[:origin]
+		for i := range offsets {
+			offsets[i] -= origin
+		}
+	}
+
+	product = wtype.MakeLinearDNASequence(strings.Join(names(fragments), "_"), seq)
+	product.Plasmid = circular
+
+	seen := make(map[wtype.Feature]bool)
+	for i, fragment := range fragments {
+		for _, feature := range fragment.Features {
+			shifted := shiftFeature(feature, offsets[i], length)
+			if !seen[shifted] {
+				seen[shifted] = true
+				product.Features = append(product.Features, shifted)
+			}
+		}
+	}
+
+	for _, join := range joins {
+		start := offsets[join.downstream]
+		if start < 0 {
+			start += length
+		}
+		overlap := strings.ToUpper(fragments[join.downstream].Seq[:join.overlap])
+		junctions = append(junctions, GibsonJunction{
+			Upstream:    fragments[join.upstream].Nm,
+			Downstream:  fragments[join.downstream].Nm,
+			Overlap:     overlap,
+			MeltingTemp: oligos.BasicMeltingTemp(wtype.MakeLinearDNASequence("overlap", overlap)),
+			Mispriming:  misprimingSites(seq, circular, overlap, start, misprimingLength),
+		})
+	}
+
+	return product, junctions, nil
+}
+
+// misprimingSites returns descriptions of the positions, other than the
+// overlap itself at start, at which the 3' ends left on either strand of the
+// overlap by exonuclease digestion could anneal in the product.
+func misprimingSites(product string, circular bool, overlap string, start int, length int) []string {
+	if length > len(overlap) {
+		length = len(overlap)
+	}
+	searched := product
+	if circular {
+		searched += product[:len(overlap)-1]
+	}
+
+	var sites []string
+	for _, end := range []struct {
+		name     string
+		probe    string
+		expected int
+	}{
+		// the top strand's 3' end is the end of the overlap and the bottom
+		// strand's is the complement of its start
+		{name: "3' end of top strand", probe: overlap[len(overlap)-length:], expected: start + len(overlap) - length},
+		{name: "3' end of bottom strand", probe: overlap[:length], expected: start},
+	} {
+		expected := end.expected
+		if circular {
+			expected %= len(product)
+		}
+		for _, strand := range []struct {
+			name  string
+			probe string
+		}{
+			{name: "forward", probe: end.probe},
+			{name: "reverse", probe: sequences.RevComp(end.probe)},
+		} {
+			if strand.name == "reverse" && strand.probe == end.probe {
+				// palindromic, so already found on the forward strand
+				continue
+			}
+			for i := 0; i+length <= len(searched); i++ {
+				if searched[i:i+length] != strand.probe {
+					continue
+				}
+				position := i
+				if circular {
+					position = i % len(product)
+				}
+				if strand.name == "forward" && position == expected {
+					continue
+				}
+				if circular && i >= len(product) {
+					// already seen at the start of the product
+					continue
+				}
+				sites = append(sites, fmt.Sprintf("%s also anneals at position %d (%s strand)", end.name, position+1, strand.name))
+			}
+		}
+	}
+	return sites
+}
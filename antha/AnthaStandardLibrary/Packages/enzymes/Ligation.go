@@ -490,6 +490,7 @@ const thresholdForOnlyTestingPartsInSpecifiedOrder int = 4
 
 /*
 Assemblysimulator simulates assembly of Assemblyparameters: returns status, number of correct assemblies, any restriction sites found, new DNA Sequences and an error.
+If a plasmid is expected to form the status also reports a heuristic estimate of the compatibility of the overhangs of each junction, see AssemblyFidelity and DefaultLigationFrequencies.
 
 Currently the more comprehensive assembly validation function (FindAllAssemblyProducts) tests all part order combinations;
 this is thorough and more powerful at detecting potential mis assemblies but is very computationally expensive hence
@@ -575,6 +576,10 @@ func Assemblysimulator(assemblyparameters Assemblyparameters) (s string, success
 		s = merr.Error()
 	}
 
+	if len(plasmidProducts) > 0 {
+		s = s + "\n" + AssemblyFidelity(assemblyparameters.Vector, assemblyparameters.Partsinorder, enzyme, nil).String()
+	}
+
 	if len(plasmidProducts) == 0 && len(failedAssemblies) > 0 {
 
 		s = fmt.Sprint("Ooh, only partial assembly expected: ", assemblyparameters.Partsinorder[(len(assemblyparameters.Partsinorder)-1)].Nm, " and ", assemblyparameters.Vector.Nm, ": ", "Not compatible, check ends")
@@ -0,0 +1,465 @@
+// antha/AnthaStandardLibrary/Packages/enzymes/Overhangdesign.go: Part of the Antha language
+// Copyright (C) 2018 The Antha authors. All rights reserved.
+//
+// This program is free software; you can redistribute it and/or
+// modify it under the terms of the GNU General Public License
+// as published by the Free Software Foundation; either version 2
+// of the License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program; if not, write to the Free Software
+// Foundation, Inc., 51 Franklin Street, Fifth Floor, Boston, MA  02110-1301, USA.
+//
+// For more information relating to the software or licensing issues please
+// contact license@antha-lang.org or write to the Antha team c/o
+// Synthace Ltd. The London Bioscience Innovation Centre
+// 2 Royal College St, London NW1 0NH UK
+
+package enzymes
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/antha-lang/antha/antha/AnthaStandardLibrary/Packages/sequences"
+	"github.com/antha-lang/antha/antha/anthalib/wtype"
+)
+
+// LigationFrequencies gives the relative frequency with which an overhang
+// anneals to a partner overhang and is ligated. Both overhangs are written 5'
+// to 3', so an overhang ligates correctly to its reverse complement.
+type LigationFrequencies interface {
+	Frequency(overhang, partner string) float64
+}
+
+// pairWeights are heuristic weights for each pair of bases in annealed
+// overhangs, keyed by the base in the overhang followed by the base in the
+// partner. The first value applies to pairs within the overhang and the
+// second to pairs at either end of it, where mismatches are better tolerated.
+// They are not measured frequencies: they only rank Watson-Crick pairs above
+// G:T mismatches, the mismatch T4 DNA ligase is most likely to join, and those
+// above other mismatches.
+var pairWeights = map[string][2]float64{
+	"AT": {0.9, 0.9},
+	"TA": {0.9, 0.9},
+	"GC": {1.0, 1.0},
+	"CG": {1.0, 1.0},
+	"GT": {0.05, 0.15},
+	"TG": {0.05, 0.15},
+	"GA": {0.01, 0.04},
+	"AG": {0.01, 0.04},
+	"TT": {0.01, 0.04},
+	"AA": {0.002, 0.01},
+	"CC": {0.002, 0.01},
+	"GG": {0.002, 0.01},
+	"AC": {0.002, 0.01},
+	"CA": {0.002, 0.01},
+	"CT": {0.002, 0.01},
+	"TC": {0.002, 0.01},
+}
+
+type pairWeightModel struct{}
+
+// Frequency returns the product of the weights of each pair of bases
+func (pairWeightModel) Frequency(overhang, partner string) float64 {
+	overhang, partner = strings.ToUpper(overhang), strings.ToUpper(partner)
+	n := len(overhang)
+	if n == 0 || len(partner) != n {
+		return 0
+	}
+	f := 1.0
+	for i := 0; i < n; i++ {
+		w, found := pairWeights[string([]byte{overhang[i], partner[n-1-i]})]
+		if !found {
+			return 0
+		}
+		if i == 0 || i == n-1 {
+			f *= w[1]
+		} else {
+			f *= w[0]
+		}
+	}
+	return f
+}
+
+// DefaultLigationFrequencies returns ligation frequencies for overhangs of
+// any length estimated by multiplying the heuristic weights of each base
+// pair. The estimates only rank overhangs roughly, so for quantitative
+// predictions use measured frequencies read with ReadLigationTable.
+func DefaultLigationFrequencies() LigationFrequencies {
+	return pairWeightModel{}
+}
+
+// LigationTable holds measured ligation frequencies, keyed by overhang and
+// then partner overhang
+type LigationTable map[string]map[string]float64
+
+// Frequency returns the frequency in the table, or zero if the pair is not
+// present
+func (t LigationTable) Frequency(overhang, partner string) float64 {
+	return t[strings.ToUpper(overhang)][strings.ToUpper(partner)]
+}
+
+// ReadLigationTable reads a matrix of ligation frequencies in CSV format. The
+// first row is a header giving the partner overhang of each column after the
+// first, and each following row gives an overhang and its frequency of
+// ligation to each partner, as in the supplementary data of Potapov et al.
+// (2018) ACS Synthetic Biology 7:2665.
+func ReadLigationTable(r io.Reader) (LigationTable, error) {
+	records, err := csv.NewReader(r).ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(records) < 2 {
+		return nil, fmt.Errorf("ligation table must have a header and at least one row")
+	}
+
+	header := records[0]
+	table := make(LigationTable, len(records)-1)
+	for i, record := range records[1:] {
+		if len(record) != len(header) {
+			return nil, fmt.Errorf("ligation table row %d has %d fields, header has %d", i+2, len(record), len(header))
+		}
+		overhang := strings.ToUpper(strings.TrimSpace(record[0]))
+		row := make(map[string]float64, len(header)-1)
+		for j := 1; j < len(record); j++ {
+			f, err := strconv.ParseFloat(strings.TrimSpace(record[j]), 64)
+			if err != nil {
+				return nil, fmt.Errorf("ligation table row %d column %d: %s", i+2, j+1, err)
+			}
+			row[strings.ToUpper(strings.TrimSpace(header[j]))] = f
+		}
+		table[overhang] = row
+	}
+	return table, nil
+}
+
+// JunctionFidelity is the predicted fidelity of one junction of an assembly
+type JunctionFidelity struct {
+	Upstream   string  // name of the part upstream of the junction
+	Downstream string  // name of the part downstream of the junction
+	Overhang   string  // overhang of the junction on the top strand
+	Fidelity   float64 // predicted fraction of ligations at this junction which are correct
+	// Misligation is the overhang most likely to be ligated incorrectly to
+	// either strand of the junction, if any
+	Misligation string
+}
+
+// FidelityReport is the predicted ligation fidelity of the junctions of an
+// assembly
+type FidelityReport struct {
+	Junctions []JunctionFidelity
+	// Fidelity is the predicted fraction of assemblies in which every
+	// junction is ligated correctly
+	Fidelity float64
+	// Heuristic is set when the report uses DefaultLigationFrequencies rather
+	// than measured frequencies, so only ranks the overhangs roughly
+	Heuristic bool
+}
+
+func (r FidelityReport) String() string {
+	title := "Predicted ligation fidelity"
+	if r.Heuristic {
+		title = "Heuristic overhang compatibility (not measured ligation fidelity)"
+	}
+	lines := []string{fmt.Sprintf("%s: %.1f%%", title, 100*r.Fidelity)}
+	for _, j := range r.Junctions {
+		line := fmt.Sprintf("%s: %.1f%%", j.Overhang, 100*j.Fidelity)
+		if j.Upstream != "" || j.Downstream != "" {
+			line = fmt.Sprintf("%s-%s %s", j.Upstream, j.Downstream, line)
+		}
+		if j.Misligation != "" {
+			line += fmt.Sprintf(" (most likely misligation with %s)", j.Misligation)
+		}
+		lines = append(lines, line)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// OverhangFidelity predicts the fidelity of ligation of each of the junction
+// overhangs of an assembly. If frequencies is nil DefaultLigationFrequencies
+// is used and the report is marked as heuristic. The fidelity of each strand of a junction is the
+// frequency of ligation to its reverse complement as a fraction of its
+// ligation to all overhangs present, which are the junction overhangs, any
+// other overhangs given and the reverse complements of both.
+func OverhangFidelity(junctions []string, others []string, frequencies LigationFrequencies) FidelityReport {
+	if frequencies == nil {
+		frequencies = DefaultLigationFrequencies()
+	}
+
+	var pool []string
+	seen := make(map[string]bool)
+	for _, o := range append(append([]string{}, junctions...), others...) {
+		o = strings.ToUpper(o)
+		for _, s := range []string{o, sequences.RevComp(o)} {
+			if !seen[s] {
+				seen[s] = true
+				pool = append(pool, s)
+			}
+		}
+	}
+
+	report := FidelityReport{Fidelity: 1}
+	_, report.Heuristic = frequencies.(pairWeightModel)
+	for _, j := range junctions {
+		jf := JunctionFidelity{Overhang: strings.ToUpper(j), Fidelity: 1}
+		var worst float64
+		for _, o := range []string{jf.Overhang, sequences.RevComp(jf.Overhang)} {
+			partner := sequences.RevComp(o)
+			var total float64
+			for _, p := range pool {
+				f := frequencies.Frequency(o, p)
+				total += f
+				if p != partner && f > worst {
+					worst, jf.Misligation = f, p
+				}
+			}
+			if total > 0 {
+				jf.Fidelity *= frequencies.Frequency(o, partner) / total
+			} else {
+				jf.Fidelity = 0
+			}
+		}
+		report.Junctions = append(report.Junctions, jf)
+		report.Fidelity *= jf.Fidelity
+	}
+	return report
+}
+
+// maxDesignedOverhangLength limits the length of overhangs which can be
+// designed, since all possible overhangs are considered
+const maxDesignedOverhangLength = 5
+
+// minOverhangDistance is the minimum number of differences between a
+// designed overhang and the other overhangs of an assembly, or their reverse
+// complements
+const minOverhangDistance = 2
+
+func hammingDistance(a, b string) int {
+	d := 0
+	for i := range a {
+		if a[i] != b[i] {
+			d++
+		}
+	}
+	return d
+}
+
+// compatibleOverhang returns whether the candidate overhang is neither a
+// palindrome nor close to any of the other overhangs or their reverse
+// complements
+func compatibleOverhang(candidate string, others []string) bool {
+	if hammingDistance(candidate, sequences.RevComp(candidate)) < minOverhangDistance {
+		return false
+	}
+	for _, o := range others {
+		if len(o) != len(candidate) {
+			continue
+		}
+		if hammingDistance(candidate, o) < minOverhangDistance || hammingDistance(candidate, sequences.RevComp(o)) < minOverhangDistance {
+			return false
+		}
+	}
+	return true
+}
+
+// allOverhangs returns all overhangs of the given length
+func allOverhangs(length int) []string {
+	arr := make([][]string, length)
+	for i := range arr {
+		arr[i] = nucleotides
+	}
+	all := allCombinations(arr)
+	sort.Strings(all)
+	return all
+}
+
+// DesignOverhangs chooses number overhangs of the given length which, with
+// the fixed overhangs of the assembly such as those of the vector, maximise
+// the predicted ligation fidelity of the assembly. Overhangs to avoid, such
+// as those already present in the parts, are not chosen and are assumed to be
+// present when predicting fidelity. Palindromes and overhangs within one base
+// of another overhang or its reverse complement are never chosen.
+// The chosen overhangs are returned in the order in which they were found,
+// along with the fidelity report of the fixed followed by the chosen
+// overhangs.
+func DesignOverhangs(number, length int, fixed, avoid []string, frequencies LigationFrequencies) (overhangs []string, report FidelityReport, err error) {
+	if length < 1 || length > maxDesignedOverhangLength {
+		return nil, report, fmt.Errorf("cannot design overhangs of length %d, length must be between 1 and %d", length, maxDesignedOverhangLength)
+	}
+	if frequencies == nil {
+		frequencies = DefaultLigationFrequencies()
+	}
+
+	upper := func(seqs []string) []string {
+		ret := make([]string, 0, len(seqs))
+		for _, s := range seqs {
+			ret = append(ret, strings.ToUpper(s))
+		}
+		return ret
+	}
+	fixed, avoid = upper(fixed), upper(avoid)
+
+	candidates := allOverhangs(length)
+	frequency := make(map[[2]string]float64, len(candidates)*len(candidates))
+	freq := func(a, b string) float64 {
+		key := [2]string{a, b}
+		f, found := frequency[key]
+		if !found {
+			f = frequencies.Frequency(a, b)
+			frequency[key] = f
+		}
+		return f
+	}
+
+	// score returns the log of the fidelity of the fixed and chosen overhangs
+	score := func(chosen []string) float64 {
+		junctions := append(append([]string{}, fixed...), chosen...)
+		var pool []string
+		for _, o := range append(append([]string{}, junctions...), avoid...) {
+			pool = append(pool, o, sequences.RevComp(o))
+		}
+		var s float64
+		for _, j := range junctions {
+			for _, o := range []string{j, sequences.RevComp(j)} {
+				var total float64
+				for _, p := range pool {
+					total += freq(o, p)
+				}
+				s += math.Log(freq(o, sequences.RevComp(o)) / total)
+			}
+		}
+		return s
+	}
+
+	// allowed returns the candidates compatible with all overhangs except
+	// the chosen overhang at position skip
+	allowed := func(chosen []string, skip int) []string {
+		others := append(append([]string{}, fixed...), avoid...)
+		for i, c := range chosen {
+			if i != skip {
+				others = append(others, c)
+			}
+		}
+		var ret []string
+		for _, c := range candidates {
+			if compatibleOverhang(c, others) {
+				ret = append(ret, c)
+			}
+		}
+		return ret
+	}
+
+	// choose greedily
+	for len(overhangs) < number {
+		best, bestScore := "", math.Inf(-1)
+		for _, c := range allowed(overhangs, -1) {
+			if s := score(append(overhangs, c)); s > bestScore {
+				best, bestScore = c, s
+			}
+		}
+		if best == "" {
+			return nil, report, fmt.Errorf("cannot design %d overhangs of length %d: only %d compatible overhangs found", number, length, len(overhangs))
+		}
+		overhangs = append(overhangs, best)
+	}
+
+	// then improve by exchanging one overhang at a time
+	current := score(overhangs)
+	for improved := true; improved; {
+		improved = false
+		for i := range overhangs {
+			old := overhangs[i]
+			for _, c := range allowed(overhangs, i) {
+				overhangs[i] = c
+				if s := score(overhangs); s > current+1e-12 {
+					current, old, improved = s, c, true
+				}
+			}
+			overhangs[i] = old
+		}
+	}
+
+	return overhangs, OverhangFidelity(append(append([]string{}, fixed...), overhangs...), avoid, frequencies), nil
+}
+
+// MakeCustomTypeIIsassemblyParts adds typeIIs assembly ends to a set of parts
+// so that they assemble into the vector in the order specified, choosing the
+// overhang of each junction between parts to maximise the predicted ligation
+// fidelity of the assembly. The overhangs at either end of the insert are
+// those of the vector cut with the enzyme, and any other overhangs produced
+// by cutting the vector or the parts themselves are avoided. The designed
+// overhangs are added as scars between the parts by AddCustomEnds. If
+// frequencies is nil DefaultLigationFrequencies is used.
+func MakeCustomTypeIIsassemblyParts(parts []wtype.DNASequence, vector wtype.DNASequence, enzyme wtype.TypeIIs, frequencies LigationFrequencies) (partswithends []wtype.DNASequence, report FidelityReport, err error) {
+	if len(parts) == 0 {
+		return nil, report, fmt.Errorf("no parts specified")
+	}
+
+	vector5prime, vector3prime := VectorEnds(vector, enzyme)
+	if vector5prime == "" || vector3prime == "" {
+		return nil, report, fmt.Errorf("vector %s is not cut twice by %s", vector.Nm, enzyme.Name())
+	}
+
+	var avoid []string
+	for _, seq := range append([]wtype.DNASequence{vector}, parts...) {
+		_, stickyends5, _ := TypeIIsdigest(seq, enzyme)
+		for _, end := range stickyends5 {
+			if end != "" && end != vector5prime && end != vector3prime {
+				avoid = append(avoid, end)
+			}
+		}
+	}
+
+	designed, _, err := DesignOverhangs(len(parts)-1, len(vector5prime), []string{vector5prime, vector3prime}, avoid, frequencies)
+	if err != nil {
+		return nil, report, err
+	}
+
+	junctions := append(append([]string{vector5prime}, designed...), vector3prime)
+	for i, part := range parts {
+		partswithends = append(partswithends, AddCustomEnds(part, enzyme, junctions[i], junctions[i+1]))
+	}
+
+	report = OverhangFidelity(junctions, avoid, frequencies)
+	for i := range report.Junctions {
+		report.Junctions[i].Upstream = vector.Nm
+		if i > 0 {
+			report.Junctions[i].Upstream = parts[i-1].Nm
+		}
+		report.Junctions[i].Downstream = vector.Nm
+		if i < len(parts) {
+			report.Junctions[i].Downstream = parts[i].Nm
+		}
+	}
+
+	return partswithends, report, nil
+}
+
+// AssemblyFidelity predicts the ligation fidelity of an assembly from the
+// overhangs produced by cutting the vector and parts with the enzyme
+func AssemblyFidelity(vector wtype.DNASequence, parts []wtype.DNASequence, enzyme wtype.TypeIIs, frequencies LigationFrequencies) FidelityReport {
+	var junctions []string
+	seen := make(map[string]bool)
+	for _, seq := range append([]wtype.DNASequence{vector}, parts...) {
+		_, stickyends5, _ := TypeIIsdigest(seq, enzyme)
+		for _, end := range stickyends5 {
+			end = strings.ToUpper(end)
+			if end != "" && !seen[end] {
+				seen[end] = true
+				junctions = append(junctions, end)
+			}
+		}
+	}
+	return OverhangFidelity(junctions, nil, frequencies)
+}
@@ -0,0 +1,177 @@
+package enzymes
+
+import (
+	"math/rand"
+	"strings"
+	"testing"
+
+	"github.com/antha-lang/antha/antha/anthalib/wtype"
+)
+
+func randomSequence(r *rand.Rand, length int) string {
+	bases := "ACGT"
+	seq := make([]byte, length)
+	for i := range seq {
+		seq[i] = bases[r.Intn(len(bases))]
+	}
+	return string(seq)
+}
+
+func gibsonTestSequences() (vector wtype.DNASequence, parts []wtype.DNASequence) {
+	r := rand.New(rand.NewSource(1))
+	vector = wtype.MakePlasmidDNASequence("vector", randomSequence(r, 300))
+	gene := wtype.MakeLinearDNASequence("gene", randomSequence(r, 200))
+	gene.Features = []wtype.Feature{{Name: "cds", Class: "CDS", StartPosition: 11, EndPosition: 190}}
+	terminator := wtype.MakeLinearDNASequence("terminator", randomSequence(r, 150))
+	return vector, []wtype.DNASequence{gene, terminator}
+}
+
+func TestDesignGibsonAssembly(t *testing.T) {
+	vector, parts := gibsonTestSequences()
+	assembly, err := DesignGibsonAssembly(parts, vector, DefaultGibsonParameters())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := strings.ToUpper(vector.Seq + parts[0].Seq + parts[1].Seq)
+	if assembly.Product.Seq != expected || !assembly.Product.Plasmid {
+		t.Errorf("expected plasmid %s, got %s", expected, assembly.Product.Seq)
+	}
+	if len(assembly.Product.Features) != 1 || assembly.Product.Features[0].StartPosition != 311 || assembly.Product.Features[0].EndPosition != 490 {
+		t.Errorf("expected cds at 311..490, got %v", assembly.Product.Features)
+	}
+
+	if len(assembly.Fragments) != 3 {
+		t.Fatalf("expected 3 fragments, got %d", len(assembly.Fragments))
+	}
+	gene := assembly.Fragments[1]
+	if !strings.HasPrefix(gene.Forward.Sequence(), strings.ToUpper(vector.Seq[len(vector.Seq)-15:])) {
+		t.Errorf("expected forward primer %s to start with end of vector", gene.Forward.Sequence())
+	}
+	if gene.Forward.MeltingTemp.SIValue() <= 55 || gene.Reverse.MeltingTemp.SIValue() <= 55 || !gene.Reverse.Reverse {
+		t.Errorf("unexpected primers %+v %+v", gene.Forward, gene.Reverse)
+	}
+	if len(gene.Product.Seq) != 15+len(parts[0].Seq)+15 {
+		t.Errorf("expected PCR product with 15 bp tails, got %d bp", len(gene.Product.Seq))
+	}
+
+	if len(assembly.Junctions) != 3 {
+		t.Fatalf("expected 3 junctions, got %d", len(assembly.Junctions))
+	}
+	for _, j := range assembly.Junctions {
+		if len(j.Overlap) != 30 || !strings.Contains(expected+expected[:29], j.Overlap) {
+			t.Errorf("unexpected overlap %s between %s and %s", j.Overlap, j.Upstream, j.Downstream)
+		}
+	}
+	if warnings := assembly.Warnings(); len(warnings) != 0 {
+		t.Errorf("unexpected warnings %v", warnings)
+	}
+
+	// the same product is made whatever order the fragments are mixed in
+	var products []wtype.DNASequence
+	for _, i := range []int{0, 2, 1} {
+		products = append(products, assembly.Fragments[i].Product)
+	}
+	product, junctions, err := SimulateGibsonAssembly(products, 20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the simulated plasmid starts with the tail of the vector
+	if len(product.Seq) != len(expected) || !strings.Contains(expected+expected, product.Seq) || len(junctions) != 3 || len(product.Features) != 1 {
+		t.Errorf("expected simulated product to match design, got %s with %d junctions", product.Seq, len(junctions))
+	}
+}
+
+func TestDesignGibsonAssemblyLinearisedVector(t *testing.T) {
+	vector, parts := gibsonTestSequences()
+	parameters := DefaultGibsonParameters()
+	parameters.AmplifyVector = false
+	assembly, err := DesignGibsonAssembly(parts, vector, parameters)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v := assembly.Fragments[0]; v.Forward.Sequence() != "" || v.Product.Seq != vector.Seq {
+		t.Errorf("expected vector not to be amplified, got %+v", v)
+	}
+	if tail := strings.ToUpper(vector.Seq[len(vector.Seq)-30:]); !strings.HasPrefix(assembly.Fragments[1].Forward.Sequence(), tail) {
+		t.Errorf("expected forward primer %s to carry whole overlap with vector", assembly.Fragments[1].Forward.Sequence())
+	}
+	if assembly.Product.Seq != strings.ToUpper(vector.Seq+parts[0].Seq+parts[1].Seq) {
+		t.Errorf("unexpected product %s", assembly.Product.Seq)
+	}
+}
+
+func TestDesignGibsonAssemblyThermodynamicConstraints(t *testing.T) {
+	vector, parts := gibsonTestSequences()
+
+	// every primer which could anneal at the start of the terminator forms
+	// a self-dimer more stable than -9 kcal/mol or anneals above 72 ℃
+	parameters := DefaultGibsonParameters()
+	parameters.Primers.NearestNeighbour = true
+	if _, err := DesignGibsonAssembly(parts, vector, parameters); err != nil {
+		t.Fatal(err)
+	}
+	parameters.Primers.MinSelfDimerDeltaG = -9
+	if _, err := DesignGibsonAssembly(parts, vector, parameters); err == nil || !strings.Contains(err.Error(), "terminator") {
+		t.Errorf("expected primers for terminator to be rejected, got %v", err)
+	}
+
+	// the start of this part folds into a hairpin with a 10 bp stem
+	r := rand.New(rand.NewSource(3))
+	hairpin := wtype.MakeLinearDNASequence("hairpin", "ATGCAGTCAGTTTTCTGACTGCAT"+randomSequence(r, 100))
+	parts = append(parts, hairpin)
+	if _, err := DesignGibsonAssembly(parts, vector, DefaultGibsonParameters()); err != nil {
+		t.Fatal(err)
+	}
+
+	parameters = DefaultGibsonParameters()
+	parameters.Primers.MinHairpinDeltaG = -3
+	if _, err := DesignGibsonAssembly(parts, vector, parameters); err == nil || !strings.Contains(err.Error(), "hairpin") {
+		t.Errorf("expected primers for hairpin to be rejected, got %v", err)
+	}
+}
+
+func TestSimulateGibsonAssembly(t *testing.T) {
+	r := rand.New(rand.NewSource(2))
+	a, overlap, b, c := randomSequence(r, 60), randomSequence(r, 25), randomSequence(r, 60), randomSequence(r, 40)
+
+	product, junctions, err := SimulateGibsonAssembly([]wtype.DNASequence{
+		wtype.MakeLinearDNASequence("b", overlap+b),
+		wtype.MakeLinearDNASequence("a", a+overlap),
+	}, 20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if product.Seq != a+overlap+b || product.Plasmid {
+		t.Errorf("expected linear product %s, got %s", a+overlap+b, product.Seq)
+	}
+	if len(junctions) != 1 || junctions[0].Upstream != "a" || len(junctions[0].Mispriming) != 0 {
+		t.Errorf("unexpected junctions %+v", junctions)
+	}
+
+	// the 3' end of the overlap is repeated in the reverse orientation
+	_, junctions, err = SimulateGibsonAssembly([]wtype.DNASequence{
+		wtype.MakeLinearDNASequence("a", a+overlap),
+		wtype.MakeLinearDNASequence("b", overlap+b+wtype.RevComp(overlap[10:])),
+	}, 20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(junctions) != 1 || len(junctions[0].Mispriming) != 1 {
+		t.Errorf("expected one mis-priming site, got %+v", junctions)
+	}
+
+	if _, _, err := SimulateGibsonAssembly([]wtype.DNASequence{
+		wtype.MakeLinearDNASequence("a", a+overlap),
+		wtype.MakeLinearDNASequence("b", overlap+b),
+		wtype.MakeLinearDNASequence("c", overlap+c),
+	}, 20); err == nil {
+		t.Error("expected error for ambiguous assembly")
+	}
+	if _, _, err := SimulateGibsonAssembly([]wtype.DNASequence{
+		wtype.MakeLinearDNASequence("a", a+overlap),
+		wtype.MakeLinearDNASequence("c", c),
+	}, 20); err == nil {
+		t.Error("expected error for fragments without overlap")
+	}
+}
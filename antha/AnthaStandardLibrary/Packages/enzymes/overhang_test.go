@@ -0,0 +1,174 @@
+package enzymes
+
+import (
+	"encoding/json"
+	"math/rand"
+	"strings"
+	"testing"
+
+	"github.com/antha-lang/antha/antha/AnthaStandardLibrary/Packages/sequences"
+	"github.com/antha-lang/antha/antha/anthalib/wtype"
+)
+
+func TestLigationFrequencies(t *testing.T) {
+	f := DefaultLigationFrequencies()
+	correct := f.Frequency("AATG", "CATT")
+	if mismatch := f.Frequency("AATG", "CATC"); mismatch >= correct {
+		t.Errorf("expected mismatch %g to ligate less than correct pair %g", mismatch, correct)
+	}
+	if wobble, other := f.Frequency("AATG", "TATT"), f.Frequency("AATG", "AATT"); wobble <= other {
+		t.Errorf("expected G:T mismatch %g to ligate more than A:A mismatch %g", wobble, other)
+	}
+
+	table, err := ReadLigationTable(strings.NewReader("Overhang,CATT,CATC\nAATG,420,3\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if table.Frequency("aatg", "CATT") != 420 || table.Frequency("AATG", "CATC") != 3 || table.Frequency("AATG", "GGGG") != 0 {
+		t.Errorf("unexpected table %v", table)
+	}
+	if _, err := ReadLigationTable(strings.NewReader("Overhang,CATT\nAATG,x\n")); err == nil {
+		t.Error("expected error for non-numeric frequency")
+	}
+}
+
+func TestOverhangFidelity(t *testing.T) {
+	good := OverhangFidelity([]string{"GGAG", "TACT", "AATG"}, nil, nil)
+	if len(good.Junctions) != 3 {
+		t.Fatalf("expected 3 junctions, got %d", len(good.Junctions))
+	}
+	product := 1.0
+	for _, j := range good.Junctions {
+		product *= j.Fidelity
+	}
+	if good.Fidelity != product {
+		t.Errorf("expected fidelity %g to be product of junction fidelities %g", good.Fidelity, product)
+	}
+
+	// AATG and AATC differ only at the end
+	bad := OverhangFidelity([]string{"GGAG", "TACT", "AATG", "AATC"}, nil, nil)
+	if bad.Fidelity >= good.Fidelity {
+		t.Errorf("expected near duplicate overhangs to reduce fidelity from %g, got %g", good.Fidelity, bad.Fidelity)
+	}
+	if !good.Heuristic || !strings.HasPrefix(good.String(), "Heuristic overhang compatibility") {
+		t.Errorf("expected report using default frequencies to be heuristic, got %s", good)
+	}
+
+	table := LigationTable{
+		"AATG": {"CATT": 400, "CATC": 4},
+		"CATT": {"AATG": 400},
+	}
+	measured := OverhangFidelity([]string{"AATG"}, nil, table)
+	if measured.Heuristic || !strings.HasPrefix(measured.String(), "Predicted ligation fidelity") {
+		t.Errorf("expected report using measured frequencies to be a prediction, got %s", measured)
+	}
+	if bad.Junctions[2].Misligation != sequences.RevComp("AATC") {
+		t.Errorf("expected AATG to misligate with %s, got %s", sequences.RevComp("AATC"), bad.Junctions[2].Misligation)
+	}
+}
+
+func TestDesignOverhangs(t *testing.T) {
+	fixed := []string{"GGAG", "CGCT"}
+	avoid := []string{"AATG"}
+	overhangs, report, err := DesignOverhangs(8, 4, fixed, avoid, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(overhangs) != 8 {
+		t.Fatalf("expected 8 overhangs, got %v", overhangs)
+	}
+	if len(report.Junctions) != 10 || report.Junctions[0].Overhang != "GGAG" {
+		t.Errorf("expected report of fixed and designed overhangs, got %v", report)
+	}
+	if report.Fidelity < 0.9 {
+		t.Errorf("expected fidelity above 90%%, got %s", report)
+	}
+
+	all := append(append(append([]string{}, fixed...), avoid...), overhangs...)
+	for i, o := range overhangs {
+		if o == sequences.RevComp(o) {
+			t.Errorf("palindrome %s chosen", o)
+		}
+		for _, other := range all {
+			if other == o {
+				continue
+			}
+			if hammingDistance(o, other) < 2 || hammingDistance(o, sequences.RevComp(other)) < 2 {
+				t.Errorf("overhang %d %s too close to %s", i, o, other)
+			}
+		}
+	}
+
+	if _, _, err := DesignOverhangs(100, 4, nil, nil, nil); err == nil {
+		t.Error("expected error designing too many overhangs")
+	}
+}
+
+// customAssemblyParts returns the vector of data0 and parts without SapI
+// sites or ends
+func customAssemblyParts(t *testing.T) (vector wtype.DNASequence, parts []wtype.DNASequence) {
+	var seqs []wtype.DNASequence
+	if err := json.Unmarshal([]byte(data0), &seqs); err != nil {
+		t.Fatal(err)
+	}
+	vector = seqs[len(seqs)-1]
+
+	r := rand.New(rand.NewSource(1))
+	for _, name := range []string{"promoter", "cds", "terminator"} {
+		seq := randomSequence(r, 120)
+		for strings.Contains(seq, "GCTCTTC") || strings.Contains(seq, "GAAGAGC") {
+			seq = randomSequence(r, 120)
+		}
+		parts = append(parts, wtype.MakeLinearDNASequence(name, seq))
+	}
+	return vector, parts
+}
+
+func TestMakeCustomTypeIIsassemblyParts(t *testing.T) {
+	vector, parts := customAssemblyParts(t)
+
+	partswithends, report, err := MakeCustomTypeIIsassemblyParts(parts, vector, SapI, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(partswithends) != len(parts) {
+		t.Fatalf("expected %d parts with ends, got %d", len(parts), len(partswithends))
+	}
+
+	vector5prime, vector3prime := VectorEnds(vector, SapI)
+	if len(report.Junctions) != len(parts)+1 {
+		t.Fatalf("expected %d junctions, got %v", len(parts)+1, report)
+	}
+	if first, last := report.Junctions[0], report.Junctions[len(parts)]; first.Overhang != vector5prime || last.Overhang != vector3prime {
+		t.Errorf("expected insert to start with %s and end with %s, got %v", vector5prime, vector3prime, report)
+	}
+	if j := report.Junctions[1]; j.Upstream != "promoter" || j.Downstream != "cds" {
+		t.Errorf("expected junction between promoter and cds, got %s-%s", j.Upstream, j.Downstream)
+	}
+
+	// each part is cut out with the overhangs of its junctions
+	for i, part := range partswithends {
+		_, stickyends5, _ := TypeIIsdigest(part, SapI)
+		if strings.Join(stickyends5, ",") != ","+report.Junctions[i].Overhang+","+report.Junctions[i+1].Overhang {
+			t.Errorf("expected %s to be cut leaving %s and %s, got %v", part.Nm, report.Junctions[i].Overhang, report.Junctions[i+1].Overhang, stickyends5)
+		}
+	}
+
+	status, count, _, _, err := Assemblysimulator(Assemblyparameters{
+		Enzymename:   "sapi",
+		Vector:       vector,
+		Partsinorder: partswithends,
+	})
+	if err != nil {
+		t.Fatalf("%s: %s", err, status)
+	}
+	if count != 1 {
+		t.Errorf("expected successful assembly, got %s", status)
+	}
+
+	// the simulator reports the same fidelity as the design
+	expected := AssemblyFidelity(vector, partswithends, SapI, nil).String()
+	if !strings.Contains(status, "Heuristic overhang compatibility") || strings.Contains(status, "Predicted ligation fidelity") || !strings.HasSuffix(status, expected) {
+		t.Errorf("expected status to end with fidelity report:\n%s\ngot:\n%s", expected, status)
+	}
+}
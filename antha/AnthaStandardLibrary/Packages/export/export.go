@@ -41,6 +41,8 @@ import (
 	"github.com/antha-lang/antha/antha/AnthaStandardLibrary/Packages/enzymes"
 	"github.com/antha-lang/antha/antha/AnthaStandardLibrary/Packages/enzymes/lookup"
 	"github.com/antha-lang/antha/antha/AnthaStandardLibrary/Packages/sequences"
+	"github.com/antha-lang/antha/antha/AnthaStandardLibrary/Packages/sequences/parse/sbol"
+	"github.com/antha-lang/antha/antha/AnthaStandardLibrary/Packages/sequences/parse/snapgene"
 	"github.com/antha-lang/antha/antha/anthalib/wtype"
 	"github.com/antha-lang/antha/antha/anthalib/wutil"
 )
@@ -542,6 +544,24 @@ func CSV(records [][]string, filename string) (wtype.File, error) {
 	return anthafile, err
 }
 
+// SnapGene exports a sequence and its features into a SnapGene (.dna) format file.
+func SnapGene(seq wtype.DNASequence, filename string) (wtype.File, error) {
+	data, err := snapgene.DNASequenceToSnapGene(seq)
+	if err != nil {
+		return wtype.File{}, err
+	}
+	return Binary(data, filename)
+}
+
+// SBOL exports sequences and their features into an SBOL document of the version specified (2 or 3).
+func SBOL(seqs []wtype.DNASequence, version int, filename string) (wtype.File, error) {
+	data, err := sbol.DNASequencesToSBOL(seqs, version, sbol.DefaultNamespace)
+	if err != nil {
+		return wtype.File{}, err
+	}
+	return Binary(data, filename)
+}
+
 // Binary export bytes into a file.
 func Binary(data []byte, filename string) (wtype.File, error) {
 	var anthafile wtype.File
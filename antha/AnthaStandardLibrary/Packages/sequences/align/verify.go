@@ -0,0 +1,425 @@
+package align
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/antha-lang/antha/antha/anthalib/wtype"
+)
+
+// VerificationParameters specify how sequencing reads are used to verify a
+// construct.
+type VerificationParameters struct {
+	// Algorithm is the scoring matrix used to align each read to the
+	// reference. Fitted is recommended since the whole of each read is
+	// aligned to a local region of the reference.
+	Algorithm ScoringMatrix
+
+	// MinIdentity is the minimum identity of the alignment of a read for it to
+	// be used; reads aligning less well are assumed not to come from the
+	// construct and are rejected.
+	MinIdentity float64
+
+	// MinDepth is the number of reads which must call a position for it to be
+	// considered verified.
+	MinDepth int
+
+	// Features are the names of the features of the reference which must be
+	// verified. If empty the whole of the reference must be verified.
+	Features []string
+}
+
+// DefaultVerificationParameters returns parameters requiring every position of
+// the reference to be called by at least one read.
+func DefaultVerificationParameters() VerificationParameters {
+	return VerificationParameters{
+		Algorithm:   Fitted,
+		MinIdentity: 0.8,
+		MinDepth:    1,
+	}
+}
+
+// VariantType classifies a difference between the reads and the reference.
+type VariantType string
+
+const (
+	// SNP is a substitution of a single base.
+	SNP VariantType = "SNP"
+	// Insertion is the addition of one or more bases to the reference.
+	Insertion VariantType = "insertion"
+	// Deletion is the loss of one or more bases of the reference.
+	Deletion VariantType = "deletion"
+)
+
+// Variant is a difference between the consensus of the reads and the
+// reference.
+type Variant struct {
+	Type VariantType
+	// Position is the human friendly position in the reference of the first
+	// base affected. Insertions are made before Position.
+	Position int
+	// Reference and Alternative are the bases of the reference and consensus
+	// respectively.
+	Reference   string
+	Alternative string
+	// Depth is the number of reads calling Position, of which Support call
+	// the variant.
+	Depth   int
+	Support int
+	// Features are the features of the reference in which the variant falls.
+	Features []wtype.Feature
+}
+
+func (v Variant) String() string {
+	ref, alt := v.Reference, v.Alternative
+	if ref == "" {
+		ref = "-"
+	}
+	if alt == "" {
+		alt = "-"
+	}
+	s := fmt.Sprintf("%s at %d %s>%s (%d/%d reads)", v.Type, v.Position, ref, alt, v.Support, v.Depth)
+	if len(v.Features) > 0 {
+		var names []string
+		for _, f := range v.Features {
+			names = append(names, f.Name)
+		}
+		s += " in " + strings.Join(names, ", ")
+	}
+	return s
+}
+
+// ReadAlignment is the alignment of a sequencing read to the reference. If
+// Reverse is true the reverse complement of the read was aligned.
+type ReadAlignment struct {
+	Read    wtype.DNASequence
+	Reverse bool
+	Result  Result
+}
+
+// Verification is the result of comparing sequencing reads to a construct.
+type Verification struct {
+	Reference wtype.DNASequence
+	// Reads are the alignments of the reads used to verify the reference.
+	Reads []ReadAlignment
+	// Rejected are the reads which did not align to the reference with the
+	// minimum identity.
+	Rejected []wtype.DNASequence
+	// Coverage is the number of reads calling each position of the reference,
+	// in code friendly format.
+	Coverage []int
+	// Consensus is the sequence called by the reads, with positions no read
+	// calls given as N.
+	Consensus string
+	Variants  []Variant
+	// Uncovered are the regions which must be verified but are called by fewer
+	// than the minimum number of reads, in human friendly format.
+	Uncovered []Position
+	// Pass is true if every position required to be verified is covered and
+	// no variants were found in them.
+	Pass bool
+}
+
+// FeatureVariants returns the variants falling in the named feature.
+func (v Verification) FeatureVariants(name string) (variants []Variant) {
+	for _, variant := range v.Variants {
+		for _, f := range variant.Features {
+			if f.Name == name {
+				variants = append(variants, variant)
+				break
+			}
+		}
+	}
+	return variants
+}
+
+func (v Verification) String() string {
+	verdict := "FAIL"
+	if v.Pass {
+		verdict = "PASS"
+	}
+	lines := []string{fmt.Sprintf("%s: %s (%d reads used, %d rejected)", v.Reference.Nm, verdict, len(v.Reads), len(v.Rejected))}
+	for _, r := range v.Rejected {
+		lines = append(lines, fmt.Sprintf("read %s does not align to %s", r.Nm, v.Reference.Nm))
+	}
+	for _, u := range v.Uncovered {
+		lines = append(lines, fmt.Sprintf("positions %d..%d not covered", u.Start, u.End))
+	}
+	for _, variant := range v.Variants {
+		lines = append(lines, variant.String())
+	}
+	return strings.Join(lines, "\n")
+}
+
+// VerifyReads aligns sequencing reads in either orientation to a reference
+// construct and calls the consensus at each position of the reference. The
+// reference may be a plasmid, in which case reads may span its origin.
+// Ns in the reads are treated as uncalled bases. Each position of the
+// reference is called by majority with ties resolved in favour of the
+// reference, and the differences between the consensus and the reference are
+// returned as variants. The construct passes if each position required by the
+// parameters is called by at least MinDepth reads and does not vary.
+func VerifyReads(reference wtype.DNASequence, reads []wtype.DNASequence, parameters VerificationParameters) (verification Verification, err error) {
+	ref := strings.ToUpper(reference.Seq)
+	if len(ref) == 0 {
+		return verification, fmt.Errorf("no sequence found for reference %s", reference.Nm)
+	}
+	if parameters.Algorithm == nil {
+		parameters.Algorithm = Fitted
+	}
+	required, err := requiredPositions(reference, parameters.Features)
+	if err != nil {
+		return verification, err
+	}
+
+	verification.Reference = reference
+	p := newPileup(len(ref))
+	for _, read := range reads {
+		aligned, err := alignRead(reference, read, parameters.Algorithm)
+		if err != nil {
+			return verification, err
+		}
+		if aligned.Result.Identity() < parameters.MinIdentity {
+			verification.Rejected = append(verification.Rejected, read)
+			continue
+		}
+		verification.Reads = append(verification.Reads, aligned)
+		p.add(aligned)
+	}
+
+	verification.Coverage = p.depth()
+	verification.Consensus, verification.Variants = p.call(ref)
+
+	for i := range verification.Variants {
+		verification.Variants[i].Features = variantFeatures(reference, verification.Variants[i])
+	}
+
+	for start := 0; start < len(ref); start++ {
+		if !required[start] || verification.Coverage[start] >= parameters.MinDepth {
+			continue
+		}
+		end := start
+		for end+1 < len(ref) && required[end+1] && verification.Coverage[end+1] < parameters.MinDepth {
+			end++
+		}
+		verification.Uncovered = append(verification.Uncovered, Position{Start: start + 1, End: end + 1, Length: end - start + 1})
+		start = end
+	}
+
+	verification.Pass = len(verification.Reads) > 0 && len(verification.Uncovered) == 0
+	for _, v := range verification.Variants {
+		if required[v.Position-1] {
+			verification.Pass = false
+		}
+	}
+	return verification, nil
+}
+
+// requiredPositions returns which positions of reference must be verified.
+func requiredPositions(reference wtype.DNASequence, features []string) ([]bool, error) {
+	required := make([]bool, len(reference.Seq))
+	if len(features) == 0 {
+		for i := range required {
+			required[i] = true
+		}
+		return required, nil
+	}
+	for _, name := range features {
+		found := false
+		for _, feature := range reference.Features {
+			if feature.Name != name {
+				continue
+			}
+			found = true
+			start, end := feature.Coordinates(wtype.CODEFRIENDLY, wtype.IGNOREDIRECTION)
+			for i := start; i <= end && i < len(required); i++ {
+				if i >= 0 {
+					required[i] = true
+				}
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("feature %s not found in %s", name, reference.Nm)
+		}
+	}
+	return required, nil
+}
+
+// alignRead aligns a read and its reverse complement to reference, returning
+// the better scoring alignment. Ns in the read are replaced with gaps since
+// they cannot be aligned.
+func alignRead(reference, read wtype.DNASequence, algorithm ScoringMatrix) (aligned ReadAlignment, err error) {
+	fwd := read
+	fwd.Seq = strings.Replace(strings.ToUpper(read.Seq), "N", string(GAP), -1)
+	rev := read
+	rev.Seq = strings.Replace(wtype.RevComp(read.Seq), "N", string(GAP), -1)
+
+	fwdResult, err := DNAFwd(reference, fwd, algorithm)
+	if err != nil {
+		return aligned, fmt.Errorf("aligning read %s to %s: %s", read.Nm, reference.Nm, err.Error())
+	}
+	revResult, err := DNAFwd(reference, rev, algorithm)
+	if err != nil {
+		return aligned, fmt.Errorf("aligning reverse complement of read %s to %s: %s", read.Nm, reference.Nm, err.Error())
+	}
+
+	if revResult.Score() > fwdResult.Score() {
+		return ReadAlignment{Read: read, Reverse: true, Result: revResult}, nil
+	}
+	return ReadAlignment{Read: read, Result: fwdResult}, nil
+}
+
+// pileup counts the calls made by reads at each position of a reference.
+type pileup struct {
+	// bases counts the base called at each position, with GAP for a deletion.
+	bases []map[string]int
+	// insertions counts the bases inserted before each position, with the
+	// empty string for reads which call no insertion.
+	insertions []map[string]int
+}
+
+func newPileup(length int) *pileup {
+	p := &pileup{
+		bases:      make([]map[string]int, length),
+		insertions: make([]map[string]int, length),
+	}
+	for i := 0; i < length; i++ {
+		p.bases[i] = make(map[string]int)
+		p.insertions[i] = make(map[string]int)
+	}
+	return p
+}
+
+// add adds the calls made by an aligned read. Positions at which the read has
+// an N are not called, and insertions are only called between two bases
+// called by the read.
+func (p *pileup) add(aligned ReadAlignment) {
+	alignment := aligned.Result.Alignment
+	query := aligned.Result.Query.Sequence()
+	templateResult := strings.ToUpper(alignment.TemplateResult)
+	queryResult := strings.ToUpper(alignment.QueryResult)
+
+	var inserted []byte
+	called := false
+	for k := range templateResult {
+		if isGap(rune(templateResult[k])) {
+			letter := queryResult[k]
+			if isGap(rune(letter)) {
+				letter = 'N'
+			}
+			inserted = append(inserted, letter)
+			continue
+		}
+		if isGap(rune(queryResult[k])) && uncalled(alignment.QueryPositions, k, query) {
+			inserted, called = nil, false
+			continue
+		}
+		position := alignment.TemplatePositions[k] - 1
+		if position < 0 || position >= len(p.bases) {
+			continue
+		}
+		p.bases[position][string(queryResult[k])]++
+		if called {
+			p.insertions[position][string(inserted)]++
+		}
+		inserted, called = nil, true
+	}
+}
+
+// uncalled returns whether a gap in the query at column k of an alignment is
+// an N in the query rather than a deletion. Deletions share the position of
+// the following query base, whereas Ns take up a position of their own.
+func uncalled(queryPositions []int, k int, query string) bool {
+	position := queryPositions[k]
+	if k+1 < len(queryPositions) && queryPositions[k+1] == position {
+		return false
+	}
+	return position > 0 && position <= len(query) && isGap(rune(query[position-1]))
+}
+
+func (p *pileup) depth() []int {
+	depth := make([]int, len(p.bases))
+	for i, counts := range p.bases {
+		for _, n := range counts {
+			depth[i] += n
+		}
+	}
+	return depth
+}
+
+// majority returns the most common call in counts and the number of times it
+// was made, preferring def in the case of a tie.
+func majority(counts map[string]int, def string) (call string, n int) {
+	var calls []string
+	for c := range counts {
+		calls = append(calls, c)
+	}
+	sort.Strings(calls)
+
+	call, n = def, counts[def]
+	for _, c := range calls {
+		if counts[c] > n {
+			call, n = c, counts[c]
+		}
+	}
+	return call, n
+}
+
+// call calls the consensus of the pileup and the variants it contains with
+// respect to ref.
+func (p *pileup) call(ref string) (consensus string, variants []Variant) {
+	var seq []string
+	for i := range ref {
+		depth := 0
+		for _, n := range p.bases[i] {
+			depth += n
+		}
+		if depth == 0 {
+			seq = append(seq, "N")
+			continue
+		}
+
+		if insertion, n := majority(p.insertions[i], ""); insertion != "" {
+			total := 0
+			for _, m := range p.insertions[i] {
+				total += m
+			}
+			seq = append(seq, insertion)
+			variants = append(variants, Variant{Type: Insertion, Position: i + 1, Alternative: insertion, Depth: total, Support: n})
+		}
+
+		base, n := majority(p.bases[i], ref[i:i+1])
+		switch {
+		case base == string(GAP):
+			last := len(variants) - 1
+			if last >= 0 && variants[last].Type == Deletion && variants[last].Position+len(variants[last].Reference) == i+1 {
+				variants[last].Reference += ref[i : i+1]
+				continue
+			}
+			variants = append(variants, Variant{Type: Deletion, Position: i + 1, Reference: ref[i : i+1], Depth: depth, Support: n})
+		case base != ref[i:i+1]:
+			seq = append(seq, base)
+			variants = append(variants, Variant{Type: SNP, Position: i + 1, Reference: ref[i : i+1], Alternative: base, Depth: depth, Support: n})
+		default:
+			seq = append(seq, base)
+		}
+	}
+	return strings.Join(seq, ""), variants
+}
+
+// variantFeatures returns the features of reference in which v falls.
+func variantFeatures(reference wtype.DNASequence, v Variant) (features []wtype.Feature) {
+	start, end := v.Position, v.Position+len(v.Reference)-1
+	for _, f := range reference.Features {
+		fstart, fend := f.Coordinates(wtype.IGNOREDIRECTION)
+		if v.Type == Insertion {
+			if start > fstart && start <= fend {
+				features = append(features, f)
+			}
+		} else if start <= fend && end >= fstart {
+			features = append(features, f)
+		}
+	}
+	return features
+}
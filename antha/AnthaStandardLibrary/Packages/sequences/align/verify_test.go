@@ -0,0 +1,148 @@
+package align
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/antha-lang/antha/antha/anthalib/wtype"
+)
+
+func TestPileup(t *testing.T) {
+	ref := "ACGTACGTAC"
+	p := newPileup(len(ref))
+
+	// SNP at 3, insertion of T before 6 and an N at 9
+	p.add(ReadAlignment{Result: Result{
+		Query: &wtype.DNASequence{Seq: "ACTTATCGT-C"},
+		Alignment: Alignment{
+			TemplateResult:    "ACgTA-CGTAC",
+			QueryResult:       "ACtTATCGT-C",
+			TemplatePositions: []int{1, 2, 3, 4, 5, 6, 6, 7, 8, 9, 10},
+			QueryPositions:    []int{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11},
+		},
+	}})
+	// deletion of 6 and 7
+	p.add(ReadAlignment{Result: Result{
+		Query: &wtype.DNASequence{Seq: "ACTTATAC"},
+		Alignment: Alignment{
+			TemplateResult:    "ACgTACGTAC",
+			QueryResult:       "ACtTA--TAC",
+			TemplatePositions: []int{1, 2, 3, 4, 5, 6, 7, 8, 9, 10},
+			QueryPositions:    []int{1, 2, 3, 4, 5, 6, 6, 6, 7, 8},
+		},
+	}})
+
+	depth := p.depth()
+	if expected := []int{2, 2, 2, 2, 2, 2, 2, 2, 1, 2}; !intsEqual(depth, expected) {
+		t.Errorf("expected depth %v, got %v", expected, depth)
+	}
+
+	consensus, variants := p.call(ref)
+	// ties between the reads are resolved in favour of the reference
+	if consensus != "ACTTACGTAC" {
+		t.Errorf("expected consensus ACTTACGTAC, got %s", consensus)
+	}
+	if len(variants) != 1 || variants[0].Type != SNP || variants[0].Position != 3 || variants[0].Alternative != "T" || variants[0].Support != 2 {
+		t.Errorf("expected SNP at 3, got %v", variants)
+	}
+
+	// a third read supporting the deletion
+	p.add(ReadAlignment{Result: Result{
+		Query: &wtype.DNASequence{Seq: "GTATAC"},
+		Alignment: Alignment{
+			TemplateResult:    "GTACGTAC",
+			QueryResult:       "GTA--TAC",
+			TemplatePositions: []int{3, 4, 5, 6, 7, 8, 9, 10},
+			QueryPositions:    []int{1, 2, 3, 4, 4, 4, 5, 6},
+		},
+	}})
+	consensus, variants = p.call(ref)
+	if consensus != "ACTTATAC" {
+		t.Errorf("expected consensus ACTTATAC, got %s", consensus)
+	}
+	if len(variants) != 2 || variants[1].Type != Deletion || variants[1].Position != 6 || variants[1].Reference != "CG" {
+		t.Errorf("expected deletion of CG at 6, got %v", variants)
+	}
+}
+
+func intsEqual(a, b []int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestVerifyReads(t *testing.T) {
+	seq := "ATGAGCAAAGGAGAAGAACTTTTCACTGGAGTTGTCCCAATTCTTGTTGAATTAGATGGTGATGTTAATGGGCACAAATTTTCTGTCAGTGGAGAGGGTGAAGGTGATGCTACATACGGAAAGCTTACCCTTAAATTTATTTGCACTACTGGAAAACTACCTGTTCCATGGCCAACACTTGTCACTACTTTCTCTTATGGTGTTCAATGCTTTTCCCGTTATCCGGATCATATGAAACGGCATGACTTTTTCAAGAGTGCCATGCCCGAAGGTTATGTACAGGAACGCACTATATCTTTCAAAGATGACGGGAACTACAAGACGCGTGCTGAAGTCAAGTTTGAAGGTGATACCCTTGTTAATCGTATCGAGTTAAAAGGTATTGATTTTAAAGAAGATGGAAACATTCTCGGACACAAACTCGAGTACAACTATAACTCACACAATGTATACATCACGGCAGACAAACAAAAGAATGGAATCAAAGCTAACTTCAAAATTCGCCACAACATTGAAGATGGATCCGTTCAACTAGCAGACCATTATCAACAAAATACTCCAATTGGCGATGGCCCTGTCCTTTTACCAGACAACCATTACCTGTCGACACAATCTGCCCTTTCGAAAGATCCCAACGAAAAGCGTGACCACATGGTCCTTCTTGAGTTTGTAACTGCTGCTGGGATTACACATGGCATGGATGAGCTCTACAAATAA"
+	reference := wtype.MakePlasmidDNASequence("construct", seq)
+	reference.Features = []wtype.Feature{
+		{Name: "start", Class: "misc", StartPosition: 1, EndPosition: 200},
+		{Name: "end", Class: "misc", StartPosition: 500, EndPosition: 717},
+	}
+
+	// a forward read over the origin, and a reverse read with a SNP at 600
+	variant := seq[400:599] + "G" + seq[600:]
+	reads := []wtype.DNASequence{
+		wtype.MakeLinearDNASequence("fwd", seq[650:]+seq[:420]),
+		wtype.MakeLinearDNASequence("rev", wtype.RevComp(variant)),
+	}
+
+	verification, err := VerifyReads(reference, reads, DefaultVerificationParameters())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(verification.Reads) != 2 || !verification.Reads[1].Reverse {
+		t.Fatalf("expected two reads to align, the second reversed, got %+v", verification.Reads)
+	}
+	if verification.Coverage[0] != 1 || verification.Coverage[410] != 2 || verification.Coverage[700] != 2 {
+		t.Errorf("unexpected coverage %v", verification.Coverage)
+	}
+	if len(verification.Uncovered) != 0 {
+		t.Errorf("expected whole construct to be covered, got %v", verification.Uncovered)
+	}
+	if len(verification.Variants) != 1 || verification.Variants[0].Position != 600 || verification.Variants[0].Alternative != "G" {
+		t.Fatalf("expected SNP at 600, got %v", verification.Variants)
+	}
+	if v := verification.FeatureVariants("end"); len(v) != 1 || len(verification.FeatureVariants("start")) != 0 {
+		t.Errorf("expected SNP to fall in end, got %v", verification.Variants[0].Features)
+	}
+	if verification.Pass || !strings.Contains(verification.String(), "FAIL") {
+		t.Errorf("expected verification to fail, got %s", verification)
+	}
+
+	// only the start is required, which has no variants
+	parameters := DefaultVerificationParameters()
+	parameters.Features = []string{"start"}
+	verification, err = VerifyReads(reference, reads, parameters)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !verification.Pass {
+		t.Errorf("expected start to be verified, got %s", verification)
+	}
+
+	// without the reverse read the end is not covered
+	parameters.Features = []string{"end"}
+	verification, err = VerifyReads(reference, reads[:1], parameters)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if verification.Pass || len(verification.Uncovered) != 1 || verification.Uncovered[0].Start != 500 || verification.Uncovered[0].End != 650 {
+		t.Errorf("expected 500..650 to be uncovered, got %v", verification.Uncovered)
+	}
+
+	// an N in a read leaves its position uncalled
+	uncalled := wtype.MakeLinearDNASequence("fwd", seq[650:]+seq[:9]+"N"+seq[10:420])
+	verification, err = VerifyReads(reference, []wtype.DNASequence{uncalled, reads[1]}, DefaultVerificationParameters())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if verification.Coverage[9] != 0 || len(verification.Uncovered) != 1 || verification.Uncovered[0].Start != 10 || verification.Uncovered[0].End != 10 || verification.Consensus[9] != 'N' {
+		t.Errorf("expected position 10 to be uncovered, got %v", verification.Uncovered)
+	}
+}
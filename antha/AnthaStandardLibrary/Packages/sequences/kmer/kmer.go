@@ -15,6 +15,16 @@ func Hashseq(s string, n int) map[string]int {
 	return m
 }
 
+// Positions returns the positions in s at which each word of length n starts.
+func Positions(s string, n int) map[string][]int {
+	m := make(map[string][]int)
+	for i := 0; i+n <= len(s); i++ {
+		w := s[i : i+n]
+		m[w] = append(m[w], i)
+	}
+	return m
+}
+
 func CompHash(h1, h2 map[string]int) float64 {
 	r := 0
 	s := 0
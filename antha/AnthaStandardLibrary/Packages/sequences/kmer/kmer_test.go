@@ -1,6 +1,7 @@
 package kmer
 
 import (
+	"reflect"
 	"testing"
 
 	"github.com/antha-lang/antha/antha/anthalib/wtype"
@@ -37,3 +38,11 @@ func Test1(t *testing.T) {
 		t.Errorf("Expected first hit to be sequence \"There\", instead got \"%s\"", sr[0].Name)
 	}
 }
+
+func TestPositions(t *testing.T) {
+	p := Positions("ACGACGT", 3)
+
+	if !reflect.DeepEqual(p["ACG"], []int{0, 3}) || !reflect.DeepEqual(p["CGT"], []int{4}) || len(p) != 4 {
+		t.Errorf("unexpected positions %v", p)
+	}
+}
@@ -0,0 +1,347 @@
+// Part of the Antha language
+// Copyright (C) 2018 The Antha authors. All rights reserved.
+//
+// This program is free software; you can redistribute it and/or
+// modify it under the terms of the GNU General Public License
+// as published by the Free Software Foundation; either version 2
+// of the License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program; if not, write to the Free Software
+// Foundation, Inc., 51 Franklin Street, Fifth Floor, Boston, MA  02110-1301, USA.
+//
+// For more information relating to the software or licensing issues please
+// contact license@antha-lang.org or write to the Antha team c/o
+// Synthace Ltd. The London Bioscience Innovation Centre
+// 2 Royal College St, London NW1 0NH UK
+
+// Package localblast searches libraries of DNA sequences for similar
+// sequences without network access, returning hits in the form of those of
+// the NCBI BLAST service.
+package localblast
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/antha-lang/antha/antha/AnthaStandardLibrary/Packages/sequences/align"
+	"github.com/antha-lang/antha/antha/AnthaStandardLibrary/Packages/sequences/biogo/ncbi/blast"
+	"github.com/antha-lang/antha/antha/AnthaStandardLibrary/Packages/sequences/kmer"
+	"github.com/antha-lang/antha/antha/AnthaStandardLibrary/Packages/sequences/parse"
+	"github.com/antha-lang/antha/antha/anthalib/wtype"
+)
+
+// DefaultWordSize is the default length of the words used to seed alignments,
+// that of blastn.
+const DefaultWordSize = 11
+
+// Scores of HSPs are calculated using the default scoring of
+// blastn and the corresponding Karlin-Altschul parameters.
+const (
+	reward    = 2
+	penalty   = -3
+	gapOpen   = 5
+	gapExtend = 2
+	lambda    = 0.625
+	kappa     = 0.41
+)
+
+// Parameters are the parameters of a similarity search.
+type Parameters struct {
+	// Algorithm is the local alignment used to extend seeds.
+	Algorithm align.ScoringMatrix
+	// MinSeeds is the number of words a sequence must share with the query on
+	// the same strand for an alignment to be attempted.
+	MinSeeds int
+	// MaxEValue is the highest expect value of HSPs returned.
+	MaxEValue float64
+	// HitListSize is the maximum number of hits returned, or unlimited if 0.
+	HitListSize int
+}
+
+// DefaultParameters returns the default parameters of searches.
+func DefaultParameters() Parameters {
+	return Parameters{
+		Algorithm:   align.SWAffine,
+		MinSeeds:    2,
+		MaxEValue:   10,
+		HitListSize: 50,
+	}
+}
+
+// seed is the position of a word in a sequence of a DB.
+type seed struct {
+	Sequence int
+	Position int
+}
+
+// DB is an index of a library of DNA sequences.
+type DB struct {
+	Sequences []wtype.DNASequence
+	K         int
+	seeds     map[string][]seed
+	length    int
+}
+
+// NewDB indexes the words of length k in seqs. Words spanning the origin
+// of plasmids are included.
+func NewDB(seqs []wtype.DNASequence, k int) (*DB, error) {
+	if k < 1 {
+		return nil, fmt.Errorf("invalid word size %d", k)
+	}
+	db := &DB{K: k, seeds: make(map[string][]seed)}
+	for i, seq := range seqs {
+		seq.Seq = searchable(seq.Seq)
+		db.Sequences = append(db.Sequences, seq)
+		db.length += len(seq.Seq)
+
+		s := seq.Seq
+		if seq.Plasmid && len(s) >= k {
+			s += s[:k-1]
+		}
+		for word, positions := range kmer.Positions(s, k) {
+			if strings.ContainsRune(word, align.GAP) {
+				continue
+			}
+			for _, p := range positions {
+				db.seeds[word] = append(db.seeds[word], seed{Sequence: i, Position: p})
+			}
+		}
+	}
+	return db, nil
+}
+
+// DBFromFiles indexes the words of length k in the sequences of a library
+// of sequence files in any format read by parse.DNAFileToDNASequence.
+func DBFromFiles(k int, files ...wtype.File) (*DB, error) {
+	var seqs []wtype.DNASequence
+	for _, file := range files {
+		s, err := parse.DNAFileToDNASequence(file)
+		if err != nil {
+			return nil, err
+		}
+		seqs = append(seqs, s...)
+	}
+	return NewDB(seqs, k)
+}
+
+// searchable returns s in upper case with ambiguous bases replaced by gaps
+// for alignment.
+func searchable(s string) string {
+	return strings.Map(func(r rune) rune {
+		switch r {
+		case 'A', 'C', 'G', 'T', 'a', 'c', 'g', 't':
+			return r &^ ('a' - 'A')
+		default:
+			return align.GAP
+		}
+	}, s)
+}
+
+// BlastN searches the database for the query using default parameters,
+// returning an error if no hits are found in the same manner as
+// blast.MegaBlastN.
+func BlastN(query string, db *DB) (hits []blast.Hit, err error) {
+	hits, err = db.Search(wtype.MakeLinearDNASequence("query", query), DefaultParameters())
+	if err != nil {
+		return
+	}
+	if len(hits) == 0 {
+		err = fmt.Errorf("no hits found")
+	}
+	return
+}
+
+// Search finds the sequences of the database similar to the query, on either
+// strand. Sequences sharing words with the query are aligned to it, and the
+// best alignment on each strand is returned as an HSP of the hit. Hits are
+// sorted by the highest bit score of their HSPs.
+func (db *DB) Search(query wtype.DNASequence, parameters Parameters) ([]blast.Hit, error) {
+	q := searchable(query.Seq)
+	if len(q) < db.K {
+		return nil, fmt.Errorf("query %s shorter than word size %d", query.Nm, db.K)
+	}
+
+	var hits []blast.Hit
+	for i, subject := range db.Sequences {
+		var hsps []blast.Hsp
+		for _, reverse := range []bool{false, true} {
+			strand := q
+			if reverse {
+				strand = wtype.RevComp(q)
+			}
+			diagonals := db.diagonals(strand, i)
+			if len(diagonals) == 0 || len(diagonals) < parameters.MinSeeds {
+				continue
+			}
+			hsp, err := db.extend(subject, strand, reverse, diagonals, parameters.Algorithm, len(q))
+			if err != nil {
+				return hits, fmt.Errorf("aligning %s to %s: %s", query.Nm, subject.Nm, err.Error())
+			}
+			if hsp.EValue <= parameters.MaxEValue {
+				hsps = append(hsps, hsp)
+			}
+		}
+		if len(hsps) == 0 {
+			continue
+		}
+		sort.SliceStable(hsps, func(a, b int) bool { return hsps[a].BitScore > hsps[b].BitScore })
+		for j := range hsps {
+			hsps[j].N = j + 1
+		}
+		hits = append(hits, blast.Hit{
+			Id:        subject.Nm,
+			Def:       subject.Nm,
+			Accession: subject.Nm,
+			Len:       len(subject.Seq),
+			Hsps:      hsps,
+		})
+	}
+
+	sort.SliceStable(hits, func(a, b int) bool { return hits[a].Hsps[0].BitScore > hits[b].Hsps[0].BitScore })
+	if parameters.HitListSize > 0 && len(hits) > parameters.HitListSize {
+		hits = hits[:parameters.HitListSize]
+	}
+	for i := range hits {
+		hits[i].N = i + 1
+	}
+	return hits, nil
+}
+
+// diagonals returns the offsets from the query of each word of the query
+// found in sequence i of the database.
+func (db *DB) diagonals(query string, i int) []int {
+	var diagonals []int
+	for word, positions := range kmer.Positions(query, db.K) {
+		if strings.ContainsRune(word, align.GAP) {
+			continue
+		}
+		for _, s := range db.seeds[word] {
+			if s.Sequence != i {
+				continue
+			}
+			for _, p := range positions {
+				diagonals = append(diagonals, s.Position-p)
+			}
+		}
+	}
+	return diagonals
+}
+
+// extend aligns the query strand to the region of the subject around the
+// seeds found.
+func (db *DB) extend(subject wtype.DNASequence, query string, reverse bool, diagonals []int, algorithm align.ScoringMatrix, queryLength int) (blast.Hsp, error) {
+	// the alignment of linear sequences is restricted to the region of the
+	// seeds, allowing for gaps; plasmids are aligned in full to find
+	// alignments spanning the origin
+	template := subject
+	offset := 0
+	if !subject.Plasmid {
+		sort.Ints(diagonals)
+		flank := queryLength / 2
+		start := diagonals[0] - flank
+		if start < 0 {
+			start = 0
+		}
+		end := diagonals[len(diagonals)-1] + queryLength + flank
+		if end > len(subject.Seq) {
+			end = len(subject.Seq)
+		}
+		template.Seq = subject.Seq[start:end]
+		offset = start
+	}
+
+	result, err := align.DNAFwd(template, wtype.MakeLinearDNASequence("query", query), algorithm)
+	if err != nil {
+		return blast.Hsp{}, err
+	}
+	return makeHsp(result, reverse, offset, queryLength, db.length), nil
+}
+
+// makeHsp converts an alignment to a blast HSP, the query having been reverse
+// complemented if reverse. Positions in the template are offset by offset.
+func makeHsp(result align.Result, reverse bool, offset, queryLength, dbLength int) blast.Hsp {
+	t, q := result.Alignment.TemplateResult, result.Alignment.QueryResult
+
+	var identity, gaps, score int
+	var midline []byte
+	for i := range q {
+		switch {
+		case rune(t[i]) == align.GAP || rune(q[i]) == align.GAP:
+			gaps++
+			score -= gapExtend
+			if i == 0 || (rune(t[i-1]) != align.GAP && rune(q[i-1]) != align.GAP) {
+				score -= gapOpen
+			}
+			midline = append(midline, ' ')
+		case strings.EqualFold(t[i:i+1], q[i:i+1]):
+			identity++
+			score += reward
+			midline = append(midline, '|')
+		default:
+			score += penalty
+			midline = append(midline, ' ')
+		}
+	}
+
+	hitFrom, hitTo := alignedRange(t, result.Alignment.TemplatePositions)
+	queryFrom, queryTo := alignedRange(q, result.Alignment.QueryPositions)
+	hitFrom += offset
+	hitTo += offset
+	hitFrame, queryFrame := 1, 1
+	querySeq, subjectSeq := strings.ToUpper(q), strings.ToUpper(t)
+
+	if reverse {
+		// report the plus strand of the query aligned to the minus strand of
+		// the hit
+		queryFrom, queryTo = queryLength-queryTo+1, queryLength-queryFrom+1
+		hitFrom, hitTo = hitTo, hitFrom
+		hitFrame = -1
+		querySeq, subjectSeq = wtype.RevComp(querySeq), wtype.RevComp(subjectSeq)
+		for i, j := 0, len(midline)-1; i < j; i, j = i+1, j-1 {
+			midline[i], midline[j] = midline[j], midline[i]
+		}
+	}
+
+	alignLen := len(q)
+	bitScore := (lambda*float64(score) - math.Log(kappa)) / math.Ln2
+	return blast.Hsp{
+		BitScore:      bitScore,
+		Score:         float64(score),
+		EValue:        kappa * float64(queryLength) * float64(dbLength) * math.Exp(-lambda*float64(score)),
+		QueryFrom:     queryFrom,
+		QueryTo:       queryTo,
+		HitFrom:       hitFrom,
+		HitTo:         hitTo,
+		QueryFrame:    &queryFrame,
+		HitFrame:      &hitFrame,
+		HspIdentity:   &identity,
+		HspPositive:   &identity,
+		HspGaps:       &gaps,
+		AlignLen:      &alignLen,
+		QuerySeq:      []byte(querySeq),
+		SubjectSeq:    []byte(subjectSeq),
+		FormatMidline: midline,
+	}
+}
+
+// alignedRange returns the first and last positions of a sequence aligned,
+// ignoring gaps.
+func alignedRange(aligned string, positions []int) (from, to int) {
+	for i := range aligned {
+		if rune(aligned[i]) != align.GAP && i < len(positions) {
+			if from == 0 {
+				from = positions[i]
+			}
+			to = positions[i]
+		}
+	}
+	return from, to
+}
@@ -0,0 +1,92 @@
+package localblast
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/antha-lang/antha/antha/AnthaStandardLibrary/Packages/sequences/blast"
+	"github.com/antha-lang/antha/antha/anthalib/wtype"
+)
+
+func randomSequence(r *rand.Rand, n int) string {
+	s := make([]byte, n)
+	for i := range s {
+		s[i] = "ACGT"[r.Intn(4)]
+	}
+	return string(s)
+}
+
+func TestSearch(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	library := []wtype.DNASequence{
+		wtype.MakeLinearDNASequence("first", randomSequence(r, 600)),
+		wtype.MakeLinearDNASequence("second", randomSequence(r, 600)),
+		wtype.MakePlasmidDNASequence("plasmid", randomSequence(r, 400)),
+	}
+	db, err := NewDB(library, DefaultWordSize)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// part of the second sequence with a substitution
+	query := []byte(library[1].Seq[200:300])
+	query[50] = map[byte]byte{'A': 'C', 'C': 'G', 'G': 'T', 'T': 'A'}[query[50]]
+
+	hits, err := BlastN(string(query), db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(hits) != 1 || hits[0].Id != "second" || hits[0].N != 1 || hits[0].Len != 600 {
+		t.Fatalf("expected a single hit to second, got %+v", hits)
+	}
+	hsp := hits[0].Hsps[0]
+	if hsp.QueryFrom != 1 || hsp.QueryTo != 100 || hsp.HitFrom != 201 || hsp.HitTo != 300 || *hsp.HitFrame != 1 {
+		t.Errorf("expected query 1..100 aligned to 201..300, got %d..%d to %d..%d", hsp.QueryFrom, hsp.QueryTo, hsp.HitFrom, hsp.HitTo)
+	}
+	if *hsp.HspIdentity != 99 || *hsp.AlignLen != 100 || hsp.EValue > 1e-20 {
+		t.Errorf("unexpected identity %d of %d with e-value %g", *hsp.HspIdentity, *hsp.AlignLen, hsp.EValue)
+	}
+
+	// the reverse complement of part of the first sequence
+	region := library[0].Seq[100:200]
+	hits, err = BlastN(wtype.RevComp(region), db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hsp = hits[0].Hsps[0]
+	if hits[0].Id != "first" || hsp.QueryFrom != 1 || hsp.QueryTo != 100 || hsp.HitFrom != 200 || hsp.HitTo != 101 || *hsp.HitFrame != -1 {
+		t.Errorf("expected query 1..100 aligned to minus strand of first 200..101, got %s %d..%d to %d..%d", hits[0].Id, hsp.QueryFrom, hsp.QueryTo, hsp.HitFrom, hsp.HitTo)
+	}
+	if string(hsp.QuerySeq) != wtype.RevComp(region) || string(hsp.SubjectSeq) != wtype.RevComp(region) {
+		t.Errorf("expected aligned sequences to be the plus strand of the query, got %s and %s", hsp.QuerySeq, hsp.SubjectSeq)
+	}
+
+	// a region spanning the origin of the plasmid
+	region = library[2].Seq[350:] + library[2].Seq[:50]
+	hits, err = db.Search(wtype.MakeLinearDNASequence("origin", region), DefaultParameters())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(hits) != 1 || hits[0].Id != "plasmid" {
+		t.Fatalf("expected a single hit to plasmid, got %+v", hits)
+	}
+	hsp = hits[0].Hsps[0]
+	if hsp.QueryFrom != 1 || hsp.QueryTo != 100 || hsp.HitFrom != 351 || hsp.HitTo != 50 {
+		t.Errorf("expected query 1..100 aligned to 351..50, got %d..%d to %d..%d", hsp.QueryFrom, hsp.QueryTo, hsp.HitFrom, hsp.HitTo)
+	}
+
+	best, identity, coverage, _, err := blast.FindBestHit(hits)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if best.Id != "plasmid" || identity != 100 || coverage != 100 {
+		t.Errorf("expected best hit to plasmid with 100%% identity and coverage, got %s with %g and %g", best.Id, identity, coverage)
+	}
+
+	if hits, err := BlastN(randomSequence(r, 100), db); err == nil {
+		t.Errorf("expected no hits for unrelated sequence, got %+v", hits)
+	}
+	if _, err := BlastN("ACGT", db); err == nil {
+		t.Error("expected error for query shorter than word size")
+	}
+}
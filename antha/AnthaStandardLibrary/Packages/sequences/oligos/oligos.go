@@ -42,6 +42,111 @@ type Primer struct {
 	MeltingTemp wunit.Temperature
 }
 
+// PrimerConstraints are the requirements primers designed by this package
+// must meet.
+type PrimerConstraints struct {
+	// MaxGCContent is between 0 and 1
+	MaxGCContent   float64
+	MinLength      int
+	MaxLength      int
+	MinMeltingTemp wunit.Temperature
+	MaxMeltingTemp wunit.Temperature
+	// SeqsToAvoid are sequences primers must not contain. If
+	// OverlapThreshold is greater than zero primers must also not overlap any
+	// of them by more than OverlapThreshold bases.
+	SeqsToAvoid      []string
+	OverlapThreshold int
+	// NearestNeighbour specifies that melting temperatures are calculated by
+	// NearestNeighbourMeltingTemp rather than BasicMeltingTemp.
+	NearestNeighbour bool
+	// Conditions are used for nearest neighbour melting temperatures and
+	// free energies of secondary structures. If not set DefaultConditions
+	// are used.
+	Conditions Conditions
+	// MinHairpinDeltaG, MinSelfDimerDeltaG and MinHeteroDimerDeltaG are the
+	// most negative free energies, in kcal/mol, of the secondary structures a
+	// primer may form. A limit of zero is not checked. Hetero-dimers are
+	// checked against Partners, such as the other primers of a reaction.
+	MinHairpinDeltaG     float64
+	MinSelfDimerDeltaG   float64
+	MinHeteroDimerDeltaG float64
+	Partners             []string
+}
+
+// DefaultPrimerConstraints returns constraints for PCR primers of 18 to 30
+// bases with nearest neighbour melting temperatures between 55 and 65 ℃
+// which do not form hairpins more stable than -3 kcal/mol or dimers more
+// stable than -9 kcal/mol.
+func DefaultPrimerConstraints() PrimerConstraints {
+	return PrimerConstraints{
+		MaxGCContent:         0.6,
+		MinLength:            18,
+		MaxLength:            30,
+		MinMeltingTemp:       wunit.NewTemperature(55, "C"),
+		MaxMeltingTemp:       wunit.NewTemperature(65, "C"),
+		NearestNeighbour:     true,
+		Conditions:           DefaultConditions(),
+		MinHairpinDeltaG:     -3,
+		MinSelfDimerDeltaG:   -9,
+		MinHeteroDimerDeltaG: -9,
+	}
+}
+
+func (c PrimerConstraints) conditions() Conditions {
+	if c.Conditions.Monovalent.ConcreteMeasurement == nil && c.Conditions.Magnesium.ConcreteMeasurement == nil {
+		return DefaultConditions()
+	}
+	return c.Conditions
+}
+
+// avoiding returns a copy of the constraints with SeqsToAvoid replaced
+func (c PrimerConstraints) avoiding(seqs []string) PrimerConstraints {
+	c.SeqsToAvoid = seqs
+	return c
+}
+
+// MeltingTemp calculates the melting temperature of primer by the method
+// specified by the constraints.
+func (c PrimerConstraints) MeltingTemp(primer wtype.DNASequence) (wunit.Temperature, error) {
+	if c.NearestNeighbour {
+		return NearestNeighbourMeltingTemp(primer, c.conditions())
+	}
+	return BasicMeltingTemp(primer), nil
+}
+
+// secondaryStructure returns a description of the first secondary structure
+// limit of the constraints which primer exceeds, or "" if there is none
+func (c PrimerConstraints) secondaryStructure(primer string) (string, error) {
+	conditions := c.conditions()
+	if c.MinHairpinDeltaG != 0 {
+		g, err := HairpinDeltaG(primer, conditions)
+		if err != nil {
+			return "", err
+		} else if g < c.MinHairpinDeltaG {
+			return fmt.Sprintf("hairpin (%.2f kcal/mol)", g), nil
+		}
+	}
+	if c.MinSelfDimerDeltaG != 0 {
+		g, err := SelfDimerDeltaG(primer, conditions)
+		if err != nil {
+			return "", err
+		} else if g < c.MinSelfDimerDeltaG {
+			return fmt.Sprintf("self-dimer (%.2f kcal/mol)", g), nil
+		}
+	}
+	if c.MinHeteroDimerDeltaG != 0 {
+		for _, partner := range c.Partners {
+			g, err := DimerDeltaG(primer, partner, conditions)
+			if err != nil {
+				return "", err
+			} else if g < c.MinHeteroDimerDeltaG {
+				return fmt.Sprintf("hetero-dimer with %s (%.2f kcal/mol)", partner, g), nil
+			}
+		}
+	}
+	return "", nil
+}
+
 // checks for overlap between sequences (not including mismatches)
 func OverlapCheck(seq1 string, seq2 string) (maxpercentOverlapofsmallest float64, maxnumberofbpOverlap int, overlappingseq string) {
 
@@ -162,151 +267,119 @@ func DNAregion(sequence wtype.DNASequence, startposition int, endposition int) (
 
 }
 
-// Takes defined region and makes an oligosequence between a defined minimum and maximum length
-// with a melting temperature between a defined minimum and maximum and a maximum GC content ( between 0 and 1).
-// function finds oligo by starting at position 0 and making sequence of the minimum length, calculating parameters
+// FWDOligoSeq takes a defined region and makes an oligosequence which meets the constraints.
+// The function finds the oligo by starting at position 0 and making a sequence of the minimum length, calculating parameters
 // and if they do not match then adds one basepair to end of sequence until the maximum length is reached.
-// if still unsuccessful, the function begins again at position 1 and cycles through until a matching oligo sequence is found.
-// overlapthresholdwithseqstoavoid allows maximum permissable partial overlap to be specified by the user, if set to -1 any overlap is tolerated
-func FWDOligoSeq(seq wtype.DNASequence, maxGCcontent float64, minlength int, maxlength int, minmeltingtemp wunit.Temperature, maxmeltingtemp wunit.Temperature, seqstoavoid []string, overlapthresholdwithseqstoavoid int) (oligoseq Primer, err error) {
+// If still unsuccessful, the function begins again at position 1 and cycles through until a matching oligo sequence is found.
+func FWDOligoSeq(seq wtype.DNASequence, constraints PrimerConstraints) (oligoseq Primer, err error) {
+	return designOligo(seq, strings.ToUpper(seq.Sequence()), false, constraints)
+}
+
+// REVOligoSeq designs an oligo annealing to the end of the region in the
+// same way as FWDOligoSeq, starting from the reverse complement of the region.
+func REVOligoSeq(seq wtype.DNASequence, constraints PrimerConstraints) (oligoseq Primer, err error) {
+	return designOligo(seq, sequences.RevComp(strings.ToUpper(seq.Sequence())), true, constraints)
+}
+
+// designOligo finds the first oligo starting near the beginning of region
+// which meets the constraints and binds once to seq
+func designOligo(seq wtype.DNASequence, region string, reverse bool, constraints PrimerConstraints) (oligoseq Primer, err error) {
+
+	maxlength, minlength := constraints.MaxLength, constraints.MinLength
 
 	if maxlength > len(seq.Sequence()) {
 		return oligoseq, fmt.Errorf("Sequence %s %s too small to design primer for or max length of primer %d too long", seq.Nm, seq.Seq, maxlength)
 	}
 
-	region := strings.ToUpper(seq.Sequence())
-
 	for start := 0; start < maxlength; start++ {
 
-		for end := minlength + start; end <= start+maxlength; end++ {
-
-			var overlapthresholdfail bool
-
-			tempoligoseq := region[start:end]
-
-			ssoligo := wtype.MakeSingleStrandedDNASequence("oligo", tempoligoseq)
-
-			temppercentage := sequences.GCcontent(tempoligoseq)
-
-			meltingtemp := BasicMeltingTemp(ssoligo)
+		for end := minlength + start; end <= start+maxlength && end <= len(region); end++ {
 
-			bindingsites := CheckNonSpecificBinding(seq, ssoligo)
-
-			if len(seqstoavoid) > 0 && overlapthresholdwithseqstoavoid > 0 {
-				for _, seq := range seqstoavoid {
-					_, overlap, _ := OverlapCheck(tempoligoseq, seq)
-
-					if overlap > overlapthresholdwithseqstoavoid {
-						overlapthresholdfail = true
-					}
-				}
-			}
-
-			if temppercentage <= maxGCcontent && minmeltingtemp.SIValue() < meltingtemp.SIValue() && maxmeltingtemp.SIValue() > meltingtemp.SIValue() && bindingsites == 1 && !search.PartialInStrings(seqstoavoid, tempoligoseq, search.IgnoreCase) && !overlapthresholdfail {
-				oligoseq.DNASequence = wtype.MakeSingleStrandedDNASequence("Primer", tempoligoseq)
-				oligoseq.GCContent = temppercentage
-				oligoseq.Length = len(tempoligoseq)
-				oligoseq.MeltingTemp = meltingtemp
-				err = nil
+			oligoseq, err = CheckPrimer(seq, region[start:end], reverse, constraints)
+			if err == nil {
 				return
-
-			} else {
-				var combinedErrors []string
-				var i bool = true
-				var primerType, seqName string = "FORWARD", seq.Name()
-				switch i {
-				case temppercentage >= maxGCcontent:
-					combinedErrors = append(combinedErrors, gcContentErrorString(primerType, seqName, temppercentage, maxGCcontent))
-				case minmeltingtemp.SIValue() > meltingtemp.SIValue():
-					combinedErrors = append(combinedErrors, meltingTempErrorString(primerType, seqName, "minimum", meltingtemp.SIValue(), minmeltingtemp.SIValue()))
-				case maxmeltingtemp.SIValue() < meltingtemp.SIValue():
-					combinedErrors = append(combinedErrors, meltingTempErrorString(primerType, seqName, "maximum", meltingtemp.SIValue(), maxmeltingtemp.SIValue()))
-				case bindingsites > 1:
-					combinedErrors = append(combinedErrors, bindingSiteErrorString(primerType, seqName, bindingsites))
-				case search.PartialInStrings(seqstoavoid, tempoligoseq, search.IgnoreCase):
-					combinedErrors = append(combinedErrors, primerErrorString(primerType, seqName, " that contain the specified sequences to avoid.", " removing these from the parameters."))
-				case overlapthresholdfail:
-					combinedErrors = append(combinedErrors, primerErrorString(primerType, seqName, " that violate the overlap threshold.", " adjusting this parameter."))
-				}
-				err = fmt.Errorf(strings.Join(combinedErrors, "\n"))
 			}
 		}
 	}
-	return
+	return Primer{}, err
 }
 
-func REVOligoSeq(seq wtype.DNASequence, maxGCcontent float64, minlength int, maxlength int, minmeltingtemp wunit.Temperature, maxmeltingtemp wunit.Temperature, seqstoavoid []string, overlapthresholdwithseqstoavoid int) (oligoseq Primer, err error) {
-
-	//var start int
-	//var end int
-
-	// get the reverse complement of the region
-
-	if maxlength > len(seq.Sequence()) {
-		return oligoseq, fmt.Errorf("Sequence %s %s too small to design primer for or max length of primer %d too long", seq.Nm, seq.Seq, maxlength)
-	}
-
-	region := seq.Sequence()
-	revregion := sequences.RevComp(region)
-
-	for start := 0; start < maxlength; start++ {
+// CheckPrimer checks whether an oligo meets the constraints and binds only
+// once to seq, returning the oligo as a Primer if so. If not, the error
+// describes the first constraint which is not met.
+func CheckPrimer(seq wtype.DNASequence, oligo string, reverse bool, constraints PrimerConstraints) (oligoseq Primer, err error) {
 
-		for end := minlength + start; end <= start+maxlength; end++ {
+	minmeltingtemp, maxmeltingtemp := constraints.MinMeltingTemp, constraints.MaxMeltingTemp
+	maxGCcontent := constraints.MaxGCContent
+	seqstoavoid, overlapthresholdwithseqstoavoid := constraints.SeqsToAvoid, constraints.OverlapThreshold
 
-			var overlapthresholdfail bool
+	var overlapthresholdfail bool
 
-			tempoligoseq := revregion[start:end]
+	tempoligoseq := strings.ToUpper(oligo)
 
-			ssoligo := wtype.MakeSingleStrandedDNASequence("oligo", tempoligoseq)
+	ssoligo := wtype.MakeSingleStrandedDNASequence("oligo", tempoligoseq)
 
-			temppercentage := sequences.GCcontent(tempoligoseq)
+	temppercentage := sequences.GCcontent(tempoligoseq)
 
-			meltingtemp := BasicMeltingTemp(ssoligo)
+	meltingtemp, tmErr := constraints.MeltingTemp(ssoligo)
 
-			bindingsites := CheckNonSpecificBinding(seq, ssoligo)
+	bindingsites := CheckNonSpecificBinding(seq, ssoligo)
 
-			if len(seqstoavoid) > 0 && overlapthresholdwithseqstoavoid > 0 {
-				for _, seq := range seqstoavoid {
-					_, overlap, _ := OverlapCheck(tempoligoseq, seq)
+	if len(seqstoavoid) > 0 && overlapthresholdwithseqstoavoid > 0 {
+		for _, seq := range seqstoavoid {
+			_, overlap, _ := OverlapCheck(tempoligoseq, seq)
 
-					if overlap > overlapthresholdwithseqstoavoid {
-						overlapthresholdfail = true
-					}
-				}
+			if overlap > overlapthresholdwithseqstoavoid {
+				overlapthresholdfail = true
 			}
+		}
+	}
 
-			if temppercentage <= maxGCcontent && minmeltingtemp.SIValue() < meltingtemp.SIValue() && maxmeltingtemp.SIValue() > meltingtemp.SIValue() && bindingsites == 1 && !search.PartialInStrings(seqstoavoid, tempoligoseq, search.IgnoreCase) && !overlapthresholdfail {
-				oligoseq.DNASequence = wtype.MakeSingleStrandedDNASequence("Primer", tempoligoseq)
-				oligoseq.GCContent = temppercentage
-				oligoseq.Length = len(tempoligoseq)
-				oligoseq.MeltingTemp = meltingtemp
-				err = nil
-				return
+	var structure string
+	var structureErr error
+	pass := tmErr == nil && temppercentage <= maxGCcontent && minmeltingtemp.SIValue() < meltingtemp.SIValue() && maxmeltingtemp.SIValue() > meltingtemp.SIValue() && bindingsites == 1 && !search.PartialInStrings(seqstoavoid, tempoligoseq, search.IgnoreCase) && !overlapthresholdfail
+	if pass {
+		// only worth checking for secondary structures if all else is well
+		structure, structureErr = constraints.secondaryStructure(tempoligoseq)
+		pass = structureErr == nil && structure == ""
+	}
 
-			} else {
-				var combinedErrors []string
-				var i bool = true
-				var primerType, seqName string = "REVERSE", seq.Name()
-				switch i {
-				case temppercentage >= maxGCcontent:
-					combinedErrors = append(combinedErrors, gcContentErrorString(primerType, seqName, temppercentage, maxGCcontent))
-				case minmeltingtemp.SIValue() > meltingtemp.SIValue():
-					combinedErrors = append(combinedErrors, meltingTempErrorString(primerType, seqName, "minimum", meltingtemp.SIValue(), minmeltingtemp.SIValue()))
-				case maxmeltingtemp.SIValue() < meltingtemp.SIValue():
-					combinedErrors = append(combinedErrors, meltingTempErrorString(primerType, seqName, "maximum", meltingtemp.SIValue(), maxmeltingtemp.SIValue()))
-				case bindingsites > 1:
-					combinedErrors = append(combinedErrors, bindingSiteErrorString(primerType, seqName, bindingsites))
-				case search.PartialInStrings(seqstoavoid, tempoligoseq, search.IgnoreCase):
-					combinedErrors = append(combinedErrors, primerErrorString(primerType, seqName, " that contain the specified sequences to avoid.", " removing these from the parameters."))
-				case overlapthresholdfail:
-					combinedErrors = append(combinedErrors, primerErrorString(primerType, seqName, " that violate the overlap threshold.", " adjusting this parameter."))
-				}
-				err = fmt.Errorf(strings.Join(combinedErrors, "\n"))
-			}
-		}
+	if pass {
+		oligoseq.DNASequence = wtype.MakeSingleStrandedDNASequence("Primer", tempoligoseq)
+		oligoseq.GCContent = temppercentage
+		oligoseq.Length = len(tempoligoseq)
+		oligoseq.MeltingTemp = meltingtemp
+		oligoseq.Reverse = reverse
+		return oligoseq, nil
 	}
 
-	return
+	var combinedErrors []string
+	var i bool = true
+	var primerType, seqName string = "FORWARD", seq.Name()
+	if reverse {
+		primerType = "REVERSE"
+	}
+	switch i {
+	case tmErr != nil:
+		combinedErrors = append(combinedErrors, primerErrorString(primerType, seqName, fmt.Sprintf(" for which the melting temperature could not be calculated: %s.", tmErr), " checking the sequence and conditions."))
+	case temppercentage >= maxGCcontent:
+		combinedErrors = append(combinedErrors, gcContentErrorString(primerType, seqName, temppercentage, maxGCcontent))
+	case minmeltingtemp.SIValue() > meltingtemp.SIValue():
+		combinedErrors = append(combinedErrors, meltingTempErrorString(primerType, seqName, "minimum", meltingtemp.SIValue(), minmeltingtemp.SIValue()))
+	case maxmeltingtemp.SIValue() < meltingtemp.SIValue():
+		combinedErrors = append(combinedErrors, meltingTempErrorString(primerType, seqName, "maximum", meltingtemp.SIValue(), maxmeltingtemp.SIValue()))
+	case bindingsites > 1:
+		combinedErrors = append(combinedErrors, bindingSiteErrorString(primerType, seqName, bindingsites))
+	case search.PartialInStrings(seqstoavoid, tempoligoseq, search.IgnoreCase):
+		combinedErrors = append(combinedErrors, primerErrorString(primerType, seqName, " that contain the specified sequences to avoid.", " removing these from the parameters."))
+	case overlapthresholdfail:
+		combinedErrors = append(combinedErrors, primerErrorString(primerType, seqName, " that violate the overlap threshold.", " adjusting this parameter."))
+	case structureErr != nil:
+		combinedErrors = append(combinedErrors, primerErrorString(primerType, seqName, fmt.Sprintf(" for which secondary structures could not be calculated: %s.", structureErr), " checking the sequence and conditions."))
+	case structure != "":
+		combinedErrors = append(combinedErrors, primerErrorString(primerType, seqName, fmt.Sprintf(" which form a %s more stable than permitted.", structure), " relaxing the secondary structure limits or selecting another region."))
+	}
+	return oligoseq, fmt.Errorf(strings.Join(combinedErrors, "\n"))
 }
 
 // primerErrorString formats a textual message in the form of an erorr related to primerdesign.
@@ -340,15 +413,15 @@ func bindingSiteErrorString(primerOrientation, sequenceName string, bindingSites
 	return primerErrorString(primerOrientation, sequenceName, fmt.Sprintf(" with more than one (%d) binding sites.", bindingSites), " selecting another region.")
 }
 
-func DesignFWDPRimerstoCoverFullSequence(seq wtype.DNASequence, sequenceinterval int, maxGCcontent float64, minlength int, maxlength int, minmeltingtemp wunit.Temperature, maxmeltingtemp wunit.Temperature, seqstoavoid []string, overlapthresholdwithseqstoavoid int) (primers []Primer) {
+func DesignFWDPRimerstoCoverFullSequence(seq wtype.DNASequence, sequenceinterval int, constraints PrimerConstraints) (primers []Primer) {
 	primers = make([]Primer, 0)
 	avoidthese := make([]string, 0)
-	avoidthese = append(avoidthese, seqstoavoid...)
+	avoidthese = append(avoidthese, constraints.SeqsToAvoid...)
 
 	for i := 1; i < len(seq.Sequence()); i = i + sequenceinterval {
 		region := DNAregion(seq, i, len(seq.Sequence()))
 
-		primer, err := FWDOligoSeq(region, maxGCcontent, minlength, maxlength, minmeltingtemp, maxmeltingtemp, avoidthese, overlapthresholdwithseqstoavoid)
+		primer, err := FWDOligoSeq(region, constraints.avoiding(avoidthese))
 
 		if err != nil {
 			panic(err.Error() + " for " + region.Nm)
@@ -363,10 +436,10 @@ func DesignFWDPRimerstoCoverFullSequence(seq wtype.DNASequence, sequenceinterval
 	return
 }
 
-func DesignFWDPRimerstoCoverRegion(seq wtype.DNASequence, regionstart, regionend, sequenceinterval int, maxGCcontent float64, minlength int, maxlength int, minmeltingtemp wunit.Temperature, maxmeltingtemp wunit.Temperature, seqstoavoid []string, overlapthresholdwithseqstoavoid int) (primers []Primer) {
+func DesignFWDPRimerstoCoverRegion(seq wtype.DNASequence, regionstart, regionend, sequenceinterval int, constraints PrimerConstraints) (primers []Primer) {
 	primers = make([]Primer, 0)
 	avoidthese := make([]string, 0)
-	avoidthese = append(avoidthese, seqstoavoid...)
+	avoidthese = append(avoidthese, constraints.SeqsToAvoid...)
 
 	if regionstart-100 > 0 {
 		regionstart = regionstart - 100
@@ -378,7 +451,7 @@ func DesignFWDPRimerstoCoverRegion(seq wtype.DNASequence, regionstart, regionend
 
 		region := DNAregion(seq, i, len(seq.Sequence()))
 
-		primer, err := FWDOligoSeq(region, maxGCcontent, minlength, maxlength, minmeltingtemp, maxmeltingtemp, avoidthese, overlapthresholdwithseqstoavoid)
+		primer, err := FWDOligoSeq(region, constraints.avoiding(avoidthese))
 
 		if err != nil {
 			panic(err.Error() + " for " + region.Nm)
@@ -393,49 +466,59 @@ func DesignFWDPRimerstoCoverRegion(seq wtype.DNASequence, regionstart, regionend
 	return
 }
 
-/*
-func DesignPrimerstoFlankRegion(seq wtype.DNASequence, regionstart, regionend int, maxGCcontent float64, minlength int, maxlength int, minmeltingtemp wunit.Temperature, maxmeltingtemp wunit.Temperature, seqstoavoid []string, overlapthresholdwithseqstoavoid int) (primers [2]Primer) {
-
-	primers = make([]Primer, 2)
-
-	avoidthese := make([]string, 0)
-
-	if len(seqstoavoid) != 0 {
-		for _, seq := range seqstoavoid {
-			avoidthese = append(avoidthese, seq)
-		}
+// flankingWindow is the distance either side of a region within which
+// primers flanking it are designed
+const flankingWindow = 100
+
+// DesignPrimerstoFlankRegion designs a pair of primers which amplify the region of seq between
+// regionstart and regionend, in user format (i.e. first position is 1 and not 0).
+// The forward primer anneals within 100 bp upstream of the region and the reverse primer within 100 bp downstream.
+// Each primer must bind only once to seq, and the reverse primer is checked for hetero-dimers with the forward primer
+// in addition to any Partners of the constraints.
+func DesignPrimerstoFlankRegion(seq wtype.DNASequence, regionstart, regionend int, constraints PrimerConstraints) (primers [2]Primer, err error) {
+	if regionstart < 1 || regionend > len(seq.Sequence()) || regionstart > regionend {
+		return primers, fmt.Errorf("region %d to %d is not within %s", regionstart, regionend, seq.Nm)
 	}
 
-	if regionstart-100 > 0 {
-		regionstart = regionstart - 100
-	} else {
-		regionstart = 0
+	upstreamStart := regionstart - flankingWindow
+	if upstreamStart < 1 {
+		upstreamStart = 1
+	}
+	downstreamEnd := regionend + flankingWindow
+	if downstreamEnd > len(seq.Sequence()) {
+		downstreamEnd = len(seq.Sequence())
+	}
+	if regionstart-upstreamStart < constraints.MaxLength || downstreamEnd-regionend < constraints.MaxLength {
+		return primers, fmt.Errorf("not enough sequence either side of region %d to %d of %s to design primers of up to %d bases", regionstart, regionend, seq.Nm, constraints.MaxLength)
 	}
 
-	for i := regionstart; i < regionend; i = i + sequenceinterval {
-
-		region := DNAregion(seq, i, len(seq.Sequence()))
-
-		primer, err := FWDOligoSeq(region, maxGCcontent, minlength, maxlength, minmeltingtemp, maxmeltingtemp, avoidthese, overlapthresholdwithseqstoavoid)
-
-		if err != nil {
-			panic(err.Error() + " for " + region.Nm)
-		}
-
-		primer.Nm = "primer_" + seq.Nm + "_" + strconv.Itoa(i) + ":" + strconv.Itoa(i-1+sequenceinterval)
-
-		primers = append(primers, primer)
+	forward, err := FWDOligoSeq(DNAregion(seq, upstreamStart, regionstart-1), constraints)
+	if err != nil {
+		return primers, err
+	}
+	partnered := constraints
+	partnered.Partners = append([]string{forward.Sequence()}, constraints.Partners...)
+	reverse, err := REVOligoSeq(DNAregion(seq, regionend+1, downstreamEnd), partnered)
+	if err != nil {
+		return primers, err
+	}
 
-		avoidthese = append(avoidthese, primer.Sequence())
+	if sites := CheckNonSpecificBinding(seq, forward.DNASequence); sites != 1 {
+		return primers, fmt.Errorf(bindingSiteErrorString("FORWARD", seq.Nm, sites))
 	}
-	return
+	if sites := CheckNonSpecificBinding(seq, reverse.DNASequence); sites != 1 {
+		return primers, fmt.Errorf(bindingSiteErrorString("REVERSE", seq.Nm, sites))
+	}
+
+	forward.Nm = "primer_" + seq.Nm + "_" + strconv.Itoa(regionstart) + ":" + strconv.Itoa(regionend) + "_F"
+	reverse.Nm = "primer_" + seq.Nm + "_" + strconv.Itoa(regionstart) + ":" + strconv.Itoa(regionend) + "_R"
+	return [2]Primer{forward, reverse}, nil
 }
-*/
 
-func DesignFWDPRimerstoCoverSequence(seq wtype.DNASequence, targetseq string, sequenceinterval int, maxGCcontent float64, minlength int, maxlength int, minmeltingtemp wunit.Temperature, maxmeltingtemp wunit.Temperature, seqstoavoid []string, overlapthresholdwithseqstoavoid int) (primers []Primer) {
+func DesignFWDPRimerstoCoverSequence(seq wtype.DNASequence, targetseq string, sequenceinterval int, constraints PrimerConstraints) (primers []Primer) {
 	primers = make([]Primer, 0)
 	avoidthese := make([]string, 0)
-	avoidthese = append(avoidthese, seqstoavoid...)
+	avoidthese = append(avoidthese, constraints.SeqsToAvoid...)
 
 	seqsfound := sequences.FindSeqsinSeqs(seq.Sequence(), []string{targetseq})
 
@@ -468,7 +551,7 @@ func DesignFWDPRimerstoCoverSequence(seq wtype.DNASequence, targetseq string, se
 
 		region := DNAregion(seq, i, len(seq.Sequence()))
 
-		primer, err := FWDOligoSeq(region, maxGCcontent, minlength, maxlength, minmeltingtemp, maxmeltingtemp, avoidthese, overlapthresholdwithseqstoavoid)
+		primer, err := FWDOligoSeq(region, constraints.avoiding(avoidthese))
 
 		if err != nil {
 			panic(err.Error() + " for " + region.Nm)
@@ -483,10 +566,10 @@ func DesignFWDPRimerstoCoverSequence(seq wtype.DNASequence, targetseq string, se
 	return
 }
 
-func DesignFWDPRimerstoCoverFeature(seq wtype.DNASequence, targetfeaturename string, sequenceinterval int, maxGCcontent float64, minlength int, maxlength int, minmeltingtemp wunit.Temperature, maxmeltingtemp wunit.Temperature, seqstoavoid []string, overlapthresholdwithseqstoavoid int) (primers []Primer) {
+func DesignFWDPRimerstoCoverFeature(seq wtype.DNASequence, targetfeaturename string, sequenceinterval int, constraints PrimerConstraints) (primers []Primer) {
 	primers = make([]Primer, 0)
 	avoidthese := make([]string, 0)
-	avoidthese = append(avoidthese, seqstoavoid...)
+	avoidthese = append(avoidthese, constraints.SeqsToAvoid...)
 
 	features := seq.GetFeatureByName(targetfeaturename)
 	if len(features) == 0 {
@@ -519,7 +602,7 @@ func DesignFWDPRimerstoCoverFeature(seq wtype.DNASequence, targetfeaturename str
 
 		region := DNAregion(seq, i, len(seq.Sequence()))
 
-		primer, err := FWDOligoSeq(region, maxGCcontent, minlength, maxlength, minmeltingtemp, maxmeltingtemp, avoidthese, overlapthresholdwithseqstoavoid)
+		primer, err := FWDOligoSeq(region, constraints.avoiding(avoidthese))
 
 		if err != nil {
 			panic(err.Error() + " for " + region.Nm)
@@ -541,18 +624,18 @@ func CheckNonSpecificBinding(fullSeq, primerSeq wtype.DNASequence) (count int) {
 	return
 }
 
-func MakeOutwardFacingPrimers(sequence wtype.DNASequence, maxGCcontent float64, minlength int, maxlength int, minmeltingtemp wunit.Temperature, maxmeltingtemp wunit.Temperature, seqstoavoid []string, overlapthresholdwithseqstoavoid int) (oligoforpartsafter Primer, oligoforpartsbefore Primer) {
+func MakeOutwardFacingPrimers(sequence wtype.DNASequence, constraints PrimerConstraints) (oligoforpartsafter Primer, oligoforpartsbefore Primer) {
 
 	endstartingpoint := wtype.MakeLinearDNASequence("endprimer", sequence.Sequence()[len(sequence.Sequence())-100:len(sequence.Sequence())-1])
 
-	oligoforpartsafter, _ = FWDOligoSeq(endstartingpoint, maxGCcontent, minlength, maxlength, minmeltingtemp, maxmeltingtemp, seqstoavoid, overlapthresholdwithseqstoavoid) // nolint
+	oligoforpartsafter, _ = FWDOligoSeq(endstartingpoint, constraints) // nolint
 
 	// now reverse
 	reversesequence := wtype.RevComp(sequence.Sequence())
 
 	endstartingpoint = wtype.MakeLinearDNASequence("endprimer", reversesequence[len(reversesequence)-100:len(reversesequence)-1])
 
-	oligoforpartsbefore, _ = FWDOligoSeq(endstartingpoint, maxGCcontent, minlength, maxlength, minmeltingtemp, maxmeltingtemp, seqstoavoid, overlapthresholdwithseqstoavoid) // nolint
+	oligoforpartsbefore, _ = FWDOligoSeq(endstartingpoint, constraints) // nolint
 
 	oligoforpartsbefore.Reverse = true
 
@@ -37,6 +37,18 @@ type testpair struct {
 	expectedError error
 }
 
+func (oligo testpair) constraints() PrimerConstraints {
+	return PrimerConstraints{
+		MaxGCContent:     oligo.maxGCcontent,
+		MinLength:        oligo.minlength,
+		MaxLength:        oligo.maxlength,
+		MinMeltingTemp:   oligo.mintemp,
+		MaxMeltingTemp:   oligo.maxtemp,
+		SeqsToAvoid:      oligo.seqstoavoid,
+		OverlapThreshold: oligo.overlapthreshold,
+	}
+}
+
 var meltingtemptests = []testpair{
 
 	{sequence: wtype.MakeSingleStrandedDNASequence("whatever", "AAAAAAAAAAAAAAAAAAA"),
@@ -155,7 +167,7 @@ func TestBasicMeltingTemp(t *testing.T) {
 
 func TestFWDOligoSeq(t *testing.T) {
 	for _, oligo := range oligotests {
-		oligoseq, err := FWDOligoSeq(oligo.sequence, oligo.maxGCcontent, oligo.minlength, oligo.maxlength, oligo.mintemp, oligo.maxtemp, oligo.seqstoavoid, oligo.overlapthreshold)
+		oligoseq, err := FWDOligoSeq(oligo.sequence, oligo.constraints())
 		if oligoseq.Sequence() != oligo.outputoligoseq {
 			t.Error(
 				"For", oligo.sequence, "\n",
@@ -179,7 +191,7 @@ func TestFWDOligoSeq(t *testing.T) {
 	}
 
 	for _, oligo := range primerErrorTests {
-		_, err := FWDOligoSeq(oligo.sequence, oligo.maxGCcontent, oligo.minlength, oligo.maxlength, oligo.mintemp, oligo.maxtemp, oligo.seqstoavoid, oligo.overlapthreshold)
+		_, err := FWDOligoSeq(oligo.sequence, oligo.constraints())
 		if err.Error() != oligo.expectedError.Error() {
 			t.Error(
 				"For", oligo.sequence.Name(), "\n",
@@ -0,0 +1,338 @@
+// Part of the Antha language
+// Copyright (C) 2018 The Antha authors. All rights reserved.
+//
+// This program is free software; you can redistribute it and/or
+// modify it under the terms of the GNU General Public License
+// as published by the Free Software Foundation; either version 2
+// of the License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program; if not, write to the Free Software
+// Foundation, Inc., 51 Franklin Street, Fifth Floor, Boston, MA  02110-1301, USA.
+//
+// For more information relating to the software or licensing issues please
+// contact license@antha-lang.org or write to the Antha team c/o
+// Synthace Ltd. The London Bioscience Innovation Centre
+// 2 Royal College St, London NW1 0NH UK
+
+package oligos
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/antha-lang/antha/antha/AnthaStandardLibrary/Packages/sequences"
+	"github.com/antha-lang/antha/antha/anthalib/wtype"
+	"github.com/antha-lang/antha/antha/anthalib/wunit"
+)
+
+// gas constant in cal/K/mol
+const gasConstant = 1.987
+
+const zeroCelsius = 273.15
+
+// nearestNeighbour is the enthalpy (kcal/mol) and entropy (cal/K/mol) of a
+// stacked pair of base pairs
+type nearestNeighbour struct {
+	deltaH, deltaS float64
+}
+
+// deltaG returns the free energy in kcal/mol at a temperature in kelvin
+func (nn nearestNeighbour) deltaG(kelvin float64) float64 {
+	return nn.deltaH - kelvin*nn.deltaS/1000
+}
+
+func (nn nearestNeighbour) add(other nearestNeighbour) nearestNeighbour {
+	return nearestNeighbour{deltaH: nn.deltaH + other.deltaH, deltaS: nn.deltaS + other.deltaS}
+}
+
+// Unified nearest neighbour parameters in 1 M NaCl, keyed by the top strand
+// 5'-3', from SantaLucia,J. and Hicks,D. (2004) Annu Rev Biophys Biomol Struct 33:415-440.
+var nearestNeighbours = func() map[string]nearestNeighbour {
+	nn := map[string]nearestNeighbour{
+		"AA": {-7.6, -21.3},
+		"AT": {-7.2, -20.4},
+		"TA": {-7.2, -21.3},
+		"CA": {-8.5, -22.7},
+		"GT": {-8.4, -22.4},
+		"CT": {-7.8, -21.0},
+		"GA": {-8.2, -22.2},
+		"CG": {-10.6, -27.2},
+		"GC": {-9.8, -24.4},
+		"GG": {-8.0, -19.9},
+	}
+	for pair, p := range nn {
+		nn[sequences.RevComp(pair)] = p
+	}
+	return nn
+}()
+
+var (
+	initiation       = nearestNeighbour{deltaH: 0.2, deltaS: -5.7}
+	terminalAT       = nearestNeighbour{deltaH: 2.2, deltaS: 6.9}
+	symmetry         = nearestNeighbour{deltaH: 0, deltaS: -1.4}
+	hairpinLoopsAt37 = map[int]float64{3: 3.5, 4: 3.5, 5: 3.3, 6: 4.0, 7: 4.2, 8: 4.3, 9: 4.5, 10: 4.6, 12: 5.0, 14: 5.1, 16: 5.3, 18: 5.5, 20: 5.7, 25: 6.1, 30: 6.3}
+)
+
+// minimum length of stems of hairpins and of duplexes in dimers
+const minStructureLength = 3
+
+const minHairpinLoop = 3
+
+// Conditions are the reaction conditions which affect the stability of DNA
+// duplexes. Oligo is the total concentration of oligo strands.
+// Temperature is that at which free energies of secondary structures are
+// calculated.
+type Conditions struct {
+	Monovalent  wunit.Concentration
+	Magnesium   wunit.Concentration
+	DNTPs       wunit.Concentration
+	Oligo       wunit.Concentration
+	Temperature wunit.Temperature
+}
+
+// DefaultConditions returns typical PCR conditions of 50 mM monovalent
+// cations, 1.5 mM magnesium, 0.6 mM dNTPs and 50 nM of each primer, with
+// free energies calculated at 37 ℃.
+func DefaultConditions() Conditions {
+	return Conditions{
+		Monovalent:  wunit.NewConcentration(50, "mM/l"),
+		Magnesium:   wunit.NewConcentration(1.5, "mM/l"),
+		DNTPs:       wunit.NewConcentration(0.6, "mM/l"),
+		Oligo:       wunit.NewConcentration(50, "nM/l"),
+		Temperature: wunit.NewTemperature(37, "C"),
+	}
+}
+
+// molar returns a concentration in mol/l, treating unset concentrations as zero
+func molar(conc wunit.Concentration) (float64, error) {
+	if conc.ConcreteMeasurement == nil {
+		return 0, nil
+	}
+	m, err := conc.InStringUnit("M/l")
+	if err != nil {
+		return 0, err
+	}
+	return m.RawValue(), nil
+}
+
+// sodiumEquivalent returns the concentration of Na+ in mol/l equivalent to
+// the monovalent and divalent cations, from von Ahsen,N., Wittwer,C.T. and
+// Schutz,E. (2001) Clin Chem 47:1956-1961.
+func (c Conditions) sodiumEquivalent() (float64, error) {
+	var concs [3]float64
+	for i, conc := range []wunit.Concentration{c.Monovalent, c.Magnesium, c.DNTPs} {
+		m, err := molar(conc)
+		if err != nil {
+			return 0, err
+		}
+		concs[i] = m
+	}
+	na := concs[0]
+	if free := concs[1] - concs[2]; free > 0 {
+		// the correction is in mM
+		na += 120 * math.Sqrt(free*1000) / 1000
+	}
+	if na <= 0 {
+		return 0, fmt.Errorf("no monovalent or divalent cations specified")
+	}
+	return na, nil
+}
+
+// kelvin returns the temperature for free energies, 37 ℃ if not set
+func (c Conditions) kelvin() float64 {
+	if c.Temperature.ConcreteMeasurement == nil {
+		return 37 + zeroCelsius
+	}
+	return c.Temperature.SIValue() + zeroCelsius
+}
+
+// saltCorrection returns the entropy correction for each nearest neighbour
+// from SantaLucia,J. (1998) Proc Natl Acad Sci USA 95:1460-1465.
+func saltCorrection(na float64) nearestNeighbour {
+	return nearestNeighbour{deltaS: 0.368 * math.Log(na)}
+}
+
+func checkBases(seq string) error {
+	for _, b := range seq {
+		if !strings.ContainsRune("ACGT", b) {
+			return fmt.Errorf("cannot calculate thermodynamics of %s: only A, C, G and T are supported, found %q", seq, b)
+		}
+	}
+	return nil
+}
+
+// duplex returns the thermodynamics of seq forming a perfectly matched
+// duplex with its complement, including the initiation and terminal
+// penalties
+func duplex(seq string, salt nearestNeighbour) nearestNeighbour {
+	total := initiation
+	for i := 0; i+1 < len(seq); i++ {
+		total = total.add(nearestNeighbours[seq[i:i+2]]).add(salt)
+	}
+	for _, end := range []byte{seq[0], seq[len(seq)-1]} {
+		if end == 'A' || end == 'T' {
+			total = total.add(terminalAT)
+		}
+	}
+	return total
+}
+
+/*
+NearestNeighbourMeltingTemp calculates the melting temperature of a DNASequence
+annealed to its complement using the unified nearest neighbour parameters of
+SantaLucia,J. and Hicks,D. (2004) Annu Rev Biophys Biomol Struct 33:415-440.
+
+The entropy is corrected for the concentration of Na+ equivalent to the
+monovalent and magnesium ions of the conditions, with the concentration of
+oligo used for the concentration of strands.
+*/
+func NearestNeighbourMeltingTemp(primersequence wtype.DNASequence, conditions Conditions) (meltingtemp wunit.Temperature, err error) {
+	seq := strings.ToUpper(primersequence.Sequence())
+	if len(seq) < 2 {
+		return meltingtemp, fmt.Errorf("sequence %s too short to calculate melting temperature", primersequence.Name())
+	}
+	if err = checkBases(seq); err != nil {
+		return meltingtemp, err
+	}
+	na, err := conditions.sodiumEquivalent()
+	if err != nil {
+		return meltingtemp, err
+	}
+	strands, err := molar(conditions.Oligo)
+	if err != nil {
+		return meltingtemp, err
+	}
+	if strands <= 0 {
+		return meltingtemp, fmt.Errorf("no oligo concentration specified")
+	}
+
+	total := duplex(seq, saltCorrection(na))
+	if seq == sequences.RevComp(seq) {
+		total = total.add(symmetry)
+	} else {
+		strands /= 4
+	}
+
+	tm := total.deltaH*1000/(total.deltaS+gasConstant*math.Log(strands)) - zeroCelsius
+	return wunit.NewTemperature(tm, "℃"), nil
+}
+
+// hairpinLoop returns the free energy in kcal/mol of closing a hairpin loop
+// of n bases, which is treated as purely entropic
+func hairpinLoop(n int, kelvin float64) float64 {
+	largest := 30
+	g, found := hairpinLoopsAt37[n]
+	if !found {
+		if n > largest {
+			g = hairpinLoopsAt37[largest] + 2.44*gasConstant*310.15*math.Log(float64(n)/float64(largest))/1000
+		} else {
+			// interpolate between the tabulated lengths either side
+			below, above := n-1, n+1
+			for ; hairpinLoopsAt37[below] == 0; below-- {
+			}
+			for ; hairpinLoopsAt37[above] == 0; above++ {
+			}
+			g = hairpinLoopsAt37[below] + (hairpinLoopsAt37[above]-hairpinLoopsAt37[below])*float64(n-below)/float64(above-below)
+		}
+	}
+	return g * kelvin / (37 + zeroCelsius)
+}
+
+// HairpinDeltaG estimates the free energy in kcal/mol of the most stable
+// hairpin formed by seq under the conditions specified. Only stems of at
+// least 3 perfectly matched base pairs, closing loops of at least 3 bases,
+// are considered. If no hairpin is more stable than the single strand 0 is
+// returned.
+func HairpinDeltaG(seq string, conditions Conditions) (float64, error) {
+	seq = strings.ToUpper(seq)
+	if err := checkBases(seq); err != nil {
+		return 0, err
+	}
+	na, err := conditions.sodiumEquivalent()
+	if err != nil {
+		return 0, err
+	}
+	salt := saltCorrection(na)
+	kelvin := conditions.kelvin()
+
+	best := 0.0
+	// the stem closing the loop pairs i with j
+	for i := 0; i < len(seq); i++ {
+		for j := i + minHairpinLoop + 1; j < len(seq); j++ {
+			var stem nearestNeighbour
+			// extend the stem outwards from the loop
+			for k := 0; i-k >= 0 && j+k < len(seq); k++ {
+				if sequences.RevComp(seq[j+k:j+k+1]) != seq[i-k:i-k+1] {
+					break
+				}
+				if k > 0 {
+					stem = stem.add(nearestNeighbours[seq[i-k:i-k+2]]).add(salt)
+				}
+				if k+1 < minStructureLength {
+					continue
+				}
+				g := stem.deltaG(kelvin) + hairpinLoop(j-i-1, kelvin)
+				if end := seq[i-k]; end == 'A' || end == 'T' {
+					g += terminalAT.deltaG(kelvin)
+				}
+				if g < best {
+					best = g
+				}
+			}
+		}
+	}
+	return best, nil
+}
+
+// DimerDeltaG estimates the free energy in kcal/mol of the most stable duplex
+// formed between seq1 and seq2 under the conditions specified. Only
+// perfectly matched duplexes of at least 3 base pairs are considered. If no
+// duplex is more stable than the single strands 0 is returned.
+func DimerDeltaG(seq1, seq2 string, conditions Conditions) (float64, error) {
+	seq1, seq2 = strings.ToUpper(seq1), strings.ToUpper(seq2)
+	for _, seq := range []string{seq1, seq2} {
+		if err := checkBases(seq); err != nil {
+			return 0, err
+		}
+	}
+	na, err := conditions.sodiumEquivalent()
+	if err != nil {
+		return 0, err
+	}
+	salt := saltCorrection(na)
+	kelvin := conditions.kelvin()
+
+	// seq1[i] pairs with seq2 where it matches complement[i+shift]
+	complement := sequences.RevComp(seq2)
+	best := 0.0
+	for shift := -len(seq1) + 1; shift < len(complement); shift++ {
+		run := 0
+		for i := 0; i <= len(seq1); i++ {
+			j := i + shift
+			if i < len(seq1) && j >= 0 && j < len(complement) && seq1[i] == complement[j] {
+				run++
+				continue
+			}
+			if run >= minStructureLength {
+				if g := duplex(seq1[i-run:i], salt).deltaG(kelvin); g < best {
+					best = g
+				}
+			}
+			run = 0
+		}
+	}
+	return best, nil
+}
+
+// SelfDimerDeltaG estimates the free energy in kcal/mol of the most stable
+// duplex formed by two copies of seq, see DimerDeltaG.
+func SelfDimerDeltaG(seq string, conditions Conditions) (float64, error) {
+	return DimerDeltaG(seq, seq, conditions)
+}
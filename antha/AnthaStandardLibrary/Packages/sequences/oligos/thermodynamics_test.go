@@ -0,0 +1,121 @@
+package oligos
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/antha-lang/antha/antha/anthalib/wtype"
+	"github.com/antha-lang/antha/antha/anthalib/wunit"
+)
+
+func TestNearestNeighbourMeltingTemp(t *testing.T) {
+	seq := wtype.MakeSingleStrandedDNASequence("primer", "CGTTCCAAAGATGTGGGCATGAGCTTAC")
+	conditions := DefaultConditions()
+	tm, err := NearestNeighbourMeltingTemp(seq, conditions)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tm.SIValue() < 60 || tm.SIValue() > 70 {
+		t.Errorf("expected melting temperature between 60 and 70 ℃, got %s", tm.ToString())
+	}
+
+	lowSalt := conditions
+	lowSalt.Magnesium = wunit.NewConcentration(0, "mM/l")
+	if lower, err := NearestNeighbourMeltingTemp(seq, lowSalt); err != nil {
+		t.Fatal(err)
+	} else if lower.SIValue() >= tm.SIValue() {
+		t.Errorf("expected lower melting temperature without magnesium, got %s and %s", lower.ToString(), tm.ToString())
+	}
+
+	moreOligo := conditions
+	moreOligo.Oligo = wunit.NewConcentration(1, "uM/l")
+	if higher, err := NearestNeighbourMeltingTemp(seq, moreOligo); err != nil {
+		t.Fatal(err)
+	} else if higher.SIValue() <= tm.SIValue() {
+		t.Errorf("expected higher melting temperature with more oligo, got %s and %s", higher.ToString(), tm.ToString())
+	}
+
+	if _, err := NearestNeighbourMeltingTemp(wtype.MakeSingleStrandedDNASequence("N", "ACGTNACGT"), conditions); err == nil {
+		t.Error("expected error for N in sequence")
+	}
+	if _, err := NearestNeighbourMeltingTemp(seq, Conditions{Monovalent: wunit.NewConcentration(50, "mM/l")}); err == nil {
+		t.Error("expected error without oligo concentration")
+	}
+}
+
+func TestSecondaryStructure(t *testing.T) {
+	conditions := DefaultConditions()
+
+	if g, err := HairpinDeltaG("CGCGCGGAAACCGCGCG", conditions); err != nil {
+		t.Fatal(err)
+	} else if g >= -3 {
+		t.Errorf("expected stable hairpin, got %g", g)
+	}
+	if g, err := HairpinDeltaG("AAAAAAAAAAAAAAAAAAAA", conditions); err != nil || g != 0 {
+		t.Errorf("expected no hairpin, got %g (%v)", g, err)
+	}
+
+	if g, err := SelfDimerDeltaG("GCGAATTCGC", conditions); err != nil {
+		t.Fatal(err)
+	} else if g >= -5 {
+		t.Errorf("expected stable self-dimer of palindrome, got %g", g)
+	}
+	if g, err := SelfDimerDeltaG("AAAAAAAAAAAAAAAAAAAA", conditions); err != nil || g != 0 {
+		t.Errorf("expected no self-dimer, got %g (%v)", g, err)
+	}
+
+	short, err := DimerDeltaG("CCCCAAAAAGGCC", "TTTTTGCTA", conditions)
+	if err != nil {
+		t.Fatal(err)
+	}
+	long, err := DimerDeltaG("CCCCAAAAAGGCC", "GGCCTTTTTGCTA", conditions)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if long >= short || short >= 0 {
+		t.Errorf("expected longer complementary duplex to be more stable, got %g and %g", long, short)
+	}
+}
+
+func TestPrimerConstraints(t *testing.T) {
+	seq := oligotests[0].sequence
+	constraints := DefaultPrimerConstraints()
+
+	primer, err := FWDOligoSeq(seq, constraints)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tm, err := NearestNeighbourMeltingTemp(primer.DNASequence, constraints.Conditions)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if primer.MeltingTemp.SIValue() != tm.SIValue() || tm.SIValue() <= 55 || tm.SIValue() >= 65 {
+		t.Errorf("expected nearest neighbour melting temperature between 55 and 65 ℃, got %s", primer.MeltingTemp.ToString())
+	}
+
+	// a hairpin at the start of the sequence must be avoided
+	hairpin := wtype.MakeLinearDNASequence("hairpin", "GCGCGCGCGGAAACCGCGCGCGC"+seq.Sequence())
+	if primer, err := FWDOligoSeq(hairpin, constraints); err != nil {
+		t.Fatal(err)
+	} else if g, _ := HairpinDeltaG(primer.Sequence(), constraints.Conditions); g < constraints.MinHairpinDeltaG {
+		t.Errorf("primer %s forms hairpin of %g kcal/mol", primer.Sequence(), g)
+	}
+
+	primers, err := DesignPrimerstoFlankRegion(seq, 300, 400, constraints)
+	if err != nil {
+		t.Fatal(err)
+	}
+	region := seq.Sequence()[299:400]
+	fwd := strings.Index(seq.Sequence(), primers[0].Sequence())
+	rev := strings.Index(seq.Sequence(), wtype.RevComp(primers[1].Sequence()))
+	if fwd < 0 || rev < 0 || fwd >= 299 || rev < 400 || !primers[1].Reverse {
+		t.Errorf("expected primers %s and %s to flank %s", primers[0].Sequence(), primers[1].Sequence(), region)
+	}
+	if g, _ := DimerDeltaG(primers[0].Sequence(), primers[1].Sequence(), constraints.Conditions); g < constraints.MinHeteroDimerDeltaG {
+		t.Errorf("primers form hetero-dimer of %g kcal/mol", g)
+	}
+
+	if _, err := DesignPrimerstoFlankRegion(seq, 10, 400, constraints); err == nil {
+		t.Error("expected error for region too close to the start of the sequence")
+	}
+}
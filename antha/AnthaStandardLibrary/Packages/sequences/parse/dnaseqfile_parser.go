@@ -30,10 +30,15 @@ import (
 	"github.com/antha-lang/antha/antha/AnthaStandardLibrary/Packages/sequences/parse/fasta"
 	"github.com/antha-lang/antha/antha/AnthaStandardLibrary/Packages/sequences/parse/gdx"
 	"github.com/antha-lang/antha/antha/AnthaStandardLibrary/Packages/sequences/parse/genbank"
+	"github.com/antha-lang/antha/antha/AnthaStandardLibrary/Packages/sequences/parse/sbol"
+	"github.com/antha-lang/antha/antha/AnthaStandardLibrary/Packages/sequences/parse/snapgene"
+	"github.com/antha-lang/antha/antha/AnthaStandardLibrary/Packages/sequences/parse/trace"
 	"github.com/antha-lang/antha/antha/anthalib/wtype"
 )
 
-// Creates a DNASequence from a sequence file of format: .gdx .fasta .gb
+// Creates a DNASequence from a sequence file of format: .gdx .fasta .gb .dna (SnapGene) .sbol
+// Sequencing chromatograms in .ab1 or .scf format are quality trimmed; use
+// ChromatogramToTrace to keep the quality of each base call.
 func DNAFileToDNASequence(sequenceFile wtype.File) (sequences []wtype.DNASequence, err error) {
 
 	sequences = make([]wtype.DNASequence, 0)
@@ -50,6 +55,16 @@ func DNAFileToDNASequence(sequenceFile wtype.File) (sequences []wtype.DNASequenc
 	case filepath.Ext(fn) == ".gb" || filepath.Ext(fn) == ".gbk":
 		seq, err = genbank.GenbankToFeaturelessDNASequence(sequenceFile)
 		sequences = append(sequences, seq)
+	case filepath.Ext(fn) == ".dna":
+		seq, err = snapgene.SnapGeneToDNASequence(sequenceFile)
+		sequences = append(sequences, seq)
+	case filepath.Ext(fn) == ".sbol" || filepath.Ext(fn) == ".rdf" || filepath.Ext(fn) == ".xml":
+		seqs, err = sbol.SBOLToDNASequences(sequenceFile)
+		sequences = append(sequences, seqs...)
+	case filepath.Ext(fn) == ".ab1" || filepath.Ext(fn) == ".abi" || filepath.Ext(fn) == ".scf":
+		var read trace.Trace
+		read, err = ChromatogramToTrace(sequenceFile)
+		sequences = append(sequences, read.DNASequence)
 	default:
 		err = fmt.Errorf("non valid sequence file format: %s", filepath.Ext(fn))
 	}
@@ -59,3 +74,15 @@ func DNAFileToDNASequence(sequenceFile wtype.File) (sequences []wtype.DNASequenc
 	}
 	return
 }
+
+// ChromatogramToTrace reads a sequencing chromatogram in .ab1 or .scf format,
+// returning the base calls of the read with their qualities, peaks and
+// channels. Base calls are quality trimmed with trace.DefaultTrimCutoff unless
+// the chromatogram has no qualities, in which case every call is returned.
+func ChromatogramToTrace(sequenceFile wtype.File) (trace.Trace, error) {
+	read, err := trace.ReadTrace(sequenceFile)
+	if err != nil {
+		return trace.Trace{}, err
+	}
+	return read.Trim(trace.DefaultTrimCutoff), nil
+}
@@ -0,0 +1,526 @@
+// Part of the Antha language
+// Copyright (C) 2018 The Antha authors. All rights reserved.
+//
+// This program is free software; you can redistribute it and/or
+// modify it under the terms of the GNU General Public License
+// as published by the Free Software Foundation; either version 2
+// of the License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program; if not, write to the Free Software
+// Foundation, Inc., 51 Franklin Street, Fifth Floor, Boston, MA  02110-1301, USA.
+//
+// For more information relating to the software or licensing issues please
+// contact license@antha-lang.org or write to the Antha team c/o
+// Synthace Ltd. The London Bioscience Innovation Centre
+// 2 Royal College St, London NW1 0NH UK
+
+// Package sbol converts between documents in the Synthetic Biology Open
+// Language (SBOL versions 2 and 3, serialised as RDF/XML) and DNA sequences.
+package sbol
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"strings"
+	"text/template"
+	"unicode"
+
+	"github.com/antha-lang/antha/antha/AnthaStandardLibrary/Packages/sequences"
+	"github.com/antha-lang/antha/antha/anthalib/wtype"
+)
+
+// DefaultNamespace is the namespace of the URIs of exported sequences if none
+// is given.
+const DefaultNamespace = "http://antha-lang.org"
+
+const (
+	// circular and linear topologies, from the Sequence Ontology
+	circularTerm = "SO:0000988"
+	linearTerm   = "SO:0000987"
+
+	// role of exported sequences
+	engineeredRegionTerm = "SO:0000804"
+
+	// role of features of unknown class
+	regionTerm = "SO:0000001"
+)
+
+// soTerms maps feature classes to their Sequence Ontology terms.
+var soTerms = map[string]string{
+	wtype.CDS:           "SO:0000316",
+	wtype.GENE:          "SO:0000704",
+	wtype.ORF:           "SO:0000236",
+	wtype.PROMOTER:      "SO:0000167",
+	wtype.TRNA:          "SO:0000253",
+	wtype.RRNA:          "SO:0000252",
+	wtype.NCRNA:         "SO:0000655",
+	wtype.REGULATORY:    "SO:0005836",
+	wtype.REPEAT_REGION: "SO:0000657",
+	wtype.MISC_FEATURE:  regionTerm,
+	"terminator":        "SO:0000141",
+	"RBS":               "SO:0000139",
+	"rep_origin":        "SO:0000296",
+	"primer_bind":       "SO:0005850",
+}
+
+// class returns the feature class of the Sequence Ontology term ending the
+// URIs given.
+func class(roles []resource) string {
+	for _, role := range roles {
+		for class, term := range soTerms {
+			if term != regionTerm && hasTerm(role.Resource, term) {
+				return class
+			}
+		}
+	}
+	return wtype.MISC_FEATURE
+}
+
+// hasTerm returns whether uri identifies the ontology term, allowing for
+// the different forms of identifiers.org URI in use.
+func hasTerm(uri, term string) bool {
+	return strings.HasSuffix(uri, "/"+term) || strings.HasSuffix(uri, ":"+strings.Replace(term, ":", "_", 1))
+}
+
+type resource struct {
+	Resource string `xml:"http://www.w3.org/1999/02/22-rdf-syntax-ns# resource,attr"`
+}
+
+type sbolRange struct {
+	Start       int      `xml:"start"`
+	End         int      `xml:"end"`
+	Orientation resource `xml:"orientation"`
+}
+
+// reverse returns whether the orientation of the range is the reverse
+// complement of the sequence, in any version of SBOL.
+func (r sbolRange) reverse() bool {
+	return strings.HasSuffix(r.Orientation.Resource, "#reverseComplement") || hasTerm(r.Orientation.Resource, "SO:0001031")
+}
+
+type sbolLocation struct {
+	Range *sbolRange `xml:"Range"`
+}
+
+type sbolSequence struct {
+	About     string `xml:"http://www.w3.org/1999/02/22-rdf-syntax-ns# about,attr"`
+	DisplayID string `xml:"displayId"`
+	Elements  string `xml:"elements"`
+}
+
+type sbol2Annotation struct {
+	DisplayID string         `xml:"http://sbols.org/v2# displayId"`
+	Title     string         `xml:"http://purl.org/dc/terms/ title"`
+	Locations []sbolLocation `xml:"http://sbols.org/v2# location"`
+	Roles     []resource     `xml:"http://sbols.org/v2# role"`
+}
+
+type sbol2Component struct {
+	About       string     `xml:"http://www.w3.org/1999/02/22-rdf-syntax-ns# about,attr"`
+	DisplayID   string     `xml:"http://sbols.org/v2# displayId"`
+	Title       string     `xml:"http://purl.org/dc/terms/ title"`
+	Types       []resource `xml:"http://sbols.org/v2# type"`
+	Sequences   []resource `xml:"http://sbols.org/v2# sequence"`
+	Annotations []struct {
+		Annotation sbol2Annotation `xml:"http://sbols.org/v2# SequenceAnnotation"`
+	} `xml:"http://sbols.org/v2# sequenceAnnotation"`
+}
+
+type sbol3Feature struct {
+	DisplayID string         `xml:"http://sbols.org/v3# displayId"`
+	Name      string         `xml:"http://sbols.org/v3# name"`
+	Locations []sbolLocation `xml:"http://sbols.org/v3# hasLocation"`
+	Roles     []resource     `xml:"http://sbols.org/v3# role"`
+}
+
+type sbol3Component struct {
+	About     string     `xml:"http://www.w3.org/1999/02/22-rdf-syntax-ns# about,attr"`
+	DisplayID string     `xml:"http://sbols.org/v3# displayId"`
+	Name      string     `xml:"http://sbols.org/v3# name"`
+	Types     []resource `xml:"http://sbols.org/v3# type"`
+	Sequences []resource `xml:"http://sbols.org/v3# hasSequence"`
+	Features  []struct {
+		Feature *sbol3Feature `xml:"http://sbols.org/v3# SequenceFeature"`
+	} `xml:"http://sbols.org/v3# hasFeature"`
+}
+
+type sbolDocument struct {
+	Components2 []sbol2Component `xml:"http://sbols.org/v2# ComponentDefinition"`
+	Sequences2  []sbolSequence   `xml:"http://sbols.org/v2# Sequence"`
+	Components3 []sbol3Component `xml:"http://sbols.org/v3# Component"`
+	Sequences3  []sbolSequence   `xml:"http://sbols.org/v3# Sequence"`
+}
+
+// component is the version independent content of an SBOL component.
+type component struct {
+	name     string
+	types    []resource
+	sequence []resource
+	features []feature
+}
+
+type feature struct {
+	name      string
+	roles     []resource
+	locations []sbolLocation
+}
+
+// isDNA returns whether the component is a DNA region.
+func (c component) isDNA() bool {
+	for _, t := range c.types {
+		if strings.HasSuffix(t.Resource, "#DnaRegion") || hasTerm(t.Resource, "SBO:0000251") {
+			return true
+		}
+	}
+	return false
+}
+
+func (c component) isCircular() bool {
+	for _, t := range c.types {
+		if hasTerm(t.Resource, circularTerm) {
+			return true
+		}
+	}
+	return false
+}
+
+// SBOLToDNASequences parses the DNA components of an SBOL document into
+// annotated DNA sequences.
+func SBOLToDNASequences(file wtype.File) ([]wtype.DNASequence, error) {
+	data, err := file.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	return ParseSBOL(data)
+}
+
+// ParseSBOL parses the DNA components of an SBOL document, of version 2 or 3,
+// into annotated DNA sequences. Features located by several ranges span from
+// the start of the first range to the end of the last.
+func ParseSBOL(data []byte) ([]wtype.DNASequence, error) {
+	var doc sbolDocument
+	if err := xml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("parsing SBOL: %s", err.Error())
+	}
+
+	elements := make(map[string]string)
+	for _, s := range append(doc.Sequences2, doc.Sequences3...) {
+		elements[s.About] = s.Elements
+	}
+
+	var components []component
+	for _, c := range doc.Components2 {
+		comp := component{name: firstNonEmpty(c.Title, c.DisplayID, c.About), types: c.Types, sequence: c.Sequences}
+		for _, a := range c.Annotations {
+			comp.features = append(comp.features, feature{
+				name:      firstNonEmpty(a.Annotation.Title, a.Annotation.DisplayID),
+				roles:     a.Annotation.Roles,
+				locations: a.Annotation.Locations,
+			})
+		}
+		components = append(components, comp)
+	}
+	for _, c := range doc.Components3 {
+		comp := component{name: firstNonEmpty(c.Name, c.DisplayID, c.About), types: c.Types, sequence: c.Sequences}
+		for _, f := range c.Features {
+			if f.Feature == nil {
+				continue
+			}
+			comp.features = append(comp.features, feature{
+				name:      firstNonEmpty(f.Feature.Name, f.Feature.DisplayID),
+				roles:     f.Feature.Roles,
+				locations: f.Feature.Locations,
+			})
+		}
+		components = append(components, comp)
+	}
+
+	var seqs []wtype.DNASequence
+	for _, c := range components {
+		if !c.isDNA() {
+			continue
+		}
+		if len(c.sequence) == 0 {
+			return seqs, fmt.Errorf("no sequence found for %s", c.name)
+		}
+		s, found := elements[c.sequence[0].Resource]
+		if !found {
+			return seqs, fmt.Errorf("sequence %s of %s not found", c.sequence[0].Resource, c.name)
+		}
+		seq := wtype.DNASequence{Nm: c.name, Seq: strings.ToUpper(strings.TrimSpace(s)), Plasmid: c.isCircular()}
+		for _, f := range c.features {
+			feat, ok, err := makeFeature(seq, f)
+			if err != nil {
+				return seqs, fmt.Errorf("parsing %s: %s", c.name, err.Error())
+			}
+			if ok {
+				seq.Features = append(seq.Features, feat)
+			}
+		}
+		seqs = append(seqs, seq)
+	}
+	return seqs, nil
+}
+
+// makeFeature converts an SBOL feature into a feature of seq, returning false
+// if the feature has no range.
+func makeFeature(seq wtype.DNASequence, f feature) (wtype.Feature, bool, error) {
+	var ranges []*sbolRange
+	for _, l := range f.locations {
+		if l.Range != nil {
+			ranges = append(ranges, l.Range)
+		}
+	}
+	if len(ranges) == 0 {
+		return wtype.Feature{}, false, nil
+	}
+
+	first, last := ranges[0], ranges[len(ranges)-1]
+	start, end := first.Start, last.End
+	if start < 1 || end < 1 || start > len(seq.Seq) || end > len(seq.Seq) || (start > end && !seq.Plasmid) {
+		return wtype.Feature{}, false, fmt.Errorf("feature %s at %d..%d outside of sequence of length %d", f.name, start, end, len(seq.Seq))
+	}
+
+	var region string
+	if start > end {
+		region = seq.Seq[start-1:] + seq.Seq[:end]
+	} else {
+		region = seq.Seq[start-1 : end]
+	}
+	rev := ""
+	if first.reverse() {
+		rev = "Reverse"
+	}
+	return sequences.MakeFeature(f.name, region, start, end, "dna", class(f.roles), rev), true, nil
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// exportComponent is the data used to write a sequence into an SBOL
+// document.
+type exportComponent struct {
+	URI         string
+	ID          string
+	Name        string
+	Circular    bool
+	SequenceURI string
+	SequenceID  string
+	Elements    string
+	Features    []exportFeature
+}
+
+type exportFeature struct {
+	URI    string
+	ID     string
+	Name   string
+	Role   string
+	Ranges []exportRange
+}
+
+type exportRange struct {
+	URI     string
+	ID      string
+	Start   int
+	End     int
+	Reverse bool
+}
+
+// displayID returns a valid SBOL identifier for name.
+func displayID(name string) string {
+	id := strings.Map(func(r rune) rune {
+		if r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_') {
+			return r
+		}
+		return '_'
+	}, name)
+	if id == "" || unicode.IsDigit(rune(id[0])) {
+		id = "_" + id
+	}
+	return id
+}
+
+func makeExportComponents(seqs []wtype.DNASequence, namespace string) []exportComponent {
+	ids := make(map[string]int)
+	var components []exportComponent
+	for _, seq := range seqs {
+		id := displayID(seq.Nm)
+		if ids[id]++; ids[id] > 1 {
+			id = fmt.Sprintf("%s_%d", id, ids[id])
+		}
+		c := exportComponent{
+			URI:         namespace + "/" + id,
+			ID:          id,
+			Name:        seq.Nm,
+			Circular:    seq.Plasmid,
+			SequenceURI: namespace + "/" + id + "_sequence",
+			SequenceID:  id + "_sequence",
+			Elements:    strings.ToLower(seq.Seq),
+		}
+		for i, f := range seq.Features {
+			fid := fmt.Sprintf("feature%d", i+1)
+			feat := exportFeature{URI: c.URI + "/" + fid, ID: fid, Name: f.Name, Role: regionTerm}
+			if term, found := soTerms[f.Class]; found {
+				feat.Role = term
+			}
+
+			start, end := f.Coordinates(wtype.IGNOREDIRECTION)
+			ranges := [][2]int{{start, end}}
+			if seq.Plasmid && f.StartPosition > f.EndPosition && !f.Reverse {
+				// forward features spanning the origin
+				ranges = [][2]int{{f.StartPosition, len(seq.Seq)}, {1, f.EndPosition}}
+			}
+			for j, r := range ranges {
+				rid := fmt.Sprintf("range%d", j+1)
+				feat.Ranges = append(feat.Ranges, exportRange{URI: feat.URI + "/" + rid, ID: rid, Start: r[0], End: r[1], Reverse: f.Reverse})
+			}
+			c.Features = append(c.Features, feat)
+		}
+		components = append(components, c)
+	}
+	return components
+}
+
+// sbol3Data is the data used to write an SBOL3 document, in which every
+// object records its namespace.
+type sbol3Data struct {
+	Components []exportComponent
+	Namespace  string
+}
+
+var funcs = template.FuncMap{
+	"xml": func(s string) (string, error) {
+		var buf bytes.Buffer
+		err := xml.EscapeText(&buf, []byte(s))
+		return buf.String(), err
+	},
+}
+
+var sbol2Template = template.Must(template.New("sbol2").Funcs(funcs).Parse(`<?xml version="1.0" encoding="UTF-8"?>
+<rdf:RDF xmlns:rdf="http://www.w3.org/1999/02/22-rdf-syntax-ns#" xmlns:dcterms="http://purl.org/dc/terms/" xmlns:prov="http://www.w3.org/ns/prov#" xmlns:sbol="http://sbols.org/v2#">
+{{- range . }}
+  <sbol:ComponentDefinition rdf:about="{{ xml .URI }}/1">
+    <sbol:persistentIdentity rdf:resource="{{ xml .URI }}"/>
+    <sbol:displayId>{{ .ID }}</sbol:displayId>
+    <sbol:version>1</sbol:version>
+    <dcterms:title>{{ xml .Name }}</dcterms:title>
+    <sbol:type rdf:resource="http://www.biopax.org/release/biopax-level3.owl#DnaRegion"/>
+    <sbol:type rdf:resource="http://identifiers.org/so/{{ if .Circular }}` + circularTerm + `{{ else }}` + linearTerm + `{{ end }}"/>
+    <sbol:role rdf:resource="http://identifiers.org/so/` + engineeredRegionTerm + `"/>
+    <sbol:sequence rdf:resource="{{ xml .SequenceURI }}/1"/>
+    {{- range .Features }}
+    <sbol:sequenceAnnotation>
+      <sbol:SequenceAnnotation rdf:about="{{ xml .URI }}/1">
+        <sbol:persistentIdentity rdf:resource="{{ xml .URI }}"/>
+        <sbol:displayId>{{ .ID }}</sbol:displayId>
+        <sbol:version>1</sbol:version>
+        <dcterms:title>{{ xml .Name }}</dcterms:title>
+        {{- range .Ranges }}
+        <sbol:location>
+          <sbol:Range rdf:about="{{ xml .URI }}/1">
+            <sbol:persistentIdentity rdf:resource="{{ xml .URI }}"/>
+            <sbol:displayId>{{ .ID }}</sbol:displayId>
+            <sbol:version>1</sbol:version>
+            <sbol:start>{{ .Start }}</sbol:start>
+            <sbol:end>{{ .End }}</sbol:end>
+            <sbol:orientation rdf:resource="http://sbols.org/v2#{{ if .Reverse }}reverseComplement{{ else }}inline{{ end }}"/>
+          </sbol:Range>
+        </sbol:location>
+        {{- end }}
+        <sbol:role rdf:resource="http://identifiers.org/so/{{ .Role }}"/>
+      </sbol:SequenceAnnotation>
+    </sbol:sequenceAnnotation>
+    {{- end }}
+  </sbol:ComponentDefinition>
+  <sbol:Sequence rdf:about="{{ xml .SequenceURI }}/1">
+    <sbol:persistentIdentity rdf:resource="{{ xml .SequenceURI }}"/>
+    <sbol:displayId>{{ .SequenceID }}</sbol:displayId>
+    <sbol:version>1</sbol:version>
+    <sbol:elements>{{ .Elements }}</sbol:elements>
+    <sbol:encoding rdf:resource="http://www.chem.qmul.ac.uk/iubmb/misc/naseq.html"/>
+  </sbol:Sequence>
+{{- end }}
+</rdf:RDF>
+`))
+
+var sbol3Template = template.Must(template.New("sbol3").Funcs(funcs).Parse(`<?xml version="1.0" encoding="UTF-8"?>
+<rdf:RDF xmlns:rdf="http://www.w3.org/1999/02/22-rdf-syntax-ns#" xmlns:prov="http://www.w3.org/ns/prov#" xmlns:sbol="http://sbols.org/v3#">
+{{- range .Components }}
+  <sbol:Component rdf:about="{{ xml .URI }}">
+    <sbol:hasNamespace rdf:resource="{{ xml $.Namespace }}"/>
+    <sbol:displayId>{{ .ID }}</sbol:displayId>
+    <sbol:name>{{ xml .Name }}</sbol:name>
+    <sbol:type rdf:resource="https://identifiers.org/SBO:0000251"/>
+    <sbol:type rdf:resource="https://identifiers.org/{{ if .Circular }}` + circularTerm + `{{ else }}` + linearTerm + `{{ end }}"/>
+    <sbol:role rdf:resource="https://identifiers.org/` + engineeredRegionTerm + `"/>
+    <sbol:hasSequence rdf:resource="{{ xml .SequenceURI }}"/>
+    {{- $seq := .SequenceURI }}
+    {{- range .Features }}
+    <sbol:hasFeature>
+      <sbol:SequenceFeature rdf:about="{{ xml .URI }}">
+        <sbol:displayId>{{ .ID }}</sbol:displayId>
+        <sbol:name>{{ xml .Name }}</sbol:name>
+        <sbol:role rdf:resource="https://identifiers.org/{{ .Role }}"/>
+        {{- range .Ranges }}
+        <sbol:hasLocation>
+          <sbol:Range rdf:about="{{ xml .URI }}">
+            <sbol:displayId>{{ .ID }}</sbol:displayId>
+            <sbol:start>{{ .Start }}</sbol:start>
+            <sbol:end>{{ .End }}</sbol:end>
+            <sbol:orientation rdf:resource="https://identifiers.org/{{ if .Reverse }}SO:0001031{{ else }}SO:0001030{{ end }}"/>
+            <sbol:hasSequence rdf:resource="{{ xml $seq }}"/>
+          </sbol:Range>
+        </sbol:hasLocation>
+        {{- end }}
+      </sbol:SequenceFeature>
+    </sbol:hasFeature>
+    {{- end }}
+  </sbol:Component>
+  <sbol:Sequence rdf:about="{{ xml .SequenceURI }}">
+    <sbol:hasNamespace rdf:resource="{{ xml $.Namespace }}"/>
+    <sbol:displayId>{{ .SequenceID }}</sbol:displayId>
+    <sbol:elements>{{ .Elements }}</sbol:elements>
+    <sbol:encoding rdf:resource="https://identifiers.org/edam:format_1207"/>
+  </sbol:Sequence>
+{{- end }}
+</rdf:RDF>
+`))
+
+// DNASequencesToSBOL returns an SBOL document of the specified version (2 or
+// 3) describing the sequences, their topology and features. URIs are created
+// within namespace, or DefaultNamespace if it is empty.
+func DNASequencesToSBOL(seqs []wtype.DNASequence, version int, namespace string) ([]byte, error) {
+	if namespace == "" {
+		namespace = DefaultNamespace
+	}
+	namespace = strings.TrimSuffix(namespace, "/")
+	components := makeExportComponents(seqs, namespace)
+
+	var buf bytes.Buffer
+	var err error
+	switch version {
+	case 2:
+		err = sbol2Template.Execute(&buf, components)
+	case 3:
+		err = sbol3Template.Execute(&buf, sbol3Data{components, namespace})
+	default:
+		return nil, fmt.Errorf("unsupported SBOL version %d", version)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
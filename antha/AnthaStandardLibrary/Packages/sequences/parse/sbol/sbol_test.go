@@ -0,0 +1,124 @@
+package sbol
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/antha-lang/antha/antha/anthalib/wtype"
+)
+
+func testSequences() []wtype.DNASequence {
+	plasmid := wtype.MakePlasmidDNASequence("pTest <1>", "ATGAAATTTGGGCCCTAAGGATCCTTAGGGCCCAAATTTCATGCATGCAATT")
+	plasmid.Features = []wtype.Feature{
+		{Name: "orf", Class: wtype.CDS, StartPosition: 1, EndPosition: 18},
+		{Name: "reverse orf", Class: wtype.CDS, StartPosition: 25, EndPosition: 42, Reverse: true},
+		{Name: "origin", Class: "rep_origin", StartPosition: 49, EndPosition: 3},
+		{Name: "other", Class: "unknown", StartPosition: 19, EndPosition: 24},
+	}
+	linear := wtype.MakeLinearDNASequence("pTest <1>", "GGATCCAAATTT")
+	linear.Features = []wtype.Feature{
+		{Name: "promoter", Class: wtype.PROMOTER, StartPosition: 1, EndPosition: 6},
+	}
+	return []wtype.DNASequence{plasmid, linear}
+}
+
+func TestSBOLRoundTrip(t *testing.T) {
+	seqs := testSequences()
+	for _, version := range []int{2, 3} {
+		data, err := DNASequencesToSBOL(seqs, version, "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(string(data), "<sbol:displayId>pTest__1__2</sbol:displayId>") {
+			t.Errorf("version %d: expected unique display identifiers in %s", version, data)
+		}
+
+		parsed, err := ParseSBOL(data)
+		if err != nil {
+			t.Fatalf("version %d: %s", version, err)
+		}
+		if len(parsed) != len(seqs) {
+			t.Fatalf("version %d: expected %d sequences, got %d", version, len(seqs), len(parsed))
+		}
+		for i, seq := range seqs {
+			p := parsed[i]
+			if p.Nm != seq.Nm || p.Seq != seq.Seq || p.Plasmid != seq.Plasmid {
+				t.Errorf("version %d: expected %s %s (plasmid %t), got %s %s (plasmid %t)", version, seq.Nm, seq.Seq, seq.Plasmid, p.Nm, p.Seq, p.Plasmid)
+			}
+			if len(p.Features) != len(seq.Features) {
+				t.Fatalf("version %d: expected %d features of %s, got %d", version, len(seq.Features), seq.Nm, len(p.Features))
+			}
+			for j, f := range seq.Features {
+				class := f.Class
+				if class == "unknown" {
+					class = wtype.MISC_FEATURE
+				}
+				pf := p.Features[j]
+				if pf.Name != f.Name || pf.Class != class || pf.StartPosition != f.StartPosition || pf.EndPosition != f.EndPosition || pf.Reverse != f.Reverse {
+					t.Errorf("version %d: expected feature %+v, got %+v", version, f, pf)
+				}
+			}
+		}
+		if parsed[0].Features[2].DNASeq != "AATTATG" {
+			t.Errorf("version %d: expected feature spanning origin, got %s", version, parsed[0].Features[2].DNASeq)
+		}
+	}
+
+	if _, err := DNASequencesToSBOL(seqs, 1, ""); err == nil {
+		t.Error("expected error for unsupported version")
+	}
+}
+
+func TestParseSBOL(t *testing.T) {
+	// SBOL2 as written by other tools, with a protein component which should
+	// be ignored
+	doc := `<?xml version="1.0" ?>
+<rdf:RDF xmlns:rdf="http://www.w3.org/1999/02/22-rdf-syntax-ns#" xmlns:dcterms="http://purl.org/dc/terms/" xmlns:sbol="http://sbols.org/v2#">
+  <sbol:ComponentDefinition rdf:about="http://example.com/BBa_J23100/1">
+    <sbol:displayId>BBa_J23100</sbol:displayId>
+    <sbol:type rdf:resource="http://www.biopax.org/release/biopax-level3.owl#DnaRegion"/>
+    <sbol:sequence rdf:resource="http://example.com/BBa_J23100_sequence/1"/>
+    <sbol:sequenceAnnotation>
+      <sbol:SequenceAnnotation rdf:about="http://example.com/BBa_J23100/anno/1">
+        <sbol:displayId>anno</sbol:displayId>
+        <sbol:location>
+          <sbol:Range rdf:about="http://example.com/BBa_J23100/anno/range/1">
+            <sbol:start>1</sbol:start>
+            <sbol:end>35</sbol:end>
+          </sbol:Range>
+        </sbol:location>
+        <sbol:role rdf:resource="http://identifiers.org/so/SO:0000167"/>
+      </sbol:SequenceAnnotation>
+    </sbol:sequenceAnnotation>
+  </sbol:ComponentDefinition>
+  <sbol:ComponentDefinition rdf:about="http://example.com/TetR/1">
+    <sbol:displayId>TetR</sbol:displayId>
+    <sbol:type rdf:resource="http://www.biopax.org/release/biopax-level3.owl#Protein"/>
+  </sbol:ComponentDefinition>
+  <sbol:Sequence rdf:about="http://example.com/BBa_J23100_sequence/1">
+    <sbol:elements>ttgacggctagctcagtcctaggtacagtgctagc</sbol:elements>
+  </sbol:Sequence>
+</rdf:RDF>`
+
+	seqs, err := ParseSBOL([]byte(doc))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(seqs) != 1 {
+		t.Fatalf("expected 1 sequence, got %d", len(seqs))
+	}
+	seq := seqs[0]
+	if seq.Nm != "BBa_J23100" || seq.Seq != "TTGACGGCTAGCTCAGTCCTAGGTACAGTGCTAGC" || seq.Plasmid {
+		t.Errorf("unexpected sequence %+v", seq)
+	}
+	if len(seq.Features) != 1 || seq.Features[0].Class != wtype.PROMOTER || seq.Features[0].Name != "anno" {
+		t.Errorf("unexpected features %+v", seq.Features)
+	}
+
+	if _, err := ParseSBOL([]byte(strings.Replace(doc, "<sbol:end>35", "<sbol:end>36", 1))); err == nil {
+		t.Error("expected error for feature beyond end of sequence")
+	}
+	if _, err := ParseSBOL([]byte(strings.Replace(doc, "BBa_J23100_sequence/1\">", "other/1\">", 1))); err == nil {
+		t.Error("expected error for missing sequence")
+	}
+}
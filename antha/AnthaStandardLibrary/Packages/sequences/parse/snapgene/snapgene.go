@@ -0,0 +1,252 @@
+// Part of the Antha language
+// Copyright (C) 2018 The Antha authors. All rights reserved.
+//
+// This program is free software; you can redistribute it and/or
+// modify it under the terms of the GNU General Public License
+// as published by the Free Software Foundation; either version 2
+// of the License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program; if not, write to the Free Software
+// Foundation, Inc., 51 Franklin Street, Fifth Floor, Boston, MA  02110-1301, USA.
+//
+// For more information relating to the software or licensing issues please
+// contact license@antha-lang.org or write to the Antha team c/o
+// Synthace Ltd. The London Bioscience Innovation Centre
+// 2 Royal College St, London NW1 0NH UK
+
+// Package snapgene converts between DNA sequence files in SnapGene (.dna)
+// format and DNA sequences.
+package snapgene
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/xml"
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/antha-lang/antha/antha/AnthaStandardLibrary/Packages/sequences"
+	"github.com/antha-lang/antha/antha/anthalib/wtype"
+)
+
+// A SnapGene file is a series of segments, each a byte giving its type and a
+// 32 bit length followed by its contents.
+const (
+	dnaSegment      = 0
+	cookieSegment   = 9
+	featuresSegment = 10
+)
+
+const (
+	cookie = "SnapGene"
+
+	// the sequence flags of the DNA segment
+	circularFlag       = 0x01
+	doubleStrandedFlag = 0x02
+
+	// the file format versions written
+	exportVersion = 11
+	importVersion = 10
+)
+
+// directionality of features
+const (
+	forward = 1
+	reverse = 2
+)
+
+type snapgeneFeatures struct {
+	XMLName     xml.Name          `xml:"Features"`
+	NextValidID int               `xml:"nextValidID,attr"`
+	Features    []snapgeneFeature `xml:"Feature"`
+}
+
+type snapgeneFeature struct {
+	RecentID       int               `xml:"recentID,attr"`
+	Name           string            `xml:"name,attr"`
+	Type           string            `xml:"type,attr"`
+	Directionality int               `xml:"directionality,attr,omitempty"`
+	Segments       []snapgeneSegment `xml:"Segment"`
+}
+
+type snapgeneSegment struct {
+	Range string `xml:"range,attr"`
+	Type  string `xml:"type,attr,omitempty"`
+}
+
+// SnapGeneToDNASequence parses a file in SnapGene format into an annotated
+// DNA sequence. SnapGene files do not store the name of the sequence so the
+// name of the file, without its extension, is used.
+func SnapGeneToDNASequence(file wtype.File) (wtype.DNASequence, error) {
+	data, err := file.ReadAll()
+	if err != nil {
+		return wtype.DNASequence{}, err
+	}
+	name := strings.TrimSuffix(filepath.Base(file.Name), filepath.Ext(file.Name))
+	return ParseSnapGene(name, data)
+}
+
+// ParseSnapGene parses the contents of a SnapGene file into an annotated DNA
+// sequence with the name given.
+func ParseSnapGene(name string, data []byte) (seq wtype.DNASequence, err error) {
+	seq.Nm = name
+
+	var foundCookie, foundDNA bool
+	var features snapgeneFeatures
+	for len(data) > 0 {
+		if len(data) < 5 {
+			return seq, fmt.Errorf("parsing %s: truncated segment", name)
+		}
+		segment, length := data[0], int(binary.BigEndian.Uint32(data[1:5]))
+		if 5+length > len(data) {
+			return seq, fmt.Errorf("parsing %s: segment of type %d beyond end of file", name, segment)
+		}
+		contents := data[5 : 5+length]
+		data = data[5+length:]
+
+		switch segment {
+		case cookieSegment:
+			if !bytes.HasPrefix(contents, []byte(cookie)) {
+				return seq, fmt.Errorf("parsing %s: not a SnapGene file", name)
+			}
+			foundCookie = true
+		case dnaSegment:
+			if length == 0 {
+				return seq, fmt.Errorf("parsing %s: empty DNA segment", name)
+			}
+			seq.Plasmid = contents[0]&circularFlag != 0
+			seq.Singlestranded = contents[0]&doubleStrandedFlag == 0
+			seq.Seq = strings.ToUpper(string(contents[1:]))
+			foundDNA = true
+		case featuresSegment:
+			if err := xml.Unmarshal(contents, &features); err != nil {
+				return seq, fmt.Errorf("parsing features of %s: %s", name, err.Error())
+			}
+		}
+	}
+
+	if !foundCookie {
+		return seq, fmt.Errorf("parsing %s: not a SnapGene file", name)
+	} else if !foundDNA {
+		return seq, fmt.Errorf("parsing %s: no DNA sequence found", name)
+	}
+
+	for _, f := range features.Features {
+		feature, err := makeFeature(seq, f)
+		if err != nil {
+			return seq, fmt.Errorf("parsing %s: %s", name, err.Error())
+		}
+		seq.Features = append(seq.Features, feature)
+	}
+	return seq, nil
+}
+
+// makeFeature converts a SnapGene feature, which may consist of several
+// segments, into a feature spanning from the start of its first segment to
+// the end of its last.
+func makeFeature(seq wtype.DNASequence, f snapgeneFeature) (wtype.Feature, error) {
+	if len(f.Segments) == 0 {
+		return wtype.Feature{}, fmt.Errorf("no location found for feature %s", f.Name)
+	}
+	start, _, err := parseRange(f.Segments[0].Range)
+	if err != nil {
+		return wtype.Feature{}, fmt.Errorf("feature %s: %s", f.Name, err.Error())
+	}
+	_, end, err := parseRange(f.Segments[len(f.Segments)-1].Range)
+	if err != nil {
+		return wtype.Feature{}, fmt.Errorf("feature %s: %s", f.Name, err.Error())
+	}
+	if start < 1 || end < 1 || start > len(seq.Seq) || end > len(seq.Seq) || (start > end && !seq.Plasmid) {
+		return wtype.Feature{}, fmt.Errorf("feature %s at %d..%d outside of sequence of length %d", f.Name, start, end, len(seq.Seq))
+	}
+
+	// features may span the origin of plasmids
+	var region string
+	if start > end {
+		region = seq.Seq[start-1:] + seq.Seq[:end]
+	} else {
+		region = seq.Seq[start-1 : end]
+	}
+
+	rev := ""
+	if f.Directionality == reverse {
+		rev = "Reverse"
+	}
+	return sequences.MakeFeature(f.Name, region, start, end, "dna", f.Type, rev), nil
+}
+
+func parseRange(r string) (start, end int, err error) {
+	fields := strings.Split(r, "-")
+	if len(fields) != 2 {
+		return 0, 0, fmt.Errorf("invalid range %q", r)
+	}
+	if start, err = strconv.Atoi(strings.TrimSpace(fields[0])); err != nil {
+		return 0, 0, fmt.Errorf("invalid range %q", r)
+	}
+	if end, err = strconv.Atoi(strings.TrimSpace(fields[1])); err != nil {
+		return 0, 0, fmt.Errorf("invalid range %q", r)
+	}
+	return start, end, nil
+}
+
+// DNASequenceToSnapGene returns the contents of a SnapGene file of the
+// sequence and its features.
+func DNASequenceToSnapGene(seq wtype.DNASequence) ([]byte, error) {
+	var buf bytes.Buffer
+	writeSegment := func(segment byte, contents []byte) {
+		buf.WriteByte(segment)
+		binary.Write(&buf, binary.BigEndian, uint32(len(contents))) // nolint
+		buf.Write(contents)
+	}
+
+	var c bytes.Buffer
+	c.WriteString(cookie)
+	for _, v := range []uint16{1, exportVersion, importVersion} {
+		binary.Write(&c, binary.BigEndian, v) // nolint
+	}
+	writeSegment(cookieSegment, c.Bytes())
+
+	var flags byte
+	if seq.Plasmid {
+		flags |= circularFlag
+	}
+	if !seq.Singlestranded {
+		flags |= doubleStrandedFlag
+	}
+	writeSegment(dnaSegment, append([]byte{flags}, []byte(seq.Seq)...))
+
+	features := snapgeneFeatures{NextValidID: len(seq.Features)}
+	for i, f := range seq.Features {
+		start, end := f.Coordinates(wtype.IGNOREDIRECTION)
+		if seq.Plasmid && f.StartPosition > f.EndPosition && !f.Reverse {
+			// forward features spanning the origin
+			start, end = f.StartPosition, f.EndPosition
+		}
+		directionality := forward
+		if f.Reverse {
+			directionality = reverse
+		}
+		features.Features = append(features.Features, snapgeneFeature{
+			RecentID:       i,
+			Name:           f.Name,
+			Type:           f.Class,
+			Directionality: directionality,
+			Segments:       []snapgeneSegment{{Range: fmt.Sprintf("%d-%d", start, end), Type: "standard"}},
+		})
+	}
+	x, err := xml.Marshal(features)
+	if err != nil {
+		return nil, err
+	}
+	writeSegment(featuresSegment, append([]byte(xml.Header), x...))
+
+	return buf.Bytes(), nil
+}
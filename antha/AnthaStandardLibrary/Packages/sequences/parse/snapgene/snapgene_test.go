@@ -0,0 +1,83 @@
+package snapgene
+
+import (
+	"io/ioutil"
+	"testing"
+
+	"github.com/antha-lang/antha/antha/anthalib/wtype"
+)
+
+func TestSnapGeneToDNASequence(t *testing.T) {
+	data, err := ioutil.ReadFile("../../../Parser/GFP_dash.dna")
+	if err != nil {
+		t.Fatal(err)
+	}
+	file := wtype.File{Name: "../../../Parser/GFP_dash.dna"}
+	if err := file.WriteAll(data); err != nil {
+		t.Fatal(err)
+	}
+	seq, err := SnapGeneToDNASequence(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if seq.Nm != "GFP_dash" || len(seq.Seq) != 891 || seq.Plasmid || seq.Singlestranded {
+		t.Errorf("unexpected sequence %s of length %d, plasmid %t, single stranded %t", seq.Nm, len(seq.Seq), seq.Plasmid, seq.Singlestranded)
+	}
+
+	expected := []struct {
+		name       string
+		start, end int
+	}{
+		{"rrnB T1 terminator", 743, 814},
+		{"T7Te terminator", 830, 857},
+	}
+	if len(seq.Features) != len(expected) {
+		t.Fatalf("expected %d features, got %d", len(expected), len(seq.Features))
+	}
+	for i, e := range expected {
+		f := seq.Features[i]
+		if f.Name != e.name || f.Class != "terminator" || f.StartPosition != e.start || f.EndPosition != e.end || f.Reverse {
+			t.Errorf("expected %s at %d..%d, got %s %s at %d..%d", e.name, e.start, e.end, f.Class, f.Name, f.StartPosition, f.EndPosition)
+		}
+		if f.DNASeq != seq.Seq[e.start-1:e.end] {
+			t.Errorf("%s: sequence of feature does not match sequence", f.Name)
+		}
+	}
+}
+
+func TestSnapGeneRoundTrip(t *testing.T) {
+	seq := wtype.MakePlasmidDNASequence("plasmid", "ATGAAATTTGGGCCCTAAGGATCCTTAGGGCCCAAATTTCATGCATGCAATT")
+	seq.Features = []wtype.Feature{
+		{Name: "orf", Class: wtype.CDS, StartPosition: 1, EndPosition: 18},
+		{Name: "reverse orf", Class: wtype.CDS, StartPosition: 25, EndPosition: 42, Reverse: true},
+		{Name: "origin", Class: wtype.MISC_FEATURE, StartPosition: 49, EndPosition: 3},
+	}
+
+	data, err := DNASequenceToSnapGene(seq)
+	if err != nil {
+		t.Fatal(err)
+	}
+	parsed, err := ParseSnapGene("plasmid", data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if parsed.Seq != seq.Seq || !parsed.Plasmid || parsed.Singlestranded {
+		t.Errorf("expected double stranded plasmid %s, got %+v", seq.Seq, parsed)
+	}
+	if len(parsed.Features) != len(seq.Features) {
+		t.Fatalf("expected %d features, got %d", len(seq.Features), len(parsed.Features))
+	}
+	for i, f := range seq.Features {
+		p := parsed.Features[i]
+		if p.Name != f.Name || p.Class != f.Class || p.StartPosition != f.StartPosition || p.EndPosition != f.EndPosition || p.Reverse != f.Reverse {
+			t.Errorf("expected feature %+v, got %+v", f, p)
+		}
+	}
+	if parsed.Features[1].DNASeq != wtype.RevComp(seq.Seq[24:42]) || parsed.Features[2].DNASeq != "AATTATG" {
+		t.Errorf("unexpected sequences of features %s and %s", parsed.Features[1].DNASeq, parsed.Features[2].DNASeq)
+	}
+
+	if _, err := ParseSnapGene("plasmid", data[5+14:]); err == nil {
+		t.Error("expected error for file without cookie")
+	}
+}
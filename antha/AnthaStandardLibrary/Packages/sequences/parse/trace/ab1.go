@@ -0,0 +1,187 @@
+// Part of the Antha language
+// Copyright (C) 2018 The Antha authors. All rights reserved.
+//
+// This program is free software; you can redistribute it and/or
+// modify it under the terms of the GNU General Public License
+// as published by the Free Software Foundation; either version 2
+// of the License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program; if not, write to the Free Software
+// Foundation, Inc., 51 Franklin Street, Fifth Floor, Boston, MA  02110-1301, USA.
+//
+// For more information relating to the software or licensing issues please
+// contact license@antha-lang.org or write to the Antha team c/o
+// Synthace Ltd. The London Bioscience Innovation Centre
+// 2 Royal College St, London NW1 0NH UK
+
+package trace
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+const (
+	abifMagic = "ABIF"
+
+	// size of an entry in the directory of an ABIF file
+	abifEntrySize = 28
+
+	// offset of the entry describing the directory
+	abifRootOffset = 6
+)
+
+// abifEntry is an entry in the directory of an ABIF file, describing an item
+// of data identified by name and number.
+type abifEntry struct {
+	Name        string
+	Number      int
+	ElementType int
+	ElementSize int
+	NumElements int
+	DataSize    int
+	DataOffset  int
+}
+
+type abifKey struct {
+	Name   string
+	Number int
+}
+
+// abif is the directory of an ABIF file.
+type abif struct {
+	data    []byte
+	entries map[abifKey]abifEntry
+}
+
+func readABIFEntry(data []byte, offset int) (abifEntry, error) {
+	if offset < 0 || offset+abifEntrySize > len(data) {
+		return abifEntry{}, fmt.Errorf("directory entry at %d beyond end of file", offset)
+	}
+	b := data[offset : offset+abifEntrySize]
+	return abifEntry{
+		Name:        string(b[0:4]),
+		Number:      int(int32(binary.BigEndian.Uint32(b[4:8]))),
+		ElementType: int(int16(binary.BigEndian.Uint16(b[8:10]))),
+		ElementSize: int(int16(binary.BigEndian.Uint16(b[10:12]))),
+		NumElements: int(int32(binary.BigEndian.Uint32(b[12:16]))),
+		DataSize:    int(int32(binary.BigEndian.Uint32(b[16:20]))),
+		DataOffset:  int(int32(binary.BigEndian.Uint32(b[20:24]))),
+	}, nil
+}
+
+func readABIF(data []byte) (*abif, error) {
+	if len(data) < abifRootOffset || string(data[:4]) != abifMagic {
+		return nil, fmt.Errorf("not an ABIF file")
+	}
+	root, err := readABIFEntry(data, abifRootOffset)
+	if err != nil {
+		return nil, err
+	}
+
+	a := &abif{data: data, entries: make(map[abifKey]abifEntry)}
+	for i := 0; i < root.NumElements; i++ {
+		entry, err := readABIFEntry(data, root.DataOffset+i*abifEntrySize)
+		if err != nil {
+			return nil, err
+		}
+		a.entries[abifKey{Name: entry.Name, Number: entry.Number}] = entry
+	}
+	return a, nil
+}
+
+// get returns the data of the entry, which is stored in place of its offset
+// if no larger than four bytes.
+func (a *abif) get(name string, number int) ([]byte, bool, error) {
+	entry, ok := a.entries[abifKey{Name: name, Number: number}]
+	if !ok {
+		return nil, false, nil
+	}
+	if entry.DataSize <= 4 {
+		b := make([]byte, 4)
+		binary.BigEndian.PutUint32(b, uint32(entry.DataOffset))
+		return b[:entry.DataSize], true, nil
+	}
+	if entry.DataOffset < 0 || entry.DataOffset+entry.DataSize > len(a.data) {
+		return nil, true, fmt.Errorf("data of %s %d beyond end of file", name, number)
+	}
+	return a.data[entry.DataOffset : entry.DataOffset+entry.DataSize], true, nil
+}
+
+// shorts returns the data of the entry as an array of 16 bit integers.
+func (a *abif) shorts(name string, number int) ([]int, error) {
+	b, _, err := a.get(name, number)
+	if err != nil {
+		return nil, err
+	}
+	values := make([]int, len(b)/2)
+	for i := range values {
+		values[i] = int(int16(binary.BigEndian.Uint16(b[2*i:])))
+	}
+	return values, nil
+}
+
+// ParseAB1 parses the contents of an ABI chromatogram. The edited base calls,
+// qualities and peak locations are used if present, otherwise those of the
+// basecaller. The sample name recorded in the file is used as the name of the
+// trace if there is one.
+func ParseAB1(name string, data []byte) (Trace, error) {
+	a, err := readABIF(data)
+	if err != nil {
+		return Trace{}, fmt.Errorf("parsing %s: %s", name, err.Error())
+	}
+
+	// entry number 2 holds edited data, 1 that of the basecaller
+	number := 2
+	if _, ok := a.entries[abifKey{Name: "PBAS", Number: 2}]; !ok {
+		number = 1
+	}
+
+	bases, found, err := a.get("PBAS", number)
+	if err != nil {
+		return Trace{}, fmt.Errorf("parsing %s: %s", name, err.Error())
+	} else if !found {
+		return Trace{}, fmt.Errorf("parsing %s: no base calls found", name)
+	}
+
+	qualities, _, err := a.get("PCON", number)
+	if err != nil {
+		return Trace{}, fmt.Errorf("parsing %s: %s", name, err.Error())
+	}
+	var phred []int
+	for _, q := range qualities {
+		phred = append(phred, int(int8(q)))
+	}
+
+	peaks, err := a.shorts("PLOC", number)
+	if err != nil {
+		return Trace{}, fmt.Errorf("parsing %s: %s", name, err.Error())
+	}
+
+	// channels 9 to 12 hold the analysed data in the order of the bases in FWO_
+	channels := make(map[string][]int)
+	if order, found, err := a.get("FWO_", 1); err != nil {
+		return Trace{}, fmt.Errorf("parsing %s: %s", name, err.Error())
+	} else if found {
+		for i, base := range order {
+			channel, err := a.shorts("DATA", 9+i)
+			if err != nil {
+				return Trace{}, fmt.Errorf("parsing %s: %s", name, err.Error())
+			}
+			channels[string(base)] = channel
+		}
+	}
+
+	// the sample name is a pascal string
+	if sample, found, err := a.get("SMPL", 1); err == nil && found && len(sample) > 1 && int(sample[0]) < len(sample) {
+		name = string(sample[1 : 1+int(sample[0])])
+	}
+
+	return newTrace(name, string(bases), phred, peaks, channels)
+}
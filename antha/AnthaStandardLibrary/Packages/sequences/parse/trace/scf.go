@@ -0,0 +1,175 @@
+// Part of the Antha language
+// Copyright (C) 2018 The Antha authors. All rights reserved.
+//
+// This program is free software; you can redistribute it and/or
+// modify it under the terms of the GNU General Public License
+// as published by the Free Software Foundation; either version 2
+// of the License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program; if not, write to the Free Software
+// Foundation, Inc., 51 Franklin Street, Fifth Floor, Boston, MA  02110-1301, USA.
+//
+// For more information relating to the software or licensing issues please
+// contact license@antha-lang.org or write to the Antha team c/o
+// Synthace Ltd. The London Bioscience Innovation Centre
+// 2 Royal College St, London NW1 0NH UK
+
+package trace
+
+import (
+	"encoding/binary"
+	"fmt"
+	"strconv"
+)
+
+const (
+	scfMagic      = ".scf"
+	scfHeaderSize = 128
+)
+
+// scfBases is the order of the channels and base probabilities in an SCF file.
+const scfBases = "ACGT"
+
+// scfHeader is the header of an SCF file.
+type scfHeader struct {
+	Samples       int
+	SamplesOffset int
+	Bases         int
+	BasesOffset   int
+	Version       float64
+	SampleSize    int
+}
+
+func readSCFHeader(data []byte) (h scfHeader, err error) {
+	if len(data) < scfHeaderSize || string(data[:4]) != scfMagic {
+		return h, fmt.Errorf("not an SCF file")
+	}
+	uint32At := func(offset int) int {
+		return int(binary.BigEndian.Uint32(data[offset : offset+4]))
+	}
+	h = scfHeader{
+		Samples:       uint32At(4),
+		SamplesOffset: uint32At(8),
+		Bases:         uint32At(12),
+		BasesOffset:   uint32At(24),
+		SampleSize:    uint32At(40),
+	}
+	if h.Version, err = strconv.ParseFloat(string(data[36:40]), 64); err != nil {
+		return h, fmt.Errorf("invalid version %q", data[36:40])
+	}
+	// sample size is only specified from version 2
+	if h.Version < 2 {
+		h.SampleSize = 1
+	}
+	if h.SampleSize != 1 && h.SampleSize != 2 {
+		return h, fmt.Errorf("invalid sample size %d", h.SampleSize)
+	}
+	return h, nil
+}
+
+// ParseSCF parses the contents of an SCF chromatogram, of version 3 or
+// earlier.
+func ParseSCF(name string, data []byte) (Trace, error) {
+	h, err := readSCFHeader(data)
+	if err != nil {
+		return Trace{}, fmt.Errorf("parsing %s: %s", name, err.Error())
+	}
+
+	samplesSize := 4 * h.Samples * h.SampleSize
+	if h.SamplesOffset+samplesSize > len(data) {
+		return Trace{}, fmt.Errorf("parsing %s: samples beyond end of file", name)
+	}
+	basesSize := 12 * h.Bases
+	if h.BasesOffset+basesSize > len(data) {
+		return Trace{}, fmt.Errorf("parsing %s: bases beyond end of file", name)
+	}
+
+	samples := data[h.SamplesOffset:]
+	sample := func(offset int) int {
+		if h.SampleSize == 1 {
+			return int(samples[offset])
+		}
+		return int(binary.BigEndian.Uint16(samples[offset : offset+2]))
+	}
+
+	channels := make(map[string][]int)
+	bases := data[h.BasesOffset:]
+	peaks := make([]int, h.Bases)
+	qualities := make([]int, h.Bases)
+	calls := make([]byte, h.Bases)
+
+	if h.Version >= 3 {
+		// each channel in turn, stored as second differences
+		for c, base := range scfBases {
+			channel := make([]int, h.Samples)
+			for i := range channel {
+				channel[i] = sample((c*h.Samples + i) * h.SampleSize)
+			}
+			channels[string(base)] = undelta(undelta(channel, h.SampleSize), h.SampleSize)
+		}
+
+		// each field in turn for all bases
+		probabilities := bases[4*h.Bases:]
+		calls = bases[8*h.Bases : 9*h.Bases]
+		for i := range peaks {
+			peaks[i] = int(binary.BigEndian.Uint32(bases[4*i:]))
+			qualities[i] = baseProbability(calls[i], probabilities, i, h.Bases)
+		}
+	} else {
+		// samples of each channel interleaved
+		for c, base := range scfBases {
+			channel := make([]int, h.Samples)
+			for i := range channel {
+				channel[i] = sample((4*i + c) * h.SampleSize)
+			}
+			channels[string(base)] = channel
+		}
+
+		// each base in turn
+		for i := range peaks {
+			b := bases[12*i : 12*i+12]
+			peaks[i] = int(binary.BigEndian.Uint32(b))
+			calls[i] = b[8]
+			qualities[i] = baseProbability(calls[i], b[4:8], 0, 1)
+		}
+	}
+
+	// files written without qualities have every probability zero
+	known := false
+	for _, q := range qualities {
+		known = known || q != 0
+	}
+	if !known {
+		qualities = nil
+	}
+
+	return newTrace(name, string(calls), qualities, peaks, channels)
+}
+
+// baseProbability returns the probability of the base called at i, the
+// probabilities of each base being stored in blocks of stride.
+func baseProbability(call byte, probabilities []byte, i, stride int) int {
+	for c := range scfBases {
+		if scfBases[c] == call || scfBases[c]+'a'-'A' == call {
+			return int(probabilities[c*stride+i])
+		}
+	}
+	return 0
+}
+
+// undelta reverses the differencing of samples of size bytes.
+func undelta(samples []int, size int) []int {
+	mask := 1<<(8*uint(size)) - 1
+	previous := 0
+	for i := range samples {
+		samples[i] = (samples[i] + previous) & mask
+		previous = samples[i]
+	}
+	return samples
+}
@@ -0,0 +1,139 @@
+// Part of the Antha language
+// Copyright (C) 2018 The Antha authors. All rights reserved.
+//
+// This program is free software; you can redistribute it and/or
+// modify it under the terms of the GNU General Public License
+// as published by the Free Software Foundation; either version 2
+// of the License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program; if not, write to the Free Software
+// Foundation, Inc., 51 Franklin Street, Fifth Floor, Boston, MA  02110-1301, USA.
+//
+// For more information relating to the software or licensing issues please
+// contact license@antha-lang.org or write to the Antha team c/o
+// Synthace Ltd. The London Bioscience Innovation Centre
+// 2 Royal College St, London NW1 0NH UK
+
+// Package trace reads Sanger sequencing chromatograms in ABI (.ab1) and SCF
+// (.scf) format into base calls with their Phred quality scores.
+package trace
+
+import (
+	"fmt"
+	"math"
+	"path/filepath"
+	"strings"
+
+	"github.com/antha-lang/antha/antha/anthalib/wtype"
+)
+
+// DefaultTrimCutoff is the error probability used by Trim, corresponding to a
+// Phred quality of 13.
+const DefaultTrimCutoff = 0.05
+
+// Trace is a sequencing read parsed from a chromatogram. The embedded
+// DNASequence holds the base calls; the remaining fields hold the data
+// supporting each call.
+type Trace struct {
+	wtype.DNASequence
+
+	// Qualities are the Phred quality scores of each base call, or nil if
+	// the chromatogram has none.
+	Qualities []int
+
+	// Peaks are the indices into the channels of the peak of each base call.
+	Peaks []int
+
+	// Channels are the intensities of the trace of each base, keyed by base.
+	Channels map[string][]int
+}
+
+// ReadTrace parses a chromatogram in ABI or SCF format, as determined by the
+// extension of the file name.
+func ReadTrace(file wtype.File) (Trace, error) {
+	data, err := file.ReadAll()
+	if err != nil {
+		return Trace{}, err
+	}
+
+	name := strings.TrimSuffix(filepath.Base(file.Name), filepath.Ext(file.Name))
+	switch ext := strings.ToLower(filepath.Ext(file.Name)); ext {
+	case ".ab1", ".abi", ".abif":
+		return ParseAB1(name, data)
+	case ".scf":
+		return ParseSCF(name, data)
+	default:
+		return Trace{}, fmt.Errorf("non valid chromatogram file format: %s", ext)
+	}
+}
+
+// MottTrim returns the region of the trace whose base calls are of high
+// enough quality to use, found by the modified Mott algorithm: the score of
+// each base is cutoff minus its probability of error, and the region is the
+// one maximising the sum of scores. Start and end are code friendly, so that
+// the region is Seq[start:end].
+func (t Trace) MottTrim(cutoff float64) (start, end int) {
+	best, sum, from := 0.0, 0.0, 0
+	for i, q := range t.Qualities {
+		sum += cutoff - math.Pow(10, -float64(q)/10)
+		if sum <= 0 {
+			sum, from = 0, i+1
+			continue
+		}
+		if sum > best {
+			best, start, end = sum, from, i+1
+		}
+	}
+	return start, end
+}
+
+// Trim returns the trace restricted to the region found by MottTrim. The
+// channels are not trimmed so the peaks remain valid indices into them. A
+// trace without qualities cannot be trimmed and is returned unchanged.
+func (t Trace) Trim(cutoff float64) Trace {
+	if len(t.Qualities) == 0 {
+		return t
+	}
+	start, end := t.MottTrim(cutoff)
+	trimmed := t
+	trimmed.Seq = t.Seq[start:end]
+	trimmed.Qualities = t.Qualities[start:end]
+	if len(t.Peaks) == len(t.Qualities) {
+		trimmed.Peaks = t.Peaks[start:end]
+	}
+	return trimmed
+}
+
+// MeanQuality returns the mean Phred quality of the base calls.
+func (t Trace) MeanQuality() float64 {
+	if len(t.Qualities) == 0 {
+		return 0
+	}
+	var sum int
+	for _, q := range t.Qualities {
+		sum += q
+	}
+	return float64(sum) / float64(len(t.Qualities))
+}
+
+// newTrace checks the data parsed for a trace is consistent.
+func newTrace(name, bases string, qualities, peaks []int, channels map[string][]int) (Trace, error) {
+	if len(qualities) != 0 && len(qualities) != len(bases) {
+		return Trace{}, fmt.Errorf("trace %s has %d base calls but %d quality values", name, len(bases), len(qualities))
+	}
+	if len(peaks) != 0 && len(peaks) != len(bases) {
+		return Trace{}, fmt.Errorf("trace %s has %d base calls but %d peak locations", name, len(bases), len(peaks))
+	}
+	return Trace{
+		DNASequence: wtype.MakeLinearDNASequence(name, strings.ToUpper(bases)),
+		Qualities:   qualities,
+		Peaks:       peaks,
+		Channels:    channels,
+	}, nil
+}
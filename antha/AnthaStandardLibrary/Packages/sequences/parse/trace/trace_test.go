@@ -0,0 +1,241 @@
+package trace
+
+import (
+	"bytes"
+	"encoding/binary"
+	"reflect"
+	"testing"
+
+	"github.com/antha-lang/antha/antha/anthalib/wtype"
+)
+
+type abifItem struct {
+	name        string
+	number      int
+	elementType int
+	elementSize int
+	data        []byte
+}
+
+// makeABIF writes an ABIF file with the items given, the directory following
+// the data.
+func makeABIF(items []abifItem) []byte {
+	var data bytes.Buffer
+	data.WriteString(abifMagic)
+	binary.Write(&data, binary.BigEndian, uint16(101)) // nolint
+	root := data.Len()
+	data.Write(make([]byte, abifEntrySize))
+	data.Write(make([]byte, 128-data.Len()))
+
+	offsets := make([]int, len(items))
+	for i, item := range items {
+		offsets[i] = data.Len()
+		if len(item.data) > 4 {
+			data.Write(item.data)
+		}
+	}
+
+	entry := func(name string, number, elementType, elementSize, numElements, dataSize, dataOffset int) []byte {
+		var b bytes.Buffer
+		b.WriteString(name)
+		for _, v := range []interface{}{int32(number), int16(elementType), int16(elementSize), int32(numElements), int32(dataSize), int32(dataOffset), int32(0)} {
+			binary.Write(&b, binary.BigEndian, v) // nolint
+		}
+		return b.Bytes()
+	}
+
+	directory := data.Len()
+	for i, item := range items {
+		offset := offsets[i]
+		if len(item.data) <= 4 {
+			var small [4]byte
+			copy(small[:], item.data)
+			offset = int(binary.BigEndian.Uint32(small[:]))
+		}
+		data.Write(entry(item.name, item.number, item.elementType, item.elementSize, len(item.data)/item.elementSize, len(item.data), offset))
+	}
+
+	b := data.Bytes()
+	copy(b[root:], entry("tdir", 1, 1023, abifEntrySize, len(items), len(items)*abifEntrySize, directory))
+	return b
+}
+
+func shortBytes(values ...int) []byte {
+	var b bytes.Buffer
+	for _, v := range values {
+		binary.Write(&b, binary.BigEndian, int16(v)) // nolint
+	}
+	return b.Bytes()
+}
+
+func TestParseAB1(t *testing.T) {
+	items := []abifItem{
+		{"PBAS", 1, 2, 1, []byte("ACGTN")},
+		{"PBAS", 2, 2, 1, []byte("ACGTA")},
+		{"PCON", 2, 2, 1, []byte{10, 20, 30, 40, 50}},
+		{"PLOC", 2, 4, 2, shortBytes(2, 5, 8, 11, 14)},
+		{"FWO_", 1, 2, 1, []byte("GATC")},
+		{"DATA", 9, 4, 2, shortBytes(1, 2, 3)},
+		{"DATA", 10, 4, 2, shortBytes(4, 5, 6)},
+		{"DATA", 11, 4, 2, shortBytes(7, 8, 9)},
+		{"DATA", 12, 4, 2, shortBytes(10, 11, 12)},
+		{"SMPL", 1, 18, 1, []byte("\x06clone1")},
+	}
+
+	trace, err := ParseAB1("file", makeABIF(items))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if trace.Nm != "clone1" || trace.Seq != "ACGTA" {
+		t.Errorf("expected edited base calls ACGTA of clone1, got %s of %s", trace.Seq, trace.Nm)
+	}
+	if !reflect.DeepEqual(trace.Qualities, []int{10, 20, 30, 40, 50}) || !reflect.DeepEqual(trace.Peaks, []int{2, 5, 8, 11, 14}) {
+		t.Errorf("unexpected qualities %v or peaks %v", trace.Qualities, trace.Peaks)
+	}
+	if !reflect.DeepEqual(trace.Channels["A"], []int{4, 5, 6}) || !reflect.DeepEqual(trace.Channels["C"], []int{10, 11, 12}) {
+		t.Errorf("unexpected channels %v", trace.Channels)
+	}
+
+	if _, err := ParseAB1("file", []byte("ABIF")); err == nil {
+		t.Error("expected error for truncated file")
+	}
+	if _, err := ParseAB1("file", makeABIF(items[3:])); err == nil {
+		t.Error("expected error for file without base calls")
+	}
+}
+
+// makeSCF writes an SCF file of the version given with two samples per
+// channel.
+func makeSCF(version string, calls string, qualities []int) []byte {
+	samples := [][]int{{1, 3}, {2, 5}, {300, 100}, {4, 4}}
+	var data bytes.Buffer
+	for _, v := range []uint32{2, scfHeaderSize, uint32(len(calls)), 0, 0, scfHeaderSize + 16, 0, 0} {
+		binary.Write(&data, binary.BigEndian, v) // nolint
+	}
+	b := append([]byte(scfMagic), data.Bytes()...)
+	b = append(b, []byte(version)...)
+	b = append(b, 0, 0, 0, 2)
+	b = append(b, make([]byte, scfHeaderSize-len(b))...)
+
+	var body bytes.Buffer
+	if version >= "3" {
+		for _, channel := range samples {
+			// second differences
+			previous, delta := 0, 0
+			for _, s := range channel {
+				d := s - previous
+				binary.Write(&body, binary.BigEndian, uint16(d-delta)) // nolint
+				previous, delta = s, d
+			}
+		}
+		for i := range calls {
+			binary.Write(&body, binary.BigEndian, uint32(i)) // nolint
+		}
+		for c := range scfBases {
+			for i := range calls {
+				q := 0
+				if calls[i] == scfBases[c] {
+					q = qualities[i]
+				}
+				body.WriteByte(byte(q))
+			}
+		}
+		body.WriteString(calls)
+		body.Write(make([]byte, 3*len(calls)))
+	} else {
+		for i := 0; i < 2; i++ {
+			for _, channel := range samples {
+				binary.Write(&body, binary.BigEndian, uint16(channel[i])) // nolint
+			}
+		}
+		for i := range calls {
+			binary.Write(&body, binary.BigEndian, uint32(i)) // nolint
+			for c := range scfBases {
+				q := 0
+				if calls[i] == scfBases[c] {
+					q = qualities[i]
+				}
+				body.WriteByte(byte(q))
+			}
+			body.WriteByte(calls[i])
+			body.Write(make([]byte, 3))
+		}
+	}
+	return append(b, body.Bytes()...)
+}
+
+func TestParseSCF(t *testing.T) {
+	for _, version := range []string{"3.00", "2.00"} {
+		file := wtype.File{Name: "reads/clone2.scf"}
+		if err := file.WriteAll(makeSCF(version, "TTGCA", []int{5, 15, 25, 35, 45})); err != nil {
+			t.Fatal(err)
+		}
+		trace, err := ReadTrace(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if trace.Nm != "clone2" || trace.Seq != "TTGCA" || !reflect.DeepEqual(trace.Qualities, []int{5, 15, 25, 35, 45}) {
+			t.Errorf("version %s: unexpected trace %+v", version, trace)
+		}
+		if !reflect.DeepEqual(trace.Peaks, []int{0, 1, 2, 3, 4}) {
+			t.Errorf("version %s: unexpected peaks %v", version, trace.Peaks)
+		}
+		if !reflect.DeepEqual(trace.Channels["G"], []int{300, 100}) || !reflect.DeepEqual(trace.Channels["C"], []int{2, 5}) {
+			t.Errorf("version %s: unexpected channels %v", version, trace.Channels)
+		}
+	}
+
+	if _, err := ReadTrace(wtype.File{Name: "clone2.scf"}); err == nil {
+		t.Error("expected error for empty file")
+	}
+	if _, err := ReadTrace(wtype.File{Name: "clone2.fasta"}); err == nil {
+		t.Error("expected error for file which is not a chromatogram")
+	}
+}
+
+func TestTrim(t *testing.T) {
+	trace := Trace{
+		DNASequence: wtype.MakeLinearDNASequence("read", "NNACGTACGTAN"),
+		Qualities:   []int{2, 4, 30, 40, 40, 8, 40, 40, 40, 30, 3, 2},
+		Peaks:       []int{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12},
+	}
+	if start, end := trace.MottTrim(DefaultTrimCutoff); start != 2 || end != 10 {
+		t.Errorf("expected to trim to 2:10, got %d:%d", start, end)
+	}
+	trimmed := trace.Trim(DefaultTrimCutoff)
+	if trimmed.Seq != "ACGTACGT" || len(trimmed.Qualities) != 8 || trimmed.Peaks[0] != 3 {
+		t.Errorf("unexpected trimmed trace %+v", trimmed)
+	}
+	if trace.Seq != "NNACGTACGTAN" {
+		t.Error("expected trace not to be modified by trimming")
+	}
+
+	if start, end := (Trace{Qualities: []int{1, 2, 3}}).MottTrim(DefaultTrimCutoff); start != end {
+		t.Errorf("expected low quality trace to be trimmed completely, got %d:%d", start, end)
+	}
+}
+
+func TestTraceWithoutQualities(t *testing.T) {
+	abif := makeABIF([]abifItem{
+		{"PBAS", 2, 2, 1, []byte("ACGTA")},
+		{"PLOC", 2, 4, 2, shortBytes(2, 5, 8, 11, 14)},
+	})
+	scf := makeSCF("3.00", "TTGCA", []int{0, 0, 0, 0, 0})
+
+	for name, data := range map[string][]byte{"clone.ab1": abif, "clone.scf": scf} {
+		file := wtype.File{Name: name}
+		if err := file.WriteAll(data); err != nil {
+			t.Fatal(err)
+		}
+		trace, err := ReadTrace(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if trace.Qualities != nil {
+			t.Errorf("%s: expected no qualities, got %v", name, trace.Qualities)
+		}
+		if trimmed := trace.Trim(DefaultTrimCutoff); trimmed.Seq != trace.Seq || len(trimmed.Seq) != 5 {
+			t.Errorf("%s: expected trace without qualities not to be trimmed, got %q", name, trimmed.Seq)
+		}
+	}
+}
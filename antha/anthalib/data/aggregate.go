@@ -0,0 +1,244 @@
+package data
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+
+	"github.com/pkg/errors"
+)
+
+/*
+ * aggregation functions
+ */
+
+// Reducer accumulates the values of a column within a single group.  Add is
+// called with each non-null value of the group, in source table order.
+// Result returns the aggregate value, which may be nil.
+type Reducer interface {
+	Add(value interface{})
+	Result() interface{}
+}
+
+// Aggregation computes a column of a grouped table from a column of the source table.
+type Aggregation struct {
+	col  ColumnName
+	name ColumnName
+	// prepare checks the source column type and returns the aggregate type and a new reducer per group
+	prepare func(col Column) (reflect.Type, func() Reducer, error)
+	// ordered is true if the result depends on the order of the values
+	ordered bool
+}
+
+// As sets the name of the aggregate column in the grouped table.
+func (a Aggregation) As(name ColumnName) Aggregation {
+	a.name = name
+	return a
+}
+
+func newAggregation(fn string, col ColumnName, prepare func(col Column) (reflect.Type, func() Reducer, error)) Aggregation {
+	return Aggregation{
+		col:     col,
+		name:    ColumnName(fmt.Sprintf("%s(%s)", fn, col)),
+		prepare: prepare,
+	}
+}
+
+func isNumeric(typ reflect.Type) bool {
+	switch typ.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	default:
+		return false
+	}
+}
+
+func checkNumeric(col Column) error {
+	if !isNumeric(col.Type) {
+		return errors.Errorf("column %s of type %v is not numeric", col.Name, col.Type)
+	}
+	return nil
+}
+
+func toFloat64(value interface{}) float64 {
+	return reflect.ValueOf(value).Convert(typeFloat64).Float()
+}
+
+// numericAggregation creates a float64 aggregation of a numeric column
+func numericAggregation(fn string, col ColumnName, newReducer func() Reducer) Aggregation {
+	return newAggregation(fn, col, func(c Column) (reflect.Type, func() Reducer, error) {
+		if err := checkNumeric(c); err != nil {
+			return nil, nil, err
+		}
+		return typeFloat64, newReducer, nil
+	})
+}
+
+// Sum adds up the non-null values of a numeric column, as float64. The sum
+// of a group containing only nulls is null.
+func Sum(col ColumnName) Aggregation {
+	return numericAggregation("sum", col, func() Reducer { return &momentsReducer{result: (*momentsReducer).sum} })
+}
+
+// Mean averages the non-null values of a numeric column, as float64.
+func Mean(col ColumnName) Aggregation {
+	return numericAggregation("mean", col, func() Reducer { return &momentsReducer{result: (*momentsReducer).mean} })
+}
+
+// StdDev computes the sample standard deviation of the non-null values of a
+// numeric column, as float64.  It is null for groups of fewer than two values.
+func StdDev(col ColumnName) Aggregation {
+	return numericAggregation("stddev", col, func() Reducer { return &momentsReducer{result: (*momentsReducer).stdDev} })
+}
+
+// Count counts the non-null values of a column, as int.
+func Count(col ColumnName) Aggregation {
+	return newAggregation("count", col, func(c Column) (reflect.Type, func() Reducer, error) {
+		return typeInt, func() Reducer { return new(countReducer) }, nil
+	})
+}
+
+// Min finds the smallest non-null value of a numeric or string column.
+func Min(col ColumnName) Aggregation {
+	return extremeAggregation("min", col, -1)
+}
+
+// Max finds the largest non-null value of a numeric or string column.
+func Max(col ColumnName) Aggregation {
+	return extremeAggregation("max", col, 1)
+}
+
+// First finds the first non-null value of a column in source table order.
+func First(col ColumnName) Aggregation {
+	a := newAggregation("first", col, func(c Column) (reflect.Type, func() Reducer, error) {
+		return c.Type, func() Reducer { return new(firstReducer) }, nil
+	})
+	a.ordered = true
+	return a
+}
+
+// Reduce creates a user-defined aggregation producing values of type typ.
+// newReducer is called once for each group; values are added to the
+// reducer in source table order.
+func Reduce(col ColumnName, typ reflect.Type, newReducer func() Reducer) Aggregation {
+	a := newAggregation("reduce", col, func(c Column) (reflect.Type, func() Reducer, error) {
+		return typ, newReducer, nil
+	})
+	a.ordered = true
+	return a
+}
+
+// ReduceFunc is a Reducer that collects all the non-null values of a group
+// and calls a function on them.
+type ReduceFunc struct {
+	Func   func(values []interface{}) interface{}
+	values []interface{}
+}
+
+// Add implements Reducer.
+func (r *ReduceFunc) Add(value interface{}) {
+	r.values = append(r.values, value)
+}
+
+// Result implements Reducer.
+func (r *ReduceFunc) Result() interface{} {
+	return r.Func(r.values)
+}
+
+// momentsReducer accumulates count, sum and sum of squares (using Welford's
+// algorithm for numerical stability)
+type momentsReducer struct {
+	count  int
+	total  float64
+	mu     float64
+	m2     float64
+	result func(*momentsReducer) interface{}
+}
+
+func (r *momentsReducer) Add(value interface{}) {
+	x := toFloat64(value)
+	r.count++
+	r.total += x
+	delta := x - r.mu
+	r.mu += delta / float64(r.count)
+	r.m2 += delta * (x - r.mu)
+}
+
+func (r *momentsReducer) Result() interface{} {
+	return r.result(r)
+}
+
+func (r *momentsReducer) sum() interface{} {
+	if r.count == 0 {
+		return nil
+	}
+	return r.total
+}
+
+func (r *momentsReducer) mean() interface{} {
+	if r.count == 0 {
+		return nil
+	}
+	return r.total / float64(r.count)
+}
+
+func (r *momentsReducer) stdDev() interface{} {
+	if r.count < 2 {
+		return nil
+	}
+	return math.Sqrt(r.m2 / float64(r.count-1))
+}
+
+type countReducer int
+
+func (r *countReducer) Add(value interface{}) { *r++ }
+func (r *countReducer) Result() interface{}   { return int(*r) }
+
+type firstReducer struct {
+	value interface{}
+}
+
+func (r *firstReducer) Add(value interface{}) {
+	if r.value == nil {
+		r.value = value
+	}
+}
+
+func (r *firstReducer) Result() interface{} { return r.value }
+
+// extremeAggregation finds the min (sign < 0) or max (sign > 0) value
+func extremeAggregation(fn string, col ColumnName, sign int) Aggregation {
+	return newAggregation(fn, col, func(c Column) (reflect.Type, func() Reducer, error) {
+		var less func(a, b interface{}) bool
+		switch {
+		case isNumeric(c.Type):
+			less = func(a, b interface{}) bool { return toFloat64(a) < toFloat64(b) }
+		case c.Type.Kind() == reflect.String:
+			less = func(a, b interface{}) bool {
+				return reflect.ValueOf(a).String() < reflect.ValueOf(b).String()
+			}
+		default:
+			return nil, nil, errors.Errorf("column %s of type %v is not orderable", c.Name, c.Type)
+		}
+		better := less
+		if sign > 0 {
+			better = func(a, b interface{}) bool { return less(b, a) }
+		}
+		return c.Type, func() Reducer { return &extremeReducer{better: better} }, nil
+	})
+}
+
+type extremeReducer struct {
+	better func(a, b interface{}) bool
+	value  interface{}
+}
+
+func (r *extremeReducer) Add(value interface{}) {
+	if r.value == nil || r.better(value, r.value) {
+		r.value = value
+	}
+}
+
+func (r *extremeReducer) Result() interface{} { return r.value }
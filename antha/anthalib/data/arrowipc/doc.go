@@ -0,0 +1,18 @@
+/*
+Package arrowipc provides tools for data tables serialization to/from the Apache Arrow IPC file format - in the form
+of files on disk, memory buffer or io.Reader/io.Writer.  The file format is the streaming format followed by a footer
+locating each record, so the whole input is needed before a table can be read.
+
+The following column types are currently supported:
+	bool
+	int64
+	int (stored as Arrow int64, with the original type recorded in the field metadata)
+	float64
+	string
+	TimestampMillis
+	TimestampMicros
+
+All the columns are written as nullable Arrow fields.
+
+*/
+package arrowipc
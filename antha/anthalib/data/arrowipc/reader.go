@@ -0,0 +1,140 @@
+package arrowipc
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+
+	"github.com/antha-lang/antha/antha/anthalib/data"
+	"github.com/antha-lang/antha/antha/anthalib/data/internal/fileschema"
+	"github.com/apache/arrow/go/arrow/array"
+	"github.com/apache/arrow/go/arrow/ipc"
+	"github.com/pkg/errors"
+)
+
+type readState struct {
+	columnNames []data.ColumnName
+}
+
+// ReadOpt sets an optional behavior when reading Arrow IPC files.
+type ReadOpt func(*readState) error
+
+// Columns returns a ReadOpt that selects a subset of columns to read from the
+// source. If no column names are specified, reads all the columns.
+func Columns(columnNames ...data.ColumnName) ReadOpt {
+	return func(r *readState) error {
+		r.columnNames = columnNames
+		return nil
+	}
+}
+
+// TableFromFile reads a data.Table eagerly from an Arrow IPC file
+func TableFromFile(filePath string, opts ...ReadOpt) (*data.Table, error) {
+	// Arrow IPC file
+	file, err := os.Open(filepath.Clean(filePath))
+	if err != nil {
+		return nil, errors.Wrapf(err, "opening Arrow IPC file '%s'", filePath)
+	}
+	defer file.Close() //nolint
+
+	// the file is read at the offsets of its records given in its footer
+	return readTable(file, opts)
+}
+
+// TableFromBytes reads a data.Table eagerly from a memory buffer
+func TableFromBytes(buffer []byte, opts ...ReadOpt) (*data.Table, error) {
+	return readTable(bytes.NewReader(buffer), opts)
+}
+
+// TableFromReader reads a data.Table eagerly from io.Reader
+func TableFromReader(reader io.Reader, opts ...ReadOpt) (*data.Table, error) {
+	// reading into a memory buffer, since the footer is at the end of the file
+	buffer, err := ioutil.ReadAll(reader)
+	if err != nil {
+		return nil, errors.Wrap(err, "reading Arrow IPC file bytes")
+	}
+
+	return TableFromBytes(buffer, opts...)
+}
+
+func readTable(reader ipc.ReadAtSeeker, opts []ReadOpt) (*data.Table, error) {
+	r := &readState{}
+	for _, o := range opts {
+		if err := o(r); err != nil {
+			return nil, errors.Wrapf(err, "ReadOpt %v generated an error state", o)
+		}
+	}
+
+	// Arrow IPC file reader
+	ipcReader, err := ipc.NewFileReader(reader)
+	if err != nil {
+		return nil, errors.Wrap(err, "reading Arrow IPC file schema")
+	}
+	defer ipcReader.Close() //nolint
+
+	// transforming Arrow schema into a table schema
+	fields := ipcReader.Schema().Fields()
+	names := make([]data.ColumnName, len(fields))
+	for i, field := range fields {
+		names[i] = data.ColumnName(field.Name)
+	}
+	schema, indexes, err := fileschema.Select(names, r.columnNames, func(i int) (data.Column, error) {
+		return columnFromField(fields[i])
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// starting building a Table
+	builder, err := data.NewTableBuilder(schema.Columns)
+	if err != nil {
+		return nil, err
+	}
+
+	// reading data record by record
+	for i := 0; i < ipcReader.NumRecords(); i++ {
+		record, err := ipcReader.Record(i)
+		if err != nil {
+			return nil, errors.Wrapf(err, "reading Arrow IPC record %d", i)
+		}
+		for row := 0; row < int(record.NumRows()); row++ {
+			values := make([]interface{}, len(indexes))
+			for i, colIndex := range indexes {
+				values[i] = arrayValue(record.Column(colIndex), row, schema.Columns[i].Type)
+			}
+			builder.Append(values)
+		}
+	}
+
+	// building a Table
+	return builder.Build(), nil
+}
+
+// reads a single value from an Arrow array, converting it to the column type
+func arrayValue(values array.Interface, index int, typ reflect.Type) interface{} {
+	if values.IsNull(index) {
+		return nil
+	}
+
+	var value interface{}
+	switch typedValues := values.(type) {
+	case *array.Boolean:
+		value = typedValues.Value(index)
+	case *array.Int64:
+		value = typedValues.Value(index)
+	case *array.Float64:
+		value = typedValues.Value(index)
+	case *array.String:
+		value = typedValues.Value(index)
+	case *array.Timestamp:
+		value = int64(typedValues.Value(index))
+	default:
+		panic(errors.Errorf("SHOULD NOT HAPPEN: unexpected Arrow array type %T", values))
+	}
+
+	// int and timestamps are stored as int64
+	return reflect.ValueOf(value).Convert(typ).Interface()
+}
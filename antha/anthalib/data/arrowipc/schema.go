@@ -0,0 +1,85 @@
+package arrowipc
+
+import (
+	"reflect"
+
+	"github.com/antha-lang/antha/antha/anthalib/data"
+	"github.com/apache/arrow/go/arrow"
+	"github.com/pkg/errors"
+)
+
+// field metadata key storing the column type when it is not determined by the Arrow type
+const typeMetadataKey = "antha.type"
+
+// a list of types supported by Arrow IPC reader and writer
+type arrowType struct {
+	name string // a column type name stored in field metadata (if needed)
+	typ  reflect.Type
+	// Arrow data type
+	dataType arrow.DataType
+}
+
+var arrowTypes = [...]arrowType{
+	{typ: reflect.TypeOf(false), dataType: arrow.FixedWidthTypes.Boolean},
+	{typ: reflect.TypeOf(int64(0)), dataType: arrow.PrimitiveTypes.Int64},
+	{name: "int", typ: reflect.TypeOf(0), dataType: arrow.PrimitiveTypes.Int64},
+	{typ: reflect.TypeOf(float64(0)), dataType: arrow.PrimitiveTypes.Float64},
+	{typ: reflect.TypeOf(""), dataType: arrow.BinaryTypes.String},
+	{typ: reflect.TypeOf(data.TimestampMillis(0)), dataType: &arrow.TimestampType{Unit: arrow.Millisecond}},
+	{typ: reflect.TypeOf(data.TimestampMicros(0)), dataType: &arrow.TimestampType{Unit: arrow.Microsecond}},
+}
+
+// converts a table schema to an Arrow schema
+func arrowSchema(schema *data.Schema) (*arrow.Schema, error) {
+	fields := make([]arrow.Field, len(schema.Columns))
+	for i, column := range schema.Columns {
+		field, err := fieldFromColumn(column)
+		if err != nil {
+			return nil, errors.Wrapf(err, "column %d", i)
+		}
+		fields[i] = field
+	}
+	return arrow.NewSchema(fields, nil), nil
+}
+
+func fieldFromColumn(column data.Column) (arrow.Field, error) {
+	for _, t := range arrowTypes {
+		if t.typ != column.Type {
+			continue
+		}
+		field := arrow.Field{
+			Name: string(column.Name),
+			Type: t.dataType,
+			// for now, all columns are considered nullable
+			Nullable: true,
+		}
+		if t.name != "" {
+			field.Metadata = arrow.NewMetadata([]string{typeMetadataKey}, []string{t.name})
+		}
+		return field, nil
+	}
+	return arrow.Field{}, errors.Errorf("unsupported type: %v", column.Type)
+}
+
+// determines a column of a table from an Arrow field
+func columnFromField(field arrow.Field) (data.Column, error) {
+	name := ""
+	if i := field.Metadata.FindKey(typeMetadataKey); i >= 0 {
+		name = field.Metadata.Values()[i]
+	}
+	for _, t := range arrowTypes {
+		if t.name == name && dataTypesMatch(t.dataType, field.Type) {
+			return data.Column{Name: data.ColumnName(field.Name), Type: t.typ}, nil
+		}
+	}
+	return data.Column{}, errors.Errorf("Unsupported: field '%s' has type '%v'", field.Name, field.Type)
+}
+
+// compares Arrow data types, ignoring timestamps time zones
+func dataTypesMatch(expected, actual arrow.DataType) bool {
+	if ts, ok := actual.(*arrow.TimestampType); ok {
+		expectedTs, ok := expected.(*arrow.TimestampType)
+		return ok && expectedTs.Unit == ts.Unit
+	}
+	return arrow.TypeEqual(expected, actual)
+}
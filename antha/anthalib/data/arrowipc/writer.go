@@ -0,0 +1,142 @@
+package arrowipc
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"os"
+	"reflect"
+
+	"github.com/antha-lang/antha/antha/anthalib/data"
+	"github.com/apache/arrow/go/arrow"
+	"github.com/apache/arrow/go/arrow/array"
+	"github.com/apache/arrow/go/arrow/ipc"
+	"github.com/apache/arrow/go/arrow/memory"
+	"github.com/pkg/errors"
+)
+
+// the number of table rows written in a single Arrow record
+const recordSize = 64 * 1024
+
+// TableToFile writes a data.Table to an Arrow IPC file
+func TableToFile(table *data.Table, filePath string) error {
+	// Arrow IPC file
+	file, err := os.Create(filePath)
+	if err != nil {
+		return errors.Wrapf(err, "creating Arrow IPC file '%s'", filePath)
+	}
+	defer file.Close() //nolint
+
+	// writing the table
+	writer := bufio.NewWriter(file)
+	if err := TableToWriter(table, writer); err != nil {
+		return err
+	}
+	return writer.Flush()
+}
+
+// TableToBytes writes a data.Table to a memory buffer
+func TableToBytes(table *data.Table) ([]byte, error) {
+	// a memory buffer writer
+	buffer := bytes.NewBuffer(nil)
+
+	// writing the table
+	if err := TableToWriter(table, buffer); err != nil {
+		return nil, err
+	}
+	return buffer.Bytes(), nil
+}
+
+// TableToWriter writes a data.Table to io.Writer
+func TableToWriter(table *data.Table, writer io.Writer) error {
+	// Arrow schema
+	tableSchema := table.Schema()
+	schema, err := arrowSchema(&tableSchema)
+	if err != nil {
+		return errors.Wrap(err, "creating Arrow schema")
+	}
+
+	// Arrow IPC file writer
+	ipcWriter, err := ipc.NewFileWriter(&positionWriter{writer: writer}, ipc.WithSchema(schema))
+	if err != nil {
+		return errors.Wrap(err, "creating Arrow IPC file writer")
+	}
+
+	// building records of up to recordSize rows
+	builder := array.NewRecordBuilder(memory.DefaultAllocator, schema)
+	defer builder.Release()
+
+	writeRecord := func() error {
+		record := builder.NewRecord()
+		defer record.Release()
+		return ipcWriter.Write(record)
+	}
+
+	rows, done := table.Iter()
+	defer done()
+
+	// writing data
+	size := 0
+	for row := range rows {
+		for i, value := range row.Values() {
+			appendValue(builder.Field(i), value.Interface())
+		}
+		if size++; size == recordSize {
+			if err := writeRecord(); err != nil {
+				return errors.Wrap(err, "writing Arrow IPC record")
+			}
+			size = 0
+		}
+	}
+	if size > 0 {
+		if err := writeRecord(); err != nil {
+			return errors.Wrap(err, "writing Arrow IPC record")
+		}
+	}
+
+	return ipcWriter.Close()
+}
+
+// positionWriter wraps io.Writer to keep track of the number of bytes
+// written, which is all the Arrow IPC file writer needs to seek for
+type positionWriter struct {
+	writer io.Writer
+	pos    int64
+}
+
+func (w *positionWriter) Write(p []byte) (int, error) {
+	n, err := w.writer.Write(p)
+	w.pos += int64(n)
+	return n, err
+}
+
+func (w *positionWriter) Seek(offset int64, whence int) (int64, error) {
+	if offset != 0 || whence != io.SeekCurrent {
+		return w.pos, errors.New("seeking is not supported when writing Arrow IPC files")
+	}
+	return w.pos, nil
+}
+
+// appends a single value to an Arrow array builder
+func appendValue(builder array.Builder, value interface{}) {
+	if value == nil {
+		builder.AppendNull()
+		return
+	}
+
+	switch typedBuilder := builder.(type) {
+	case *array.BooleanBuilder:
+		typedBuilder.Append(value.(bool))
+	case *array.Int64Builder:
+		// int64 or int
+		typedBuilder.Append(reflect.ValueOf(value).Int())
+	case *array.Float64Builder:
+		typedBuilder.Append(value.(float64))
+	case *array.StringBuilder:
+		typedBuilder.Append(value.(string))
+	case *array.TimestampBuilder:
+		typedBuilder.Append(arrow.Timestamp(reflect.ValueOf(value).Int()))
+	default:
+		panic(errors.Errorf("SHOULD NOT HAPPEN: unexpected Arrow builder type %T", builder))
+	}
+}
@@ -91,7 +91,14 @@ Here the value of the Table would be:
 
 Sort is a special case, as it is eager: it materializes the entire table.
 
-Other data manipulation methods include Project, Slice, Sort, Filter, Distinct, Pivot, and Join.
+Other data manipulation methods include Project, Slice, Sort, Filter, Distinct, Pivot, Melt, and Join.
+
+Rows can be grouped and summarized with aggregate functions such as Sum, Mean, Min, Max, Count, StdDev and First,
+or with a user-defined Reducer:
+	table.GroupBy("label").Aggregate(data.Mean("quantity"), data.Count("quantity").As("n"))
+
+Rows with a timestamp column can also be grouped into equal sized time windows:
+	table.GroupBy("label").Window(&data.TimestampWindowSpec{Column: "time", Duration: time.Minute}).Aggregate(data.Max("quantity"))
 
 Calling Cache on a Table returns a fully materialized copy, which is useful if the data needs to be used for more than one
 subsequent operation.
@@ -100,6 +107,8 @@ Data export
 
 We can write data back to files, such as Parquet, using parquet.TableToBytes.
 
+The csv, arrowipc and jsonl subpackages provide similar functions for CSV, Arrow IPC and JSON lines files.
+
 Also it is possible to populate a slice of structs with a table data:
 	type myType struct {
 		Capacity float64
@@ -137,9 +146,9 @@ Future features
 
 The main features still to implement include:
 
-Aggregation, such as group by, along with aggregate functions such as average, max, etc.
+Sliding windows and aggregation over unbounded datasets.
 
-Relational operations - concat, union, join.
+Relational operations - union.
 
 Complete Parquet and CSV read/write support.
 
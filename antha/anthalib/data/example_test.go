@@ -456,6 +456,40 @@ func ExampleTable_Pivot() {
 	// |3|    grog|  <nil>|      44|
 }
 
+func ExampleTable_GroupBy() {
+	// create a table
+	pirateBootyByShip := NewTable(
+		Must().NewSeriesFromSlice("Ship", []string{"Revenge", "Revenge", "Fortune", "Fortune", "Revenge"}, nil),
+		Must().NewSeriesFromSlice("Name", []string{"doubloon", "grog", "doubloon", "cutlass", "chest"}, nil),
+		Must().NewSeriesFromSlice("Quantity", []int64{1200, 44, 300, 30, 2}, nil),
+	)
+
+	// summarize the booty of each ship
+	summary, _ := pirateBootyByShip.GroupBy("Ship").Aggregate(Sum("Quantity"), Count("Name").As("Kinds"), First("Name"))
+	fmt.Println(summary.ToRows())
+	// Output: 2 Row(s):
+	// | |   Ship|sum(Quantity)|Kinds|first(Name)|
+	// | | string|      float64|  int|     string|
+	// -------------------------------------------
+	// |0|Fortune|          330|    2|   doubloon|
+	// |1|Revenge|         1246|    3|   doubloon|
+}
+
+func ExampleTable_Melt() {
+	// make a narrow table from pirateBooty
+	pirateBootyNarrow, _ := pirateBooty.Project("Name", "Price")
+	pirateBootyNarrow, _ = pirateBootyNarrow.Melt([]ColumnName{"Name"}, nil, "PropertyName", "PropertyValue")
+	fmt.Println(pirateBootyNarrow.ToRows())
+	// Output: 4 Row(s):
+	// | |    Name|PropertyName|PropertyValue|
+	// | |  string|      string|      float64|
+	// ---------------------------------------
+	// |0|doubloon|       Price|            1|
+	// |1|    grog|       Price|        <nil>|
+	// |2| cutlass|       Price|          5.5|
+	// |3|   chest|       Price|          600|
+}
+
 func ExampleTable_Join_naturalInner() {
 	// create another table to join
 	currency := NewTable(
@@ -0,0 +1,283 @@
+package data
+
+import (
+	"reflect"
+	"strconv"
+
+	"github.com/pkg/errors"
+)
+
+/*
+ * group by interfaces
+ */
+
+// GroupBySelection contains a table to group, and the key columns and window
+// to group it by.
+type GroupBySelection struct {
+	table  *Table
+	key    []ColumnName
+	window WindowSpec
+}
+
+// Window additionally groups rows by the window they belong to.  The window
+// column is placed after the key columns in the grouped table.
+func (gs *GroupBySelection) Window(spec WindowSpec) *GroupBySelection {
+	return &GroupBySelection{table: gs.table, key: gs.key, window: spec}
+}
+
+// Aggregate returns a table with one row for each distinct key (and
+// window), containing the key columns followed by one column for each
+// aggregation.  The returned table is sorted by key.
+func (gs *GroupBySelection) Aggregate(aggs ...Aggregation) (*Table, error) {
+	return groupTable(gs.table, gs.key, gs.window, aggs)
+}
+
+/*
+ * group by internals
+ */
+
+func groupTable(table *Table, key []ColumnName, window WindowSpec, aggs []Aggregation) (*Table, error) {
+	if err := table.schema.CheckColumnsExist(key...); err != nil {
+		return nil, errors.Wrap(err, "group by columns lookup")
+	}
+
+	// the intermediate table series: |key1|...|keyN|[window]|[row index]|agg1|...|aggM|
+	var inputs []*Series
+	var columns []Column
+	for _, k := range key {
+		series, _ := table.seriesByName(k)
+		inputs = append(inputs, series)
+		columns = append(columns, table.schema.MustCol(k))
+	}
+	if window != nil {
+		series, err := window.windowSeries(table)
+		if err != nil {
+			return nil, err
+		}
+		inputs = append(inputs, series)
+		columns = append(columns, Column{Name: series.col, Type: series.typ})
+	}
+	numKeys := len(inputs)
+
+	// aggregations which depend on the order of the values need a stable sort
+	ordered := false
+	for _, agg := range aggs {
+		ordered = ordered || agg.ordered
+	}
+	if ordered {
+		inputs = append(inputs, newRowIndexSeries(table))
+	}
+	aggOffset := len(inputs)
+
+	newReducers := make([]func() Reducer, len(aggs))
+	for i, agg := range aggs {
+		series, err := table.seriesByName(agg.col)
+		if err != nil {
+			return nil, errors.Wrap(err, "aggregate columns lookup")
+		}
+		typ, newReducer, err := agg.prepare(table.schema.MustCol(agg.col))
+		if err != nil {
+			return nil, errors.Wrapf(err, "aggregating %s", agg.name)
+		}
+		inputs = append(inputs, series)
+		columns = append(columns, Column{Name: agg.name, Type: typ})
+		newReducers[i] = newReducer
+	}
+
+	seen := make(map[ColumnName]bool, len(columns))
+	for _, c := range columns {
+		if seen[c.Name] {
+			return nil, errors.Errorf("duplicate column '%s' in grouped table, use Aggregation.As to rename", c.Name)
+		}
+		seen[c.Name] = true
+	}
+
+	// the intermediate columns are renamed by position, since key and
+	// aggregate columns may coincide
+	intermediateKey := make(Key, aggOffset)
+	for i := range inputs {
+		series := inputs[i]
+		inputs[i] = &Series{col: ColumnName(strconv.Itoa(i)), typ: series.typ, meta: series.meta, read: series.read}
+		if i < aggOffset {
+			intermediateKey[i] = ColumnKey{Column: inputs[i].col, Asc: true}
+		}
+	}
+
+	outputKey := make([]ColumnKey, numKeys)
+	for i := range outputKey {
+		outputKey[i] = ColumnKey{Column: columns[i].Name, Asc: true}
+	}
+	outputTable := newFromSchema(NewSchema(columns), outputKey...)
+
+	// the output table max size: in worst case, equals to the source table max size
+	_, maxSize, _ := seriesSize(table.series)
+
+	group := &iterGroup{func() interface{} {
+		intermediate := NewTable(inputs...).Must().Sort(intermediateKey)
+		return newGroupState(intermediate, numKeys, aggOffset, columns[numKeys:], newReducers)
+	}}
+
+	wrap := func(colIndex int) func(cache *seriesIterCache) iterator {
+		return func(cache *seriesIterCache) iterator {
+			iter := &groupIter{colIndex: colIndex, commonState: cache.EnsureGroup(group).(*groupState)}
+			iter.pos = iter.commonState.index
+			return iter
+		}
+	}
+
+	for i, series := range outputTable.series {
+		series.read = wrap(i)
+		series.meta = &groupedSeriesMeta{maxSize: maxSize}
+	}
+
+	return outputTable, nil
+}
+
+// newRowIndexSeries creates a series containing the index of each row of the table
+func newRowIndexSeries(table *Table) *Series {
+	return &Series{
+		typ:  typeInt,
+		meta: newExtendSeriesMeta(table.series, false),
+		read: func(cache *seriesIterCache) iterator {
+			source := &readRow{iteratorCache: cache}
+			source.fill(table.series)
+			return &rowIndexIter{index: -1}
+		},
+	}
+}
+
+type rowIndexIter struct {
+	index int
+}
+
+func (i *rowIndexIter) Next() bool {
+	i.index++
+	return true
+}
+
+func (i *rowIndexIter) Value() interface{} { return i.index }
+
+// groupState stores the common state of grouped table series iterators
+type groupState struct {
+	numKeys     int              // number of the key columns (including window)
+	aggOffset   int              // index of the first aggregated column in the intermediate table
+	aggColumns  []Column         // aggregate columns of the output table
+	newReducers []func() Reducer // reducer constructors, one per aggregate column
+
+	intermediate *tableIterator // iterator over the intermediate sorted table
+	index        Index          // iterator index
+	exhausted    bool           // true if the intermediate table has been read to the end
+
+	currRow []interface{} // current row of the output table: |key1|...|keyN|agg1|...|aggM|
+	nextRow []interface{} // first intermediate row of the next group
+}
+
+func newGroupState(intermediate *Table, numKeys int, aggOffset int, aggColumns []Column, newReducers []func() Reducer) *groupState {
+	return &groupState{
+		numKeys:      numKeys,
+		aggOffset:    aggOffset,
+		aggColumns:   aggColumns,
+		newReducers:  newReducers,
+		intermediate: newTableIterator(intermediate.series),
+		index:        -1,
+	}
+}
+
+// nextIntermediateRow reads a row from the intermediate table, or returns nil at the end
+func (gs *groupState) nextIntermediateRow() []interface{} {
+	if gs.exhausted || !gs.intermediate.Next() {
+		gs.exhausted = true
+		return nil
+	}
+	return gs.intermediate.rawValue()
+}
+
+func (gs *groupState) advance() {
+	// cleaning the current row
+	gs.currRow = nil
+
+	row := gs.nextRow
+	gs.nextRow = nil
+	if row == nil {
+		row = gs.nextIntermediateRow()
+	}
+	if row == nil {
+		return
+	}
+
+	key := row[:gs.numKeys]
+	reducers := make([]Reducer, len(gs.newReducers))
+	for i, newReducer := range gs.newReducers {
+		reducers[i] = newReducer()
+	}
+
+	// feeding reducers until the intermediate table iterator reaches a new key
+	for row != nil {
+		if !gs.keysEqual(key, row[:gs.numKeys]) {
+			gs.nextRow = row
+			break
+		}
+		for i, r := range reducers {
+			if v := row[gs.aggOffset+i]; v != nil {
+				r.Add(v)
+			}
+		}
+		row = gs.nextIntermediateRow()
+	}
+
+	gs.currRow = make([]interface{}, gs.numKeys+len(reducers))
+	copy(gs.currRow, key)
+	for i, r := range reducers {
+		gs.currRow[gs.numKeys+i] = convertResult(r.Result(), gs.aggColumns[i].Type)
+	}
+	gs.index++
+}
+
+// keysEqual compares two keys
+func (gs *groupState) keysEqual(keys1 []interface{}, keys2 []interface{}) bool {
+	return reflect.DeepEqual(keys1, keys2)
+}
+
+// convertResult converts a reducer result to the aggregate column type where possible
+func convertResult(value interface{}, typ reflect.Type) interface{} {
+	if value == nil {
+		return nil
+	}
+	v := reflect.ValueOf(value)
+	if v.Type() != typ && v.Type().ConvertibleTo(typ) {
+		return v.Convert(typ).Interface()
+	}
+	return value
+}
+
+type groupIter struct {
+	commonState *groupState
+	pos         Index
+	colIndex    int // column index in the grouped table
+}
+
+// Next advances the shared state unless another iterator already has.
+func (iter *groupIter) Next() bool {
+	// see if we need to discard the current shared state
+	retain := iter.pos != iter.commonState.index
+	if !retain {
+		iter.commonState.advance()
+		iter.pos = iter.commonState.index
+	}
+	return iter.commonState.currRow != nil
+}
+
+// Value reads the cached column value
+func (iter *groupIter) Value() interface{} {
+	return iter.commonState.currRow[iter.colIndex]
+}
+
+// metadata for grouped table series
+type groupedSeriesMeta struct {
+	// the number of groups is unknown until the source table has been read
+	maxSize int
+}
+
+func (m *groupedSeriesMeta) IsMaterialized() bool { return false }
+func (m *groupedSeriesMeta) ExactSize() int       { return -1 }
+func (m *groupedSeriesMeta) MaxSize() int         { return m.maxSize }
@@ -0,0 +1,46 @@
+// Package fileschema contains schema conversion logic shared by the file
+// format subpackages of data.
+package fileschema
+
+import (
+	"github.com/antha-lang/antha/antha/anthalib/data"
+)
+
+// IsSelected determines whether to read a column from a file
+func IsSelected(name data.ColumnName, columnNames []data.ColumnName) bool {
+	// if no column names are specified by user, then reading all columns
+	if len(columnNames) == 0 {
+		return true
+	}
+	// otherwise, looking for this column name in the user-defined list
+	for _, columnName := range columnNames {
+		if name == columnName {
+			return true
+		}
+	}
+	return false
+}
+
+// Select builds a schema from the columns stored in a file, keeping the
+// selected columns only. columnType determines the type of the i-th file
+// column; it is only called for the selected columns.  Also returns the
+// indexes of the selected columns in the file.
+func Select(names []data.ColumnName, columnNames []data.ColumnName, columnType func(i int) (data.Column, error)) (*data.Schema, []int, error) {
+	columns := []data.Column{}
+	indexes := []int{}
+	for i, name := range names {
+		if !IsSelected(name, columnNames) {
+			continue
+		}
+
+		column, err := columnType(i)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		columns = append(columns, column)
+		indexes = append(indexes, i)
+	}
+
+	return data.NewSchema(columns), indexes, nil
+}
@@ -38,6 +38,18 @@ func (js *JoinSelection) commonCols(t *Table) []ColumnName {
 	return common
 }
 
+// NaturalRightOuter performs right outer join between js.t and t by columns having the same names.
+func (js *JoinSelection) NaturalRightOuter(t *Table) (*Table, error) {
+	cols := js.commonCols(t)
+	return hashJoin(js.t, cols, t, cols, rightOuter)
+}
+
+// NaturalFullOuter performs full outer join between js.t and t by columns having the same names.
+func (js *JoinSelection) NaturalFullOuter(t *Table) (*Table, error) {
+	cols := js.commonCols(t)
+	return hashJoin(js.t, cols, t, cols, fullOuter)
+}
+
 // On sets left table columns for a join.
 func (js *JoinSelection) On(cols ...ColumnName) *JoinOn {
 	return &JoinOn{t: js.t, cols: cols}
@@ -59,11 +71,24 @@ func (jo *JoinOn) LeftOuter(t *Table, cols ...ColumnName) (*Table, error) {
 	return hashJoin(jo.t, jo.cols, t, cols, leftOuter)
 }
 
+// RightOuter sets a right table and columns for right outer join and performs the join itself.
+// Right table rows without a match in the left table follow the matched rows, in the right table order.
+func (jo *JoinOn) RightOuter(t *Table, cols ...ColumnName) (*Table, error) {
+	return hashJoin(jo.t, jo.cols, t, cols, rightOuter)
+}
+
+// FullOuter sets a right table and columns for full outer join and performs the join itself.
+// Right table rows without a match in the left table follow the other rows, in the right table order.
+func (jo *JoinOn) FullOuter(t *Table, cols ...ColumnName) (*Table, error) {
+	return hashJoin(jo.t, jo.cols, t, cols, fullOuter)
+}
+
 /*
  * join internals
  */
 
-// Hash join itself. Currently only inner and left outer joins are supported.
+// Hash join itself. Since all columns are nullable, the columns of the joint table have the same types
+// as the input columns; a row without a match in the other table is completed with nulls.
 func hashJoin(left *Table, leftCols []ColumnName, right *Table, rightCols []ColumnName, typ joinType) (*Table, error) {
 	jq := &joinQuery{
 		left:  joinQueryTable{t: left, cols: leftCols},
@@ -126,10 +151,20 @@ type joinType int
 const (
 	inner joinType = iota
 	leftOuter
-	//rightOuter  - not implemented yet
-	//fullOuter - not implemented yet
+	rightOuter
+	fullOuter
 )
 
+// keepsUnmatchedLeft is true if the left table rows without a match are present in the joint table
+func (typ joinType) keepsUnmatchedLeft() bool {
+	return typ == leftOuter || typ == fullOuter
+}
+
+// keepsUnmatchedRight is true if the right table rows without a match are present in the joint table
+func (typ joinType) keepsUnmatchedRight() bool {
+	return typ == rightOuter || typ == fullOuter
+}
+
 // check checks input tables and columns correctness
 func (jq *joinQuery) check() error {
 	// checking the left table data
@@ -179,8 +214,11 @@ func (jqt *joinQueryTable) check() error {
 func (jq *joinQuery) newJointTable() *Table {
 	// joint table contains all columns of both tables
 	columns := append(append([]Column{}, jq.left.t.schema.Columns...), jq.right.t.schema.Columns...)
-	// current implementation of join preserves the left table sort key
-	key := jq.left.t.sortKey
+	// current implementation of join preserves the left table sort key, unless unmatched right rows are appended
+	var key Key
+	if !jq.typ.keepsUnmatchedRight() {
+		key = jq.left.t.sortKey
+	}
 
 	return newFromSchema(NewSchema(columns), key...)
 }
@@ -190,7 +228,14 @@ func (jq *joinQuery) newJointSeriesMeta() *jointSeriesMeta {
 	// calculating resulting series max size
 	_, leftMaxSize, _ := seriesSize(jq.left.t.series)
 	_, rightMaxSize, _ := seriesSize(jq.right.t.series)
-	return &jointSeriesMeta{maxSize: leftMaxSize * rightMaxSize}
+	maxSize := leftMaxSize * rightMaxSize
+	if jq.typ.keepsUnmatchedLeft() {
+		maxSize += leftMaxSize
+	}
+	if jq.typ.keepsUnmatchedRight() {
+		maxSize += rightMaxSize
+	}
+	return &jointSeriesMeta{maxSize: maxSize}
 }
 
 // joinState stores the common state of the joined table series iterators
@@ -203,9 +248,12 @@ type joinState struct {
 	leftProjection projection     // projection to calculate left index value
 	leftKey        reflect.Value  // current left key
 
-	right      indexMap // the right (presumably small) table in the form of a reflectively created index: indexKey -> []row (rows with such key)
-	rightRows  []raw    // the right table rows corresponding to the current left table row
-	rightIndex Index
+	right        indexMap // the right (presumably small) table in the form of a reflectively created index: indexKey -> []int (numbers of rows with such key)
+	rightTable   []raw    // the right table rows
+	rightMatched []bool   // whether each right table row has been matched by some left table row
+	rightRows    []int    // numbers of the right table rows corresponding to the current left table row
+	rightIndex   Index
+	leftDone     bool // the left table is exhausted, so unmatched right table rows are being returned
 
 	currRow raw // current row of the output table: |leftCol1|...|leftColN|rightCol1|...|rightColM|
 	next    bool
@@ -214,7 +262,7 @@ type joinState struct {
 
 func (jq *joinQuery) newJoinState(indexKeyType reflect.Type) *joinState {
 	// indexing the right table
-	rightIndex := indexTable(jq.right.t, jq.right.cols, indexKeyType)
+	rightIndex, rightTable := indexTable(jq.right.t, jq.right.cols, indexKeyType)
 
 	leftColsNum := len(jq.left.t.schema.Columns)
 	rightColsNum := len(jq.right.t.schema.Columns)
@@ -227,17 +275,21 @@ func (jq *joinQuery) newJoinState(indexKeyType reflect.Type) *joinState {
 		leftProjection: mustNewProjection(jq.left.t.schema, jq.left.cols...),
 		leftKey:        reflect.New(indexKeyType).Elem(),
 		right:          rightIndex,
+		rightTable:     rightTable,
+		rightMatched:   make([]bool, len(rightTable)),
 		rightIndex:     -1,
 		currRow:        newRaw(leftColsNum + rightColsNum),
 		index:          -1,
 	}
 }
 
-// creates a reflective index of a table by specified key columns: key -> []raw (rows having this key)
-func indexTable(t *Table, keyCols []ColumnName, indexKeyType reflect.Type) indexMap {
+// creates a reflective index of a table by specified key columns: key -> []int (numbers of rows having this key);
+// also returns the table rows
+func indexTable(t *Table, keyCols []ColumnName, indexKeyType reflect.Type) (indexMap, []raw) {
 	keyProjection := mustNewProjection(t.schema, keyCols...)
 	iter := t.read(t.series)
-	index := newIndexMap(indexKeyType, reflect.TypeOf([]raw{}))
+	index := newIndexMap(indexKeyType, reflect.TypeOf([]int{}))
+	rows := []raw{}
 	key := reflect.New(indexKeyType).Elem() // creating the reflective key once in order not to call reflect.New multiple times
 	for iter.Next() {
 		// load a row
@@ -247,29 +299,39 @@ func indexTable(t *Table, keyCols []ColumnName, indexKeyType reflect.Type) index
 		// load key column values into the reflective key
 		loadIndexKeyFromRow(rowProj, key)
 		// adding the row to the index
-		var keyRows []raw // rows having this key
+		var keyRows []int // rows having this key
 		if keyRowsValue, ok := index.lookup(key); ok {
-			keyRows = keyRowsValue.Interface().([]raw)
+			keyRows = keyRowsValue.Interface().([]int)
 		}
-		keyRows = append(keyRows, row)
+		keyRows = append(keyRows, len(rows))
 		index.set(key, reflect.ValueOf(keyRows))
+		rows = append(rows, row)
 	}
-	return index
+	return index, rows
 }
 
 func (js *joinState) advance() {
 	for {
+		// the left table is exhausted => return unmatched right rows, if required
+		if js.leftDone {
+			js.advanceUnmatchedRight()
+			return
+		}
+
 		// advance rightRows if possible
 		if js.rightIndex+1 < Index(len(js.rightRows)) {
 			js.rightIndex++
-			js.setRightRow(js.rightRows[js.rightIndex])
+			rowNum := js.rightRows[js.rightIndex]
+			js.rightMatched[rowNum] = true
+			js.setRightRow(js.rightTable[rowNum])
 			return
 		}
 
 		// rightRows is exhausted => advance left table if possible
 		if !js.left.Next() {
-			js.next = false
-			return
+			js.leftDone = true
+			js.rightIndex = -1
+			continue
 		}
 
 		// fetch left row
@@ -280,20 +342,35 @@ func (js *joinState) advance() {
 		leftRowProj := leftRow.project(js.leftProjection)
 		loadIndexKeyFromRow(leftRowProj, js.leftKey) // load current key from left row
 		if rightRawsValue, ok := js.right.lookup(js.leftKey); ok {
-			js.rightRows = rightRawsValue.Interface().([]raw)
+			js.rightRows = rightRawsValue.Interface().([]int)
 		} else {
 			js.rightRows = nil
 		}
 		js.rightIndex = -1
 
-		// in case of left join, return empty rightRow if rightRows is empty
-		if js.jq.typ == leftOuter && len(js.rightRows) == 0 {
+		// in case of left or full join, return empty rightRow if rightRows is empty
+		if js.jq.typ.keepsUnmatchedLeft() && len(js.rightRows) == 0 {
 			js.setRightRow(newRaw(js.rightColsNum))
 			return
 		}
 	}
 }
 
+// advanceUnmatchedRight finds the next right table row which has not been matched, and returns it with an empty left row
+func (js *joinState) advanceUnmatchedRight() {
+	if js.jq.typ.keepsUnmatchedRight() {
+		for js.rightIndex+1 < Index(len(js.rightTable)) {
+			js.rightIndex++
+			if !js.rightMatched[js.rightIndex] {
+				copy(js.currRow[:js.leftColsNum], newRaw(js.leftColsNum))
+				js.setRightRow(js.rightTable[js.rightIndex])
+				return
+			}
+		}
+	}
+	js.next = false
+}
+
 // setRightRow sets the right row data and indicates that the next value is fetched
 func (js *joinState) setRightRow(rightRow raw) {
 	copy(js.currRow[js.leftColsNum:], rightRow)
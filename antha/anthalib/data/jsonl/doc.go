@@ -0,0 +1,27 @@
+/*
+Package jsonl provides tools for data tables serialization to/from JSON lines (newline-delimited JSON) files.
+
+Each line of a file is a JSON object, mapping column names to values:
+	{"column1name": value1_1, "column2name": value1_2, ... , "columnNname": value1_N}
+	{"column1name": value2_1, "column2name": value2_2, ... , "columnNname": value2_N}
+
+A missing key or a null value is read as null.  Nested objects and arrays are not supported.
+
+When reading, the schema can be given explicitly by the Schema option.  Otherwise it is inferred from the data:
+the columns are ordered by their first appearance, and their types are one of bool, int64 (if all the values of the
+column are integral numbers), float64 or string.  Inferring the schema needs all the lines to be read before the table
+is built, whereas with an explicit schema each line is added to the table as it is read.
+
+The following column types are currently supported:
+	bool
+	int64
+	int
+	float64
+	string
+	TimestampMillis
+	TimestampMicros
+
+Timestamps are written as integers.  They can also be read from RFC 3339 strings.
+
+*/
+package jsonl
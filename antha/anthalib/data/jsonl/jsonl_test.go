@@ -0,0 +1,155 @@
+package jsonl
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/antha-lang/antha/antha/anthalib/data"
+	"github.com/pkg/errors"
+)
+
+func TestJSONL(t *testing.T) {
+	// create a Table
+	table := data.NewTable(
+		data.Must().NewSeriesFromSlice("bool_column", []bool{true, true, false, false, true}, nil),
+		data.Must().NewSeriesFromSlice("int64_column", []int64{10, 10, 30, -1, 5}, []bool{true, true, true, false, true}),
+		data.Must().NewSeriesFromSlice("int_column", []int{10, 10, 30, -1, 5}, []bool{true, true, true, false, true}),
+		data.Must().NewSeriesFromSlice("float64_column", []float64{1.5, 2.5, 3.5, 0, 5.5}, []bool{true, true, true, false, true}),
+		data.Must().NewSeriesFromSlice("string_column", []string{"aa", "bb", "x\"x", "", "cc"}, []bool{true, true, true, false, true}),
+		data.Must().NewSeriesFromSlice("timestamp_millis_column", []data.TimestampMillis{1, 2, 3, 4, 5}, nil),
+		data.Must().NewSeriesFromSlice("timestamp_micros_column", []data.TimestampMicros{1000, 2000, 3000, 4000, 5000}, nil),
+	)
+	schema := Schema(table.Schema().Columns...)
+
+	// file: read + write
+	fileName := jsonlFileName(t)
+	defer os.Remove(fileName)
+
+	if err := TableToFile(table, fileName); err != nil {
+		t.Errorf("write table: %s", err)
+	}
+
+	readTable, err := TableFromFile(fileName, schema)
+	if err != nil {
+		t.Errorf("read table: %s", err)
+	}
+
+	assertEqual(t, table, readTable, "tables are different after serialization to file")
+
+	// bytes: write + read
+	blob, err := TableToBytes(table)
+	if err != nil {
+		t.Errorf("TableToBytes: %s", err)
+	}
+
+	readTable, err = TableFromBytes(blob, schema)
+	if err != nil {
+		t.Errorf("TableFromBytes: %s", err)
+	}
+
+	assertEqual(t, table, readTable, "tables are different after serialization to a memory buffer")
+
+	// write to io.Writer + read a subset of columns from io.Reader
+	buffer := bytes.NewBuffer(nil)
+
+	if err := TableToWriter(table, buffer); err != nil {
+		t.Errorf("TableToWriter: %s", err)
+	}
+
+	readTable, err = TableFromReader(buffer, schema, Columns("int_column", "string_column"))
+	if err != nil {
+		t.Errorf("TableFromReader: %s", err)
+	}
+
+	assertEqual(t, table.Must().Project("int_column", "string_column"), readTable, "tables are different after serialization to io.Writer")
+}
+
+func TestJSONLInferSchema(t *testing.T) {
+	blob := []byte(`{"well": "A1", "od": 1, "ok": true, "count": 3}
+
+{"od": 0.5, "well": "B1", "count": null, "note": "bubble"}
+{"well": "C1", "ok": false, "count": -2}
+`)
+
+	readTable, err := TableFromBytes(blob)
+	if err != nil {
+		t.Fatalf("TableFromBytes: %s", err)
+	}
+
+	expected := data.NewTable(
+		data.Must().NewSeriesFromSlice("well", []string{"A1", "B1", "C1"}, nil),
+		data.Must().NewSeriesFromSlice("od", []float64{1, 0.5, 0}, []bool{true, true, false}),
+		data.Must().NewSeriesFromSlice("ok", []bool{true, false, false}, []bool{true, false, true}),
+		data.Must().NewSeriesFromSlice("count", []int64{3, 0, -2}, []bool{true, false, true}),
+		data.Must().NewSeriesFromSlice("note", []string{"", "bubble", ""}, []bool{false, true, false}),
+	)
+	assertEqual(t, expected, readTable, "inferred table")
+
+	// timestamps from RFC 3339 strings
+	readTable, err = TableFromBytes([]byte(`{"time": "1970-01-01T00:00:01.5Z"}`+"\n"+`{"time": 2500}`),
+		Schema(data.Column{Name: "time", Type: reflect.TypeOf(data.TimestampMillis(0))}))
+	if err != nil {
+		t.Fatalf("TableFromBytes: %s", err)
+	}
+	expected = data.NewTable(
+		data.Must().NewSeriesFromSlice("time", []data.TimestampMillis{1500, 2500}, nil),
+	)
+	assertEqual(t, expected, readTable, "timestamps table")
+
+	// invalid inputs
+	for _, invalid := range []string{
+		`{"a": 1}` + "\n" + `{"a": "x"}`,
+		`{"a": [1, 2]}`,
+		`[1, 2]`,
+		`{"a": 1`,
+	} {
+		if _, err := TableFromBytes([]byte(invalid)); err == nil {
+			t.Errorf("no err reading %q", invalid)
+		}
+	}
+}
+
+// a reader failing on every read
+type failingReader struct{}
+
+func (failingReader) Read([]byte) (int, error) {
+	return 0, errors.New("read past the first line")
+}
+
+func TestJSONLStreaming(t *testing.T) {
+	// with the schema given, the first line is parsed before the rest is read
+	reader := io.MultiReader(strings.NewReader(`{"a": "x"}`+"\n"), failingReader{})
+	_, err := TableFromReader(reader, Schema(data.Column{Name: "a", Type: reflect.TypeOf(int64(0))}))
+	if err == nil {
+		t.Fatal("no err reading invalid first line")
+	} else if !strings.Contains(err.Error(), "line 1") {
+		t.Errorf("expecting an error parsing line 1, got: %s", err)
+	}
+
+	// inferring the schema needs all the lines
+	reader = io.MultiReader(strings.NewReader(`{"a": 1}`+"\n"), failingReader{})
+	if _, err := TableFromReader(reader); err == nil || !strings.Contains(err.Error(), "read past the first line") {
+		t.Errorf("expecting an error reading the whole input, got: %v", err)
+	}
+}
+
+func jsonlFileName(t *testing.T) string {
+	f, err := ioutil.TempFile("", "table*.jsonl")
+	if err != nil {
+		t.Errorf("create temp file: %s", err)
+	}
+	defer f.Close() //nolint
+	return f.Name()
+}
+
+func assertEqual(t *testing.T, expected, actual *data.Table, msg string) {
+	if !actual.Equal(expected) {
+		t.Error(msg)
+		t.Log("actual", actual.Head(20).ToRows())
+	}
+}
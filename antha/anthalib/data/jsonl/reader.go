@@ -0,0 +1,269 @@
+package jsonl
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/antha-lang/antha/antha/anthalib/data"
+	"github.com/antha-lang/antha/antha/anthalib/data/internal/fileschema"
+	"github.com/pkg/errors"
+)
+
+// the maximum length of a single line of a JSON lines file
+const maxLineSize = 64 * 1024 * 1024
+
+type readState struct {
+	columnNames []data.ColumnName
+	columns     []data.Column
+}
+
+// ReadOpt sets an optional behavior when reading JSON lines files.
+type ReadOpt func(*readState) error
+
+// Columns returns a ReadOpt that selects a subset of columns to read from the
+// source. If no column names are specified, reads all the columns.
+func Columns(columnNames ...data.ColumnName) ReadOpt {
+	return func(r *readState) error {
+		r.columnNames = columnNames
+		return nil
+	}
+}
+
+// Schema returns a ReadOpt that sets the columns of the table and their
+// types, rather than inferring them from the data.  Keys of the JSON objects
+// which do not correspond to these columns are ignored.
+func Schema(columns ...data.Column) ReadOpt {
+	return func(r *readState) error {
+		for _, column := range columns {
+			if _, err := jsonTypeByReflectType(column.Type); err != nil {
+				return errors.Wrapf(err, "column '%s'", column.Name)
+			}
+		}
+		r.columns = columns
+		return nil
+	}
+}
+
+// TableFromFile reads a data.Table eagerly from a JSON lines file
+func TableFromFile(filePath string, opts ...ReadOpt) (*data.Table, error) {
+	// JSON lines file
+	file, err := os.Open(filepath.Clean(filePath))
+	if err != nil {
+		return nil, errors.Wrapf(err, "opening JSON lines file '%s'", filePath)
+	}
+	defer file.Close() //nolint
+
+	// reading a table from an io.Reader on the top of the file
+	return TableFromReader(bufio.NewReader(file), opts...)
+}
+
+// TableFromBytes reads a data.Table eagerly from a memory buffer
+func TableFromBytes(buffer []byte, opts ...ReadOpt) (*data.Table, error) {
+	// reading a table from an io.Reader on the top of a memory buffer
+	return TableFromReader(bytes.NewReader(buffer), opts...)
+}
+
+// TableFromReader reads a data.Table eagerly from io.Reader
+func TableFromReader(reader io.Reader, opts ...ReadOpt) (*data.Table, error) {
+	r := &readState{}
+	for _, o := range opts {
+		if err := o(r); err != nil {
+			return nil, errors.Wrapf(err, "ReadOpt %v generated an error state", o)
+		}
+	}
+
+	// with the schema given, records are added to the table as they are read
+	if r.columns != nil {
+		schema, err := r.readSchema(nil)
+		if err != nil {
+			return nil, errors.Wrap(err, "reading JSON lines file schema")
+		}
+		builder, err := data.NewTableBuilder(schema.Columns)
+		if err != nil {
+			return nil, err
+		}
+		if err := scanRecords(reader, func(rec *record) error {
+			return appendRecord(builder, schema, rec)
+		}); err != nil {
+			return nil, err
+		}
+		return builder.Build(), nil
+	}
+
+	// otherwise reading all the records, since the schema depends on all of them
+	records := []*record{}
+	if err := scanRecords(reader, func(rec *record) error {
+		records = append(records, rec)
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	// inferring schema
+	schema, err := r.readSchema(records)
+	if err != nil {
+		return nil, errors.Wrap(err, "reading JSON lines file schema")
+	}
+
+	// starting building a Table
+	builder, err := data.NewTableBuilder(schema.Columns)
+	if err != nil {
+		return nil, err
+	}
+
+	// parsing records into rows
+	for _, rec := range records {
+		if err := appendRecord(builder, schema, rec); err != nil {
+			return nil, err
+		}
+	}
+
+	// building a Table
+	return builder.Build(), nil
+}
+
+// parses a record into a row of the table being built
+func appendRecord(builder *data.TableBuilder, schema *data.Schema, rec *record) error {
+	row, err := parseRecord(schema, rec)
+	if err != nil {
+		return errors.Wrapf(err, "parsing JSON lines record")
+	}
+	builder.Append(row)
+	return nil
+}
+
+// a single JSON object read from a line
+type record struct {
+	line   int
+	keys   []string // keys in their order in the line
+	values map[string]interface{}
+}
+
+// reads JSON objects from the non-empty lines one at a time, passing each to fn
+func scanRecords(reader io.Reader, fn func(*record) error) error {
+	scanner := bufio.NewScanner(reader)
+	scanner.Buffer(nil, maxLineSize)
+
+	for line := 1; scanner.Scan(); line++ {
+		text := bytes.TrimSpace(scanner.Bytes())
+		if len(text) == 0 {
+			continue
+		}
+		rec, err := readRecord(text, line)
+		if err != nil {
+			return errors.Wrapf(err, "reading JSON lines record at line %d", line)
+		}
+		if err := fn(rec); err != nil {
+			return err
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return errors.Wrap(err, "reading JSON lines file")
+	}
+	return nil
+}
+
+// reads a JSON object, keeping the order of its keys
+func readRecord(text []byte, line int) (*record, error) {
+	decoder := json.NewDecoder(bytes.NewReader(text))
+	decoder.UseNumber()
+
+	if token, err := decoder.Token(); err != nil {
+		return nil, err
+	} else if token != json.Delim('{') {
+		return nil, errors.Errorf("expecting a JSON object, got %v", token)
+	}
+
+	rec := &record{line: line, values: make(map[string]interface{})}
+	for decoder.More() {
+		token, err := decoder.Token()
+		if err != nil {
+			return nil, err
+		}
+		key := token.(string) // object keys are always strings
+
+		var value interface{}
+		if err := decoder.Decode(&value); err != nil {
+			return nil, errors.Wrapf(err, "key '%s'", key)
+		}
+		switch value.(type) {
+		case map[string]interface{}, []interface{}:
+			return nil, errors.Errorf("key '%s': nested objects and arrays are not supported", key)
+		}
+
+		if _, seen := rec.values[key]; !seen {
+			rec.keys = append(rec.keys, key)
+		}
+		rec.values[key] = value
+	}
+
+	if _, err := decoder.Token(); err != nil {
+		return nil, err
+	}
+	return rec, nil
+}
+
+// reads a schema either from the read options or by inferring it from the records
+func (r *readState) readSchema(records []*record) (*data.Schema, error) {
+	var names []data.ColumnName
+	var columnType func(i int) (data.Column, error)
+
+	if r.columns != nil {
+		for _, column := range r.columns {
+			names = append(names, column.Name)
+		}
+		columnType = func(i int) (data.Column, error) {
+			return r.columns[i], nil
+		}
+	} else {
+		// the columns are ordered by their first appearance
+		seen := make(map[string]bool)
+		for _, rec := range records {
+			for _, key := range rec.keys {
+				if !seen[key] {
+					seen[key] = true
+					names = append(names, data.ColumnName(key))
+				}
+			}
+		}
+		columnType = func(i int) (data.Column, error) {
+			inferred := &inferredColumn{name: names[i]}
+			for _, rec := range records {
+				inferred.add(rec.values[string(names[i])])
+			}
+			return inferred.column()
+		}
+	}
+
+	schema, _, err := fileschema.Select(names, r.columnNames, columnType)
+	return schema, err
+}
+
+// parses column values in a single record
+func parseRecord(schema *data.Schema, rec *record) ([]interface{}, error) {
+	row := make([]interface{}, len(schema.Columns))
+	for i, column := range schema.Columns {
+		// missing keys and JSON nulls are treated as nulls
+		valueJSON := rec.values[string(column.Name)]
+		if valueJSON == nil {
+			continue
+		}
+
+		jsonType, err := jsonTypeByReflectType(column.Type)
+		if err != nil {
+			panic(err)
+		}
+
+		value, err := jsonType.parse(valueJSON)
+		if err != nil {
+			return nil, errors.Wrapf(err, "line %d, column '%s'", rec.line, column.Name)
+		}
+		row[i] = value
+	}
+
+	return row, nil
+}
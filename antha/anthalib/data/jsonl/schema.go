@@ -0,0 +1,159 @@
+package jsonl
+
+import (
+	"encoding/json"
+	"reflect"
+	"strconv"
+	"time"
+
+	"github.com/antha-lang/antha/antha/anthalib/data"
+	"github.com/pkg/errors"
+)
+
+// a list of types supported by JSON lines reader and writer
+type jsonType struct {
+	typ   reflect.Type
+	parse func(interface{}) (interface{}, error)
+}
+
+var jsonTypes = [...]jsonType{
+	{typ: reflect.TypeOf(false), parse: parseBool},
+	{typ: reflect.TypeOf(int64(0)), parse: parseInt64},
+	{typ: reflect.TypeOf(0), parse: parseInt},
+	{typ: reflect.TypeOf(float64(0)), parse: parseFloat64},
+	{typ: reflect.TypeOf(""), parse: parseString},
+	{typ: reflect.TypeOf(data.TimestampMillis(0)), parse: parseTimestampMillis},
+	{typ: reflect.TypeOf(data.TimestampMicros(0)), parse: parseTimestampMicros},
+}
+
+var byType = make(map[reflect.Type]*jsonType)
+
+func init() {
+	for i := range jsonTypes {
+		byType[jsonTypes[i].typ] = &jsonTypes[i]
+	}
+}
+
+func jsonTypeByReflectType(typ reflect.Type) (*jsonType, error) {
+	jsonType, ok := byType[typ]
+	if !ok {
+		return nil, errors.Errorf("unsupported type: %v", typ)
+	}
+	return jsonType, nil
+}
+
+// JSON values are decoded as nil, bool, json.Number or string
+
+func parseBool(v interface{}) (interface{}, error) {
+	value, ok := v.(bool)
+	if !ok {
+		return nil, errors.Errorf("expecting a boolean, got %v", v)
+	}
+	return value, nil
+}
+
+func parseInt64(v interface{}) (interface{}, error) {
+	number, ok := v.(json.Number)
+	if !ok {
+		return nil, errors.Errorf("expecting a number, got %q", v)
+	}
+	return strconv.ParseInt(string(number), 10, 64)
+}
+
+func parseInt(v interface{}) (interface{}, error) {
+	value, err := parseInt64(v)
+	if err != nil {
+		return nil, err
+	}
+	return int(value.(int64)), nil
+}
+
+func parseFloat64(v interface{}) (interface{}, error) {
+	number, ok := v.(json.Number)
+	if !ok {
+		return nil, errors.Errorf("expecting a number, got %q", v)
+	}
+	return strconv.ParseFloat(string(number), 64)
+}
+
+func parseString(v interface{}) (interface{}, error) {
+	value, ok := v.(string)
+	if !ok {
+		return nil, errors.Errorf("expecting a string, got %v", v)
+	}
+	return value, nil
+}
+
+// parses a timestamp either from an integer or from an RFC 3339 string
+func parseTimestamp(v interface{}, unit time.Duration) (int64, error) {
+	if s, ok := v.(string); ok {
+		t, err := time.Parse(time.RFC3339Nano, s)
+		if err != nil {
+			return 0, err
+		}
+		return t.UnixNano() / int64(unit), nil
+	}
+	value, err := parseInt64(v)
+	if err != nil {
+		return 0, err
+	}
+	return value.(int64), nil
+}
+
+func parseTimestampMillis(v interface{}) (interface{}, error) {
+	value, err := parseTimestamp(v, time.Millisecond)
+	if err != nil {
+		return nil, err
+	}
+	return data.TimestampMillis(value), nil
+}
+
+func parseTimestampMicros(v interface{}) (interface{}, error) {
+	value, err := parseTimestamp(v, time.Microsecond)
+	if err != nil {
+		return nil, err
+	}
+	return data.TimestampMicros(value), nil
+}
+
+// inferredColumn accumulates the kinds of values seen in a column in order to infer its type
+type inferredColumn struct {
+	name     data.ColumnName
+	hasBool  bool
+	hasInt   bool
+	hasFloat bool
+	hasStr   bool
+}
+
+func (c *inferredColumn) add(v interface{}) {
+	switch value := v.(type) {
+	case bool:
+		c.hasBool = true
+	case string:
+		c.hasStr = true
+	case json.Number:
+		if _, err := strconv.ParseInt(string(value), 10, 64); err == nil {
+			c.hasInt = true
+		} else {
+			c.hasFloat = true
+		}
+	}
+}
+
+func (c *inferredColumn) column() (data.Column, error) {
+	var typ reflect.Type
+	switch {
+	case c.hasBool && !c.hasInt && !c.hasFloat && !c.hasStr:
+		typ = reflect.TypeOf(false)
+	case c.hasInt && !c.hasBool && !c.hasFloat && !c.hasStr:
+		typ = reflect.TypeOf(int64(0))
+	case c.hasFloat && !c.hasBool && !c.hasStr:
+		typ = reflect.TypeOf(float64(0))
+	case !c.hasBool && !c.hasInt && !c.hasFloat:
+		// strings, or nulls only
+		typ = reflect.TypeOf("")
+	default:
+		return data.Column{}, errors.Errorf("column '%s' has values of different types", c.name)
+	}
+	return data.Column{Name: c.name, Type: typ}, nil
+}
@@ -0,0 +1,118 @@
+package jsonl
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io"
+	"os"
+	"reflect"
+
+	"github.com/antha-lang/antha/antha/anthalib/data"
+	"github.com/pkg/errors"
+)
+
+// TableToFile writes a data.Table to a JSON lines file
+func TableToFile(table *data.Table, filePath string) error {
+	// JSON lines file
+	file, err := os.Create(filePath)
+	if err != nil {
+		return errors.Wrapf(err, "creating JSON lines file '%s'", filePath)
+	}
+	defer file.Close() //nolint
+
+	// writing the table
+	writer := bufio.NewWriter(file)
+	if err := TableToWriter(table, writer); err != nil {
+		return err
+	}
+	return writer.Flush()
+}
+
+// TableToBytes writes a data.Table to a memory buffer
+func TableToBytes(table *data.Table) ([]byte, error) {
+	// a memory buffer writer
+	buffer := bytes.NewBuffer(nil)
+
+	// writing the table
+	if err := TableToWriter(table, buffer); err != nil {
+		return nil, err
+	}
+	return buffer.Bytes(), nil
+}
+
+// TableToWriter writes a data.Table to io.Writer
+func TableToWriter(table *data.Table, writer io.Writer) error {
+	// checking the column types and encoding column names once
+	schema := table.Schema()
+	keys := make([][]byte, len(schema.Columns))
+	for i, column := range schema.Columns {
+		if _, err := jsonTypeByReflectType(column.Type); err != nil {
+			return errors.Wrapf(err, "column %d", i)
+		}
+		key, err := json.Marshal(string(column.Name))
+		if err != nil {
+			return err
+		}
+		keys[i] = key
+	}
+
+	rows, done := table.Iter()
+	defer done()
+
+	// writing data
+	var line bytes.Buffer
+	for lineNum := 1; ; lineNum++ {
+		// reading a row from the table
+		row, ok := <-rows
+		if !ok {
+			break
+		}
+
+		// making a JSON object
+		line.Reset()
+		if err := writeRecord(&line, keys, row); err != nil {
+			return errors.Wrapf(err, "making a JSON lines record at line %d", lineNum)
+		}
+
+		// writing to buffer
+		if _, err := writer.Write(line.Bytes()); err != nil {
+			return errors.Wrapf(err, "writing a JSON lines record at line %d", lineNum)
+		}
+	}
+
+	return nil
+}
+
+// writes a row as a JSON object in the form `{"columnName1":value1,"columnName2":value2...}` followed by a newline
+func writeRecord(buffer *bytes.Buffer, keys [][]byte, row data.Row) error {
+	buffer.WriteByte('{')
+	for i, value := range row.Values() {
+		if i > 0 {
+			buffer.WriteByte(',')
+		}
+		buffer.Write(keys[i])
+		buffer.WriteByte(':')
+
+		valueJSON, err := marshalValue(value.Interface())
+		if err != nil {
+			return errors.Wrapf(err, "column %d", i)
+		}
+		buffer.Write(valueJSON)
+	}
+	buffer.WriteString("}\n")
+	return nil
+}
+
+func marshalValue(value interface{}) ([]byte, error) {
+	switch value.(type) {
+	case nil:
+		return []byte("null"), nil
+	case data.TimestampMillis, data.TimestampMicros:
+		// timestamps are written as integers
+		return json.Marshal(reflect.ValueOf(value).Int())
+	default:
+		// fails for NaN and infinite floats, which have no JSON representation
+		return json.Marshal(value)
+	}
+}
@@ -0,0 +1,197 @@
+package data
+
+import (
+	"github.com/pkg/errors"
+)
+
+/*
+ * melt internals
+ */
+
+func meltTable(table *Table, idCols []ColumnName, valueCols []ColumnName, varName ColumnName, valueName ColumnName) (*Table, error) {
+	// by default, all non-id columns are value columns
+	if len(valueCols) == 0 {
+		for _, series := range table.ProjectAllBut(idCols...).series {
+			valueCols = append(valueCols, series.col)
+		}
+	}
+
+	// checking columns assertions
+	if err := checkMeltColumns(&table.schema, idCols, valueCols, varName, valueName); err != nil {
+		return nil, err
+	}
+
+	// melted table columns: id columns, variable column and value column
+	columns := make([]Column, 0, len(idCols)+2)
+	for _, id := range idCols {
+		columns = append(columns, table.schema.MustCol(id))
+	}
+	columns = append(columns,
+		Column{Name: varName, Type: typeString},
+		Column{Name: valueName, Type: table.schema.MustCol(valueCols[0]).Type},
+	)
+
+	// the melted table preserves the part of the source table sort key which consists of id columns
+	outputTable := newFromSchema(NewSchema(columns), meltedKey(table.sortKey, idCols)...)
+
+	group := &iterGroup{func() interface{} {
+		return newMeltState(table, idCols, valueCols)
+	}}
+
+	// an iterator creation function (cannot place this code directly into the loop below because then it will capture loop variable 'i' by reference)
+	wrap := func(colIndex int) func(cache *seriesIterCache) iterator {
+		return func(cache *seriesIterCache) iterator {
+			iter := &meltIter{colIndex: colIndex, commonState: cache.EnsureGroup(group).(*meltState)}
+			iter.pos = iter.commonState.index
+			return iter
+		}
+	}
+
+	// each source row produces exactly one melted row per value column
+	meta := newMeltedSeriesMeta(table.series, len(valueCols))
+	for i, series := range outputTable.series {
+		series.read = wrap(i)
+		series.meta = meta
+	}
+
+	return outputTable, nil
+}
+
+// checkMeltColumns checks melt column assertions
+func checkMeltColumns(schema *Schema, idCols []ColumnName, valueCols []ColumnName, varName ColumnName, valueName ColumnName) error {
+	if err := schema.CheckColumnsExist(idCols...); err != nil {
+		return errors.Wrap(err, "melt id columns lookup")
+	}
+	if err := schema.CheckColumnsExist(valueCols...); err != nil {
+		return errors.Wrap(err, "melt value columns lookup")
+	}
+	if len(valueCols) == 0 {
+		return errors.New("no value columns to melt")
+	}
+
+	// the melted table consists of the id columns, the variable column and the value column, so their names must differ
+	isID := map[ColumnName]bool{}
+	for _, id := range idCols {
+		isID[id] = true
+	}
+	for _, col := range valueCols {
+		if isID[col] {
+			return errors.Errorf("melt column '%s' is both an id column and a value column", col)
+		}
+	}
+	if varName == valueName {
+		return errors.Errorf("melt variable and value columns are both named '%s'", varName)
+	}
+	for _, name := range []ColumnName{varName, valueName} {
+		if isID[name] {
+			return errors.Errorf("melt output column '%s' has the same name as an id column", name)
+		}
+	}
+
+	// all value columns go into the same output column, so they must be of the same type
+	valueType := schema.MustCol(valueCols[0]).Type
+	for _, col := range valueCols[1:] {
+		if typ := schema.MustCol(col).Type; !typesEqual(typ, valueType) {
+			return errors.Errorf("melt value column '%s' is of type %v, while column '%s' is of type %v", col, typ, valueCols[0], valueType)
+		}
+	}
+	return nil
+}
+
+// meltedKey returns the longest prefix of key which consists of id columns
+func meltedKey(key Key, idCols []ColumnName) Key {
+	isID := map[ColumnName]bool{}
+	for _, id := range idCols {
+		isID[id] = true
+	}
+	var melted Key
+	for _, k := range key {
+		if !isID[k.Column] {
+			break
+		}
+		melted = append(melted, k)
+	}
+	return melted
+}
+
+// meltState stores the common state of melted table series iterators
+type meltState struct {
+	numIds    int          // number of the id columns
+	varNames  []ColumnName // value column names
+	source    *tableIterator
+	projected projection // projection of the source table onto |id1|...|idN|value1|...|valueM|
+
+	sourceRow raw   // current row of the source table, projected
+	varIndex  int   // index of the current value column
+	index     Index // current index of the output table
+
+	currRow []interface{} // current row of the output table: |id1|...|idN|variable|value|
+}
+
+func newMeltState(table *Table, idCols []ColumnName, valueCols []ColumnName) *meltState {
+	return &meltState{
+		numIds:    len(idCols),
+		varNames:  valueCols,
+		source:    newTableIterator(table.series),
+		projected: mustNewProjection(table.schema, append(append([]ColumnName{}, idCols...), valueCols...)...),
+		varIndex:  len(valueCols),
+		index:     -1,
+	}
+}
+
+func (ms *meltState) advance() {
+	ms.currRow = nil
+
+	// the current source row is exhausted => fetching the next one
+	if ms.varIndex+1 >= len(ms.varNames) {
+		if !ms.source.Next() {
+			return
+		}
+		ms.sourceRow = ms.source.rawValue().project(ms.projected)
+		ms.varIndex = -1
+	}
+	ms.varIndex++
+
+	ms.currRow = make([]interface{}, ms.numIds+2)
+	copy(ms.currRow, ms.sourceRow[:ms.numIds])
+	ms.currRow[ms.numIds] = string(ms.varNames[ms.varIndex])
+	ms.currRow[ms.numIds+1] = ms.sourceRow[ms.numIds+ms.varIndex]
+	ms.index++
+}
+
+type meltIter struct {
+	commonState *meltState
+	pos         Index
+	colIndex    int // column index in the melted table
+}
+
+// Next advances the shared state unless another iterator already has.
+func (iter *meltIter) Next() bool {
+	// see if we need to discard the current shared state
+	retain := iter.pos != iter.commonState.index
+	if !retain {
+		iter.commonState.advance()
+		iter.pos = iter.commonState.index
+	}
+	return iter.commonState.currRow != nil
+}
+
+// Value reads the cached column value
+func (iter *meltIter) Value() interface{} {
+	return iter.commonState.currRow[iter.colIndex]
+}
+
+// newMeltedSeriesMeta creates metadata for melted table series: the source table sizes multiplied by the number of value columns
+func newMeltedSeriesMeta(series []*Series, numValueCols int) seriesMeta {
+	m := &combinedSeriesMeta{isMaterialized: false}
+	if isBounded(series) {
+		b := &boundedCombinedSeriesMeta{combinedSeriesMeta: m}
+		b.exact, b.max, _ = seriesSize(series)
+		if b.exact >= 0 {
+			b.exact *= numValueCols
+		}
+		b.max *= numValueCols
+		return b
+	}
+	return m
+}
@@ -88,6 +88,13 @@ func (m MustTable) SortByFunc(f SortFunc) *Table {
 	return t
 }
 
+// Melt panics unless *Table.Melt.
+func (m MustTable) Melt(idCols []ColumnName, valueCols []ColumnName, varName ColumnName, valueName ColumnName) *Table {
+	t, err := m.Table.Melt(idCols, valueCols, varName, valueName)
+	handle(err)
+	return t
+}
+
 // Filter
 
 // Filter returns a proxy for *Table.Filter.
@@ -242,6 +249,35 @@ func (mpk *MustPivotKey) Columns(pivot ColumnName, value ColumnName) *Table {
 	return table
 }
 
+// GroupBy
+
+// GroupBy returns a proxy for *Table.GroupBy.
+func (m MustTable) GroupBy(key ...ColumnName) *MustGroupBySelection {
+	return &MustGroupBySelection{m.Table.GroupBy(key...)}
+}
+
+// Window returns a proxy for *Table.Window.
+func (m MustTable) Window(spec WindowSpec) *MustGroupBySelection {
+	return &MustGroupBySelection{m.Table.Window(spec)}
+}
+
+// MustGroupBySelection panics on any error when creating derived tables.
+type MustGroupBySelection struct {
+	*GroupBySelection
+}
+
+// Window returns a proxy for GroupBySelection.Window.
+func (s *MustGroupBySelection) Window(spec WindowSpec) *MustGroupBySelection {
+	return &MustGroupBySelection{s.GroupBySelection.Window(spec)}
+}
+
+// Aggregate panics on any error when creating derived tables.
+func (s *MustGroupBySelection) Aggregate(aggs ...Aggregation) *Table {
+	table, err := s.GroupBySelection.Aggregate(aggs...)
+	handle(err)
+	return table
+}
+
 // Join
 
 // Join returns a proxy for *Table.Join.
@@ -268,6 +304,20 @@ func (js *MustJoinSelection) NaturalLeftOuter(t *Table) *Table {
 	return table
 }
 
+// NaturalRightOuter performs right outer join between js.t and t by columns having the same names.
+func (js *MustJoinSelection) NaturalRightOuter(t *Table) *Table {
+	table, err := js.js.NaturalRightOuter(t)
+	handle(err)
+	return table
+}
+
+// NaturalFullOuter performs full outer join between js.t and t by columns having the same names.
+func (js *MustJoinSelection) NaturalFullOuter(t *Table) *Table {
+	table, err := js.js.NaturalFullOuter(t)
+	handle(err)
+	return table
+}
+
 // On sets left table columns for a join.
 func (js *MustJoinSelection) On(cols ...ColumnName) *MustJoinOn {
 	return &MustJoinOn{jo: js.js.On(cols...)}
@@ -292,6 +342,20 @@ func (jo *MustJoinOn) LeftOuter(t *Table, cols ...ColumnName) *Table {
 	return table
 }
 
+// RightOuter sets a right table and columns for right outer join and performs the join itself.
+func (jo *MustJoinOn) RightOuter(t *Table, cols ...ColumnName) *Table {
+	table, err := jo.jo.RightOuter(t, cols...)
+	handle(err)
+	return table
+}
+
+// FullOuter sets a right table and columns for full outer join and performs the join itself.
+func (jo *MustJoinOn) FullOuter(t *Table, cols ...ColumnName) *Table {
+	table, err := jo.jo.FullOuter(t, cols...)
+	handle(err)
+	return table
+}
+
 // Append
 
 // Append panics unless Table.Append.
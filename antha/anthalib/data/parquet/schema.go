@@ -7,6 +7,7 @@ import (
 	"strings"
 
 	"github.com/antha-lang/antha/antha/anthalib/data"
+	"github.com/antha-lang/antha/antha/anthalib/data/internal/fileschema"
 	"github.com/pkg/errors"
 	"github.com/xitongsys/parquet-go/parquet"
 )
@@ -183,42 +184,19 @@ func schemaFromParquetMetadata(metadata *parquet.FileMetaData, columnNames []dat
 	}
 
 	// 2) list of other elements, each of them corresponds to one column
-	columns := []data.Column{}
-	for i := 0; i < columnsCount; i++ {
-		element := schema[i+1]
-		name := data.ColumnName(element.Name)
-
-		if !doAddColumn(name, columnNames) {
-			continue
-		}
-
-		columnType, err := columnTypeFromSchemaElement(element)
-		if err != nil {
-			return nil, err
-		}
-
-		columns = append(columns, data.Column{
-			Name: name,
-			Type: columnType,
-		})
+	names := make([]data.ColumnName, columnsCount)
+	for i := range names {
+		names[i] = data.ColumnName(schema[i+1].Name)
 	}
-
-	return &parquetSchema{data.NewSchema(columns), schema[0].Name}, nil
-}
-
-// determines whether to read the column from Parquet
-func doAddColumn(name data.ColumnName, columnNames []data.ColumnName) bool {
-	// if no column names are specified by user, then reading all columns
-	if len(columnNames) == 0 {
-		return true
-	}
-	// otherwise, looking for this column name in the user-defined list
-	for _, columnName := range columnNames {
-		if name == columnName {
-			return true
-		}
+	tableSchema, _, err := fileschema.Select(names, columnNames, func(i int) (data.Column, error) {
+		columnType, err := columnTypeFromSchemaElement(schema[i+1])
+		return data.Column{Name: names[i], Type: columnType}, err
+	})
+	if err != nil {
+		return nil, err
 	}
-	return false
+
+	return &parquetSchema{tableSchema, schema[0].Name}, nil
 }
 
 // nolint
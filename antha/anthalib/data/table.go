@@ -406,6 +406,65 @@ func (t *Table) Pivot() *PivotSelection {
 	return &PivotSelection{table: t}
 }
 
+// Melt is the inverse of Pivot: it takes a "wide" table, for instance:
+//
+//  | |     Id|  column1|  column2|
+//  | | idType|valueType|valueType|
+//  -------------------------------
+//  |1|    id1|   value1|   value2|
+//  |2|    id2|   value3|    <nil>|
+//
+// and transforms it into a "narrow" table, for instance (with varName = "Variable" and valueName = "Value"):
+//
+//  | |     Id| Variable|    Value|
+//  | | idType|   string|valueType|
+//  -------------------------------
+//  |1|    id1|"column1"|   value1|
+//  |2|    id1|"column2"|   value2|
+//  |3|    id2|"column1"|   value3|
+//  |4|    id2|"column2"|    <nil>|
+//
+// The value columns must all have the same type and must not be id columns.  varName and valueName must
+// differ from each other and from the id columns.  If no value columns are given, all the columns except
+// the id columns are melted.  Null values are preserved.
+func (t *Table) Melt(idCols []ColumnName, valueCols []ColumnName, varName ColumnName, valueName ColumnName) (*Table, error) {
+	return meltTable(t, idCols, valueCols, varName, valueName)
+}
+
+// GroupBy groups the rows of a table by the values of the key columns.  The
+// groups can then be summarized using aggregate functions, for instance
+//
+//  t.GroupBy("Key").Aggregate(data.Sum("Value"), data.Count("Value").As("N"))
+//
+// transforms the table
+//
+//  | |    Key|  Value|
+//  | |keyType|float64|
+//  ------------------
+//  |1|   key1|      1|
+//  |2|   key2|      2|
+//  |3|   key1|      3|
+//
+// into
+//
+//  | |    Key|sum(Value)|  N|
+//  | |keyType|   float64|int|
+//  --------------------------
+//  |1|   key1|         4|  2|
+//  |2|   key2|         2|  1|
+//
+// Null keys form a group of their own.  With no key columns, the whole table
+// forms a single group.
+func (t *Table) GroupBy(key ...ColumnName) *GroupBySelection {
+	return &GroupBySelection{table: t, key: key}
+}
+
+// Window groups the rows of a table by window, for instance by fixed time
+// intervals.  Equivalent to t.GroupBy().Window(spec).
+func (t *Table) Window(spec WindowSpec) *GroupBySelection {
+	return t.GroupBy().Window(spec)
+}
+
 // Join performs a "horizontal" join of two tables by some key. E.g. if the left table is
 //
 //  | |LeftKeyColumn|OtherLeftColumn|
@@ -436,6 +495,9 @@ func (t *Table) Pivot() *PivotSelection {
 //  |2|         key2| someLeftValue2|          key2| someRightValue1|
 //  |3|         key2| someLeftValue3|          key2| someRightValue1|
 //  |4|         key3| someLeftValue4|          key3| someRightValue2|
+//
+// Outer joins (LeftOuter, RightOuter and FullOuter) also keep the rows of one or both tables which have
+// no match in the other table, filling the columns of the other table with nulls.
 func (t *Table) Join() *JoinSelection {
 	return &JoinSelection{t: t}
 }
@@ -4,6 +4,7 @@ import (
 	"math"
 	"reflect"
 	"testing"
+	"time"
 )
 
 func assertEqual(t *testing.T, expected, actual *Table, msg string) {
@@ -615,6 +616,99 @@ func TestPivot(t *testing.T) {
 		assertEqual(t, noKeyPivotedReference, noKeyPivoted, "pivot no key")
 	})
 }
+func TestGroupBy(t *testing.T) {
+	runSubTests(t, func(t *testing.T, makeSeries makeSeriesType) {
+		a := NewTable(
+			makeSeries("key", []string{"b", "a", "b", "a", "c", ""}, []bool{true, true, true, true, true, false}),
+			makeSeries("int", []int64{1, 2, 3, 4, 0, 6}, []bool{true, true, true, true, false, true}),
+			makeSeries("float", []float64{1.5, 2, 0, 6, 1, 2}, []bool{true, true, false, true, true, true}),
+		)
+		if _, err := a.GroupBy("XYZ").Aggregate(Sum("int")); err == nil {
+			t.Error("no err, no such key column")
+		}
+		if _, err := a.GroupBy("key").Aggregate(Sum("XYZ")); err == nil {
+			t.Error("no err, no such aggregate column")
+		}
+		if _, err := a.GroupBy("key").Aggregate(Sum("key")); err == nil {
+			t.Error("no err, sum of string column")
+		}
+		if _, err := a.GroupBy("key").Aggregate(Sum("int"), Mean("float").As("sum(int)")); err == nil {
+			t.Error("no err, duplicate column")
+		}
+
+		grouped := a.Must().GroupBy("key").Aggregate(
+			Sum("int"),
+			Mean("float"),
+			Min("float"),
+			Max("int").As("max"),
+			Count("float"),
+			StdDev("int"),
+			First("float"),
+			Max("key"),
+		)
+		groupedReference := NewTable(
+			makeSeries("key", []string{"", "a", "b", "c"}, []bool{false, true, true, true}),
+			makeSeries("sum(int)", []float64{6, 6, 4, 0}, []bool{true, true, true, false}),
+			makeSeries("mean(float)", []float64{2, 4, 1.5, 1}, nil),
+			makeSeries("min(float)", []float64{2, 2, 1.5, 1}, nil),
+			makeSeries("max", []int64{6, 4, 3, 0}, []bool{true, true, true, false}),
+			makeSeries("count(float)", []int{1, 2, 1, 1}, nil),
+			makeSeries("stddev(int)", []float64{0, math.Sqrt2, math.Sqrt2, 0}, []bool{false, true, true, false}),
+			makeSeries("first(float)", []float64{2, 2, 1.5, 1}, nil),
+			makeSeries("max(key)", []string{"", "a", "b", "c"}, []bool{false, true, true, true}),
+		)
+		assertEqual(t, groupedReference, grouped, "group by")
+
+		// a user-defined reducer, which sees values in source order
+		concat := Reduce("key", reflect.TypeOf(""), func() Reducer {
+			return &ReduceFunc{Func: func(values []interface{}) interface{} {
+				s := ""
+				for _, v := range values {
+					s += v.(string)
+				}
+				return s
+			}}
+		}).As("keys")
+		noKey := a.Must().GroupBy().Aggregate(concat, Count("int"))
+		noKeyReference := NewTable(
+			makeSeries("keys", []string{"babac"}, nil),
+			makeSeries("count(int)", []int{5}, nil),
+		)
+		assertEqual(t, noKeyReference, noKey, "group by no key")
+	})
+}
+
+func TestWindow(t *testing.T) {
+	runSubTests(t, func(t *testing.T, makeSeries makeSeriesType) {
+		a := NewTable(
+			makeSeries("time", []TimestampMillis{-1, 1000, 1999, 0, 2500, 3000}, nil),
+			makeSeries("label", []string{"a", "a", "a", "b", "a", "b"}, nil),
+			makeSeries("value", []int64{1, 2, 3, 4, 5, 6}, nil),
+		)
+		if _, err := a.Window(&TimestampWindowSpec{Column: "label", Duration: time.Second}).Aggregate(); err == nil {
+			t.Error("no err, window column is not a timestamp")
+		}
+		if _, err := a.Window(&TimestampWindowSpec{Column: "time", Duration: time.Microsecond}).Aggregate(); err == nil {
+			t.Error("no err, window duration is not a multiple of a millisecond")
+		}
+
+		windowed := a.Must().Window(&TimestampWindowSpec{Column: "time", Duration: 2 * time.Second}).Aggregate(Sum("value"))
+		windowedReference := NewTable(
+			makeSeries("time", []TimestampMillis{-2000, 0, 2000}, nil),
+			makeSeries("sum(value)", []float64{1, 9, 11}, nil),
+		)
+		assertEqual(t, windowedReference, windowed, "window")
+
+		keyed := a.Must().GroupBy("label").Window(&TimestampWindowSpec{Column: "time", Duration: time.Second}).Aggregate(Count("value"))
+		keyedReference := NewTable(
+			makeSeries("label", []string{"a", "a", "a", "b", "b"}, nil),
+			makeSeries("time", []TimestampMillis{-1000, 1000, 2000, 0, 3000}, nil),
+			makeSeries("count(value)", []int{1, 2, 1, 1, 1}, nil),
+		)
+		assertEqual(t, keyedReference, keyed, "window with key")
+	})
+}
+
 func TestJoin(t *testing.T) {
 	runSubTests(t, func(t *testing.T, makeSeries makeSeriesType) {
 		a := NewTable(
@@ -707,6 +801,105 @@ func TestJoin(t *testing.T) {
 		)
 
 		assertEqual(t, jointReference, joint, "multiple columns left outer join")
+
+		// a single column right outer join test
+		joint = a.Must().Join().On("user").RightOuter(b, "username")
+
+		jointReference = NewTable(
+			makeSeries("user", []string{"Alice", "John", ""}, []bool{true, true, false}),
+			makeSeries("password", []string{"123", "qwerty", ""}, []bool{true, true, false}),
+			makeSeries("username", []string{"Alice", "John", "Peter"}, nil),
+			makeSeries("password", []string{"123456", "qwerty", "password"}, nil),
+		)
+
+		assertEqual(t, jointReference, joint, "single column right outer join")
+
+		// a single column full outer join test
+		joint = a.Must().Join().On("user").FullOuter(b, "username")
+
+		jointReference = NewTable(
+			makeSeries("user", []string{"Alice", "Bob", "John", ""}, []bool{true, true, true, false}),
+			makeSeries("password", []string{"123", "password", "qwerty", ""}, []bool{true, true, true, false}),
+			makeSeries("username", []string{"Alice", "", "John", "Peter"}, []bool{true, false, true, true}),
+			makeSeries("password", []string{"123456", "", "qwerty", "password"}, []bool{true, false, true, true}),
+		)
+
+		assertEqual(t, jointReference, joint, "single column full outer join")
+
+		// a natural full outer join test
+		joint = a.Must().Join().NaturalFullOuter(b)
+
+		jointReference = NewTable(
+			makeSeries("user", []string{"Alice", "Bob", "John", ""}, []bool{true, true, true, false}),
+			makeSeries("password", []string{"123", "password", "qwerty", ""}, []bool{true, true, true, false}),
+			makeSeries("username", []string{"", "Peter", "John", "Alice"}, []bool{false, true, true, true}),
+			makeSeries("password", []string{"", "password", "qwerty", "123456"}, []bool{false, true, true, true}),
+		)
+
+		assertEqual(t, jointReference, joint, "natural full outer join")
+	})
+}
+
+func TestMelt(t *testing.T) {
+	runSubTests(t, func(t *testing.T, makeSeries makeSeriesType) {
+		a := NewTable(
+			makeSeries("well", []string{"A1", "B1"}, nil),
+			makeSeries("450nm", []float64{0.1, 0.2}, nil),
+			makeSeries("600nm", []float64{0.3, 0}, []bool{true, false}),
+			makeSeries("note", []string{"x", "y"}, nil),
+		)
+		_, err := a.Melt([]ColumnName{"XYZ"}, []ColumnName{"450nm"}, "wavelength", "od")
+		if err == nil {
+			t.Error("no err, no such id column")
+		}
+		_, err = a.Melt([]ColumnName{"well"}, []ColumnName{"XYZ"}, "wavelength", "od")
+		if err == nil {
+			t.Error("no err, no such value column")
+		}
+		_, err = a.Melt([]ColumnName{"well"}, nil, "wavelength", "od")
+		if err == nil {
+			t.Error("no err, value columns of different types")
+		}
+		_, err = a.Melt([]ColumnName{"well"}, []ColumnName{"well", "note"}, "variable", "value")
+		if err == nil {
+			t.Error("no err, id column is also a value column")
+		}
+		_, err = a.Melt([]ColumnName{"well"}, []ColumnName{"450nm"}, "od", "od")
+		if err == nil {
+			t.Error("no err, variable and value columns have the same name")
+		}
+		_, err = a.Melt([]ColumnName{"well"}, []ColumnName{"450nm"}, "well", "od")
+		if err == nil {
+			t.Error("no err, variable column has the name of an id column")
+		}
+		_, err = a.Melt([]ColumnName{"well"}, []ColumnName{"450nm"}, "wavelength", "well")
+		if err == nil {
+			t.Error("no err, value column has the name of an id column")
+		}
+
+		melted := a.Must().Melt([]ColumnName{"well"}, []ColumnName{"450nm", "600nm"}, "wavelength", "od")
+		meltedReference := NewTable(
+			makeSeries("well", []string{"A1", "A1", "B1", "B1"}, nil),
+			makeSeries("wavelength", []string{"450nm", "600nm", "450nm", "600nm"}, nil),
+			makeSeries("od", []float64{0.1, 0.3, 0.2, 0}, []bool{true, true, true, false}),
+		)
+		assertEqual(t, meltedReference, melted, "melt")
+		if melted.Size() != 4 {
+			t.Errorf("melted size %d", melted.Size())
+		}
+
+		// melting all the non-id columns
+		allMelted := a.Must().Project("well", "note").Must().Melt([]ColumnName{"well"}, nil, "variable", "value")
+		allMeltedReference := NewTable(
+			makeSeries("well", []string{"A1", "B1"}, nil),
+			makeSeries("variable", []string{"note", "note"}, nil),
+			makeSeries("value", []string{"x", "y"}, nil),
+		)
+		assertEqual(t, allMeltedReference, allMelted, "melt all")
+
+		// melt is the inverse of pivot
+		pivoted := melted.Must().Pivot().Key("well").Columns("wavelength", "od")
+		assertEqual(t, a.Must().Project("well", "450nm", "600nm"), pivoted, "pivot melted")
 	})
 }
 
@@ -1,44 +1,82 @@
 package data
 
-// import (
-// 	"time"
-// )
-
-// type WindowSpec interface {
-// }
-
-// // TODO window semantics
-// func (t *Table) Window(spec WindowSpec) *Window {
-// 	return nil
-// }
-
-// // TimestampWindowSpec implements windowing by equal sized time buckets (Tumble)
-// type TimestampWindowSpec struct {
-// 	Column   ColumnName
-// 	Duration time.Duration
-// }
-
-// var _ WindowSpec = (*TimestampWindowSpec)(nil)
-
-// // Window provides aggregate functions over row groups
-// type Window struct {
-// 	t *Table
-// 	w WindowSpec
-// }
-
-// // Reduce
-// func (w *Window) Reduce(f func(r1, r2 Row) Row) *Table {
-// 	return nil
-// }
-
-// //Aggregate() *Table
-
-// // Fold
-// func (w *Window) Fold(initial Row, f func(r1, r2 Row) Row) *Table {
-// 	return nil
-// }
-
-// // TODO code gen for the static types!
-// func (w *Window) FoldInt32Col(initial int32, f func(r1 []int32) int32) *Table {
-// 	return nil
-// }
+import (
+	"reflect"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+/*
+ * windows for aggregation
+ */
+
+// WindowSpec assigns the rows of a table to windows, which are aggregated
+// separately.  See GroupBySelection.Window.
+type WindowSpec interface {
+	// windowSeries returns a series containing the start of the window of each row
+	windowSeries(table *Table) (*Series, error)
+}
+
+// TimestampWindowSpec implements windowing by equal sized time buckets
+// (Tumble).  Windows are aligned to the Unix epoch, and are identified by
+// their start time.  Rows with a null timestamp are grouped together.
+type TimestampWindowSpec struct {
+	Column   ColumnName
+	Duration time.Duration
+}
+
+var _ WindowSpec = (*TimestampWindowSpec)(nil)
+
+func (spec *TimestampWindowSpec) windowSeries(table *Table) (*Series, error) {
+	source, err := table.seriesByName(spec.Column)
+	if err != nil {
+		return nil, errors.Wrap(err, "window column lookup")
+	}
+
+	var unit time.Duration
+	switch source.typ {
+	case typeTimestampMillis:
+		unit = time.Millisecond
+	case typeTimestampMicros:
+		unit = time.Microsecond
+	default:
+		return nil, errors.Errorf("window column '%s' of type %v is not a timestamp column", spec.Column, source.typ)
+	}
+	if spec.Duration <= 0 || spec.Duration%unit != 0 {
+		return nil, errors.Errorf("window duration %v must be a positive multiple of %v", spec.Duration, unit)
+	}
+	width := int64(spec.Duration / unit)
+
+	return &Series{
+		col:  spec.Column,
+		typ:  source.typ,
+		meta: newExtendSeriesMeta([]*Series{source}, false),
+		read: func(cache *seriesIterCache) iterator {
+			return &windowIter{source: cache.Ensure(source), width: width}
+		},
+	}, nil
+}
+
+// windowIter maps timestamps to the start of their windows
+type windowIter struct {
+	source iterator
+	width  int64
+}
+
+func (i *windowIter) Next() bool { return true }
+
+func (i *windowIter) Value() interface{} {
+	v := i.source.Value()
+	if v == nil {
+		return nil
+	}
+	value := reflect.ValueOf(v)
+	ts := value.Int()
+	start := ts - ts%i.width
+	if start > ts {
+		// rounding towards negative infinity for timestamps before the epoch
+		start -= i.width
+	}
+	return reflect.ValueOf(start).Convert(value.Type()).Interface()
+}
@@ -52,6 +52,15 @@ type LHInstruction struct {
 	Message          string
 	WaitTime         time.Duration
 	PassThrough      map[string]*Liquid // 1:1 pass through, only applies to prompts
+	Refills          []WellRefill       // wells topped up by the user, only applies to prompts
+}
+
+// WellRefill is a volume of the liquid already in a well which the user is
+// asked to add to it, identified by plate ID and well coordinates
+type WellRefill struct {
+	PlateID string
+	Well    string
+	Volume  wunit.Volume
 }
 
 func (ins LHInstruction) String() string {
@@ -388,6 +388,7 @@ type LHHeadAssembly struct {
 	Positions      []*LHHeadAssemblyPosition
 	MotionLimits   *BBox          //the limits on range of motion of the head assembly, nil if unspecified
 	VelocityLimits *VelocityRange // the range of valid velocities for the head, nil if unspecified
+	IndependentZ   bool           // heads are raised and lowered independently, so idle heads are kept clear of the deck
 }
 
 //NewLHHeadAssembly build a new head assembly
@@ -405,6 +406,7 @@ func (self *LHHeadAssembly) DupWithoutHeads() *LHHeadAssembly {
 		Positions:      make([]*LHHeadAssemblyPosition, 0, len(self.Positions)),
 		MotionLimits:   self.MotionLimits.Dup(),
 		VelocityLimits: self.VelocityLimits.Dup(),
+		IndependentZ:   self.IndependentZ,
 	}
 	for _, pos := range self.Positions {
 		ret.AddPosition(pos.Offset)
@@ -283,6 +283,24 @@ func (w *LHWell) MaxWorkingVolume() wunit.Volume {
 	return ret
 }
 
+//TopUp add a volume of the liquid already in the well
+func (w *LHWell) TopUp(v wunit.Volume) error {
+	if w.IsEmpty() {
+		return fmt.Errorf("cannot top up empty well \"%s\"", w.GetName())
+	}
+	maxVol := w.MaxVolume()
+	finalVol := wunit.AddVolumes(w.CurrentVolume(), v)
+
+	if finalVol.GreaterThan(maxVol) {
+		// overflow errors disabled as in AddComponent
+		fmt.Printf("Topping up well \"%s\" with %s, even though well already contains %s and maximum volume is %s\n", w.GetName(), v, w.CurrentVolume(), maxVol)
+	}
+
+	// set the volume directly, since mixing a liquid with itself renames it
+	w.Contents().SetVolume(finalVol)
+	return nil
+}
+
 //AddComponent add some liquid to the well
 func (w *LHWell) AddComponent(c *Liquid) error {
 	if w == nil {
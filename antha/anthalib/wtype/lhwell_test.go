@@ -99,6 +99,30 @@ func TestAddComponentOverfilled(t *testing.T) {
 	}
 }
 
+func TestTopUp(t *testing.T) {
+	well := getTestWell(100.0, 1.0)
+
+	if err := well.TopUp(wunit.NewVolume(10.0, "ul")); err == nil {
+		t.Error("topped up an empty well without error")
+	}
+
+	cmp := getTestComponent(50.0)
+	cmp.CName = "water"
+	if err := well.AddComponent(cmp); err != nil {
+		t.Fatal(err)
+	}
+	if err := well.TopUp(wunit.NewVolume(20.0, "ul")); err != nil {
+		t.Fatal(err)
+	}
+
+	if e, g := 70.0, well.CurrentVolume().ConvertToString("ul"); e != g {
+		t.Errorf("well volume was %f ul after top up, expected %f", g, e)
+	}
+	if e, g := cmp.CName, well.Contents().CName; e != g {
+		t.Errorf("well contains %q after top up, expected %q", g, e)
+	}
+}
+
 func TestRemoveVolume(t *testing.T) {
 	well := getTestWell(100.0, 1.0)
 	cmp := getTestComponent(50.0)
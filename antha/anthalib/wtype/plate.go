@@ -1212,11 +1212,7 @@ func (p *Plate) AllNonEmptyWells() []*LHWell {
 //aim is to deprecate IsSpecial
 func (p *Plate) AreWellTargetsEnabled(adaptorChannels int, channelSpacing float64) bool {
 
-	if p.NRows() != 1 {
-		return false
-	}
-
-	if !BoxShape.Equals(p.Welltype.Shape().Type) {
+	if !p.IsReservoir() {
 		return false
 	}
 
@@ -1226,6 +1222,12 @@ func (p *Plate) AreWellTargetsEnabled(adaptorChannels int, channelSpacing float6
 
 }
 
+//IsReservoir is the plate a trough or reservoir, i.e. a single row of box shaped wells
+//which may be wide enough for several channels to access each well at once
+func (p *Plate) IsReservoir() bool {
+	return p.NRows() == 1 && BoxShape.Equals(p.Welltype.Shape().Type)
+}
+
 func (p *Plate) IsSpecial() bool {
 	if p == nil || p.Welltype.Extra == nil {
 		return false
@@ -596,3 +596,22 @@ func TestGetWellBounds(t *testing.T) {
 		t.Errorf("GetWellBounds incorrect: expected %v, got %v", eBounds, bounds)
 	}
 }
+
+func TestIsReservoir(t *testing.T) {
+	if p := makeplatefortest(); p.IsReservoir() {
+		t.Errorf("%s should not be a reservoir", p.Type)
+	}
+
+	trough := maketroughfortest()
+	if !trough.IsReservoir() {
+		t.Errorf("%s should be a reservoir", trough.Type)
+	}
+
+	// 72mm across fits 8 channels at 9mm spacing
+	if !trough.AreWellTargetsEnabled(8, 9.0) {
+		t.Errorf("%s should allow 8 channels", trough.Type)
+	}
+	if trough.AreWellTargetsEnabled(9, 9.0) {
+		t.Errorf("%s should not allow 9 channels", trough.Type)
+	}
+}
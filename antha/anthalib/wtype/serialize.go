@@ -400,6 +400,7 @@ type SerializableHeadAssembly struct {
 	Positions      []*sHeadAssemblyPosition
 	MotionLimits   *BBox
 	VelocityLimits *VelocityRange
+	IndependentZ   bool
 }
 
 // NewSerializableHeadAssembly convert to an easily serialisable representation of a head assembly
@@ -414,6 +415,7 @@ func NewSerializableHeadAssembly(ha *LHHeadAssembly, heads map[*LHHead]int) *Ser
 		Positions:      positions,
 		MotionLimits:   ha.MotionLimits,
 		VelocityLimits: ha.VelocityLimits,
+		IndependentZ:   ha.IndependentZ,
 	}
 }
 
@@ -428,6 +430,7 @@ func (sha *SerializableHeadAssembly) Fill(ha *LHHeadAssembly, heads []*LHHead) {
 	ha.Positions = positions
 	ha.MotionLimits = sha.MotionLimits
 	ha.VelocityLimits = sha.VelocityLimits
+	ha.IndependentZ = sha.IndependentZ
 }
 
 type SerializableHead struct {
@@ -364,6 +364,17 @@ func Walk(v Visitor, node Node) {
 			Walk(v, n.Body)
 		}
 
+	case *AnthaDecl:
+		if n.Doc != nil {
+			Walk(v, n.Doc)
+		}
+		if n.Name != nil {
+			Walk(v, n.Name)
+		}
+		if n.Body != nil {
+			Walk(v, n.Body)
+		}
+
 	// Files and packages
 	case *File:
 		if n.Doc != nil {
@@ -76,6 +76,16 @@ func (e posError) Pos() token.Pos {
 	return e.pos
 }
 
+// An Error is an error in an element at a position in its source.
+type Error struct {
+	Pos token.Position
+	Msg string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%s:%d: %s", e.Pos.Filename, e.Pos.Line, e.Msg)
+}
+
 func throwErrorf(pos token.Pos, format string, args ...interface{}) {
 	panic(posError{
 		message: fmt.Sprintf(format, args...),
@@ -469,11 +479,22 @@ func unwrapRecover(res interface{}, fileSet *token.FileSet, pos token.Pos) error
 	p := fileSet.Position(pos)
 
 	if ok {
-		return fmt.Errorf("%s:%d: %s", p.Filename, p.Line, msg)
+		return &Error{Pos: p, Msg: msg}
 	}
 	return fmt.Errorf("%s: %s", p.Filename, msg)
 }
 
+// TypeString returns the go type of an Antha type expression, qualifying
+// Antha types such as Volume with their package.
+func (p *Antha) TypeString(e ast.Expr) (s string, err error) {
+	defer func() {
+		if res := recover(); res != nil {
+			err = fmt.Errorf("%v", res)
+		}
+	}()
+	return p.getTypeString(e, nil), nil
+}
+
 // normalizePath takes a filepath and returns a slash path relative to GOPATH
 func normalizePath(filename string) string {
 	filename, _ = relativeTo(getGoPath(), filename)
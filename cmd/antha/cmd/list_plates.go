@@ -30,6 +30,8 @@ import (
 	"strings"
 
 	"github.com/antha-lang/antha/antha/anthalib/wunit"
+	"github.com/antha-lang/antha/inventory"
+	"github.com/antha-lang/antha/inventory/fileinventory"
 	"github.com/antha-lang/antha/inventory/testinventory"
 	"github.com/ghodss/yaml"
 	"github.com/mgutz/ansi"
@@ -77,9 +79,20 @@ func listPlates(cmd *cobra.Command, args []string) error {
 	}
 
 	ctx := testinventory.NewContext(context.Background())
+	if dirs := GetStringSlice("inventory"); len(dirs) != 0 {
+		var err error
+		if ctx, err = fileinventory.NewContext(ctx, dirs...); err != nil {
+			return err
+		}
+	}
+
+	plates, err := inventory.XXXNewPlates(ctx)
+	if err != nil {
+		return err
+	}
 
 	var ps simplePlates
-	for _, p := range testinventory.GetPlates(ctx) {
+	for _, p := range plates {
 		ps = append(ps, simplePlate{
 			Type:          p.Type,
 			WellsX:        p.WellsX(),
@@ -138,5 +151,8 @@ func listPlates(cmd *cobra.Command, args []string) error {
 
 func init() {
 	c := listPlatesCmd
+	flags := c.Flags()
 	listCmd.AddCommand(c)
+
+	flags.StringSlice("inventory", nil, "Directories of plate, tipbox, tipwaste and component definitions overriding the built-in inventory")
 }
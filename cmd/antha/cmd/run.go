@@ -39,7 +39,9 @@ import (
 	"github.com/antha-lang/antha/execute"
 	"github.com/antha-lang/antha/execute/executeutil"
 	"github.com/antha-lang/antha/inject"
+	"github.com/antha-lang/antha/inventory/fileinventory"
 	"github.com/antha-lang/antha/inventory/testinventory"
+	"github.com/antha-lang/antha/microArch/driver/liquidhandling/opentrons"
 	"github.com/antha-lang/antha/target"
 	"github.com/antha-lang/antha/target/auto"
 	"github.com/antha-lang/antha/target/mixer"
@@ -110,6 +112,11 @@ func makeMixerOpt(ctx context.Context) (mixer.Opt, error) {
 
 	opt.FixVolumes = viper.GetBool("fixVolumes")
 
+	if viper.GetBool("ot2") {
+		config := opentrons.DefaultConfig()
+		opt.OT2 = &config
+	}
+
 	return opt, nil
 }
 
@@ -126,6 +133,9 @@ func makeContext() (context.Context, error) {
 		}
 	}
 	ctx = testinventory.NewContext(ctx)
+	if dirs := GetStringSlice("inventory"); len(dirs) != 0 {
+		return fileinventory.NewContext(ctx, dirs...)
+	}
 	return ctx, nil
 }
 
@@ -139,7 +149,10 @@ type runOpt struct {
 	TestBundleFileName     string
 	LayoutSummaryFile      string
 	MixSummaryFile         string
+	MixSimulationFile      string
 	RunTest                bool
+	MaxConcurrency         int
+	CheckpointDir          string
 }
 
 func (a *runOpt) Run() error {
@@ -157,13 +170,13 @@ func (a *runOpt) Run() error {
 		opt.Endpoints = append(opt.Endpoints, auto.Endpoint{URI: uri})
 	}
 
-	// Auto detect gRPC devices on network interfaces
-	t, err := auto.New(opt)
+	ctx, err := makeContext()
 	if err != nil {
 		return err
 	}
 
-	ctx, err := makeContext()
+	// Auto detect gRPC devices on network interfaces
+	t, err := auto.New(ctx, opt)
 	if err != nil {
 		return err
 	}
@@ -173,6 +186,8 @@ func (a *runOpt) Run() error {
 		Workflow:                   &bundle.Desc,
 		Params:                     &bundle.RawParams,
 		TransitionalReadLocalFiles: true,
+		MaxConcurrency:             a.MaxConcurrency,
+		CheckpointDir:              a.CheckpointDir,
 	})
 	if err != nil {
 		return err
@@ -215,6 +230,21 @@ func (a *runOpt) Run() error {
 		}
 	}
 
+	if a.MixSimulationFile != "" {
+		for i, mix := range mixes {
+			outFile := a.MixSimulationFile
+			if len(mixes) > 1 {
+				outFile = fmt.Sprintf("%s.%d", a.MixSimulationFile, i)
+			}
+
+			if bs, err := json.Marshal(mix.SimulationInput()); err != nil {
+				return err
+			} else if err := ioutil.WriteFile(outFile, bs, 0644); err != nil {
+				return err
+			}
+		}
+	}
+
 	// if option is set, add liquid handling instruction output
 	if a.MixInstructionFileName != "" {
 		countFiles := 1
@@ -333,6 +363,9 @@ func runWorkflow(cmd *cobra.Command, args []string) error {
 		RunTest:                viper.GetBool("runTest"),
 		LayoutSummaryFile:      viper.GetString("layoutSummary"),
 		MixSummaryFile:         viper.GetString("mixSummary"),
+		MixSimulationFile:      viper.GetString("mixSimulation"),
+		MaxConcurrency:         viper.GetInt("maxConcurrency"),
+		CheckpointDir:          viper.GetString("checkpointDir"),
 	}
 
 	return opt.Run()
@@ -351,6 +384,8 @@ func init() {
 	flags.Float64("residualVolumeWeight", 0.0, "Residual volume weight")
 	flags.Int("maxPlates", 0, "Maximum number of plates")
 	flags.Int("maxWells", 0, "Maximum number of wells on a plate")
+	flags.Int("maxConcurrency", 1, "Maximum number of workflow processes to run at the same time")
+	flags.String("checkpointDir", "", "Save the outputs of workflow processes that issue no instructions to this directory and reuse them when rerunning with the same inputs")
 	flags.String("bundle", "", "Input bundle with parameters and workflow together (overrides parameter and workflow arguments)")
 	flags.String("makeTestBundle", "", "Generate json format bundle for testing and put it here")
 	flags.String("mixInstructionFileName", "", "Name of instructions files to output to for mixes")
@@ -358,13 +393,16 @@ func init() {
 	flags.String("workflow", "", "Workflow definition file")
 	flags.String("mixSummary", "", "save a summary of the generated liquidhandling actions to the given filename")
 	flags.String("layoutSummary", "", "save a summary of the generated deck layout to the given filename")
+	flags.String("mixSimulation", "", "save the initial deck state and instructions of each mix to the given filename, for use with \"antha simulate\"")
 	flags.StringSlice("component", nil, "Uris of remote components ({tcp,go}://...); use multiple flags for multiple components")
 	flags.StringSlice("driver", nil, "Uris of remote drivers ({tcp,go}://...); use multiple flags for multiple drivers")
 	flags.StringSlice("inputPlateTypes", nil, "Default input plate types (in order of preference)")
 	flags.StringSlice("inputPlates", nil, "File containing input plates")
+	flags.StringSlice("inventory", nil, "Directories of plate, tipbox, tipwaste and component definitions overriding the built-in inventory")
 	flags.StringSlice("outputPlateTypes", nil, "Default output plate types (in order of preference)")
 	flags.StringSlice("tipTypes", nil, "Names of permitted tip types")
 	flags.Bool("runTest", false, "compare mix instructions and time estimates with results previously generated by using the makeTestBundle flag. ")
+	flags.Bool("ot2", false, "Generate a Python protocol for an Opentrons OT-2 with single channel P20 and P300 pipettes rather than using a driver")
 	flags.Bool("fixVolumes", true, "Make all volumes sufficient for later uses")
 	flags.String("policyFile", "", "Design file of custom liquid policies in format of .xlsx JMP file")
 }
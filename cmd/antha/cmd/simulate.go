@@ -0,0 +1,182 @@
+// simulate.go: Part of the Antha language
+// Copyright (C) 2018 The Antha authors. All rights reserved.
+//
+// This program is free software; you can redistribute it and/or
+// modify it under the terms of the GNU General Public License
+// as published by the Free Software Foundation; either version 2
+// of the License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program; if not, write to the Free Software
+// Foundation, Inc., 51 Franklin Street, Fifth Floor, Boston, MA  02110-1301, USA.
+//
+// For more information relating to the software or licensing issues please
+// contact license@antha-lang.org or write to the Antha team c/o
+// Synthace Ltd. The London Bioscience Innovation Centre
+// 2 Royal College St, London NW1 0NH UK
+
+package cmd
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"strings"
+
+	lh "github.com/antha-lang/antha/microArch/scheduler/liquidhandling"
+	simulator "github.com/antha-lang/antha/microArch/simulator/liquidhandling"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var simulateCmd = &cobra.Command{
+	Use:   "simulate file",
+	Short: "Step through the simulation of a mix",
+	Long: `Replay the instructions of a mix in the liquid handler simulator.
+
+The file is generated by "antha run --mixSimulation file". The state of the
+robot after the given step is printed as JSON in the format of the layout
+summary, with the position and tips of each adaptor. Use --interactive to step
+forwards and backwards through the instructions.`,
+	RunE: runSimulate,
+}
+
+func runSimulate(cmd *cobra.Command, args []string) error {
+	if err := viper.BindPFlags(cmd.Flags()); err != nil {
+		return err
+	}
+
+	if len(args) != 1 {
+		return fmt.Errorf("expected exactly one simulation file, got %d", len(args))
+	}
+
+	bs, err := ioutil.ReadFile(args[0])
+	if err != nil {
+		return err
+	}
+
+	var input lh.SimulationInput
+	if err := json.Unmarshal(bs, &input); err != nil {
+		return fmt.Errorf("cannot read %s: %s", args[0], err)
+	}
+
+	replay, err := input.NewReplay()
+	if err != nil {
+		return err
+	}
+
+	if viper.GetBool("interactive") {
+		return stepInteractively(replay, os.Stdin, os.Stdout)
+	}
+
+	step := viper.GetInt("step")
+	if step < 0 {
+		step = replay.Len()
+	}
+	return printStep(replay, step, os.Stdout)
+}
+
+func printStep(replay *simulator.Replay, step int, out io.Writer) error {
+	bs, err := lh.SummarizeStep(replay, step)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintln(out, string(bs))
+	return err
+}
+
+func printStatus(replay *simulator.Replay, out io.Writer) error {
+	var desc string
+	if ins := replay.LastInstruction(); ins != nil {
+		desc = ins.Type().Name
+		for _, err := range replay.LastErrors() {
+			desc += fmt.Sprintf("\n  %s: %s", err.Severity(), err.Error())
+		}
+	} else {
+		desc = "initial state"
+	}
+	_, err := fmt.Fprintf(out, "step %d/%d: %s\n", replay.Step(), replay.Len(), desc)
+	return err
+}
+
+const simulateHelp = `commands:
+  n, next [count]   simulate the next count instructions
+  b, back [count]   undo the last count instructions
+  g, goto step      simulate exactly step instructions
+  s, show           print the state of the robot as JSON
+  q, quit           exit`
+
+func stepInteractively(replay *simulator.Replay, in io.Reader, out io.Writer) error {
+	if err := printStatus(replay, out); err != nil {
+		return err
+	}
+
+	scanner := bufio.NewScanner(in)
+	for {
+		if _, err := fmt.Fprint(out, "> "); err != nil {
+			return err
+		}
+		if !scanner.Scan() {
+			return scanner.Err()
+		}
+
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+
+		arg := 1
+		if len(fields) > 1 {
+			var err error
+			if arg, err = strconv.Atoi(fields[1]); err != nil {
+				fmt.Fprintf(out, "invalid argument %q\n", fields[1]) // nolint: errcheck
+				continue
+			}
+		}
+
+		var err error
+		switch fields[0] {
+		case "n", "next":
+			err = replay.Seek(replay.Step() + arg)
+		case "b", "back":
+			err = replay.Seek(replay.Step() - arg)
+		case "g", "goto":
+			if len(fields) < 2 {
+				err = fmt.Errorf("goto requires a step")
+			} else {
+				err = replay.Seek(arg)
+			}
+		case "s", "show":
+			err = printStep(replay, replay.Step(), out)
+		case "q", "quit":
+			return nil
+		default:
+			_, err = fmt.Fprintln(out, simulateHelp)
+			continue
+		}
+
+		if err != nil {
+			fmt.Fprintln(out, err) // nolint: errcheck
+		} else if err := printStatus(replay, out); err != nil {
+			return err
+		}
+	}
+}
+
+func init() {
+	c := simulateCmd
+	flags := c.Flags()
+	RootCmd.AddCommand(c)
+
+	flags.Int("step", -1, "Number of instructions to simulate before printing the state of the robot, defaults to all of them")
+	flags.Bool("interactive", false, "Read commands to step through the simulation from standard input")
+}
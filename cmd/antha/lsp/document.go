@@ -0,0 +1,268 @@
+package lsp
+
+import (
+	"fmt"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+
+	"github.com/antha-lang/antha/antha/ast"
+	"github.com/antha-lang/antha/antha/compile"
+	"github.com/antha-lang/antha/antha/format"
+	"github.com/antha-lang/antha/antha/parser"
+	"github.com/antha-lang/antha/antha/scanner"
+	"github.com/antha-lang/antha/antha/token"
+)
+
+// A document is an element file open in the editor.
+type document struct {
+	uri  string
+	text string
+}
+
+// filename returns the path of the file of the document, which is used to
+// check that the protocol is in the directory of the same name.
+func (d *document) filename() string {
+	u, err := url.Parse(d.uri)
+	if err != nil || u.Scheme != "file" {
+		return d.uri
+	}
+	return filepath.FromSlash(u.Path)
+}
+
+// offset returns the byte offset in the document of a position.
+func (d *document) offset(pos Position) int {
+	offset := 0
+	for line := 0; line < pos.Line; line++ {
+		i := strings.IndexByte(d.text[offset:], '\n')
+		if i < 0 {
+			return len(d.text)
+		}
+		offset += i + 1
+	}
+	for units := 0; units < pos.Character && offset < len(d.text); {
+		r, size := utf8.DecodeRuneInString(d.text[offset:])
+		if r == '\n' {
+			break
+		}
+		units += len(utf16.Encode([]rune{r}))
+		offset += size
+	}
+	return offset
+}
+
+// position returns the position in the document of a byte offset.
+func (d *document) position(offset int) Position {
+	if offset > len(d.text) {
+		offset = len(d.text)
+	} else if offset < 0 {
+		offset = 0
+	}
+	var pos Position
+	lineStart := 0
+	if i := strings.LastIndexByte(d.text[:offset], '\n'); i >= 0 {
+		pos.Line = strings.Count(d.text[:offset], "\n")
+		lineStart = i + 1
+	}
+	for _, r := range d.text[lineStart:offset] {
+		pos.Character += len(utf16.Encode([]rune{r}))
+	}
+	return pos
+}
+
+// applyChange updates the document with a change sent by the editor, which
+// replaces the whole document if it has no range.
+func (d *document) applyChange(change textDocumentContentChangeEvent) {
+	if change.Range == nil {
+		d.text = change.Text
+		return
+	}
+	start, end := d.offset(change.Range.Start), d.offset(change.Range.End)
+	d.text = d.text[:start] + change.Text + d.text[end:]
+}
+
+func (d *document) parse() (*token.FileSet, *ast.File, error) {
+	fileSet := token.NewFileSet()
+	file, err := parser.ParseFile(fileSet, d.filename(), d.text, parser.ParseComments)
+	return fileSet, file, err
+}
+
+func (d *document) diagnostic(offset int, message string) Diagnostic {
+	pos := d.position(offset)
+	return Diagnostic{
+		Range:    Range{Start: pos, End: pos},
+		Severity: Error,
+		Source:   "antha",
+		Message:  message,
+	}
+}
+
+// diagnostics returns the errors found parsing the document or, if it
+// parses, compiling it.
+func (d *document) diagnostics() (diagnostics []Diagnostic) {
+	fileSet, file, err := d.parse()
+	switch err := err.(type) {
+	case nil:
+	case scanner.ErrorList:
+		for _, e := range err {
+			diagnostics = append(diagnostics, d.diagnostic(e.Pos.Offset, e.Msg))
+		}
+		return diagnostics
+	default:
+		return []Diagnostic{d.diagnostic(0, err.Error())}
+	}
+
+	// errors without a position are reported at the protocol name
+	offset := fileSet.Position(file.Name.Pos()).Offset
+	defer func() {
+		if res := recover(); res != nil {
+			diagnostics = []Diagnostic{d.diagnostic(offset, fmt.Sprint(res))}
+		}
+	}()
+
+	antha := compile.NewAntha(compile.NewAnthaRoot(""))
+	switch err := antha.Transform(fileSet, file).(type) {
+	case nil:
+	case *compile.Error:
+		diagnostics = append(diagnostics, d.diagnostic(err.Pos.Offset, err.Msg))
+	default:
+		diagnostics = append(diagnostics, d.diagnostic(offset, err.Error()))
+	}
+	return diagnostics
+}
+
+// format returns the edits formatting the document with the Antha printer.
+func (d *document) format() ([]TextEdit, error) {
+	out, err := format.Source([]byte(d.text))
+	if err != nil {
+		return nil, err
+	}
+	if string(out) == d.text {
+		return []TextEdit{}, nil
+	}
+	return []TextEdit{{
+		Range:   Range{Start: Position{}, End: d.position(len(d.text))},
+		NewText: string(out),
+	}}, nil
+}
+
+// A declaration is a field of a Parameters, Inputs, Outputs or Data block.
+type declaration struct {
+	Block token.Token
+	Name  *ast.Ident
+	Field *ast.Field
+}
+
+// declarations returns the fields of the element's blocks by name.
+func declarations(file *ast.File) map[string]declaration {
+	decls := make(map[string]declaration)
+	for _, decl := range file.Decls {
+		gen, ok := decl.(*ast.GenDecl)
+		if !ok {
+			continue
+		}
+		switch gen.Tok {
+		case token.PARAMETERS, token.INPUTS, token.OUTPUTS, token.DATA:
+		default:
+			continue
+		}
+		for _, spec := range gen.Specs {
+			spec, ok := spec.(*ast.TypeSpec)
+			if !ok {
+				continue
+			}
+			typ, ok := spec.Type.(*ast.StructType)
+			if !ok || typ.Fields == nil {
+				continue
+			}
+			for _, field := range typ.Fields.List {
+				for _, name := range field.Names {
+					decls[name.Name] = declaration{Block: gen.Tok, Name: name, Field: field}
+				}
+			}
+		}
+	}
+	return decls
+}
+
+// identAt returns the identifier at the offset, excluding selectors of other
+// values.
+func identAt(fileSet *token.FileSet, file *ast.File, offset int) *ast.Ident {
+	var found *ast.Ident
+	selectors := make(map[*ast.Ident]bool)
+	ast.Inspect(file, func(n ast.Node) bool {
+		if n == nil || found != nil {
+			return false
+		}
+		start, end := fileSet.Position(n.Pos()).Offset, fileSet.Position(n.End()).Offset
+		if offset < start || offset > end {
+			return false
+		}
+		switch n := n.(type) {
+		case *ast.SelectorExpr:
+			selectors[n.Sel] = true
+		case *ast.Ident:
+			if !selectors[n] {
+				found = n
+			}
+		}
+		return true
+	})
+	return found
+}
+
+// lookup returns the declaration of the block field named at the position.
+func (d *document) lookup(pos Position) (*token.FileSet, *ast.Ident, declaration, bool) {
+	fileSet, file, _ := d.parse()
+	if file == nil {
+		return nil, nil, declaration{}, false
+	}
+	ident := identAt(fileSet, file, d.offset(pos))
+	if ident == nil {
+		return nil, nil, declaration{}, false
+	}
+	decl, found := declarations(file)[ident.Name]
+	return fileSet, ident, decl, found
+}
+
+func (d *document) identRange(fileSet *token.FileSet, ident *ast.Ident) Range {
+	return Range{
+		Start: d.position(fileSet.Position(ident.Pos()).Offset),
+		End:   d.position(fileSet.Position(ident.End()).Offset),
+	}
+}
+
+// definition returns the location of the declaration of the block field
+// named at the position.
+func (d *document) definition(pos Position) []Location {
+	fileSet, _, decl, found := d.lookup(pos)
+	if !found {
+		return []Location{}
+	}
+	return []Location{{URI: d.uri, Range: d.identRange(fileSet, decl.Name)}}
+}
+
+// hover returns the block, go type and documentation of the block field
+// named at the position.
+func (d *document) hover(pos Position) *Hover {
+	fileSet, ident, decl, found := d.lookup(pos)
+	if !found {
+		return nil
+	}
+	typ, err := compile.NewAntha(compile.NewAnthaRoot("")).TypeString(decl.Field.Type)
+	if err != nil {
+		return nil
+	}
+
+	value := fmt.Sprintf("```go\n%s %s %s\n```", decl.Block, decl.Name.Name, typ)
+	if doc := strings.TrimSpace(decl.Field.Doc.Text() + decl.Field.Comment.Text()); doc != "" {
+		value += "\n\n" + doc
+	}
+	r := d.identRange(fileSet, ident)
+	return &Hover{
+		Contents: MarkupContent{Kind: "markdown", Value: value},
+		Range:    &r,
+	}
+}
@@ -0,0 +1,303 @@
+package lsp
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+const testURI = "file:///tmp/Test/Test.an"
+
+const testElement = `protocol Test
+
+import (
+	"fmt"
+)
+
+Parameters {
+	// Volume to dispense
+	Vol Volume
+}
+
+Data {
+	Out string
+}
+
+Inputs {}
+
+Outputs {}
+
+Requirements {}
+
+Setup {}
+
+Steps {
+	Out = fmt.Sprint(Vol)
+}
+
+Analysis {}
+
+Validation {}
+`
+
+// session sends requests to a server and returns the messages it writes.
+type session struct {
+	t   *testing.T
+	in  bytes.Buffer
+	id  int
+	ids map[string]int
+}
+
+func newSession(t *testing.T) *session {
+	return &session{t: t, ids: make(map[string]int)}
+}
+
+func (s *session) send(name, method string, params interface{}) {
+	msg := map[string]interface{}{
+		"jsonrpc": "2.0",
+		"method":  method,
+		"params":  params,
+	}
+	if name != "" {
+		s.id++
+		s.ids[name] = s.id
+		msg["id"] = s.id
+	}
+	if err := writeMessage(&s.in, msg); err != nil {
+		s.t.Fatal(err)
+	}
+}
+
+type received struct {
+	ID     *int            `json:"id"`
+	Method string          `json:"method"`
+	Params json.RawMessage `json:"params"`
+	Result json.RawMessage `json:"result"`
+	Error  *responseError  `json:"error"`
+}
+
+// run serves the requests sent and returns the responses by request name and
+// the notifications in order.
+func (s *session) run() (map[string]received, []received) {
+	var out bytes.Buffer
+	if err := NewServer(&s.in, &out).Serve(); err != nil {
+		s.t.Fatal(err)
+	}
+
+	responses := make(map[string]received)
+	var notifications []received
+	r := bufio.NewReader(&out)
+	for {
+		content, err := readMessage(r)
+		if err != nil {
+			break
+		}
+		var msg received
+		if err := json.Unmarshal(content, &msg); err != nil {
+			s.t.Fatal(err)
+		}
+		if msg.ID == nil {
+			notifications = append(notifications, msg)
+			continue
+		}
+		for name, id := range s.ids {
+			if id == *msg.ID {
+				responses[name] = msg
+			}
+		}
+	}
+	return responses, notifications
+}
+
+func (s *session) open(text string) {
+	s.send("", "textDocument/didOpen", map[string]interface{}{
+		"textDocument": map[string]interface{}{
+			"uri":        testURI,
+			"languageId": "antha",
+			"version":    1,
+			"text":       text,
+		},
+	})
+}
+
+func (s *session) at(name, method string, line, character int) {
+	s.send(name, method, map[string]interface{}{
+		"textDocument": map[string]interface{}{"uri": testURI},
+		"position":     Position{Line: line, Character: character},
+	})
+}
+
+func diagnosticsOf(t *testing.T, msg received) []Diagnostic {
+	if msg.Method != "textDocument/publishDiagnostics" {
+		t.Fatalf("expected diagnostics, got %q", msg.Method)
+	}
+	var params publishDiagnosticsParams
+	if err := json.Unmarshal(msg.Params, &params); err != nil {
+		t.Fatal(err)
+	}
+	return params.Diagnostics
+}
+
+func TestInitialize(t *testing.T) {
+	s := newSession(t)
+	s.send("init", "initialize", map[string]interface{}{})
+	s.send("", "initialized", map[string]interface{}{})
+	s.send("unknown", "workspace/symbol", map[string]interface{}{})
+	s.send("shutdown", "shutdown", nil)
+	s.send("", "exit", nil)
+	responses, _ := s.run()
+
+	var result initializeResult
+	if err := json.Unmarshal(responses["init"].Result, &result); err != nil {
+		t.Fatal(err)
+	}
+	c := result.Capabilities
+	if c.TextDocumentSync != syncFull || !c.HoverProvider || !c.DefinitionProvider || !c.DocumentFormattingProvider {
+		t.Errorf("unexpected capabilities %+v", c)
+	}
+	if e := responses["unknown"].Error; e == nil || e.Code != methodNotFound {
+		t.Errorf("expected method not found, got %+v", e)
+	}
+	if _, found := responses["shutdown"]; !found {
+		t.Error("no response to shutdown")
+	}
+}
+
+func TestExitWithoutShutdown(t *testing.T) {
+	var in, out bytes.Buffer
+	if err := writeMessage(&in, map[string]string{"jsonrpc": "2.0", "method": "exit"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := NewServer(&in, &out).Serve(); err == nil {
+		t.Error("expected error exiting without shutdown")
+	}
+}
+
+func TestParseDiagnostics(t *testing.T) {
+	s := newSession(t)
+	s.open(strings.Replace(testElement, "Vol Volume\n", "Vol Volume\n\tfunc\n", 1))
+	_, notifications := s.run()
+
+	if len(notifications) != 1 {
+		t.Fatalf("expected 1 notification, got %d", len(notifications))
+	}
+	diagnostics := diagnosticsOf(t, notifications[0])
+	if len(diagnostics) == 0 {
+		t.Fatal("expected diagnostics")
+	}
+	if pos := diagnostics[0].Range.Start; pos.Line != 9 {
+		t.Errorf("expected diagnostic on line 9, got %+v", pos)
+	}
+}
+
+func TestCompileDiagnostics(t *testing.T) {
+	s := newSession(t)
+	s.open(testElement)
+	s.send("", "textDocument/didChange", map[string]interface{}{
+		"textDocument":   map[string]interface{}{"uri": testURI, "version": 2},
+		"contentChanges": []map[string]interface{}{{"text": strings.Replace(testElement, "Sprint(Vol)", "Sprint(Vol)\n\tRunSteps(Vol)", 1)}},
+	})
+	s.send("", "textDocument/didClose", map[string]interface{}{
+		"textDocument": map[string]interface{}{"uri": testURI},
+	})
+	_, notifications := s.run()
+
+	if len(notifications) != 3 {
+		t.Fatalf("expected 3 notifications, got %d", len(notifications))
+	}
+	if diagnostics := diagnosticsOf(t, notifications[0]); len(diagnostics) != 0 {
+		t.Errorf("expected no diagnostics, got %+v", diagnostics)
+	}
+	diagnostics := diagnosticsOf(t, notifications[1])
+	if len(diagnostics) != 1 {
+		t.Fatalf("expected 1 diagnostic, got %+v", diagnostics)
+	}
+	if pos := diagnostics[0].Range.Start; pos != (Position{Line: 25, Character: 1}) {
+		t.Errorf("expected diagnostic at 25:1, got %+v", pos)
+	}
+	if diagnostics := diagnosticsOf(t, notifications[2]); len(diagnostics) != 0 {
+		t.Errorf("expected diagnostics to be cleared, got %+v", diagnostics)
+	}
+}
+
+func TestHoverAndDefinition(t *testing.T) {
+	s := newSession(t)
+	s.open(testElement)
+	// Vol in the Steps block
+	s.at("hover", "textDocument/hover", 24, 20)
+	s.at("definition", "textDocument/definition", 24, 20)
+	// fmt is not a field of a block
+	s.at("none", "textDocument/hover", 24, 8)
+	responses, _ := s.run()
+
+	var hover Hover
+	if err := json.Unmarshal(responses["hover"].Result, &hover); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"Parameters Vol wunit.Volume", "Volume to dispense"} {
+		if !strings.Contains(hover.Contents.Value, want) {
+			t.Errorf("expected hover %q to contain %q", hover.Contents.Value, want)
+		}
+	}
+	if hover.Range == nil || hover.Range.Start != (Position{Line: 24, Character: 18}) {
+		t.Errorf("unexpected hover range %+v", hover.Range)
+	}
+
+	var locations []Location
+	if err := json.Unmarshal(responses["definition"].Result, &locations); err != nil {
+		t.Fatal(err)
+	}
+	want := Location{URI: testURI, Range: Range{Start: Position{Line: 8, Character: 1}, End: Position{Line: 8, Character: 4}}}
+	if len(locations) != 1 || locations[0] != want {
+		t.Errorf("expected %+v, got %+v", want, locations)
+	}
+
+	if r := string(responses["none"].Result); r != "null" {
+		t.Errorf("expected no hover, got %s", r)
+	}
+}
+
+func TestFormatting(t *testing.T) {
+	s := newSession(t)
+	s.open(strings.Replace(testElement, "\tOut = fmt.Sprint(Vol)", "Out   =   fmt.Sprint( Vol )", 1))
+	s.send("format", "textDocument/formatting", map[string]interface{}{
+		"textDocument": map[string]interface{}{"uri": testURI},
+	})
+	responses, _ := s.run()
+
+	var edits []TextEdit
+	if err := json.Unmarshal(responses["format"].Result, &edits); err != nil {
+		t.Fatal(err)
+	}
+	if len(edits) != 1 {
+		t.Fatalf("expected 1 edit, got %d", len(edits))
+	}
+	if !strings.Contains(edits[0].NewText, "\tOut = fmt.Sprint(Vol)\n") {
+		t.Errorf("unexpected formatting %s", edits[0].NewText)
+	}
+}
+
+func TestPositions(t *testing.T) {
+	d := &document{text: "ab\ncé𝄞d\n"}
+	for offset, want := range map[int]Position{
+		0:  {0, 0},
+		3:  {1, 0},
+		6:  {1, 2},
+		10: {1, 4},
+		11: {1, 5},
+		12: {2, 0},
+	} {
+		if got := d.position(offset); got != want {
+			t.Errorf("position(%d): expected %+v, got %+v", offset, want, got)
+		}
+		if got := d.offset(want); got != offset {
+			t.Errorf("offset(%+v): expected %d, got %d", want, offset, got)
+		}
+	}
+	d.applyChange(textDocumentContentChangeEvent{Range: &Range{Start: Position{1, 1}, End: Position{1, 4}}, Text: "x"})
+	if d.text != "ab\ncxd\n" {
+		t.Errorf("unexpected text after change %q", d.text)
+	}
+}
@@ -0,0 +1,195 @@
+// Package lsp implements a Language Server Protocol server for Antha element
+// files, providing diagnostics, formatting, hover information and
+// definitions of the fields of element blocks.
+package lsp
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"io"
+)
+
+var errExitWithoutShutdown = errors.New("exit requested before shutdown")
+
+// A Server answers the requests of an editor read from in, writing responses
+// and notifications to out.
+type Server struct {
+	in       *bufio.Reader
+	out      io.Writer
+	docs     map[string]*document
+	shutdown bool
+}
+
+// NewServer returns a server communicating over in and out, typically stdin
+// and stdout.
+func NewServer(in io.Reader, out io.Writer) *Server {
+	return &Server{
+		in:   bufio.NewReader(in),
+		out:  out,
+		docs: make(map[string]*document),
+	}
+}
+
+// Serve handles messages until the editor asks the server to exit or closes
+// the connection.
+func (s *Server) Serve() error {
+	for {
+		content, err := readMessage(s.in)
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+
+		var msg message
+		if err := json.Unmarshal(content, &msg); err != nil {
+			if err := s.reply(nil, nil, &responseError{Code: parseError, Message: err.Error()}); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if msg.Method == "exit" {
+			if !s.shutdown {
+				return errExitWithoutShutdown
+			}
+			return nil
+		}
+
+		result, rerr := s.handle(msg)
+		if msg.ID == nil {
+			// notifications have no response
+			continue
+		}
+		if err := s.reply(msg.ID, result, rerr); err != nil {
+			return err
+		}
+	}
+}
+
+func (s *Server) reply(id *json.RawMessage, result interface{}, rerr *responseError) error {
+	resp := response{JSONRPC: "2.0", ID: id, Error: rerr}
+	if rerr == nil {
+		r, err := json.Marshal(result)
+		if err != nil {
+			return err
+		}
+		raw := json.RawMessage(r)
+		resp.Result = &raw
+	}
+	return writeMessage(s.out, resp)
+}
+
+func (s *Server) notify(method string, params interface{}) *responseError {
+	if err := writeMessage(s.out, notification{JSONRPC: "2.0", Method: method, Params: params}); err != nil {
+		return &responseError{Code: internalError, Message: err.Error()}
+	}
+	return nil
+}
+
+func (s *Server) publishDiagnostics(doc *document) *responseError {
+	diagnostics := doc.diagnostics()
+	if diagnostics == nil {
+		diagnostics = []Diagnostic{}
+	}
+	return s.notify("textDocument/publishDiagnostics", publishDiagnosticsParams{URI: doc.uri, Diagnostics: diagnostics})
+}
+
+func (s *Server) document(uri string) (*document, *responseError) {
+	doc, found := s.docs[uri]
+	if !found {
+		return nil, &responseError{Code: invalidParams, Message: "unknown document " + uri}
+	}
+	return doc, nil
+}
+
+// handle handles a request or notification, returning its result.
+func (s *Server) handle(msg message) (interface{}, *responseError) {
+	unmarshal := func(v interface{}) *responseError {
+		if err := json.Unmarshal(msg.Params, v); err != nil {
+			return &responseError{Code: invalidParams, Message: err.Error()}
+		}
+		return nil
+	}
+
+	switch msg.Method {
+	case "initialize":
+		return initializeResult{Capabilities: serverCapabilities{
+			TextDocumentSync:           syncFull,
+			HoverProvider:              true,
+			DefinitionProvider:         true,
+			DocumentFormattingProvider: true,
+		}}, nil
+
+	case "shutdown":
+		s.shutdown = true
+		return nil, nil
+
+	case "textDocument/didOpen":
+		var params didOpenTextDocumentParams
+		if err := unmarshal(&params); err != nil {
+			return nil, err
+		}
+		doc := &document{uri: params.TextDocument.URI, text: params.TextDocument.Text}
+		s.docs[doc.uri] = doc
+		return nil, s.publishDiagnostics(doc)
+
+	case "textDocument/didChange":
+		var params didChangeTextDocumentParams
+		if err := unmarshal(&params); err != nil {
+			return nil, err
+		}
+		doc, err := s.document(params.TextDocument.URI)
+		if err != nil {
+			return nil, err
+		}
+		for _, change := range params.ContentChanges {
+			doc.applyChange(change)
+		}
+		return nil, s.publishDiagnostics(doc)
+
+	case "textDocument/didClose":
+		var params didCloseTextDocumentParams
+		if err := unmarshal(&params); err != nil {
+			return nil, err
+		}
+		delete(s.docs, params.TextDocument.URI)
+		return nil, s.notify("textDocument/publishDiagnostics", publishDiagnosticsParams{URI: params.TextDocument.URI, Diagnostics: []Diagnostic{}})
+
+	case "textDocument/formatting":
+		var params documentFormattingParams
+		if err := unmarshal(&params); err != nil {
+			return nil, err
+		}
+		doc, rerr := s.document(params.TextDocument.URI)
+		if rerr != nil {
+			return nil, rerr
+		}
+		edits, err := doc.format()
+		if err != nil {
+			return nil, &responseError{Code: internalError, Message: err.Error()}
+		}
+		return edits, nil
+
+	case "textDocument/definition", "textDocument/hover":
+		var params textDocumentPositionParams
+		if err := unmarshal(&params); err != nil {
+			return nil, err
+		}
+		doc, err := s.document(params.TextDocument.URI)
+		if err != nil {
+			return nil, err
+		}
+		if msg.Method == "textDocument/definition" {
+			return doc.definition(params.Position), nil
+		}
+		return doc.hover(params.Position), nil
+
+	case "initialized", "textDocument/didSave", "$/cancelRequest":
+		return nil, nil
+
+	default:
+		return nil, &responseError{Code: methodNotFound, Message: "method not supported: " + msg.Method}
+	}
+}
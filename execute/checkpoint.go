@@ -0,0 +1,302 @@
+package execute
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/antha-lang/antha/antha/anthalib/wtype"
+	api "github.com/antha-lang/antha/api/v1"
+	"github.com/antha-lang/antha/inject"
+	"github.com/antha-lang/antha/meta"
+	"github.com/antha-lang/antha/workflow"
+)
+
+// A Checkpoint saves the outputs of each workflow process to a directory,
+// keyed by process name and a hash of the process inputs, so that a later
+// run can skip processes whose inputs have not changed.
+//
+// Only processes that do not issue instructions are saved: instructions
+// refer to samples that only exist within a single run and cannot be
+// replayed.
+type Checkpoint struct {
+	Dir    string
+	traces *processTraces // if not nil, the instructions issued by each process
+}
+
+var _ workflow.Checkpointer = (*Checkpoint)(nil)
+
+func (a *Checkpoint) marshal(value inject.Value) ([]byte, error) {
+	m := &meta.Marshaler{
+		Struct: marshalStruct,
+	}
+	return m.Marshal(value)
+}
+
+// hash returns a digest of the inputs of a process that is stable between
+// runs
+func (a *Checkpoint) hash(funcName string, params inject.Value) (string, error) {
+	m := &meta.Marshaler{
+		Struct: hashStruct,
+	}
+	bs, err := m.Marshal(params)
+	if err != nil {
+		return "", err
+	}
+
+	h := sha256.New()
+	fmt.Fprintf(h, "%s\n", funcName) // nolint: errcheck
+	h.Write(bs)                      // nolint: errcheck
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// hashStruct is marshalStruct without the IDs of liquids, instructions,
+// plates and tipboxes, which are regenerated on every run. Liquids inside
+// other structs are marshaled as they are.
+func hashStruct(obj interface{}) ([]byte, error) {
+	switch obj := obj.(type) {
+	case wtype.Liquid:
+		return json.Marshal(stableLiquid(&obj))
+	case wtype.LHInstruction:
+		obj.ID = ""
+		obj.BlockID = wtype.BlockID{}
+		obj.Inputs = stableLiquids(obj.Inputs)
+		obj.Outputs = stableLiquids(obj.Outputs)
+		return json.Marshal(obj)
+	case wtype.Plate:
+		return json.Marshal(stablePlate(&obj))
+	case wtype.LHTipbox:
+		tb, err := stableTipbox(&obj)
+		if err != nil {
+			return nil, err
+		}
+		return json.Marshal(tb)
+	default:
+		return marshalStruct(obj)
+	}
+}
+
+func stableLiquid(l *wtype.Liquid) *wtype.Liquid {
+	if l == nil {
+		return nil
+	}
+	r := *l
+	r.ID = ""
+	r.BlockID = wtype.BlockID{}
+	r.ParentID = ""
+	r.DaughtersID = nil
+	r.Inst = ""
+	return &r
+}
+
+func stableLiquids(ls []*wtype.Liquid) []*wtype.Liquid {
+	var r []*wtype.Liquid
+	for _, l := range ls {
+		r = append(r, stableLiquid(l))
+	}
+	return r
+}
+
+// stablePlate returns the serialized form of a plate without the IDs of the
+// plate, its wells or their contents, nor the name and locations derived
+// from the ID of the plate
+func stablePlate(p *wtype.Plate) wtype.SLHPlate {
+	r := p.ToSLHPLate()
+	r.ID = ""
+	r.Inst = ""
+	if r.Name == defaultName(p.Type, p.ID) {
+		r.Name = ""
+	}
+	r.Welltype = stableWell(p.Welltype, p.ID)
+	r.Wellcoords = make(map[string]*wtype.LHWell, len(p.Wellcoords))
+	for crd, w := range p.Wellcoords {
+		r.Wellcoords[crd] = stableWell(w, p.ID)
+	}
+	return r
+}
+
+func stableWell(w *wtype.LHWell, plateID string) *wtype.LHWell {
+	if w == nil {
+		return nil
+	}
+	r := *w
+	r.ID = ""
+	r.Inst = ""
+	r.WContents = stableLiquid(w.WContents)
+	if r.WContents != nil && plateID != "" {
+		r.WContents.Loc = strings.TrimPrefix(r.WContents.Loc, plateID+":")
+	}
+	return &r
+}
+
+// defaultName returns the name given to new plates and tipboxes, which is
+// derived from their ID
+func defaultName(typ, id string) string {
+	if len(id) < 3 {
+		return ""
+	}
+	return fmt.Sprintf("%s_%s", typ, id[1:len(id)-2])
+}
+
+// stableTipbox returns the fields of the serialized form of a tipbox
+// without the IDs of the tipbox, its tips or their contents, nor the name
+// derived from the ID of the tipbox
+func stableTipbox(tb *wtype.LHTipbox) (map[string]interface{}, error) {
+	r := *tb
+	r.ID = ""
+	if r.Boxname == defaultName(tb.Type, tb.ID) {
+		r.Boxname = ""
+	}
+	r.AsWell = stableWell(tb.AsWell, tb.ID)
+	r.Tiptype = nil
+	r.Tips = nil
+
+	fields, err := jsonFields(&r)
+	if err != nil {
+		return nil, err
+	}
+
+	if fields["Tiptype"], err = stableTip(tb.Tiptype); err != nil {
+		return nil, err
+	}
+	tips := make([][]map[string]interface{}, len(tb.Tips))
+	for i, row := range tb.Tips {
+		tips[i] = make([]map[string]interface{}, len(row))
+		for j, tip := range row {
+			if tips[i][j], err = stableTip(tip); err != nil {
+				return nil, err
+			}
+		}
+	}
+	fields["Tips"] = tips
+	return fields, nil
+}
+
+// stableTip returns the fields of the serialized form of a tip without the
+// IDs of the tip or its contents
+func stableTip(tip *wtype.LHTip) (map[string]interface{}, error) {
+	if tip == nil {
+		return nil, nil
+	}
+	fields, err := jsonFields(tip)
+	if err != nil {
+		return nil, err
+	}
+	fields["ID"] = ""
+	fields["Contents"] = stableLiquid(tip.Contents())
+	return fields, nil
+}
+
+// jsonFields returns the fields of the JSON object that obj marshals to
+func jsonFields(obj interface{}) (map[string]interface{}, error) {
+	bs, err := json.Marshal(obj)
+	if err != nil {
+		return nil, err
+	}
+	var fields map[string]interface{}
+	if err := json.Unmarshal(bs, &fields); err != nil {
+		return nil, err
+	}
+	return fields, nil
+}
+
+func (a *Checkpoint) fileName(process, funcName string, params inject.Value) (string, error) {
+	h, err := a.hash(funcName, params)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(a.Dir, process, h+".json"), nil
+}
+
+// Load implements workflow.Checkpointer
+func (a *Checkpoint) Load(ctx context.Context, process, funcName string, params inject.Value) (inject.Value, bool, error) {
+	fn, err := a.fileName(process, funcName, params)
+	if err != nil {
+		return nil, false, err
+	}
+
+	bs, err := ioutil.ReadFile(fn)
+	if os.IsNotExist(err) {
+		return nil, false, nil
+	} else if err != nil {
+		return nil, false, err
+	}
+
+	var saved map[string]json.RawMessage
+	if err := json.Unmarshal(bs, &saved); err != nil {
+		return nil, false, fmt.Errorf("cannot read %s: %s", fn, err)
+	}
+
+	runner, err := inject.Find(ctx, inject.NameQuery{
+		Repo:  funcName,
+		Stage: api.ElementStage_STEPS,
+	})
+	if err != nil {
+		return nil, false, err
+	}
+	cr, ok := runner.(inject.TypedRunner)
+	if !ok {
+		return nil, false, fmt.Errorf("cannot get type information for component %q: type %T", funcName, runner)
+	}
+
+	um := &unmarshaler{}
+	m := &meta.Unmarshaler{
+		Struct: func(data []byte, obj interface{}) error {
+			return um.unmarshalStruct(ctx, data, obj)
+		},
+	}
+
+	outs := inject.MakeValue(cr.Output())
+	out := make(inject.Value)
+	for name, data := range saved {
+		value, ok := outs[name]
+		if !ok {
+			// Output saved by an older version of the component
+			return nil, false, nil
+		}
+		if err := m.Unmarshal(data, &value); err != nil {
+			return nil, false, fmt.Errorf("cannot read output %q from %s: %s", name, fn, err)
+		}
+		out[name] = value
+	}
+	if len(out) != len(outs) {
+		return nil, false, nil
+	}
+
+	return out, true, nil
+}
+
+// Save implements workflow.Checkpointer
+func (a *Checkpoint) Save(ctx context.Context, process, funcName string, params, out inject.Value) error {
+	if a.traces != nil && a.traces.Issued(process) {
+		return nil
+	}
+
+	fn, err := a.fileName(process, funcName, params)
+	if err != nil {
+		return err
+	}
+
+	bs, err := a.marshal(out)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(fn), 0755); err != nil {
+		return err
+	}
+
+	// Write to a temporary file first so that an interrupted run never
+	// leaves a partial checkpoint behind
+	tmp := fn + ".tmp"
+	if err := ioutil.WriteFile(tmp, bs, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, fn)
+}
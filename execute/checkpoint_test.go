@@ -0,0 +1,251 @@
+package execute
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/antha-lang/antha/antha/anthalib/wtype"
+	"github.com/antha-lang/antha/antha/anthalib/wunit"
+	"github.com/antha-lang/antha/inject"
+	"github.com/antha-lang/antha/inventory"
+	"github.com/antha-lang/antha/inventory/testinventory"
+)
+
+type checkpointInput struct {
+	Name   string
+	Sample *wtype.Liquid
+}
+
+type checkpointOutput struct {
+	Report wtype.File
+	Sample *wtype.Liquid
+}
+
+func TestCheckpoint(t *testing.T) {
+	dir, err := ioutil.TempDir("", "checkpoint")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir) // nolint: errcheck
+
+	calls := 0
+	ctx := inject.NewContext(context.Background())
+	if err := inject.Add(ctx, inject.Name{Repo: "Report"}, &inject.CheckedRunner{
+		RunFunc: func(_ context.Context, value inject.Value) (inject.Value, error) {
+			calls++
+			var in checkpointInput
+			if err := inject.Assign(value, &in); err != nil {
+				return nil, err
+			}
+			out := checkpointOutput{
+				Report: wtype.File{Name: in.Name},
+				Sample: in.Sample,
+			}
+			if err := out.Report.WriteAll([]byte("hello " + in.Name)); err != nil {
+				return nil, err
+			}
+			return inject.MakeValue(out), nil
+		},
+		In:  &checkpointInput{},
+		Out: &checkpointOutput{},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	cp := &Checkpoint{Dir: dir}
+
+	makeParams := func(name string) inject.Value {
+		sample := wtype.NewLHComponent()
+		sample.CName = "water"
+		return inject.MakeValue(checkpointInput{Name: name, Sample: sample})
+	}
+
+	if _, found, err := cp.Load(ctx, "p", "Report", makeParams("a")); err != nil {
+		t.Fatal(err)
+	} else if found {
+		t.Fatal("expecting no checkpoint before saving")
+	}
+
+	params := makeParams("a")
+	out, err := inject.Call(ctx, inject.NameQuery{Repo: "Report"}, params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cp.Save(ctx, "p", "Report", params, out); err != nil {
+		t.Fatal(err)
+	}
+
+	// New sample IDs should not change the checkpoint used
+	loaded, found, err := cp.Load(ctx, "p", "Report", makeParams("a"))
+	if err != nil {
+		t.Fatal(err)
+	} else if !found {
+		t.Fatal("expecting checkpoint to be found")
+	}
+
+	var got checkpointOutput
+	if err := inject.Assign(loaded, &got); err != nil {
+		t.Fatal(err)
+	}
+	if bs, err := got.Report.ReadAll(); err != nil {
+		t.Fatal(err)
+	} else if string(bs) != "hello a" {
+		t.Errorf("expecting %q but got %q", "hello a", string(bs))
+	}
+	if got.Sample == nil || got.Sample.CName != "water" {
+		t.Errorf("expecting sample water but got %v", got.Sample)
+	}
+
+	if _, found, err := cp.Load(ctx, "p", "Report", makeParams("b")); err != nil {
+		t.Fatal(err)
+	} else if found {
+		t.Error("expecting no checkpoint for different inputs")
+	}
+
+	if calls != 1 {
+		t.Errorf("expecting 1 call but got %d", calls)
+	}
+}
+
+type layoutInput struct {
+	Plate *wtype.Plate
+	Tips  *wtype.LHTipbox
+}
+
+type layoutOutput struct {
+	Wells int
+}
+
+func TestCheckpointPlates(t *testing.T) {
+	dir, err := ioutil.TempDir("", "checkpoint")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir) // nolint: errcheck
+
+	ctx := testinventory.NewContext(inject.NewContext(context.Background()))
+	if err := inject.Add(ctx, inject.Name{Repo: "Layout"}, &inject.CheckedRunner{
+		RunFunc: func(_ context.Context, value inject.Value) (inject.Value, error) {
+			var in layoutInput
+			if err := inject.Assign(value, &in); err != nil {
+				return nil, err
+			}
+			return inject.MakeValue(layoutOutput{Wells: in.Plate.Nwells}), nil
+		},
+		In:  &layoutInput{},
+		Out: &layoutOutput{},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	cp := &Checkpoint{Dir: dir}
+
+	// each run loads new plates and tipboxes, with new IDs
+	makeParams := func(plateType string) inject.Value {
+		plate, err := inventory.NewPlate(ctx, plateType)
+		if err != nil {
+			t.Fatal(err)
+		}
+		water := wtype.NewLHComponent()
+		water.CName = "water"
+		water.SetVolume(wunit.NewVolume(50.0, "ul"))
+		if err := plate.Wellcoords["A1"].AddComponent(water); err != nil {
+			t.Fatal(err)
+		}
+		tips, err := inventory.NewTipbox(ctx, "CyBio250Tipbox")
+		if err != nil {
+			t.Fatal(err)
+		}
+		return inject.MakeValue(layoutInput{Plate: plate, Tips: tips})
+	}
+
+	params := makeParams("pcrplate_with_cooler")
+	out, err := inject.Call(ctx, inject.NameQuery{Repo: "Layout"}, params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cp.Save(ctx, "p", "Layout", params, out); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, found, err := cp.Load(ctx, "p", "Layout", makeParams("pcrplate_with_cooler")); err != nil {
+		t.Fatal(err)
+	} else if !found {
+		t.Error("expecting checkpoint to be found for the same plate type")
+	}
+
+	if _, found, err := cp.Load(ctx, "p", "Layout", makeParams("pcrplate_skirted")); err != nil {
+		t.Fatal(err)
+	} else if found {
+		t.Error("expecting no checkpoint for a different plate type")
+	}
+}
+
+type userRecord struct {
+	ID   string
+	Inst string
+}
+
+func TestCheckpointHashKeepsUserFields(t *testing.T) {
+	cp := &Checkpoint{}
+
+	hash := func(value interface{}) string {
+		h, err := cp.hash("Report", inject.Value{"Record": value})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return h
+	}
+
+	for _, pair := range [][2]interface{}{
+		{userRecord{ID: "a"}, userRecord{ID: "b"}},
+		{userRecord{Inst: "a"}, userRecord{Inst: "b"}},
+		{map[string]string{"ParentID": "a"}, map[string]string{"ParentID": "b"}},
+		{[]map[string]string{{"BlockID": "a"}}, []map[string]string{{"BlockID": "b"}}},
+	} {
+		if hash(pair[0]) == hash(pair[1]) {
+			t.Errorf("expecting %v and %v to hash differently", pair[0], pair[1])
+		}
+	}
+
+	a, b := wtype.NewLHComponent(), wtype.NewLHComponent()
+	a.CName, b.CName = "water", "water"
+	a.ParentID, a.Inst = "parent", "inst"
+	if hash(a) != hash(b) {
+		t.Error("expecting liquids differing only in IDs to hash the same")
+	}
+}
+
+func TestCheckpointSkipsInstructions(t *testing.T) {
+	dir, err := ioutil.TempDir("", "checkpoint")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir) // nolint: errcheck
+
+	traces := newProcessTraces()
+	cp := &Checkpoint{Dir: dir, traces: traces}
+	ctx := inject.NewContext(context.Background())
+
+	params := inject.MakeValue(checkpointInput{Name: "a"})
+	out := inject.MakeValue(checkpointOutput{})
+
+	ctxA := traces.WithTrace(ctx, "a")
+	traces.WithTrace(ctx, "b")
+	Issue(ctxA, &commandInst{})
+
+	for process, saved := range map[string]bool{"a": false, "b": true} {
+		if err := cp.Save(ctx, process, "Report", params, out); err != nil {
+			t.Fatal(err)
+		}
+		fn, err := cp.fileName(process, "Report", params)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := os.Stat(fn); saved != (err == nil) {
+			t.Errorf("process %s: expecting saved %t, got error %v", process, saved, err)
+		}
+	}
+}
@@ -35,13 +35,30 @@ type Opt struct {
 	// content for each wtype.File from file of the same name in the current
 	// directory.
 	TransitionalReadLocalFiles bool
+	// Maximum number of workflow processes to run at the same time.
+	MaxConcurrency int
+	// If not empty, directory to save process outputs to and to reuse
+	// them from on later runs.
+	CheckpointDir string
 }
 
 // Run is a simple entrypoint for one-shot execution of workflows.
 func Run(parent context.Context, opt Opt) (res *Result, err error) {
 	ctx := sampletracker.NewContext(target.WithTarget(withID(parent, opt.ID), opt.Target))
 
-	w, err := workflow.New(workflow.Opt{FromDesc: opt.Workflow})
+	ctxTr, tr := WithTrace(ctx)
+	procTrs := newProcessTraces()
+
+	wopt := workflow.Opt{
+		FromDesc:       opt.Workflow,
+		MaxConcurrency: opt.MaxConcurrency,
+		ProcessContext: procTrs.WithTrace,
+	}
+	if opt.CheckpointDir != "" {
+		wopt.Checkpointer = &Checkpoint{Dir: opt.CheckpointDir, traces: procTrs}
+	}
+
+	w, err := workflow.New(wopt)
 	if err != nil {
 		return nil, err
 	}
@@ -50,7 +67,6 @@ func Run(parent context.Context, opt Opt) (res *Result, err error) {
 		return nil, err
 	}
 
-	ctxTr, tr := WithTrace(ctx)
 	defer func() {
 		if res := recover(); res == nil {
 			return
@@ -65,8 +81,9 @@ func Run(parent context.Context, opt Opt) (res *Result, err error) {
 		}
 	}()
 	if err := w.Run(ctxTr); err != nil {
-		return nil, err
+		return nil, userError(err)
 	}
+	procTrs.MergeInto(tr, w.Order())
 
 	t, err := target.GetTarget(ctx)
 	if err != nil {
@@ -89,3 +106,21 @@ func Run(parent context.Context, opt Opt) (res *Result, err error) {
 		Insts:    instrs,
 	}, nil
 }
+
+// userError returns the UserError raised by a process run concurrently, if
+// any, so that it is reported in the same way as when processes are run one
+// at a time.
+func userError(err error) error {
+	errs, ok := err.(workflow.Errors)
+	if !ok {
+		return err
+	}
+	for _, pErr := range errs {
+		if p, ok := pErr.Err.(*workflow.PanicError); !ok {
+			continue
+		} else if uErr, ok := p.Value.(UserError); ok {
+			return uErr
+		}
+	}
+	return err
+}
@@ -137,6 +137,21 @@ func (a *unmarshaler) unmarshalStruct(ctx context.Context, data []byte, obj inte
 	return err
 }
 
+// marshalStruct is the inverse of unmarshaler.unmarshalStruct for the
+// values produced by elements.
+func marshalStruct(obj interface{}) ([]byte, error) {
+	switch obj := obj.(type) {
+	case wtype.LHTipbox:
+		return obj.MarshalJSON()
+	case wtype.Plate:
+		return obj.MarshalJSON()
+	case wtype.File:
+		return obj.MarshalJSON()
+	default:
+		return json.Marshal(obj)
+	}
+}
+
 func setParam(ctx context.Context, um *unmarshaler, w *workflow.Workflow, process, name string, data []byte, in map[string]interface{}) error {
 	value, ok := in[name]
 	if !ok {
@@ -155,6 +170,27 @@ func setParam(ctx context.Context, um *unmarshaler, w *workflow.Workflow, proces
 	return w.SetParam(workflow.Port{Process: process, Port: name}, value)
 }
 
+// processInputs returns an example of the inputs of the component run by
+// process
+func processInputs(ctx context.Context, w *workflow.Workflow, process string) (inject.Value, error) {
+	c, err := w.FuncName(process)
+	if err != nil {
+		return nil, fmt.Errorf("cannot get component for process %q: %s", process, err)
+	}
+	runner, err := inject.Find(ctx, inject.NameQuery{
+		Repo:  c,
+		Stage: api.ElementStage_STEPS,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("unknown component %q: %s", c, err)
+	}
+	cr, ok := runner.(inject.TypedRunner)
+	if !ok {
+		return nil, fmt.Errorf("cannot get type information for component %q: type %T", c, runner)
+	}
+	return inject.MakeValue(cr.Input()), nil
+}
+
 func setParams(ctx context.Context, w *workflow.Workflow, params *RawParams, readLocalFiles bool) (*mixer.Opt, error) {
 	if params == nil {
 		return nil, nil
@@ -165,26 +201,22 @@ func setParams(ctx context.Context, w *workflow.Workflow, params *RawParams, rea
 	}
 
 	for process, params := range params.Parameters {
-		c, err := w.FuncName(process)
-		if err != nil {
-			return nil, fmt.Errorf("cannot get component for process %q: %s", process, err)
-		}
-		runner, err := inject.Find(ctx, inject.NameQuery{
-			Repo:  c,
-			Stage: api.ElementStage_STEPS,
-		})
-		if err != nil {
-			return nil, fmt.Errorf("unknown component %q: %s", c, err)
-		}
-		cr, ok := runner.(inject.TypedRunner)
-		if !ok {
-			return nil, fmt.Errorf("cannot get type information for component %q: type %T", c, runner)
-		}
-		in := inject.MakeValue(cr.Input())
 		for name, value := range params {
-			if err := setParam(ctx, um, w, process, name, value, in); err != nil {
-				return nil, fmt.Errorf("cannot assign parameter %q of process %q to %s: %s",
-					name, process, string(value), err)
+			// Parameters of sub-workflows are set on each inner process
+			// that exposes them
+			ports, err := w.ResolvePorts(workflow.Port{Process: process, Port: name})
+			if err != nil {
+				return nil, fmt.Errorf("cannot find parameter %q of process %q: %s", name, process, err)
+			}
+			for _, port := range ports {
+				in, err := processInputs(ctx, w, port.Process)
+				if err != nil {
+					return nil, err
+				}
+				if err := setParam(ctx, um, w, port.Process, port.Port, value, in); err != nil {
+					return nil, fmt.Errorf("cannot assign parameter %q of process %q to %s: %s",
+						name, process, string(value), err)
+				}
 			}
 		}
 	}
@@ -56,3 +56,52 @@ func WithTrace(parent context.Context) (context.Context, *Trace) {
 	tr := &Trace{}
 	return context.WithValue(parent, theTraceKey, tr), tr
 }
+
+// processTraces holds a separate trace for each workflow process so that
+// instructions from processes run concurrently can be merged in the
+// deterministic scheduling order of the workflow.
+type processTraces struct {
+	lock   sync.Mutex
+	traces map[string]*Trace
+}
+
+func newProcessTraces() *processTraces {
+	return &processTraces{
+		traces: make(map[string]*Trace),
+	}
+}
+
+// WithTrace returns a context with a new trace for the given process
+func (a *processTraces) WithTrace(parent context.Context, process string) context.Context {
+	ctx, tr := WithTrace(parent)
+
+	a.lock.Lock()
+	defer a.lock.Unlock()
+
+	a.traces[process] = tr
+	return ctx
+}
+
+// Issued returns true if the given process has issued any instructions
+func (a *processTraces) Issued(process string) bool {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+
+	tr := a.traces[process]
+	return tr != nil && len(tr.Instructions()) != 0
+}
+
+// MergeInto issues the instructions of each process to tr in the given
+// process order.
+func (a *processTraces) MergeInto(tr *Trace, order []string) {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+
+	for _, process := range order {
+		if ptr := a.traces[process]; ptr != nil {
+			for _, inst := range ptr.Instructions() {
+				tr.Issue(inst)
+			}
+		}
+	}
+}
@@ -0,0 +1,223 @@
+package fileinventory
+
+import (
+	"fmt"
+
+	"github.com/antha-lang/antha/antha/anthalib/wtype"
+	"github.com/antha-lang/antha/antha/anthalib/wunit"
+	"github.com/antha-lang/antha/inventory/testinventory"
+)
+
+var (
+	vunit = "ul" // unit used for volumes specified in definitions
+	lunit = "mm" // unit used for lengths specified in definitions
+)
+
+// standard X/Y footprint of SBS format labware
+const (
+	sbsX = 127.76
+	sbsY = 85.48
+)
+
+// Definitions is the contents of a single inventory file. Plates use the
+// same format as the built-in plate library.
+type Definitions struct {
+	Plates     []testinventory.PlateForSerializing
+	Tipboxes   []Tipbox
+	Tipwastes  []Tipwaste
+	Components []Component
+}
+
+// Well describes the wells of a tipbox or tipwaste
+type Well struct {
+	Shape      string               // name of well shape, e.g. "box" or "cylinder"
+	H          float64              // size of well shape in X direction
+	W          float64              // size of well shape in Y direction
+	D          float64              // size of well shape in Z direction
+	MaxVol     float64              // maximum volume well can hold in microlitres
+	MinVol     float64              // residual volume of well in microlitres
+	BottomType wtype.WellBottomType // shape of well bottom
+	BottomH    float64              // height of well bottom in mm
+	X          float64              // size of well in X direction
+	Y          float64              // size of well in Y direction
+	Z          float64              // size of well in Z direction
+	Extra      map[string]interface{}
+}
+
+// Tipbox describes a type of tipbox and the tips it contains
+type Tipbox struct {
+	TipboxType         string
+	Manufacturer       string
+	ColSize            int     // number of tips in a column
+	RowSize            int     // number of tips in a row
+	Height             float64 // size of tipbox in Z direction
+	TipType            string
+	TipManufacturer    string
+	TipMinVol          float64 // minimum volume of tip in microlitres
+	TipMaxVol          float64 // maximum volume of tip in microlitres
+	Filtered           bool
+	TipEffectiveHeight float64 // defaults to the depth of the well shape
+	Well               Well    // well holding each tip, the tip has the same shape
+	TipXOffset         float64 // distance between adjacent tip centres in X direction
+	TipYOffset         float64 // distance between adjacent tip centres in Y direction
+	TipXStart          float64 // offset from corner of tipbox to centre of first tip in X direction
+	TipYStart          float64 // offset from corner of tipbox to centre of first tip in Y direction
+	TipZStart          float64 // offset from top of tipbox to tip
+}
+
+// Tipwaste describes a type of tipwaste
+type Tipwaste struct {
+	TipwasteType string
+	Manufacturer string
+	Capacity     int     // number of tips that can be discarded
+	Height       float64 // size of tipwaste in Z direction
+	Well         Well
+	WellXStart   float64
+	WellYStart   float64
+	WellZStart   float64
+}
+
+// Component describes a named liquid
+type Component struct {
+	Name              string
+	LiquidType        string  // defaults to water
+	Smax              float64 // maximum solubility
+	Concentration     float64 // optional stock concentration
+	ConcentrationUnit string
+}
+
+func validateWell(w Well) error {
+	if wtype.ShapeTypeFromName(w.Shape) == nil {
+		return fmt.Errorf("unknown well shape %q", w.Shape)
+	}
+	if w.BottomType < 0 || int(w.BottomType) >= len(wtype.WellBottomNames) {
+		return fmt.Errorf("unknown well bottom type %d", w.BottomType)
+	}
+	if w.MaxVol <= 0 || w.MinVol < 0 || w.MinVol > w.MaxVol {
+		return fmt.Errorf("invalid well volumes: min %f, max %f", w.MinVol, w.MaxVol)
+	}
+	if w.X <= 0 || w.Y <= 0 || w.Z <= 0 {
+		return fmt.Errorf("invalid well size %f x %f x %f", w.X, w.Y, w.Z)
+	}
+	return nil
+}
+
+func (w Well) shape() *wtype.Shape {
+	return wtype.NewShape(wtype.ShapeTypeFromName(w.Shape), lunit, w.H, w.W, w.D)
+}
+
+func (w Well) lhWell() *wtype.LHWell {
+	well := wtype.NewLHWell(vunit, w.MaxVol, w.MinVol, w.shape(), w.BottomType, w.X, w.Y, w.Z, w.BottomH, lunit)
+	for k, v := range w.Extra {
+		well.Extra[k] = v
+	}
+	return well
+}
+
+func validateLayout(colSize, rowSize int, height float64) error {
+	if colSize <= 0 || rowSize <= 0 {
+		return fmt.Errorf("invalid layout %d by %d", rowSize, colSize)
+	}
+	if height <= 0 {
+		return fmt.Errorf("invalid height %f", height)
+	}
+	return nil
+}
+
+// makePlate validates a plate definition and returns it if it is valid
+func makePlate(p testinventory.PlateForSerializing) (testinventory.PlateForSerializing, error) {
+	if p.PlateType == "" {
+		return p, fmt.Errorf("missing plate type")
+	}
+	err := validateLayout(p.ColSize, p.RowSize, p.Height)
+	if err == nil {
+		err = validateWell(Well{
+			Shape:      p.WellShape,
+			MaxVol:     p.MaxVol,
+			MinVol:     p.MinVol,
+			BottomType: p.BottomType,
+			X:          p.WellX,
+			Y:          p.WellY,
+			Z:          p.WellZ,
+		})
+	}
+	if err != nil {
+		return p, fmt.Errorf("plate %s: %s", p.PlateType, err)
+	}
+	return p, nil
+}
+
+// makeTipbox validates a tipbox definition and constructs it
+func makeTipbox(t Tipbox) (*wtype.LHTipbox, error) {
+	if t.TipboxType == "" {
+		return nil, fmt.Errorf("missing tipbox type")
+	}
+
+	err := validateLayout(t.ColSize, t.RowSize, t.Height)
+	if err == nil {
+		err = validateWell(t.Well)
+	}
+	if err == nil && t.TipType == "" {
+		err = fmt.Errorf("missing tip type")
+	}
+	if err == nil && (t.TipMaxVol <= 0 || t.TipMinVol < 0 || t.TipMinVol > t.TipMaxVol) {
+		err = fmt.Errorf("invalid tip volumes: min %f, max %f", t.TipMinVol, t.TipMaxVol)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("tipbox %s: %s", t.TipboxType, err)
+	}
+
+	tip := wtype.NewLHTip(t.TipManufacturer, t.TipType, t.TipMinVol, t.TipMaxVol, vunit, t.Filtered, t.Well.shape(), t.TipEffectiveHeight)
+	size := wtype.Coordinates3D{X: sbsX, Y: sbsY, Z: t.Height}
+	return wtype.NewLHTipbox(t.ColSize, t.RowSize, size, t.Manufacturer, t.TipboxType, tip, t.Well.lhWell(), t.TipXOffset, t.TipYOffset, t.TipXStart, t.TipYStart, t.TipZStart), nil
+}
+
+// makeTipwaste validates a tipwaste definition and constructs it
+func makeTipwaste(t Tipwaste) (*wtype.LHTipwaste, error) {
+	if t.TipwasteType == "" {
+		return nil, fmt.Errorf("missing tipwaste type")
+	}
+
+	err := validateLayout(1, 1, t.Height)
+	if err == nil {
+		err = validateWell(t.Well)
+	}
+	if err == nil && t.Capacity <= 0 {
+		err = fmt.Errorf("invalid capacity %d", t.Capacity)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("tipwaste %s: %s", t.TipwasteType, err)
+	}
+
+	size := wtype.Coordinates3D{X: sbsX, Y: sbsY, Z: t.Height}
+	return wtype.NewLHTipwaste(t.Capacity, t.TipwasteType, t.Manufacturer, size, t.Well.lhWell(), t.WellXStart, t.WellYStart, t.WellZStart), nil
+}
+
+// makeComponent validates a component definition and constructs it
+func makeComponent(c Component) (*wtype.Liquid, error) {
+	if c.Name == "" {
+		return nil, fmt.Errorf("missing component name")
+	}
+
+	lt := wtype.LTWater
+	if c.LiquidType != "" {
+		var err error
+		if lt, err = wtype.LiquidTypeFromString(wtype.PolicyName(c.LiquidType)); err != nil {
+			return nil, fmt.Errorf("component %s: %s", c.Name, err)
+		}
+	}
+
+	l := wtype.NewLHComponent()
+	l.CName = c.Name
+	l.Type = lt
+	l.Smax = c.Smax
+
+	if c.ConcentrationUnit != "" {
+		if !wunit.GetGlobalUnitRegistry().ValidUnitForType("Concentration", c.ConcentrationUnit) {
+			return nil, fmt.Errorf("component %s: unknown concentration unit %q", c.Name, c.ConcentrationUnit)
+		}
+		l.SetConcentration(wunit.NewConcentration(c.Concentration, c.ConcentrationUnit))
+	}
+
+	return l, nil
+}
@@ -0,0 +1,212 @@
+// Package fileinventory provides an inventory of plates, tipboxes, tipwastes
+// and components defined by JSON or YAML files.
+//
+// Each file in an inventory directory contains Definitions, for example:
+//
+//	Plates:
+//	- PlateType: my_plate
+//	  WellShape: cylinder
+//	  ...
+//	Components:
+//	- Name: glycerol
+//	  LiquidType: glycerol
+//
+// Definitions are checked and constructed when they are loaded. An inventory
+// can be layered over another inventory, in which case its definitions take
+// precedence over those of the underlying inventory.
+package fileinventory
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/antha-lang/antha/antha/anthalib/wtype"
+	"github.com/antha-lang/antha/inventory"
+	"github.com/antha-lang/antha/inventory/testinventory"
+)
+
+type plateLister interface {
+	XXXGetPlates(ctx context.Context) ([]*wtype.Plate, error)
+}
+
+// Inventory is an inventory.Inventory backed by definition files
+type Inventory struct {
+	base            inventory.Inventory
+	componentByName map[string]*wtype.Liquid
+	plateByType     map[string]testinventory.PlateForSerializing
+	tipboxByType    map[string]*wtype.LHTipbox
+	tipwasteByType  map[string]*wtype.LHTipwaste
+}
+
+// New returns an inventory containing the definitions in the given
+// directories. Definitions in later directories override those in earlier
+// ones, and all definitions override those of base. Items not defined in any
+// directory are looked up in base, which may be nil.
+func New(base inventory.Inventory, dirs ...string) (*Inventory, error) {
+	inv := &Inventory{
+		base:            base,
+		componentByName: make(map[string]*wtype.Liquid),
+		plateByType:     make(map[string]testinventory.PlateForSerializing),
+		tipboxByType:    make(map[string]*wtype.LHTipbox),
+		tipwasteByType:  make(map[string]*wtype.LHTipwaste),
+	}
+
+	for _, dir := range dirs {
+		layer, err := readDir(dir)
+		if err != nil {
+			return nil, err
+		}
+		if err := inv.add(layer); err != nil {
+			return nil, fmt.Errorf("%s: %s", dir, err)
+		}
+	}
+
+	return inv, nil
+}
+
+// NewContext returns a context whose inventory is the inventory in ctx
+// overridden by the definitions in the given directories
+func NewContext(ctx context.Context, dirs ...string) (context.Context, error) {
+	inv, err := New(inventory.GetInventory(ctx), dirs...)
+	if err != nil {
+		return nil, err
+	}
+	return inventory.NewContext(ctx, inv), nil
+}
+
+// add constructs the definitions of a single layer. Definitions within a
+// layer must be unique but may override those of previous layers.
+func (i *Inventory) add(defs []*Definitions) error {
+	components := make(map[string]bool)
+	plates := make(map[string]bool)
+	tipboxes := make(map[string]bool)
+	tipwastes := make(map[string]bool)
+
+	for _, d := range defs {
+		for _, c := range d.Components {
+			l, err := makeComponent(c)
+			if err != nil {
+				return err
+			} else if components[l.CName] {
+				return fmt.Errorf("component %s already defined", l.CName)
+			}
+			components[l.CName] = true
+			i.componentByName[l.CName] = l
+		}
+
+		for _, p := range d.Plates {
+			p, err := makePlate(p)
+			if err != nil {
+				return err
+			} else if plates[p.PlateType] {
+				return fmt.Errorf("plate %s already defined", p.PlateType)
+			}
+			plates[p.PlateType] = true
+			i.plateByType[p.PlateType] = p
+		}
+
+		for _, t := range d.Tipboxes {
+			tb, err := makeTipbox(t)
+			if err != nil {
+				return err
+			}
+			// tipboxes can be looked up by tipbox type or tip type
+			typs := []string{tb.Type}
+			if tb.Tiptype.Type != tb.Type {
+				typs = append(typs, tb.Tiptype.Type)
+			}
+			for _, typ := range typs {
+				if tipboxes[typ] {
+					return fmt.Errorf("tipbox %s already defined", typ)
+				}
+				tipboxes[typ] = true
+				i.tipboxByType[typ] = tb
+			}
+		}
+
+		for _, t := range d.Tipwastes {
+			tw, err := makeTipwaste(t)
+			if err != nil {
+				return err
+			} else if tipwastes[tw.Type] {
+				return fmt.Errorf("tipwaste %s already defined", tw.Type)
+			}
+			tipwastes[tw.Type] = true
+			i.tipwasteByType[tw.Type] = tw
+		}
+	}
+
+	return nil
+}
+
+// NewComponent implements inventory.Inventory
+func (i *Inventory) NewComponent(ctx context.Context, name string) (*wtype.Liquid, error) {
+	if c, ok := i.componentByName[name]; ok {
+		// Cp is required here to ensure component IDs are unique
+		return c.Cp(), nil
+	} else if i.base != nil {
+		return i.base.NewComponent(ctx, name)
+	}
+	return nil, fmt.Errorf("%s: invalid solution: %s", inventory.ErrUnknownType, name)
+}
+
+// NewPlate implements inventory.Inventory
+func (i *Inventory) NewPlate(ctx context.Context, typ string) (*wtype.Plate, error) {
+	if p, ok := i.plateByType[typ]; ok {
+		return p.LHPlate(), nil
+	} else if i.base != nil {
+		return i.base.NewPlate(ctx, typ)
+	}
+	return nil, fmt.Errorf("%s: invalid plate: %s", inventory.ErrUnknownType, typ)
+}
+
+// NewTipbox implements inventory.Inventory
+func (i *Inventory) NewTipbox(ctx context.Context, typ string) (*wtype.LHTipbox, error) {
+	if tb, ok := i.tipboxByType[typ]; ok {
+		return tb.Dup(), nil
+	} else if i.base != nil {
+		return i.base.NewTipbox(ctx, typ)
+	}
+	return nil, inventory.ErrUnknownType
+}
+
+// NewTipwaste implements inventory.Inventory
+func (i *Inventory) NewTipwaste(ctx context.Context, typ string) (*wtype.LHTipwaste, error) {
+	if tw, ok := i.tipwasteByType[typ]; ok {
+		return tw.Dup(), nil
+	} else if i.base != nil {
+		return i.base.NewTipwaste(ctx, typ)
+	}
+	return nil, inventory.ErrUnknownType
+}
+
+// XXXGetPlates returns the plates of the underlying inventory, if it can
+// list them, merged with the plates defined by files. Plates are sorted by
+// type.
+func (i *Inventory) XXXGetPlates(ctx context.Context) ([]*wtype.Plate, error) {
+	plateByType := make(map[string]*wtype.Plate)
+	if lister, ok := i.base.(plateLister); ok {
+		plates, err := lister.XXXGetPlates(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, p := range plates {
+			plateByType[p.Type] = p
+		}
+	}
+	for typ, p := range i.plateByType {
+		plateByType[typ] = p.LHPlate()
+	}
+
+	var ps []*wtype.Plate
+	for _, p := range plateByType {
+		ps = append(ps, p)
+	}
+
+	sort.Slice(ps, func(i, j int) bool {
+		return ps[i].Type < ps[j].Type
+	})
+
+	return ps, nil
+}
@@ -0,0 +1,205 @@
+package fileinventory
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/antha-lang/antha/inventory"
+	"github.com/antha-lang/antha/inventory/testinventory"
+)
+
+const platesYAML = `
+Plates:
+- PlateType: pcrplate_skirted
+  Manufacturer: Me
+  WellShape: cylinder
+  WellH: 5.5
+  WellW: 5.5
+  WellD: 15
+  MaxVol: 150
+  MinVol: 5
+  BottomType: 1
+  BottomH: 1.4
+  WellX: 5.5
+  WellY: 5.5
+  WellZ: 15
+  ColSize: 8
+  RowSize: 12
+  Height: 15.5
+  WellXOffset: 9
+  WellYOffset: 9
+  WellXStart: 14.28
+  WellYStart: 11.24
+  WellZStart: 1.3
+- PlateType: my_reservoir
+  WellShape: box
+  MaxVol: 300000
+  MinVol: 5000
+  WellX: 108
+  WellY: 72
+  WellZ: 40
+  ColSize: 1
+  RowSize: 1
+  Height: 44
+  WellXStart: 63.88
+  WellYStart: 42.74
+  WellZStart: 4
+`
+
+const othersJSON = `{
+  "Components": [
+    {"Name": "glycerol", "LiquidType": "glycerol", "Smax": 9999},
+    {"Name": "water", "LiquidType": "multiwater", "Smax": 9999, "Concentration": 2, "ConcentrationUnit": "X"}
+  ],
+  "Tipboxes": [
+    {
+      "TipboxType": "MyTipbox",
+      "Manufacturer": "Me",
+      "ColSize": 8,
+      "RowSize": 12,
+      "Height": 60,
+      "TipType": "MyTip",
+      "TipManufacturer": "Me",
+      "TipMinVol": 1,
+      "TipMaxVol": 100,
+      "Well": {"Shape": "cylinder", "H": 7.3, "W": 7.3, "D": 51.2, "MaxVol": 100, "MinVol": 1, "X": 7.3, "Y": 7.3, "Z": 51.2},
+      "TipXOffset": 9,
+      "TipYOffset": 9,
+      "TipXStart": 14.28,
+      "TipYStart": 11.24
+    }
+  ],
+  "Tipwastes": [
+    {
+      "TipwasteType": "MyTipwaste",
+      "Capacity": 500,
+      "Height": 90,
+      "Well": {"Shape": "box", "H": 90, "W": 170, "D": 90, "MaxVol": 800000, "MinVol": 800000, "X": 90, "Y": 170, "Z": 90}
+    }
+  ]
+}`
+
+func writeFiles(t *testing.T, files map[string]string) string {
+	dir, err := ioutil.TempDir("", "fileinventory")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, content := range files {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestLayeredLookup(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"plates.yaml": platesYAML,
+		"others.json": othersJSON,
+		"README":      "ignored",
+	})
+	defer os.RemoveAll(dir) // nolint: errcheck
+
+	ctx, err := NewContext(testinventory.NewContext(context.Background()), dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// overridden built-in
+	if p, err := inventory.NewPlate(ctx, "pcrplate_skirted"); err != nil {
+		t.Error(err)
+	} else if e, f := 150.0, p.Welltype.MaxVol; e != f {
+		t.Errorf("expected overridden max volume %f found %f", e, f)
+	}
+
+	// built-in
+	if _, err := inventory.NewPlate(ctx, "greiner384"); err != nil {
+		t.Error(err)
+	}
+
+	// new definitions
+	if p, err := inventory.NewPlate(ctx, "my_reservoir"); err != nil {
+		t.Error(err)
+	} else if p.WellsX() != 1 || p.WellsY() != 1 {
+		t.Errorf("expected 1 by 1 plate found %d by %d", p.WellsX(), p.WellsY())
+	}
+	for _, typ := range []string{"MyTipbox", "MyTip"} {
+		if tb, err := inventory.NewTipbox(ctx, typ); err != nil {
+			t.Error(err)
+		} else if tb.Type != "MyTipbox" {
+			t.Errorf("expected tipbox MyTipbox found %s", tb.Type)
+		}
+	}
+	if _, err := inventory.NewTipwaste(ctx, "MyTipwaste"); err != nil {
+		t.Error(err)
+	}
+	if c, err := inventory.NewComponent(ctx, "water"); err != nil {
+		t.Error(err)
+	} else if e, f := "multiwater", c.TypeName(); e != f {
+		t.Errorf("expected overridden liquid type %s found %s", e, f)
+	}
+
+	if _, err := inventory.NewPlate(ctx, "no_such_plate"); err == nil {
+		t.Error("expected error for unknown plate")
+	}
+}
+
+func TestGetPlates(t *testing.T) {
+	dir := writeFiles(t, map[string]string{"plates.yml": platesYAML})
+	defer os.RemoveAll(dir) // nolint: errcheck
+
+	ctx := testinventory.NewContext(context.Background())
+	builtin, err := inventory.XXXNewPlates(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if ctx, err = NewContext(ctx, dir); err != nil {
+		t.Fatal(err)
+	}
+	plates, err := inventory.XXXNewPlates(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if e, f := len(builtin)+1, len(plates); e != f {
+		t.Errorf("expected %d plates found %d", e, f)
+	}
+	for i := 1; i < len(plates); i++ {
+		if plates[i-1].Type >= plates[i].Type {
+			t.Errorf("plates not sorted: %s before %s", plates[i-1].Type, plates[i].Type)
+		}
+	}
+	for _, p := range plates {
+		if p.Type == "pcrplate_skirted" && p.Welltype.MaxVol != 150.0 {
+			t.Errorf("expected overridden plate, found max volume %f", p.Welltype.MaxVol)
+		}
+	}
+}
+
+func TestInvalidDefinitions(t *testing.T) {
+	for name, content := range map[string]string{
+		"unknown shape":   `{"Plates": [{"PlateType": "p", "WellShape": "blob", "MaxVol": 1, "WellX": 1, "WellY": 1, "WellZ": 1, "ColSize": 1, "RowSize": 1, "Height": 1}]}`,
+		"bad volumes":     `{"Plates": [{"PlateType": "p", "WellShape": "box", "MaxVol": 1, "MinVol": 2, "WellX": 1, "WellY": 1, "WellZ": 1, "ColSize": 1, "RowSize": 1, "Height": 1}]}`,
+		"unknown field":   `{"Plates": [{"PlateTyp": "p"}]}`,
+		"bad unit":        `{"Components": [{"Name": "c", "Concentration": 1, "ConcentrationUnit": "furlongs"}]}`,
+		"duplicate":       `{"Components": [{"Name": "c"}, {"Name": "c"}]}`,
+		"missing tip":     `{"Tipboxes": [{"TipboxType": "t", "ColSize": 1, "RowSize": 1, "Height": 1, "Well": {"Shape": "box", "MaxVol": 1, "X": 1, "Y": 1, "Z": 1}}]}`,
+		"no tip capacity": `{"Tipwastes": [{"TipwasteType": "t", "Height": 1, "Well": {"Shape": "box", "MaxVol": 1, "X": 1, "Y": 1, "Z": 1}}]}`,
+	} {
+		t.Run(name, func(t *testing.T) {
+			dir := writeFiles(t, map[string]string{"defs.json": content})
+			defer os.RemoveAll(dir) // nolint: errcheck
+
+			if _, err := New(nil, dir); err == nil {
+				t.Error("expected error")
+			} else if !strings.Contains(err.Error(), dir) {
+				t.Errorf("expected error to name %s, found %q", dir, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,69 @@
+package fileinventory
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/ghodss/yaml"
+)
+
+// readDir reads the definitions of every JSON and YAML file in dir and its
+// subdirectories, in lexical order
+func readDir(dir string) ([]*Definitions, error) {
+	var defs []*Definitions
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		} else if info.IsDir() {
+			return nil
+		}
+
+		var isYAML bool
+		switch strings.ToLower(filepath.Ext(path)) {
+		case ".json":
+		case ".yaml", ".yml":
+			isYAML = true
+		default:
+			return nil
+		}
+
+		d, err := readFile(path, isYAML)
+		if err != nil {
+			return err
+		}
+		defs = append(defs, d)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return defs, nil
+}
+
+// readFile reads the definitions in a single file. Unknown fields are
+// rejected to catch misspelt properties.
+func readFile(path string, isYAML bool) (*Definitions, error) {
+	bs, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if isYAML {
+		if bs, err = yaml.YAMLToJSON(bs); err != nil {
+			return nil, fmt.Errorf("%s: %s", path, err)
+		}
+	}
+
+	var d Definitions
+	dec := json.NewDecoder(bytes.NewReader(bs))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&d); err != nil {
+		return nil, fmt.Errorf("%s: %s", path, err)
+	}
+	return &d, nil
+}
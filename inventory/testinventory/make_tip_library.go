@@ -68,6 +68,8 @@ func makeTipboxes() (tipboxes []*wtype.LHTipbox) {
 
 	tipboxes = append(tipboxes, makeHamiltonTipboxes()...)
 
+	tipboxes = append(tipboxes, makeOpentronsTipboxes()...)
+
 	return tipboxes
 }
 
@@ -193,3 +195,23 @@ func makeHamiltonTipboxes() []*wtype.LHTipbox {
 
 	return ret
 }
+
+func makeOpentronsTipboxes() []*wtype.LHTipbox {
+	var ret []*wtype.LHTipbox
+
+	size := wtype.Coordinates3D{X: sbsX, Y: sbsY, Z: 64.49}
+
+	shp := wtype.NewShape(wtype.CylinderShape, "mm", 5.2, 5.2, 39.2)
+	w := wtype.NewLHWell("ul", 20.0, 1.0, shp, 0, 5.2, 5.2, 39.2, 0.0, "mm")
+	tip := wtype.NewLHTip("Opentrons", "Opentrons20", 1.0, 20.0, "ul", false, shp, 39.2)
+	tb := wtype.NewLHTipbox(8, 12, size, "Opentrons", "Opentrons 96 Tiprack 20ul", tip, w, 9.0, 9.0, xOffset, yOffset, 25.29)
+	ret = append(ret, tb)
+
+	shp = wtype.NewShape(wtype.CylinderShape, "mm", 5.23, 5.23, 59.3)
+	w = wtype.NewLHWell("ul", 300.0, 20.0, shp, 0, 5.23, 5.23, 59.3, 0.0, "mm")
+	tip = wtype.NewLHTip("Opentrons", "Opentrons300", 20.0, 300.0, "ul", false, shp, 59.3)
+	tb = wtype.NewLHTipbox(8, 12, size, "Opentrons", "Opentrons 96 Tiprack 300ul", tip, w, 9.0, 9.0, xOffset, yOffset, 5.19)
+	ret = append(ret, tb)
+
+	return ret
+}
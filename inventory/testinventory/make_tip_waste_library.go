@@ -24,7 +24,7 @@ package testinventory
 import "github.com/antha-lang/antha/antha/anthalib/wtype"
 
 func makeTipwastes() (tipwastes []*wtype.LHTipwaste) {
-	tipwastes = append(tipwastes, makeGilsonTipWaste(), makeGilsonTipChute(), makeCyBioTipwaste(), makeManualTipwaste(), makeTecanTipwaste())
+	tipwastes = append(tipwastes, makeGilsonTipWaste(), makeGilsonTipChute(), makeCyBioTipwaste(), makeManualTipwaste(), makeTecanTipwaste(), makeOpentronsFixedTrash())
 	return
 }
 
@@ -65,3 +65,11 @@ func makeTecanTipwaste() *wtype.LHTipwaste {
 	lht := wtype.NewLHTipwaste(2000, "Tecantipwaste", "Tecan", wtype.Coordinates3D{X: sbsX, Y: sbsY, Z: 90.5}, w, 85.5+xOffset, 45.0+yOffset, 0.0)
 	return lht
 }
+
+// makeOpentronsFixedTrash is the fixed trash in slot 12 of the OT-2
+func makeOpentronsFixedTrash() *wtype.LHTipwaste {
+	shp := wtype.NewShape(wtype.BoxShape, "mm", 172.86, 165.86, 82.0)
+	w := wtype.NewLHWell("ul", 800000.0, 800000.0, shp, 0, 172.86, 165.86, 82.0, 0.0, "mm")
+	lht := wtype.NewLHTipwaste(1000, "OpentronsFixedTrash", "Opentrons", wtype.Coordinates3D{X: sbsX, Y: sbsY, Z: 82.0}, w, sbsX/2.0, sbsY/2.0, 0.0)
+	return lht
+}
@@ -50,8 +50,7 @@ func (i *testInventory) NewTipwaste(ctx context.Context, typ string) (*wtype.LHT
 }
 
 func (i *testInventory) XXXGetPlates(ctx context.Context) ([]*wtype.Plate, error) {
-	plates := GetPlates(ctx)
-	return plates, nil
+	return i.plates(), nil
 }
 
 // NewContext creates a new test inventory context
@@ -121,9 +120,12 @@ func GetTipboxes(ctx context.Context) []*wtype.LHTipbox {
 
 // GetPlates returns the plates in a test inventory context
 func GetPlates(ctx context.Context) []*wtype.Plate {
-	inv := inventory.GetInventory(ctx).(*testInventory)
+	return inventory.GetInventory(ctx).(*testInventory).plates()
+}
+
+func (i *testInventory) plates() []*wtype.Plate {
 	var ps []*wtype.Plate
-	for _, p := range inv.plateByType {
+	for _, p := range i.plateByType {
 		ps = append(ps, p.LHPlate())
 	}
 
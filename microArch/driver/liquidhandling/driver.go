@@ -134,3 +134,11 @@ type HighLevelLiquidhandlingDriver interface {
 	LiquidhandlingDriver
 	Transfer(what, platefrom, wellfrom, plateto, wellto []string, volume []float64) driver.CommandStatus
 }
+
+// RefillingDriver is implemented by drivers which track the contents of
+// wells, and so need to be told when the user has been asked to top up a well
+type RefillingDriver interface {
+	//Refill add volume (in ul) of the liquid already in the well to the given
+	//well of the plate with ID plateID
+	Refill(plateID, well string, volume float64) driver.CommandStatus
+}
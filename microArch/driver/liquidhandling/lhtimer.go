@@ -12,6 +12,14 @@ type LHTimer interface {
 	TimeFor(r RobotInstruction) time.Duration
 }
 
+// A TimedInstruction is an instruction with its estimated start time and
+// duration, both measured from the start of the run
+type TimedInstruction struct {
+	Instruction RobotInstruction
+	Start       time.Duration
+	Duration    time.Duration
+}
+
 // deprecate this mess
 type OldLHTimer struct {
 	Times map[*InstructionType]time.Duration
@@ -15,6 +15,7 @@ type MessageInstruction struct {
 	Message     string
 	WaitTime    time.Duration
 	PassThrough map[string]*wtype.Liquid
+	Refills     []wtype.WellRefill
 }
 
 func NewMessageInstruction(lhi *wtype.LHInstruction) *MessageInstruction {
@@ -27,6 +28,7 @@ func NewMessageInstruction(lhi *wtype.LHInstruction) *MessageInstruction {
 		msi.Message = lhi.Message
 		msi.WaitTime = lhi.WaitTime
 		msi.PassThrough = lhi.PassThrough
+		msi.Refills = lhi.Refills
 	}
 
 	return msi
@@ -40,6 +42,18 @@ func (msi *MessageInstruction) Generate(ctx context.Context, policy *wtype.LHPol
 	// use side effect to keep IDs straight
 
 	prms.UpdateComponentIDs(msi.PassThrough)
+
+	// the user tops up these wells when they see the message
+	for _, refill := range msi.Refills {
+		if plate, ok := prms.PlateLookup[refill.PlateID].(*wtype.Plate); !ok {
+			return nil, fmt.Errorf("cannot refill well %s: no plate with ID %s", refill.Well, refill.PlateID)
+		} else if well, ok := plate.Wellcoords[refill.Well]; !ok {
+			return nil, fmt.Errorf("cannot refill well %s: no such well in plate %s", refill.Well, plate.GetName())
+		} else if err := well.TopUp(refill.Volume); err != nil {
+			return nil, err
+		}
+	}
+
 	return nil, nil
 }
 
@@ -96,7 +110,17 @@ func (msi *MessageInstruction) OutputTo(driver LiquidhandlingDriver) error {
 
 	if newMessage.MessageInstruction != nil {
 		//level int, title, text string, showcancel bool
-		return driver.Message(0, "", msi.Message, false).GetError()
+		if err := driver.Message(0, "", msi.Message, false).GetError(); err != nil {
+			return err
+		}
+	}
+
+	if refiller, ok := driver.(RefillingDriver); ok {
+		for _, refill := range msi.Refills {
+			if err := refiller.Refill(refill.PlateID, refill.Well, refill.Volume.ConvertToString("ul")).GetError(); err != nil {
+				return err
+			}
+		}
 	}
 
 	return nil
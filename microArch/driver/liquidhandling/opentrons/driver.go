@@ -0,0 +1,544 @@
+package opentrons
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/antha-lang/antha/antha/anthalib/wtype"
+	"github.com/antha-lang/antha/microArch/driver"
+	"github.com/antha-lang/antha/microArch/driver/liquidhandling"
+)
+
+var (
+	_ liquidhandling.LowLevelLiquidhandlingDriver = &OT2{}
+	_ liquidhandling.ExtendedLiquidhandlingDriver = &OT2{}
+)
+
+const (
+	apiLevel = "2.0"
+	indent   = "    "
+)
+
+// labware is an object loaded onto the deck
+type labware struct {
+	name     string // name of the variable holding the labware
+	loadName string
+	label    string
+	slot     int
+	trash    bool
+}
+
+// location is the position of a head set by the last move
+type location struct {
+	position  string
+	well      string
+	reference int
+	offsetZ   float64
+}
+
+// OT2 is a liquid handling driver which translates instructions into an
+// Opentrons Python API protocol, returned by GetOutputFile
+type OT2 struct {
+	config    Config
+	labware   map[string]string   // load names by plate and tipbox type
+	deck      map[string]*labware // labware by position name
+	locations map[int]*location   // location of each head
+	commands  []string
+	props     *liquidhandling.LHProperties
+}
+
+// New returns a driver for an OT-2 configured with config, whose tips are
+// found in the inventory of the context
+func New(ctx context.Context, config Config) (*OT2, error) {
+	if err := config.validate(); err != nil {
+		return nil, err
+	}
+
+	props, err := makeProperties(ctx, config)
+	if err != nil {
+		return nil, err
+	}
+
+	lw := make(map[string]string, len(DefaultLabware)+len(config.Labware))
+	for k, v := range DefaultLabware {
+		lw[k] = v
+	}
+	for k, v := range config.Labware {
+		lw[k] = v
+	}
+
+	return &OT2{
+		config:    config,
+		labware:   lw,
+		deck:      make(map[string]*labware),
+		locations: make(map[int]*location),
+		props:     props,
+	}, nil
+}
+
+func (d *OT2) addCommand(format string, args ...interface{}) {
+	d.commands = append(d.commands, fmt.Sprintf(format, args...))
+}
+
+// pipette returns the pipette of the head, checking that it can address
+// multi channels at once
+func (d *OT2) pipette(head, multi int) (Pipette, error) {
+	if head < 0 || head >= len(d.config.Pipettes) {
+		return Pipette{}, fmt.Errorf("unknown head %d", head)
+	}
+	p := d.config.Pipettes[head]
+	if multi != 1 && multi != p.Channels {
+		return Pipette{}, fmt.Errorf("pipette %s cannot use %d of its %d channels", p.Name, multi, p.Channels)
+	}
+	return p, nil
+}
+
+// firstChannel returns the first channel given a value by the arguments of
+// an instruction
+func firstChannel(values []string) (int, error) {
+	for i, v := range values {
+		if v != "" {
+			return i, nil
+		}
+	}
+	return 0, fmt.Errorf("no channel given")
+}
+
+// commonVolume returns the volume of the channels used, which must all be equal
+func commonVolume(volumes []float64) (float64, error) {
+	var ret float64
+	for _, v := range volumes {
+		if v == 0.0 {
+			continue
+		} else if ret != 0.0 && v != ret {
+			return 0.0, fmt.Errorf("channels cannot move different volumes: %v", volumes)
+		}
+		ret = v
+	}
+	return ret, nil
+}
+
+func pyFloat(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
+
+// well returns the expression for a well of the labware at a position
+func (d *OT2) well(position, well string) (string, error) {
+	lw, ok := d.deck[position]
+	if !ok || lw.trash {
+		return "", fmt.Errorf("no labware at %s", position)
+	}
+	return fmt.Sprintf("%s[%s]", lw.name, strconv.Quote(well)), nil
+}
+
+// target returns the expression for the location of a head
+func (d *OT2) target(head int) (string, error) {
+	loc, ok := d.locations[head]
+	if !ok {
+		return "", fmt.Errorf("head %d has not been moved", head)
+	}
+	well, err := d.well(loc.position, loc.well)
+	if err != nil {
+		return "", err
+	}
+	if loc.reference == int(wtype.TopReference) {
+		return fmt.Sprintf("%s.top(z=%s)", well, pyFloat(loc.offsetZ)), nil
+	}
+	// the liquid level is not known to the robot so use the bottom
+	return fmt.Sprintf("%s.bottom(z=%s)", well, pyFloat(loc.offsetZ)), nil
+}
+
+// DriverType implements LiquidhandlingDriver
+func (d *OT2) DriverType() ([]string, error) {
+	return []string{"antha.mixer.v1.Mixer", "OpentronsOT2"}, nil
+}
+
+// AddPlateTo implements LiquidhandlingDriver, loading the labware
+// equivalent to the plate or tipbox into the slot of the position
+func (d *OT2) AddPlateTo(position string, plate interface{}, name string) driver.CommandStatus {
+	slot, err := slotOf(position)
+	if err != nil {
+		return driver.CommandError(err.Error())
+	}
+
+	var typ string
+	switch p := plate.(type) {
+	case *wtype.LHTipwaste:
+		if position != TrashPosition {
+			return driver.CommandError(fmt.Sprintf("tip waste must be at %s, not %s", TrashPosition, position))
+		}
+		d.deck[position] = &labware{slot: slot, trash: true}
+		return driver.CommandOk()
+	case *wtype.Plate:
+		typ = p.Type
+	case *wtype.LHTipbox:
+		typ = p.Type
+	default:
+		return driver.CommandError(fmt.Sprintf("cannot add %T to OT-2", plate))
+	}
+
+	if slot == numSlots {
+		return driver.CommandError(fmt.Sprintf("cannot add %s to %s, which holds the fixed trash", name, position))
+	}
+	loadName, ok := d.labware[typ]
+	if !ok {
+		return driver.CommandError(fmt.Sprintf("no Opentrons labware known for type %q", typ))
+	}
+	d.deck[position] = &labware{
+		name:     fmt.Sprintf("labware_%d", slot),
+		loadName: loadName,
+		label:    name,
+		slot:     slot,
+	}
+	return driver.CommandOk()
+}
+
+// RemoveAllPlates implements LiquidhandlingDriver
+func (d *OT2) RemoveAllPlates() driver.CommandStatus {
+	d.deck = make(map[string]*labware)
+	return driver.CommandOk()
+}
+
+// RemovePlateAt implements LiquidhandlingDriver
+func (d *OT2) RemovePlateAt(position string) driver.CommandStatus {
+	delete(d.deck, position)
+	return driver.CommandOk()
+}
+
+// Initialize implements LiquidhandlingDriver, starting a new protocol
+func (d *OT2) Initialize() driver.CommandStatus {
+	d.commands = nil
+	d.locations = make(map[int]*location)
+	return driver.CommandOk()
+}
+
+// Finalize implements LiquidhandlingDriver
+func (d *OT2) Finalize() driver.CommandStatus {
+	return driver.CommandOk()
+}
+
+// Message implements LiquidhandlingDriver, pausing the protocol until the
+// user resumes it
+func (d *OT2) Message(level int, title, text string, showcancel bool) driver.CommandStatus {
+	if title != "" {
+		text = title + ": " + text
+	}
+	if text != "" {
+		d.addCommand("protocol.pause(%s)", strconv.Quote(text))
+	}
+	return driver.CommandOk()
+}
+
+// GetOutputFile implements LiquidhandlingDriver, returning the Python
+// protocol
+func (d *OT2) GetOutputFile() ([]byte, driver.CommandStatus) {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "from opentrons import protocol_api\n\n")
+	fmt.Fprintf(&buf, "metadata = {\n")
+	fmt.Fprintf(&buf, "%s\"protocolName\": %s,\n", indent, strconv.Quote(d.config.ProtocolName))
+	fmt.Fprintf(&buf, "%s\"source\": \"Generated by Antha\",\n", indent)
+	fmt.Fprintf(&buf, "%s\"apiLevel\": \"%s\",\n", indent, apiLevel)
+	fmt.Fprintf(&buf, "}\n\n\n")
+	fmt.Fprintf(&buf, "def run(protocol: protocol_api.ProtocolContext):\n")
+
+	var deck []*labware
+	for _, lw := range d.deck {
+		if !lw.trash {
+			deck = append(deck, lw)
+		}
+	}
+	sort.Slice(deck, func(i, j int) bool {
+		return deck[i].slot < deck[j].slot
+	})
+	for _, lw := range deck {
+		fmt.Fprintf(&buf, "%s%s = protocol.load_labware(%s, \"%d\", label=%s)\n", indent, lw.name, strconv.Quote(lw.loadName), lw.slot, strconv.Quote(lw.label))
+	}
+
+	for _, p := range d.config.Pipettes {
+		fmt.Fprintf(&buf, "%s%s = protocol.load_instrument(%s, %s)\n", indent, p.Mount, strconv.Quote(p.Name), strconv.Quote(p.Mount))
+	}
+
+	if len(d.commands) != 0 {
+		fmt.Fprintf(&buf, "\n%s%s\n", indent, strings.Join(d.commands, "\n"+indent))
+	}
+
+	return buf.Bytes(), driver.CommandOk()
+}
+
+// GetCapabilities implements LiquidhandlingDriver
+func (d *OT2) GetCapabilities() (liquidhandling.LHProperties, driver.CommandStatus) {
+	return *d.props.Dup(), driver.CommandOk()
+}
+
+// Move implements LowLevelLiquidhandlingDriver, recording the location to
+// use for the next instructions of the head
+func (d *OT2) Move(deckposition []string, wellcoords []string, reference []int, offsetX, offsetY, offsetZ []float64, plate_type []string, head int) driver.CommandStatus {
+	if _, err := d.pipette(head, 1); err != nil {
+		return driver.CommandError(err.Error())
+	}
+	ch, err := firstChannel(deckposition)
+	if err != nil {
+		return driver.CommandError(fmt.Sprintf("move: %s", err))
+	}
+	if ch >= len(wellcoords) || ch >= len(reference) || ch >= len(offsetZ) {
+		return driver.CommandError("move: arguments differ in length")
+	}
+	if _, ok := d.deck[deckposition[ch]]; !ok {
+		return driver.CommandError(fmt.Sprintf("move: no labware at %s", deckposition[ch]))
+	}
+
+	d.locations[head] = &location{
+		position:  deckposition[ch],
+		well:      wellcoords[ch],
+		reference: reference[ch],
+		offsetZ:   offsetZ[ch],
+	}
+	return driver.CommandOk()
+}
+
+// Aspirate implements LowLevelLiquidhandlingDriver
+func (d *OT2) Aspirate(volume []float64, overstroke []bool, head int, multi int, platetype []string, what []string, llf []bool) driver.CommandStatus {
+	return d.liquidCommand("aspirate", head, multi, volume, nil)
+}
+
+// Dispense implements LowLevelLiquidhandlingDriver, blowing out after the
+// dispense if any channel requires it
+func (d *OT2) Dispense(volume []float64, blowout []bool, head int, multi int, platetype []string, what []string, llf []bool) driver.CommandStatus {
+	return d.liquidCommand("dispense", head, multi, volume, blowout)
+}
+
+func (d *OT2) liquidCommand(command string, head, multi int, volumes []float64, blowout []bool) driver.CommandStatus {
+	p, err := d.pipette(head, multi)
+	if err != nil {
+		return driver.CommandError(fmt.Sprintf("%s: %s", command, err))
+	}
+	vol, err := commonVolume(volumes)
+	if err != nil {
+		return driver.CommandError(fmt.Sprintf("%s: %s", command, err))
+	}
+	target, err := d.target(head)
+	if err != nil {
+		return driver.CommandError(fmt.Sprintf("%s: %s", command, err))
+	}
+
+	d.addCommand("%s.%s(%s, %s)", p.Mount, command, pyFloat(vol), target)
+	if anyTrue(blowout) {
+		d.addCommand("%s.blow_out(%s)", p.Mount, target)
+	}
+	return driver.CommandOk()
+}
+
+func anyTrue(bs []bool) bool {
+	for _, b := range bs {
+		if b {
+			return true
+		}
+	}
+	return false
+}
+
+// LoadTips implements LowLevelLiquidhandlingDriver
+func (d *OT2) LoadTips(channels []int, head, multi int, platetype, position, well []string) driver.CommandStatus {
+	p, err := d.pipette(head, multi)
+	if err != nil {
+		return driver.CommandError(fmt.Sprintf("load tips: %s", err))
+	}
+	ch, err := tipChannel(channels, position, well)
+	if err != nil {
+		return driver.CommandError(fmt.Sprintf("load tips: %s", err))
+	}
+	tip, err := d.well(position[ch], well[ch])
+	if err != nil {
+		return driver.CommandError(fmt.Sprintf("load tips: %s", err))
+	}
+	d.addCommand("%s.pick_up_tip(%s)", p.Mount, tip)
+	return driver.CommandOk()
+}
+
+// UnloadTips implements LowLevelLiquidhandlingDriver, dropping tips into
+// the fixed trash unless they are returned to a tipbox
+func (d *OT2) UnloadTips(channels []int, head, multi int, platetype, position, well []string) driver.CommandStatus {
+	p, err := d.pipette(head, multi)
+	if err != nil {
+		return driver.CommandError(fmt.Sprintf("unload tips: %s", err))
+	}
+	ch, err := tipChannel(channels, position, well)
+	if err != nil {
+		return driver.CommandError(fmt.Sprintf("unload tips: %s", err))
+	}
+	if lw, ok := d.deck[position[ch]]; !ok || lw.trash {
+		d.addCommand("%s.drop_tip()", p.Mount)
+		return driver.CommandOk()
+	}
+	tip, err := d.well(position[ch], well[ch])
+	if err != nil {
+		return driver.CommandError(fmt.Sprintf("unload tips: %s", err))
+	}
+	d.addCommand("%s.drop_tip(%s)", p.Mount, tip)
+	return driver.CommandOk()
+}
+
+// tipChannel returns the first of the channels, to which the OT-2 aligns
+// multichannel pipettes. If no channels are given, all channels with a
+// position are used.
+func tipChannel(channels []int, position, well []string) (int, error) {
+	if len(channels) == 0 {
+		return firstChannel(position)
+	}
+	ch := channels[0]
+	for _, c := range channels {
+		if c < ch {
+			ch = c
+		}
+	}
+	if ch < 0 || ch >= len(position) || ch >= len(well) {
+		return 0, fmt.Errorf("no position given for channel %d", ch)
+	}
+	return ch, nil
+}
+
+// SetPipetteSpeed implements LowLevelLiquidhandlingDriver, setting the
+// aspirate and dispense flow rates from a rate in ml/min
+func (d *OT2) SetPipetteSpeed(head, channel int, rate float64) driver.CommandStatus {
+	p, err := d.pipette(head, 1)
+	if err != nil {
+		return driver.CommandError(err.Error())
+	}
+	// flow rates are in ul/s
+	ulPerS := pyFloat(rate * 1000.0 / 60.0)
+	d.addCommand("%s.flow_rate.aspirate = %s", p.Mount, ulPerS)
+	d.addCommand("%s.flow_rate.dispense = %s", p.Mount, ulPerS)
+	return driver.CommandOk()
+}
+
+// SetDriveSpeed implements LowLevelLiquidhandlingDriver; the OT-2 always
+// moves at its default speed
+func (d *OT2) SetDriveSpeed(drive string, rate float64) driver.CommandStatus {
+	return driver.CommandOk()
+}
+
+// Wait implements LowLevelLiquidhandlingDriver
+func (d *OT2) Wait(time float64) driver.CommandStatus {
+	if time > 0.0 {
+		d.addCommand("protocol.delay(seconds=%s)", pyFloat(time))
+	}
+	return driver.CommandOk()
+}
+
+// Mix implements LowLevelLiquidhandlingDriver, mixing at the location of the
+// last move of the head
+func (d *OT2) Mix(head int, volume []float64, platetype []string, cycles []int, multi int, what []string, blowout []bool) driver.CommandStatus {
+	p, err := d.pipette(head, multi)
+	if err != nil {
+		return driver.CommandError(fmt.Sprintf("mix: %s", err))
+	}
+	vol, err := commonVolume(volume)
+	if err != nil {
+		return driver.CommandError(fmt.Sprintf("mix: %s", err))
+	}
+	var n int
+	for _, c := range cycles {
+		if c > n {
+			n = c
+		}
+	}
+	target, err := d.target(head)
+	if err != nil {
+		return driver.CommandError(fmt.Sprintf("mix: %s", err))
+	}
+
+	d.addCommand("%s.mix(%d, %s, %s)", p.Mount, n, pyFloat(vol), target)
+	if anyTrue(blowout) {
+		d.addCommand("%s.blow_out(%s)", p.Mount, target)
+	}
+	return driver.CommandOk()
+}
+
+// ResetPistons implements LowLevelLiquidhandlingDriver
+func (d *OT2) ResetPistons(head, channel int) driver.CommandStatus {
+	return driver.CommandOk()
+}
+
+// UpdateMetaData implements LowLevelLiquidhandlingDriver
+func (d *OT2) UpdateMetaData(props *liquidhandling.LHProperties) driver.CommandStatus {
+	return driver.CommandOk()
+}
+
+// SetPositionState implements ExtendedLiquidhandlingDriver
+func (d *OT2) SetPositionState(position string, state driver.PositionState) driver.CommandStatus {
+	return driver.CommandNotImplemented("SetPositionState")
+}
+
+// GetCurrentPosition implements ExtendedLiquidhandlingDriver
+func (d *OT2) GetCurrentPosition(head int) (string, driver.CommandStatus) {
+	if loc, ok := d.locations[head]; ok {
+		return loc.position, driver.CommandOk()
+	}
+	return "", driver.CommandOk()
+}
+
+// GetPositionState implements ExtendedLiquidhandlingDriver
+func (d *OT2) GetPositionState(position string) (string, driver.CommandStatus) {
+	return "", driver.CommandNotImplemented("GetPositionState")
+}
+
+// GetHeadState implements ExtendedLiquidhandlingDriver
+func (d *OT2) GetHeadState(head int) (string, driver.CommandStatus) {
+	return "", driver.CommandNotImplemented("GetHeadState")
+}
+
+// GetStatus implements ExtendedLiquidhandlingDriver
+func (d *OT2) GetStatus() (driver.Status, driver.CommandStatus) {
+	return driver.Status{}, driver.CommandOk()
+}
+
+// UnloadHead implements ExtendedLiquidhandlingDriver
+func (d *OT2) UnloadHead(param int) driver.CommandStatus {
+	return driver.CommandNotImplemented("UnloadHead")
+}
+
+// LoadHead implements ExtendedLiquidhandlingDriver
+func (d *OT2) LoadHead(param int) driver.CommandStatus {
+	return driver.CommandNotImplemented("LoadHead")
+}
+
+// LightsOn implements ExtendedLiquidhandlingDriver
+func (d *OT2) LightsOn() driver.CommandStatus {
+	d.addCommand("protocol.set_rail_lights(True)")
+	return driver.CommandOk()
+}
+
+// LightsOff implements ExtendedLiquidhandlingDriver
+func (d *OT2) LightsOff() driver.CommandStatus {
+	d.addCommand("protocol.set_rail_lights(False)")
+	return driver.CommandOk()
+}
+
+// LoadAdaptor implements ExtendedLiquidhandlingDriver
+func (d *OT2) LoadAdaptor(param int) driver.CommandStatus {
+	return driver.CommandNotImplemented("LoadAdaptor")
+}
+
+// MoveRaw implements ExtendedLiquidhandlingDriver
+func (d *OT2) MoveRaw(head int, x, y, z float64) driver.CommandStatus {
+	return driver.CommandNotImplemented("MoveRaw")
+}
+
+// UnloadAdaptor implements ExtendedLiquidhandlingDriver
+func (d *OT2) UnloadAdaptor(param int) driver.CommandStatus {
+	return driver.CommandNotImplemented("UnloadAdaptor")
+}
+
+// Open implements ExtendedLiquidhandlingDriver
+func (d *OT2) Open() driver.CommandStatus {
+	return driver.CommandNotImplemented("Open")
+}
+
+// Close implements ExtendedLiquidhandlingDriver
+func (d *OT2) Close() driver.CommandStatus {
+	return driver.CommandNotImplemented("Close")
+}
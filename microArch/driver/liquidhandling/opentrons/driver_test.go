@@ -0,0 +1,198 @@
+package opentrons
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/antha-lang/antha/antha/anthalib/wtype"
+	"github.com/antha-lang/antha/antha/anthalib/wunit"
+	"github.com/antha-lang/antha/inventory"
+	"github.com/antha-lang/antha/inventory/testinventory"
+	"github.com/antha-lang/antha/microArch/driver"
+)
+
+var update = flag.Bool("update", false, "update golden files")
+
+func newTestDriver(t *testing.T, config Config) (context.Context, *OT2) {
+	ctx := testinventory.NewContext(context.Background())
+	d, err := New(ctx, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ctx, d
+}
+
+func assertOk(t *testing.T, name string, status driver.CommandStatus) {
+	if !status.Ok() {
+		t.Fatalf("%s: %s", name, status)
+	}
+}
+
+func TestCapabilities(t *testing.T) {
+	_, d := newTestDriver(t, DefaultConfig())
+
+	props, status := d.GetCapabilities()
+	assertOk(t, "GetCapabilities", status)
+
+	if props.Mnfr != Manufacturer || props.Model != Model {
+		t.Errorf("expected %s %s, got %s %s", Manufacturer, Model, props.Mnfr, props.Model)
+	}
+	if len(props.Positions) != numSlots {
+		t.Errorf("expected %d positions, got %d", numSlots, len(props.Positions))
+	}
+	if len(props.Heads) != 2 || props.CountHeadsLoaded() != 2 {
+		t.Errorf("expected 2 loaded heads, got %d of %d", props.CountHeadsLoaded(), len(props.Heads))
+	}
+	tips := make(map[string]bool)
+	for _, tip := range props.Tips {
+		tips[tip.Type] = true
+	}
+	if !tips["Opentrons20"] || !tips["Opentrons300"] {
+		t.Errorf("unexpected tips %v", tips)
+	}
+}
+
+func TestInvalidConfig(t *testing.T) {
+	ctx := testinventory.NewContext(context.Background())
+
+	config := DefaultConfig()
+	config.Pipettes[1].Mount = "left"
+	if _, err := New(ctx, config); err == nil {
+		t.Error("expected error mounting two pipettes on the left")
+	}
+
+	config = DefaultConfig()
+	config.Pipettes[0].TipboxType = "not a tipbox"
+	if _, err := New(ctx, config); err == nil {
+		t.Error("expected error for unknown tipbox")
+	}
+}
+
+func TestProtocol(t *testing.T) {
+	config := DefaultConfig()
+	config.ProtocolName = "Test protocol"
+	config.Pipettes[0] = Pipette{
+		Name:       "p20_multi_gen2",
+		Mount:      "left",
+		Channels:   8,
+		MinVolume:  wunit.NewVolume(1.0, "ul"),
+		MaxVolume:  wunit.NewVolume(20.0, "ul"),
+		TipboxType: "Opentrons 96 Tiprack 20ul",
+	}
+	ctx, d := newTestDriver(t, config)
+
+	input, err := inventory.NewPlate(ctx, "DWST12")
+	if err != nil {
+		t.Fatal(err)
+	}
+	output, err := inventory.NewPlate(ctx, "pcrplate_skirted")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tips300, err := inventory.NewTipbox(ctx, "Opentrons300")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tips20, err := inventory.NewTipbox(ctx, "Opentrons20")
+	if err != nil {
+		t.Fatal(err)
+	}
+	trash, err := inventory.NewTipwaste(ctx, "OpentronsFixedTrash")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assertOk(t, "Initialize", d.Initialize())
+	assertOk(t, "AddPlateTo", d.AddPlateTo("position_4", input, "input"))
+	assertOk(t, "AddPlateTo", d.AddPlateTo("position_1", output, "output"))
+	assertOk(t, "AddPlateTo", d.AddPlateTo("position_11", tips20, "tips20"))
+	assertOk(t, "AddPlateTo", d.AddPlateTo("position_10", tips300, "tips300"))
+	assertOk(t, "AddPlateTo", d.AddPlateTo(TrashPosition, trash, "trash"))
+
+	one := func(s string) []string { return []string{s} }
+	eight := func(s string) []string {
+		return []string{s, s, s, s, s, s, s, s}
+	}
+	column := func(col string) []string {
+		var ret []string
+		for _, row := range "ABCDEFGH" {
+			ret = append(ret, string(row)+col)
+		}
+		return ret
+	}
+	zeros := func(n int) []float64 { return make([]float64, n) }
+	channels := []int{0, 1, 2, 3, 4, 5, 6, 7}
+
+	// single channel transfer with the P300
+	assertOk(t, "SetPipetteSpeed", d.SetPipetteSpeed(1, 0, 3.0))
+	assertOk(t, "LoadTips", d.LoadTips([]int{0}, 1, 1, one("Opentrons 96 Tiprack 300ul"), one("position_10"), one("A1")))
+	assertOk(t, "Move", d.Move(one("position_4"), one("A1"), []int{0}, zeros(1), zeros(1), []float64{1.5}, one("DWST12"), 1))
+	assertOk(t, "Aspirate", d.Aspirate([]float64{150}, []bool{false}, 1, 1, one("DWST12"), one("water"), []bool{false}))
+	assertOk(t, "Move", d.Move(one("position_1"), one("B2"), []int{1}, zeros(1), zeros(1), []float64{-2}, one("pcrplate_skirted"), 1))
+	assertOk(t, "Dispense", d.Dispense([]float64{150}, []bool{true}, 1, 1, one("pcrplate_skirted"), one("water"), []bool{false}))
+	assertOk(t, "UnloadTips", d.UnloadTips([]int{0}, 1, 1, one(""), one(TrashPosition), one("A1")))
+
+	// multichannel transfer and mix with the P20
+	assertOk(t, "LoadTips", d.LoadTips(channels, 0, 8, eight("Opentrons 96 Tiprack 20ul"), eight("position_11"), column("3")))
+	assertOk(t, "Move", d.Move(eight("position_4"), eight("A2"), make([]int, 8), zeros(8), zeros(8), zeros(8), eight("DWST12"), 0))
+	assertOk(t, "Aspirate", d.Aspirate([]float64{5, 5, 5, 5, 5, 5, 5, 5}, make([]bool, 8), 0, 8, eight("DWST12"), eight("water"), make([]bool, 8)))
+	assertOk(t, "Move", d.Move(eight("position_1"), column("12"), make([]int, 8), zeros(8), zeros(8), []float64{0.5, 0.5, 0.5, 0.5, 0.5, 0.5, 0.5, 0.5}, eight("pcrplate_skirted"), 0))
+	assertOk(t, "Dispense", d.Dispense([]float64{5, 5, 5, 5, 5, 5, 5, 5}, make([]bool, 8), 0, 8, eight("pcrplate_skirted"), eight("water"), make([]bool, 8)))
+	assertOk(t, "Mix", d.Mix(0, []float64{10, 10, 10, 10, 10, 10, 10, 10}, eight("pcrplate_skirted"), []int{3, 3, 3, 3, 3, 3, 3, 3}, 8, eight("water"), make([]bool, 8)))
+	assertOk(t, "UnloadTips", d.UnloadTips(channels, 0, 8, eight(""), eight(TrashPosition), column("1")))
+
+	assertOk(t, "Wait", d.Wait(30))
+	assertOk(t, "Message", d.Message(0, "", "Seal the output plate", false))
+	assertOk(t, "Finalize", d.Finalize())
+
+	got, status := d.GetOutputFile()
+	assertOk(t, "GetOutputFile", status)
+
+	golden := filepath.Join("testdata", "protocol.py")
+	if *update {
+		if err := ioutil.WriteFile(golden, got, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	expected, err := ioutil.ReadFile(golden)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, expected) {
+		t.Errorf("generated protocol differs from %s, got:\n%s", golden, got)
+	}
+}
+
+func TestErrors(t *testing.T) {
+	ctx, d := newTestDriver(t, DefaultConfig())
+
+	plate, err := inventory.NewPlate(ctx, "pcrplate_skirted")
+	if err != nil {
+		t.Fatal(err)
+	}
+	unknown, err := inventory.NewPlate(ctx, "EGEL48")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	one := func(s string) []string { return []string{s} }
+
+	for name, status := range map[string]driver.CommandStatus{
+		"unknown labware":     d.AddPlateTo("position_1", unknown, "gel"),
+		"unknown position":    d.AddPlateTo("position_13", plate, "plate"),
+		"plate in trash":      d.AddPlateTo(TrashPosition, plate, "plate"),
+		"tip waste off trash": d.AddPlateTo("position_1", &wtype.LHTipwaste{}, "trash"),
+		"move to empty slot":  d.Move(one("position_2"), one("A1"), []int{0}, []float64{0}, []float64{0}, []float64{0}, one(""), 0),
+		"aspirate unmoved":    d.Aspirate([]float64{10}, []bool{false}, 0, 1, one(""), one(""), []bool{false}),
+		"multi on single":     d.LoadTips([]int{0, 1}, 0, 2, []string{"", ""}, []string{"position_1", "position_1"}, []string{"A1", "B1"}),
+		"unknown head":        d.SetPipetteSpeed(2, 0, 1.0),
+	} {
+		if status.Ok() {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+}
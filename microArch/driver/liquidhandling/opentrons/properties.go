@@ -0,0 +1,191 @@
+// Package opentrons provides an in-process driver for the Opentrons OT-2
+// which generates a protocol for the Opentrons Python API.
+package opentrons
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/antha-lang/antha/antha/anthalib/wtype"
+	"github.com/antha-lang/antha/antha/anthalib/wunit"
+	"github.com/antha-lang/antha/inventory"
+	"github.com/antha-lang/antha/microArch/driver/liquidhandling"
+)
+
+const (
+	// Manufacturer of the OT-2
+	Manufacturer = "Opentrons"
+	// Model of the OT-2, used to select the fixed trash as tip waste
+	Model = "OT-2"
+
+	// TrashPosition is the position of the fixed trash in slot 12
+	TrashPosition = "position_12"
+
+	positionFormat = "position_%d"
+	numSlots       = 12
+	slotPitchX     = 132.5
+	slotPitchY     = 90.5
+
+	// distance of the left mount to the left of the right one
+	mountOffsetX = 34.0
+)
+
+// rates supported by all pipettes
+var (
+	minRate = wunit.NewFlowRate(0.06, "ml/min")
+	maxRate = wunit.NewFlowRate(60.0, "ml/min")
+)
+
+// A Pipette is an OT-2 pipette mounted on the robot
+type Pipette struct {
+	Name       string // instrument name in the Opentrons API, e.g. p300_single_gen2
+	Mount      string // left or right
+	Channels   int    // 1 or 8
+	MinVolume  wunit.Volume
+	MaxVolume  wunit.Volume
+	TipboxType string // inventory type of the tipboxes used by the pipette
+}
+
+// Config describes the robot a protocol is generated for
+type Config struct {
+	// ProtocolName is the name of the protocol in its metadata
+	ProtocolName string
+	// Pipettes mounted on the robot; the index of each is its head
+	Pipettes []Pipette
+	// Labware maps plate and tipbox types to labware load names, overriding
+	// DefaultLabware
+	Labware map[string]string
+}
+
+// DefaultConfig returns the configuration of an OT-2 with single channel
+// P20 and P300 pipettes
+func DefaultConfig() Config {
+	return Config{
+		ProtocolName: "Antha protocol",
+		Pipettes: []Pipette{
+			{
+				Name:       "p20_single_gen2",
+				Mount:      "left",
+				Channels:   1,
+				MinVolume:  wunit.NewVolume(1.0, "ul"),
+				MaxVolume:  wunit.NewVolume(20.0, "ul"),
+				TipboxType: "Opentrons 96 Tiprack 20ul",
+			},
+			{
+				Name:       "p300_single_gen2",
+				Mount:      "right",
+				Channels:   1,
+				MinVolume:  wunit.NewVolume(20.0, "ul"),
+				MaxVolume:  wunit.NewVolume(300.0, "ul"),
+				TipboxType: "Opentrons 96 Tiprack 300ul",
+			},
+		},
+	}
+}
+
+// DefaultLabware maps plate and tipbox types to the load names of the
+// equivalent Opentrons labware
+var DefaultLabware = map[string]string{
+	"pcrplate":                   "biorad_96_wellplate_200ul_pcr",
+	"pcrplate_skirted":           "biorad_96_wellplate_200ul_pcr",
+	"pcrplate_semi_skirted":      "biorad_96_wellplate_200ul_pcr",
+	"strip_tubes_0.2ml":          "opentrons_96_aluminumblock_generic_pcr_strip_200ul",
+	"greiner96Black":             "corning_96_wellplate_360ul_flat",
+	"SRWFB96":                    "corning_96_wellplate_360ul_flat",
+	"greiner384":                 "corning_384_wellplate_112ul_flat",
+	"DSW96":                      "usascientific_96_wellplate_2.4ml_deep",
+	"Nunc96DeepWell":             "usascientific_96_wellplate_2.4ml_deep",
+	"DWST12":                     "usascientific_12_reservoir_22ml",
+	"reservoir":                  "agilent_1_reservoir_290ml",
+	"falcon6wellAgar":            "corning_6_wellplate_16.8ml_flat",
+	"Nuncon12well":               "corning_12_wellplate_6.9ml_flat",
+	"costar48well":               "corning_48_wellplate_1.6ml_flat",
+	"eppendorfrack425_1.5ml":     "opentrons_24_tuberack_eppendorf_1.5ml_safelock_snapcap",
+	"eppendorfrack425_2ml":       "opentrons_24_tuberack_eppendorf_2ml_safelock_snapcap",
+	"Opentrons 96 Tiprack 20ul":  "opentrons_96_tiprack_20ul",
+	"Opentrons 96 Tiprack 300ul": "opentrons_96_tiprack_300ul",
+}
+
+// slotOf returns the OT-2 slot of a position
+func slotOf(position string) (int, error) {
+	var slot int
+	if _, err := fmt.Sscanf(position, positionFormat, &slot); err != nil || slot < 1 || slot > numSlots {
+		return 0, fmt.Errorf("unknown OT-2 position %q", position)
+	}
+	return slot, nil
+}
+
+// validate checks that the pipettes can be mounted together
+func (c Config) validate() error {
+	if len(c.Pipettes) == 0 || len(c.Pipettes) > 2 {
+		return fmt.Errorf("OT-2 must have one or two pipettes, got %d", len(c.Pipettes))
+	}
+	mounted := make(map[string]bool)
+	for _, p := range c.Pipettes {
+		if p.Mount != "left" && p.Mount != "right" {
+			return fmt.Errorf("pipette %s: unknown mount %q", p.Name, p.Mount)
+		} else if mounted[p.Mount] {
+			return fmt.Errorf("pipette %s: %s mount already in use", p.Name, p.Mount)
+		} else if p.Channels != 1 && p.Channels != 8 {
+			return fmt.Errorf("pipette %s: must have 1 or 8 channels, got %d", p.Name, p.Channels)
+		}
+		mounted[p.Mount] = true
+	}
+	return nil
+}
+
+// makeProperties returns the properties of an OT-2 with the configured
+// pipettes, using the tips in the inventory
+func makeProperties(ctx context.Context, config Config) (*liquidhandling.LHProperties, error) {
+	layout := make(map[string]*wtype.LHPosition, numSlots)
+	for slot := 1; slot <= numSlots; slot++ {
+		x := float64((slot-1)%3) * slotPitchX
+		y := float64((slot-1)/3) * slotPitchY
+		pos := wtype.NewLHPosition(fmt.Sprintf(positionFormat, slot), wtype.Coordinates3D{X: x, Y: y}, wtype.SBSFootprint)
+		layout[pos.Name] = pos
+	}
+
+	lhp := liquidhandling.NewLHProperties(Model, Manufacturer, liquidhandling.LLLiquidHandler, liquidhandling.DisposableTips, layout)
+
+	lhp.Preferences = &liquidhandling.LayoutOpt{
+		Tipboxes:  liquidhandling.Addresses{"position_10", "position_11", "position_7", "position_8", "position_9"},
+		Inputs:    liquidhandling.Addresses{"position_4", "position_5", "position_6", "position_1", "position_2", "position_3"},
+		Outputs:   liquidhandling.Addresses{"position_1", "position_2", "position_3", "position_4", "position_5", "position_6"},
+		Tipwastes: liquidhandling.Addresses{TrashPosition},
+	}
+
+	// each mount raises and lowers its pipette independently
+	assembly := wtype.NewLHHeadAssembly(nil)
+	assembly.IndependentZ = true
+	seenTips := make(map[string]bool)
+	for i, p := range config.Pipettes {
+		tb, err := inventory.NewTipbox(ctx, p.TipboxType)
+		if err != nil {
+			return nil, fmt.Errorf("pipette %s: %s", p.Name, err)
+		}
+		if !seenTips[tb.Tiptype.Type] {
+			seenTips[tb.Tiptype.Type] = true
+			lhp.Tips = append(lhp.Tips, tb.Tiptype)
+		}
+
+		params := wtype.NewLHChannelParameter(p.Name, Model, p.MinVolume, p.MaxVolume, minRate, maxRate, p.Channels, false, wtype.LHVChannel, i)
+		adaptor := wtype.NewLHAdaptor(p.Name, Manufacturer, params)
+		head := wtype.NewLHHead(p.Mount, Manufacturer, params)
+		head.Adaptor = adaptor
+
+		offset := wtype.Coordinates3D{}
+		if p.Mount == "left" {
+			offset.X = -mountOffsetX
+		}
+		assembly.AddPosition(offset)
+		if err := assembly.LoadHead(head); err != nil {
+			return nil, err
+		}
+
+		lhp.Heads = append(lhp.Heads, head)
+		lhp.Adaptors = append(lhp.Adaptors, adaptor)
+	}
+	lhp.HeadAssemblies = append(lhp.HeadAssemblies, assembly)
+
+	return lhp, nil
+}
@@ -0,0 +1,31 @@
+from opentrons import protocol_api
+
+metadata = {
+    "protocolName": "Test protocol",
+    "source": "Generated by Antha",
+    "apiLevel": "2.0",
+}
+
+
+def run(protocol: protocol_api.ProtocolContext):
+    labware_1 = protocol.load_labware("biorad_96_wellplate_200ul_pcr", "1", label="output")
+    labware_4 = protocol.load_labware("usascientific_12_reservoir_22ml", "4", label="input")
+    labware_10 = protocol.load_labware("opentrons_96_tiprack_300ul", "10", label="tips300")
+    labware_11 = protocol.load_labware("opentrons_96_tiprack_20ul", "11", label="tips20")
+    left = protocol.load_instrument("p20_multi_gen2", "left")
+    right = protocol.load_instrument("p300_single_gen2", "right")
+
+    right.flow_rate.aspirate = 50
+    right.flow_rate.dispense = 50
+    right.pick_up_tip(labware_10["A1"])
+    right.aspirate(150, labware_4["A1"].bottom(z=1.5))
+    right.dispense(150, labware_1["B2"].top(z=-2))
+    right.blow_out(labware_1["B2"].top(z=-2))
+    right.drop_tip()
+    left.pick_up_tip(labware_11["A3"])
+    left.aspirate(5, labware_4["A2"].bottom(z=0))
+    left.dispense(5, labware_1["A12"].bottom(z=0.5))
+    left.mix(3, 10, labware_1["A12"].bottom(z=0.5))
+    left.drop_tip()
+    protocol.delay(seconds=30)
+    protocol.pause("Seal the output plate")
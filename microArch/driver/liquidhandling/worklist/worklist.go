@@ -0,0 +1,294 @@
+// Package worklist provides a high level liquid handling driver which writes
+// the transfers it is given as a worklist for the robot software to run.
+package worklist
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/antha-lang/antha/antha/anthalib/wtype"
+	"github.com/antha-lang/antha/microArch/driver"
+	"github.com/antha-lang/antha/microArch/driver/liquidhandling"
+)
+
+var _ liquidhandling.HighLevelLiquidhandlingDriver = &Worklist{}
+
+// A Format is a worklist file format
+type Format string
+
+const (
+	// TecanGWL is a Tecan EVOware generic worklist of aspirate, dispense and
+	// wash records
+	TecanGWL Format = "tecan-gwl"
+	// HamiltonCSV is a comma separated list of transfers as read by Hamilton
+	// VENUS methods
+	HamiltonCSV Format = "hamilton-csv"
+	// EchoPickList is a Labcyte Echo pick list, with volumes in nl
+	EchoPickList Format = "echo-picklist"
+)
+
+// Formats are the supported worklist formats
+var Formats = []Format{TecanGWL, HamiltonCSV, EchoPickList}
+
+// ParseFormat returns the format with the given name
+func ParseFormat(name string) (Format, error) {
+	for _, f := range Formats {
+		if string(f) == strings.ToLower(name) {
+			return f, nil
+		}
+	}
+	return "", fmt.Errorf("unknown worklist format %q, expecting one of %v", name, Formats)
+}
+
+// Extension returns the file name extension of worklists in the format
+func (f Format) Extension() string {
+	if f == TecanGWL {
+		return ".gwl"
+	}
+	return ".csv"
+}
+
+// ContentType returns the media type of worklists in the format
+func (f Format) ContentType() string {
+	if f == TecanGWL {
+		return "text/plain"
+	}
+	return "text/csv"
+}
+
+// plate is a plate on the deck, labelled with its name
+type plate struct {
+	label string
+	typ   string
+	rows  int
+}
+
+// record is a single transfer or, if it has no volume, a comment
+type record struct {
+	comment  string
+	what     string
+	from     plate
+	fromWell string
+	to       plate
+	toWell   string
+	volume   float64 // ul
+}
+
+// Worklist is a high level liquid handling driver which renders transfers
+// as a worklist returned by GetOutputFile
+type Worklist struct {
+	format  Format
+	props   *liquidhandling.LHProperties
+	plates  map[string]plate // plates by position name
+	records []record
+}
+
+// New returns a driver writing worklists in the format for a robot with
+// the given properties
+func New(format Format, props *liquidhandling.LHProperties) (*Worklist, error) {
+	if _, err := ParseFormat(string(format)); err != nil {
+		return nil, err
+	}
+
+	p := props.Dup()
+	p.LHType = liquidhandling.HLLiquidHandler
+
+	return &Worklist{
+		format: format,
+		props:  p,
+		plates: make(map[string]plate),
+	}, nil
+}
+
+// Format returns the format of the worklist
+func (w *Worklist) Format() Format {
+	return w.format
+}
+
+// DriverType implements LiquidhandlingDriver
+func (w *Worklist) DriverType() ([]string, error) {
+	return []string{"antha.mixer.v1.Mixer", "Worklist"}, nil
+}
+
+// AddPlateTo implements LiquidhandlingDriver, recording the name of the
+// plate to label its wells in the worklist
+func (w *Worklist) AddPlateTo(position string, obj interface{}, name string) driver.CommandStatus {
+	switch p := obj.(type) {
+	case *wtype.Plate:
+		w.plates[position] = plate{label: name, typ: p.Type, rows: p.WlsY}
+	case wtype.Named:
+		// tipboxes and wastes are managed by the robot software
+	default:
+		return driver.CommandError(fmt.Sprintf("cannot add %T to worklist", obj))
+	}
+	return driver.CommandOk()
+}
+
+// RemoveAllPlates implements LiquidhandlingDriver
+func (w *Worklist) RemoveAllPlates() driver.CommandStatus {
+	w.plates = make(map[string]plate)
+	return driver.CommandOk()
+}
+
+// RemovePlateAt implements LiquidhandlingDriver
+func (w *Worklist) RemovePlateAt(position string) driver.CommandStatus {
+	delete(w.plates, position)
+	return driver.CommandOk()
+}
+
+// Initialize implements LiquidhandlingDriver, starting a new worklist
+func (w *Worklist) Initialize() driver.CommandStatus {
+	w.records = nil
+	return driver.CommandOk()
+}
+
+// Finalize implements LiquidhandlingDriver
+func (w *Worklist) Finalize() driver.CommandStatus {
+	return driver.CommandOk()
+}
+
+// Message implements LiquidhandlingDriver, adding a comment to worklists
+// which support them
+func (w *Worklist) Message(level int, title, text string, showcancel bool) driver.CommandStatus {
+	if title != "" {
+		text = title + ": " + text
+	}
+	if text != "" {
+		w.records = append(w.records, record{comment: text})
+	}
+	return driver.CommandOk()
+}
+
+// GetCapabilities implements LiquidhandlingDriver
+func (w *Worklist) GetCapabilities() (liquidhandling.LHProperties, driver.CommandStatus) {
+	return *w.props.Dup(), driver.CommandOk()
+}
+
+// Transfer implements HighLevelLiquidhandlingDriver, adding a record to the
+// worklist for each channel which moves liquid
+func (w *Worklist) Transfer(what, platefrom, wellfrom, plateto, wellto []string, volume []float64) driver.CommandStatus {
+	n := len(volume)
+	if len(what) != n || len(platefrom) != n || len(wellfrom) != n || len(plateto) != n || len(wellto) != n {
+		return driver.CommandError("transfer: arguments differ in length")
+	}
+
+	for i, vol := range volume {
+		if vol == 0.0 || platefrom[i] == "" || plateto[i] == "" {
+			continue
+		}
+		from, ok := w.plates[platefrom[i]]
+		if !ok {
+			return driver.CommandError(fmt.Sprintf("transfer: no plate at %s", platefrom[i]))
+		}
+		to, ok := w.plates[plateto[i]]
+		if !ok {
+			return driver.CommandError(fmt.Sprintf("transfer: no plate at %s", plateto[i]))
+		}
+		w.records = append(w.records, record{
+			what:     what[i],
+			from:     from,
+			fromWell: wellfrom[i],
+			to:       to,
+			toWell:   wellto[i],
+			volume:   vol,
+		})
+	}
+	return driver.CommandOk()
+}
+
+// GetOutputFile implements LiquidhandlingDriver, returning the worklist
+func (w *Worklist) GetOutputFile() ([]byte, driver.CommandStatus) {
+	var (
+		out []byte
+		err error
+	)
+	switch w.format {
+	case TecanGWL:
+		out, err = w.tecanGWL()
+	case HamiltonCSV:
+		out, err = w.hamiltonCSV()
+	case EchoPickList:
+		out, err = w.echoPickList()
+	}
+	if err != nil {
+		return nil, driver.CommandError(err.Error())
+	}
+	return out, driver.CommandOk()
+}
+
+// formatVolume formats a volume to two decimal places, without trailing
+// zeros
+func formatVolume(v float64) string {
+	return strconv.FormatFloat(math.Round(v*100.0)/100.0, 'f', -1, 64)
+}
+
+// tecanPosition returns the index of the well, counting down each column
+func tecanPosition(p plate, well string) (int, error) {
+	wc := wtype.MakeWellCoords(well)
+	if wc.IsZero() || p.rows == 0 {
+		return 0, fmt.Errorf("invalid well %q of plate %s", well, p.label)
+	}
+	return wc.X*p.rows + wc.Y + 1, nil
+}
+
+func (w *Worklist) tecanGWL() ([]byte, error) {
+	var buf bytes.Buffer
+	for _, r := range w.records {
+		if r.volume == 0.0 {
+			fmt.Fprintf(&buf, "C;%s\r\n", strings.Replace(r.comment, ";", ",", -1))
+			continue
+		}
+		from, err := tecanPosition(r.from, r.fromWell)
+		if err != nil {
+			return nil, err
+		}
+		to, err := tecanPosition(r.to, r.toWell)
+		if err != nil {
+			return nil, err
+		}
+		vol := formatVolume(r.volume)
+		fmt.Fprintf(&buf, "A;%s;;%s;%d;;%s\r\n", r.from.label, r.from.typ, from, vol)
+		fmt.Fprintf(&buf, "D;%s;;%s;%d;;%s\r\n", r.to.label, r.to.typ, to, vol)
+		fmt.Fprintf(&buf, "W;\r\n")
+	}
+	return buf.Bytes(), nil
+}
+
+func writeCSV(header []string, rows [][]string) ([]byte, error) {
+	var buf bytes.Buffer
+	cw := csv.NewWriter(&buf)
+	if err := cw.Write(header); err != nil {
+		return nil, err
+	}
+	if err := cw.WriteAll(rows); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (w *Worklist) hamiltonCSV() ([]byte, error) {
+	var rows [][]string
+	for _, r := range w.records {
+		if r.volume == 0.0 {
+			continue
+		}
+		rows = append(rows, []string{r.from.label, r.fromWell, r.to.label, r.toWell, formatVolume(r.volume), r.what})
+	}
+	return writeCSV([]string{"SourceLabware", "SourceWell", "DestinationLabware", "DestinationWell", "Volume", "Liquid"}, rows)
+}
+
+func (w *Worklist) echoPickList() ([]byte, error) {
+	var rows [][]string
+	for _, r := range w.records {
+		if r.volume == 0.0 {
+			continue
+		}
+		// the Echo transfers in nl
+		rows = append(rows, []string{r.from.label, r.from.typ, r.fromWell, r.to.label, r.toWell, formatVolume(r.volume * 1000.0)})
+	}
+	return writeCSV([]string{"Source Plate Name", "Source Plate Type", "Source Well", "Destination Plate Name", "Destination Well", "Transfer Volume"}, rows)
+}
@@ -0,0 +1,116 @@
+package worklist
+
+import (
+	"context"
+	"testing"
+
+	"github.com/antha-lang/antha/antha/anthalib/wtype"
+	"github.com/antha-lang/antha/inventory"
+	"github.com/antha-lang/antha/inventory/testinventory"
+	"github.com/antha-lang/antha/microArch/driver/liquidhandling"
+)
+
+func makeTestWorklist(t *testing.T, format Format) *Worklist {
+	ctx := testinventory.NewContext(context.Background())
+
+	props := liquidhandling.NewLHProperties("Evo", "Tecan", liquidhandling.LLLiquidHandler, liquidhandling.DisposableTips, map[string]*wtype.LHPosition{
+		"position_1": wtype.NewLHPosition("position_1", wtype.Coordinates3D{}, wtype.SBSFootprint),
+		"position_2": wtype.NewLHPosition("position_2", wtype.Coordinates3D{X: 150.0}, wtype.SBSFootprint),
+	})
+	props.Preferences = &liquidhandling.LayoutOpt{
+		Inputs:  liquidhandling.Addresses{"position_1"},
+		Outputs: liquidhandling.Addresses{"position_2"},
+	}
+
+	w, err := New(format, props)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if p, status := w.GetCapabilities(); !status.Ok() {
+		t.Fatal(status)
+	} else if p.GetLHType() != liquidhandling.HLLiquidHandler {
+		t.Errorf("expected high level properties, got %s", p.GetLHType())
+	}
+
+	source, err := inventory.NewPlate(ctx, "DWST12")
+	if err != nil {
+		t.Fatal(err)
+	}
+	dest, err := inventory.NewPlate(ctx, "pcrplate_skirted")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tips, err := inventory.NewTipbox(ctx, "Tecan200")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, status := range []interface {
+		Ok() bool
+	}{
+		w.Initialize(),
+		w.AddPlateTo("position_1", source, "Reagents"),
+		w.AddPlateTo("position_2", dest, "Assay"),
+		w.AddPlateTo("position_3", tips, "tips"),
+		w.Transfer([]string{"water", "", "dna"}, []string{"position_1", "", "position_1"}, []string{"A1", "", "A3"}, []string{"position_2", "", "position_2"}, []string{"A1", "", "C2"}, []float64{20, 0, 2.5}),
+		w.Message(0, "", "Spin down the plate", false),
+		w.Transfer([]string{"water"}, []string{"position_1"}, []string{"A2"}, []string{"position_2"}, []string{"H12"}, []float64{1.006}),
+		w.Finalize(),
+	} {
+		if !status.Ok() {
+			t.Fatal(status)
+		}
+	}
+
+	return w
+}
+
+func TestFormats(t *testing.T) {
+	for format, expected := range map[Format]string{
+		TecanGWL: "A;Reagents;;DWST12;1;;20\r\n" +
+			"D;Assay;;pcrplate_skirted;1;;20\r\n" +
+			"W;\r\n" +
+			"A;Reagents;;DWST12;3;;2.5\r\n" +
+			"D;Assay;;pcrplate_skirted;11;;2.5\r\n" +
+			"W;\r\n" +
+			"C;Spin down the plate\r\n" +
+			"A;Reagents;;DWST12;2;;1.01\r\n" +
+			"D;Assay;;pcrplate_skirted;96;;1.01\r\n" +
+			"W;\r\n",
+		HamiltonCSV: "SourceLabware,SourceWell,DestinationLabware,DestinationWell,Volume,Liquid\n" +
+			"Reagents,A1,Assay,A1,20,water\n" +
+			"Reagents,A3,Assay,C2,2.5,dna\n" +
+			"Reagents,A2,Assay,H12,1.01,water\n",
+		EchoPickList: "Source Plate Name,Source Plate Type,Source Well,Destination Plate Name,Destination Well,Transfer Volume\n" +
+			"Reagents,DWST12,A1,Assay,A1,20000\n" +
+			"Reagents,DWST12,A3,Assay,C2,2500\n" +
+			"Reagents,DWST12,A2,Assay,H12,1006\n",
+	} {
+		w := makeTestWorklist(t, format)
+		out, status := w.GetOutputFile()
+		if !status.Ok() {
+			t.Fatal(status)
+		}
+		if string(out) != expected {
+			t.Errorf("%s: expected\n%s\ngot\n%s", format, expected, out)
+		}
+	}
+}
+
+func TestErrors(t *testing.T) {
+	if _, err := ParseFormat("bravo"); err == nil {
+		t.Error("expected error for unknown format")
+	}
+	if f, err := ParseFormat("Tecan-GWL"); err != nil || f != TecanGWL {
+		t.Errorf("expected %s, got %s: %v", TecanGWL, f, err)
+	}
+
+	w := makeTestWorklist(t, HamiltonCSV)
+	if status := w.Transfer([]string{"water"}, []string{"position_1"}, []string{"A1"}, []string{"position_4"}, []string{"A1"}, []float64{10}); status.Ok() {
+		t.Error("expected error transferring to empty position")
+	}
+	if status := w.Transfer([]string{"water"}, []string{"position_1"}, []string{"A1"}, []string{"position_2"}, []string{"A1"}, []float64{10, 10}); status.Ok() {
+		t.Error("expected error for arguments of different lengths")
+	}
+}
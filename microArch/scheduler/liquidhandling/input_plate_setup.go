@@ -124,11 +124,41 @@ func (rq *LHRequest) inputPlateSetup(ctx context.Context, carryVolume wunit.Volu
 		if len(input_platetypes) == 0 {
 			return fmt.Errorf("no input plate set: \n  - Please upload plate file or select at least one input plate type in Configuration > Preferences > inputPlateTypes. \n - Important: Please add a riser to the plate choice for low profile plates such as PCR plates, 96 and 384 well plates. ")
 		}
-		var err error
-		well_count_assignments, err = choosePlateAssignments(input_volumes, input_platetypes, weights_constraints)
+		// bulk components held in reservoirs take a single well each,
+		// everything else is assigned wells of the other plate types
+		lp_volumes := input_volumes
+		lp_platetypes := input_platetypes
+		if len(rq.Reservoirs) != 0 {
+			lp_volumes = make(map[string]wunit.Volume, len(input_volumes))
+			for cname, vol := range input_volumes {
+				if _, ok := rq.Reservoirs[cname]; !ok {
+					lp_volumes[cname] = vol
+				}
+			}
 
-		if err != nil {
-			return err
+			var others []*wtype.Plate
+			for _, p := range input_platetypes {
+				if !p.IsReservoir() {
+					others = append(others, p)
+				}
+			}
+			if len(others) != 0 {
+				lp_platetypes = others
+			}
+		}
+
+		well_count_assignments = make(map[string]map[*wtype.Plate]int, len(input_volumes))
+		if len(lp_volumes) != 0 {
+			var err error
+			well_count_assignments, err = choosePlateAssignments(lp_volumes, lp_platetypes, weights_constraints)
+
+			if err != nil {
+				return err
+			}
+		}
+
+		for cname, res := range rq.Reservoirs {
+			well_count_assignments[cname] = map[*wtype.Plate]int{res.Platetype.Dup(): 1}
 		}
 	}
 
@@ -169,8 +199,8 @@ func (rq *LHRequest) inputPlateSetup(ctx context.Context, carryVolume wunit.Volu
 		for platetype, nwells := range well_assignments {
 			WellTot := nwells + 1
 
-			// unless it's an instance
-			if isInstance(cname) {
+			// unless it's an instance, or a reservoir which is refilled instead
+			if _, isReservoir := rq.Reservoirs[cname]; isInstance(cname) || isReservoir {
 				WellTot = nwells
 			}
 
@@ -221,6 +251,10 @@ func (rq *LHRequest) inputPlateSetup(ctx context.Context, carryVolume wunit.Volu
 					newcomponent.Vunit = curr_well.MaxVolume().Unit().PrefixedSymbol()
 					newcomponent.Loc = location
 
+					if res, ok := rq.Reservoirs[cname]; ok {
+						res.Location = location
+					}
+
 					//usefulVolume is the most we can get from the well assuming one transfer
 					usefulVolume := curr_well.CurrentWorkingVolume()
 					usefulVolume.Subtract(carryVolume)
@@ -0,0 +1,400 @@
+package liquidhandling
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/antha-lang/antha/antha/anthalib/wtype"
+	"github.com/antha-lang/antha/antha/anthalib/wunit"
+	"github.com/antha-lang/antha/microArch/driver/liquidhandling"
+)
+
+// maximum number of improving moves made by the layout optimizer
+const maxLayoutMoves = 1000
+
+// LayoutTravel is the estimated distance travelled by the head to make the
+// transfers in a request, for the layout chosen by the setup agent and for
+// the optimized layout
+type LayoutTravel struct {
+	Before wunit.Length
+	After  wunit.Length
+}
+
+func (lt LayoutTravel) String() string {
+	return fmt.Sprintf("estimated head travel %.1f mm before layout optimization, %.1f mm after", lt.Before.ConvertToString("mm"), lt.After.ConvertToString("mm"))
+}
+
+// the tipbox item stands in for the first tipbox used, which is not placed
+// until instructions are generated
+const firstTipboxID = "first tipbox"
+
+// a layoutItem is an object whose position may be chosen by the optimizer
+type layoutItem struct {
+	id      string
+	allowed []string // permitted positions in order of preference
+}
+
+// a layoutTransfer is a movement of liquid between two deck objects,
+// identified by ID, made count times
+type layoutTransfer struct {
+	from, to string
+	count    int
+}
+
+// layoutProblem is the layout of deck objects for a set of transfers
+type layoutProblem struct {
+	positions map[string]*wtype.LHPosition
+	items     []layoutItem
+	fixed     map[string]string // position by ID of objects which may not move
+	transfers []layoutTransfer
+	tipbox    string // ID of the object tips are fetched from, if any
+	tipwaste  string // ID of the object tips are dropped into, if any
+}
+
+// OptimizeLayout moves the input and output plates and tip waste placed by
+// the setup agent, and reorders the tipbox preferences, to reduce the
+// distance travelled by the head in making the transfers in the request.
+// Objects are only moved between positions permitted by the layout
+// preferences and plate constraints of the liquid handler. The estimated
+// travel before and after optimization is recorded in request.LayoutTravel.
+func OptimizeLayout(request *LHRequest, params *liquidhandling.LHProperties) error {
+	prob, initial := newLayoutProblem(request, params)
+
+	before := prob.travel(initial)
+	layout := prob.optimize(initial)
+	after := prob.travel(layout)
+
+	if after < before {
+		if err := applyLayout(request, params, layout); err != nil {
+			return err
+		}
+	} else {
+		after = before
+	}
+
+	request.LayoutTravel = &LayoutTravel{
+		Before: wunit.NewLength(before, "mm"),
+		After:  wunit.NewLength(after, "mm"),
+	}
+
+	return nil
+}
+
+// newLayoutProblem finds the objects which may be moved, the positions they
+// may be moved to and the transfers made between them, returning the
+// problem and the current positions of the movable objects
+func newLayoutProblem(request *LHRequest, params *liquidhandling.LHProperties) (*layoutProblem, map[string]string) {
+	prob := &layoutProblem{
+		positions: params.Positions,
+		fixed:     make(map[string]string, len(params.PosLookup)),
+		transfers: layoutTransfers(request),
+	}
+	initial := make(map[string]string)
+
+	allowedFor := func(p *wtype.Plate, prefs []string) []string {
+		allowed, isConstrained := p.IsConstrainedOn(params.Model)
+		var ret []string
+		for _, pref := range prefs {
+			if !isConstrained || isInStrArr(pref, allowed) {
+				ret = append(ret, pref)
+			}
+		}
+		return ret
+	}
+
+	addItem := func(id string, prefs []string, pos string) {
+		var allowed []string
+		for _, addr := range prefs {
+			if params.Exists(addr) {
+				allowed = append(allowed, addr)
+			}
+		}
+		prob.items = append(prob.items, layoutItem{id: id, allowed: allowed})
+		initial[id] = pos
+	}
+
+	for id, pos := range request.PlateLookup {
+		if p, ok := request.InputPlates[id]; ok {
+			addItem(id, allowedFor(p, params.Preferences.Inputs), pos)
+		} else if p, ok := request.OutputPlates[id]; ok {
+			addItem(id, allowedFor(p, params.Preferences.Outputs), pos)
+		}
+	}
+
+	// tips are only dropped in the first tip waste
+	for _, addr := range params.Preferences.Tipwastes {
+		if tw, ok := params.Tipwastes[addr]; ok {
+			prob.tipwaste = tw.ID
+			addItem(tw.ID, params.Preferences.Tipwastes, addr)
+			break
+		}
+	}
+
+	// tipboxes already on deck are used first, otherwise they are added
+	// during instruction generation in order of preference so we choose
+	// the position of the first
+	if tt := params.GetTipType(); tt == liquidhandling.DisposableTips || tt == liquidhandling.MixedDisposableAndFixedTips {
+		for _, addr := range params.Preferences.Tipboxes {
+			if tb, ok := params.Tipboxes[addr]; ok {
+				prob.tipbox = tb.ID
+				break
+			}
+		}
+		if prob.tipbox == "" {
+			for _, addr := range params.Preferences.Tipboxes {
+				if params.IsEmpty(addr) {
+					prob.tipbox = firstTipboxID
+					addItem(firstTipboxID, params.Preferences.Tipboxes, addr)
+					break
+				}
+			}
+		}
+	}
+
+	sort.Slice(prob.items, func(i, j int) bool {
+		return prob.items[i].id < prob.items[j].id
+	})
+
+	for addr, id := range params.PosLookup {
+		if _, movable := initial[id]; id != "" && !movable {
+			prob.fixed[id] = addr
+		}
+	}
+
+	return prob, initial
+}
+
+// layoutTransfers counts the transfers made between each pair of plates by
+// the mix instructions in the request
+func layoutTransfers(request *LHRequest) []layoutTransfer {
+	plateOf := func(loc string) string {
+		if tx := strings.Split(loc, ":"); len(tx) == 2 {
+			return tx[0]
+		}
+		return ""
+	}
+
+	sourceOf := func(cmp *wtype.Liquid) string {
+		if id := plateOf(cmp.Loc); id != "" {
+			return id
+		}
+		for _, key := range []string{cmp.CNID(), cmp.CName} {
+			if ass := request.InputAssignments[key]; len(ass) != 0 {
+				return plateOf(ass[0])
+			}
+		}
+		return ""
+	}
+
+	counts := make(map[layoutTransfer]int)
+	for _, ins := range request.LHInstructions {
+		if ins.Type != wtype.LHIMIX || ins.PlateID == "" {
+			continue
+		}
+		for i, cmp := range ins.Inputs {
+			if i == 0 && ins.IsMixInPlace() {
+				continue
+			}
+			if from := sourceOf(cmp); from != "" {
+				counts[layoutTransfer{from: from, to: ins.PlateID}]++
+			}
+		}
+	}
+
+	ret := make([]layoutTransfer, 0, len(counts))
+	for t, count := range counts {
+		t.count = count
+		ret = append(ret, t)
+	}
+	sort.Slice(ret, func(i, j int) bool {
+		if ret[i].from != ret[j].from {
+			return ret[i].from < ret[j].from
+		}
+		return ret[i].to < ret[j].to
+	})
+
+	return ret
+}
+
+// distance returns the distance between the centres of two positions in mm
+func (prob *layoutProblem) distance(a, b string) float64 {
+	pa, oka := prob.positions[a]
+	pb, okb := prob.positions[b]
+	if !oka || !okb {
+		return 0.0
+	}
+	dx := (pa.Location.X + pa.Size.X/2.0) - (pb.Location.X + pb.Size.X/2.0)
+	dy := (pa.Location.Y + pa.Size.Y/2.0) - (pb.Location.Y + pb.Size.Y/2.0)
+	return math.Sqrt(dx*dx + dy*dy)
+}
+
+// travel estimates the distance travelled by the head in mm to make the
+// transfers with the given positions of the movable items. With disposable
+// tips each transfer fetches a tip, moves from the source to the
+// destination and drops the tip.
+func (prob *layoutProblem) travel(layout map[string]string) float64 {
+	posOf := func(id string) string {
+		if pos, ok := layout[id]; ok {
+			return pos
+		}
+		return prob.fixed[id]
+	}
+
+	tipbox := posOf(prob.tipbox)
+	tipwaste := posOf(prob.tipwaste)
+
+	var total float64
+	for _, t := range prob.transfers {
+		from, to := posOf(t.from), posOf(t.to)
+		if from == "" || to == "" {
+			continue
+		}
+		d := prob.distance(from, to)
+		if tipbox != "" {
+			d += prob.distance(tipbox, from)
+		}
+		if tipwaste != "" {
+			d += prob.distance(to, tipwaste)
+			if tipbox != "" {
+				d += prob.distance(tipwaste, tipbox)
+			}
+		}
+		total += float64(t.count) * d
+	}
+	return total
+}
+
+// optimize improves the layout by repeatedly making the single move of an
+// item to a free position, or swap of two items, which reduces the travel
+// the most
+func (prob *layoutProblem) optimize(initial map[string]string) map[string]string {
+	layout := make(map[string]string, len(initial))
+	for id, pos := range initial {
+		layout[id] = pos
+	}
+
+	const epsilon = 1.0e-6
+	current := prob.travel(layout)
+
+	for n := 0; n < maxLayoutMoves; n++ {
+		taken := make(map[string]string, len(layout)+len(prob.fixed))
+		for id, pos := range prob.fixed {
+			taken[pos] = id
+		}
+		for id, pos := range layout {
+			taken[pos] = id
+		}
+
+		best := current
+		var bestID, bestPos string
+
+		for _, item := range prob.items {
+			here := layout[item.id]
+			for _, pos := range item.allowed {
+				if pos == here || !prob.canMove(item.id, pos, layout, taken) {
+					continue
+				}
+				prob.move(item.id, pos, layout, taken)
+				if t := prob.travel(layout); t < best-epsilon {
+					best, bestID, bestPos = t, item.id, pos
+				}
+				prob.move(item.id, here, layout, taken)
+			}
+		}
+
+		if bestID == "" {
+			break
+		}
+		prob.move(bestID, bestPos, layout, taken)
+		current = best
+	}
+
+	return layout
+}
+
+// canMove returns true if the item with the id can be moved to pos, either
+// because it is free or because the item there can take its place
+func (prob *layoutProblem) canMove(id, pos string, layout, taken map[string]string) bool {
+	other, ok := taken[pos]
+	return !ok || prob.allows(other, layout[id])
+}
+
+// move moves the item with the id to pos, swapping it with any item there
+func (prob *layoutProblem) move(id, pos string, layout, taken map[string]string) {
+	here := layout[id]
+	if other, ok := taken[pos]; ok {
+		layout[other] = here
+		taken[here] = other
+	} else {
+		delete(taken, here)
+	}
+	layout[id] = pos
+	taken[pos] = id
+}
+
+// allows returns true if the movable item with the id may be placed at pos
+func (prob *layoutProblem) allows(id, pos string) bool {
+	for _, item := range prob.items {
+		if item.id == id {
+			return isInStrArr(pos, item.allowed)
+		}
+	}
+	return false
+}
+
+// applyLayout moves the objects on deck to the optimized positions and
+// makes the position of the first tipbox the most preferred
+func applyLayout(request *LHRequest, params *liquidhandling.LHProperties, layout map[string]string) error {
+	// take everything off the deck before putting it back so that swaps
+	// don't collide
+	plates := make(map[string]*wtype.Plate)
+	tipwastes := make(map[string]*wtype.LHTipwaste)
+	for id, pos := range layout {
+		if id == firstTipboxID || params.PlateIDLookup[id] == pos {
+			continue
+		}
+		switch obj := params.PlateLookup[id].(type) {
+		case *wtype.Plate:
+			plates[id] = obj
+			params.RemovePlateWithID(id)
+		case *wtype.LHTipwaste:
+			tipwastes[id] = obj
+			addr := params.PlateIDLookup[id]
+			delete(params.Tipwastes, addr)
+			delete(params.PosLookup, addr)
+			delete(params.PlateIDLookup, id)
+			delete(params.PlateLookup, id)
+		default:
+			return wtype.LHErrorf(wtype.LH_ERR_DIRE, "while optimizing layout: cannot move object of type %T", obj)
+		}
+	}
+
+	for id, p := range plates {
+		if err := params.AddPlateTo(layout[id], p); err != nil {
+			return err
+		}
+		request.PlateLookup[id] = layout[id]
+	}
+
+	for id, tw := range tipwastes {
+		if err := params.AddTipWasteTo(layout[id], tw); err != nil {
+			return err
+		}
+	}
+
+	if pos, ok := layout[firstTipboxID]; ok {
+		prefs := params.Preferences.Dup()
+		prefs.Tipboxes = make(liquidhandling.Addresses, 0, len(params.Preferences.Tipboxes))
+		prefs.Tipboxes = append(prefs.Tipboxes, pos)
+		for _, addr := range params.Preferences.Tipboxes {
+			if addr != pos {
+				prefs.Tipboxes = append(prefs.Tipboxes, addr)
+			}
+		}
+		params.Preferences = prefs
+	}
+
+	return nil
+}
@@ -0,0 +1,145 @@
+package liquidhandling
+
+import (
+	"context"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/antha-lang/antha/antha/anthalib/wtype"
+	"github.com/antha-lang/antha/inventory"
+	"github.com/antha-lang/antha/inventory/testinventory"
+	"github.com/antha-lang/antha/microArch/driver/liquidhandling"
+)
+
+// makeLayoutRequest returns a request which transfers n times from an input
+// plate to an output plate
+func makeLayoutRequest(ctx context.Context, t *testing.T, n int) (*LHRequest, *wtype.Plate, *wtype.Plate) {
+	req := NewLHRequest()
+
+	input, err := inventory.NewPlate(ctx, "DWST12")
+	if err != nil {
+		t.Fatal(err)
+	}
+	output, err := inventory.NewPlate(ctx, "pcrplate_skirted")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req.InputPlates[input.ID] = input
+	req.OutputPlates[output.ID] = output
+	req.InputAssignments["water"] = []string{input.ID + ":A1"}
+
+	for i := 0; i < n; i++ {
+		ins := wtype.NewLHMixInstruction()
+		smp := getComponentWithNameVolume("water", 50.0)
+		smp.SetSample(true)
+		ins.Inputs = []*wtype.Liquid{smp}
+		ins.PlateID = output.ID
+		res := getComponentWithNameVolume("water", 50.0)
+		res.Loc = output.ID + ":A1"
+		ins.AddOutput(res)
+		req.LHInstructions[ins.ID] = ins
+		req.OutputOrder = append(req.OutputOrder, ins.ID)
+	}
+
+	return req, input, output
+}
+
+func TestOptimizeLayout(t *testing.T) {
+	ctx := testinventory.NewContext(context.Background())
+	lh := GetLiquidHandlerForTest(ctx)
+	req, input, output := makeLayoutRequest(ctx, t, 10)
+	req.Options.OptimizeLayout = true
+
+	if err := lh.Setup(ctx, req); err != nil {
+		t.Fatal(err)
+	}
+
+	if req.LayoutTravel == nil {
+		t.Fatal("expected layout travel to be reported")
+	}
+	before, after := req.LayoutTravel.Before.ConvertToString("mm"), req.LayoutTravel.After.ConvertToString("mm")
+	if after >= before {
+		t.Errorf("expected optimization to reduce travel, got %f mm before and %f mm after", before, after)
+	}
+	if summary := req.PlanSummary(); len(summary) != 1 || !strings.HasPrefix(summary[0], "Layout: ") {
+		t.Errorf("expected layout travel in plan summary, got %q", summary)
+	}
+
+	props := lh.Properties
+	for _, p := range []*wtype.Plate{input, output} {
+		pos := props.PlateIDLookup[p.ID]
+		if pos == "" || req.PlateLookup[p.ID] != pos || props.Plates[pos] != p {
+			t.Errorf("plate %s inconsistently placed: %q in properties, %q in request", p.ID, pos, req.PlateLookup[p.ID])
+		}
+	}
+	if pos := props.PlateIDLookup[input.ID]; !isInStrArr(pos, props.Preferences.Inputs) {
+		t.Errorf("input plate moved to %s, not one of %s", pos, props.Preferences.Inputs)
+	}
+	if pos := props.PlateIDLookup[output.ID]; !isInStrArr(pos, props.Preferences.Outputs) {
+		t.Errorf("output plate moved to %s, not one of %s", pos, props.Preferences.Outputs)
+	}
+	if n := props.TipWastesMounted(); n != 1 {
+		t.Errorf("expected 1 tip waste mounted, got %d", n)
+	}
+
+	// the first tipbox goes in the most preferred position, which must be
+	// free
+	if tb := props.Preferences.Tipboxes[0]; !props.IsEmpty(tb) {
+		t.Errorf("first tipbox position %s is not empty", tb)
+	}
+
+	// the travel reported afterwards should match the layout
+	prob, initial := newLayoutProblem(req, props)
+	if got := prob.travel(initial); math.Abs(got-after) > 1.0e-6 {
+		t.Errorf("expected travel of %f mm for optimized layout, got %f mm", after, got)
+	}
+}
+
+func TestOptimizeLayoutConstrained(t *testing.T) {
+	ctx := testinventory.NewContext(context.Background())
+	lh := GetLiquidHandlerForTest(ctx)
+	req, input, output := makeLayoutRequest(ctx, t, 1)
+	req.Options.OptimizeLayout = true
+
+	input.SetConstrained(lh.Properties.Model, []string{"position_4"})
+	output.SetConstrained(lh.Properties.Model, []string{"position_7"})
+
+	if err := lh.Setup(ctx, req); err != nil {
+		t.Fatal(err)
+	}
+
+	if pos := lh.Properties.PlateIDLookup[input.ID]; pos != "position_4" {
+		t.Errorf("constrained input plate moved to %s", pos)
+	}
+	if pos := lh.Properties.PlateIDLookup[output.ID]; pos != "position_7" {
+		t.Errorf("constrained output plate moved to %s", pos)
+	}
+}
+
+func TestOptimizeLayoutFixedTips(t *testing.T) {
+	ctx := testinventory.NewContext(context.Background())
+	lh := GetLiquidHandlerForTest(ctx)
+	lh.Properties.TipType = liquidhandling.FixedTips
+	req, _, _ := makeLayoutRequest(ctx, t, 1)
+
+	prefs := lh.Properties.Preferences.Tipboxes.Dup()
+
+	if err := lh.Setup(ctx, req); err != nil {
+		t.Fatal(err)
+	}
+	if req.LayoutTravel != nil {
+		t.Error("didn't expect layout travel without optimization")
+	}
+
+	if err := OptimizeLayout(req, lh.Properties); err != nil {
+		t.Fatal(err)
+	}
+	for i, addr := range prefs {
+		if lh.Properties.Preferences.Tipboxes[i] != addr {
+			t.Errorf("expected tipbox preferences to be unchanged without disposable tips, got %s", lh.Properties.Preferences.Tipboxes)
+			break
+		}
+	}
+}
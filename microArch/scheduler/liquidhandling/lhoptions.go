@@ -8,6 +8,9 @@ type LHOptions struct {
 	LegacyVolume             bool
 	FixVolumes               bool
 	IgnorePhysicalSimulation bool
+	OptimizeLayout           bool
+	OptimizeTransferOrder    bool
+	UseReservoirs            bool
 }
 
 func NewLHOptions() LHOptions {
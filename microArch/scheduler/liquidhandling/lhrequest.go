@@ -64,13 +64,33 @@ type LHRequest struct {
 	OutputOrder           []string
 	OutputIteratorFactory func(wtype.Addressable) wtype.AddressIterator `json:"-"`
 	InstructionChain      *wtype.IChain
-	TimeEstimate          float64 // in seconds
+	TimeEstimate          float64                           // in seconds
+	Timeline              []liquidhandling.TimedInstruction // estimated timing of each instruction from physical simulation
 	InstructionSets       [][]*wtype.LHInstruction
 	Options               LHOptions
 	NUserPlates           int
 	OutputSort            bool
 	TipsUsed              []wtype.TipEstimate
-	InputSolutions        *InputSolutions //store properties related to the Liquids for the request
+	InputSolutions        *InputSolutions       //store properties related to the Liquids for the request
+	LayoutTravel          *LayoutTravel         // head travel before and after layout optimization, if requested
+	TransferOrder         *TransferOrderReport  // tips and time before and after transfer reordering, if requested
+	Reservoirs            map[string]*Reservoir // bulk components held in reservoirs by kind, if requested
+}
+
+// PlanSummary describes the effect of the optimizations made in planning the
+// request and the reservoirs used, one line each
+func (req *LHRequest) PlanSummary() []string {
+	var lines []string
+	if req.LayoutTravel != nil {
+		lines = append(lines, fmt.Sprintf("Layout: %s", req.LayoutTravel))
+	}
+	if req.TransferOrder != nil {
+		lines = append(lines, fmt.Sprintf("Transfer order: %s", req.TransferOrder))
+	}
+	for _, name := range sortedReservoirNames(req.Reservoirs) {
+		lines = append(lines, fmt.Sprintf("Reservoir: %s", req.Reservoirs[name]))
+	}
+	return lines
 }
 
 func (req *LHRequest) GetPlate(id string) (*wtype.Plate, bool) {
@@ -113,6 +113,13 @@ func (this *Liquidhandler) MakeSolutions(ctx context.Context, request *LHRequest
 		fmt.Printf("  %v\n", tipEstimate)
 	}
 
+	if summary := request.PlanSummary(); len(summary) != 0 {
+		fmt.Println("Plan Summary:")
+		for _, line := range summary {
+			fmt.Printf("  %s\n", line)
+		}
+	}
+
 	if err := this.Simulate(request); err != nil && !request.Options.IgnorePhysicalSimulation {
 		return errors.WithMessage(err, "during physical simulation")
 	}
@@ -147,17 +154,8 @@ func (this *Liquidhandler) AddSetupInstructions(request *LHRequest) error {
 	return nil
 }
 
-// run the request via the physical simulator
-func (this *Liquidhandler) Simulate(request *LHRequest) error {
-
-	instructions := request.Instructions
-	if len(instructions) == 0 {
-		return wtype.LHError(wtype.LH_ERR_OTHER, "cannot simulate request: no instructions")
-	}
-
-	// set up the simulator with default settings
-	props := this.Properties.DupKeepIDs()
-
+// newSimulatorSettings the settings used to simulate requests
+func newSimulatorSettings() *simulator_lh.SimulatorSettings {
 	settings := simulator_lh.DefaultSimulatorSettings()
 
 	//Make this warning less noisy since it's not really important
@@ -170,7 +168,21 @@ func (this *Liquidhandler) Simulate(request *LHRequest) error {
 	//collisions when when tips are picked up sequentially
 	settings.EnableTipboxCollision(false)
 
-	vlh, err := simulator_lh.NewVirtualLiquidHandler(props, settings)
+	return settings
+}
+
+// run the request via the physical simulator
+func (this *Liquidhandler) Simulate(request *LHRequest) error {
+
+	instructions := request.Instructions
+	if len(instructions) == 0 {
+		return wtype.LHError(wtype.LH_ERR_OTHER, "cannot simulate request: no instructions")
+	}
+
+	// set up the simulator with default settings
+	props := this.Properties.DupKeepIDs()
+
+	vlh, err := simulator_lh.NewVirtualLiquidHandler(props, newSimulatorSettings())
 	if err != nil {
 		return err
 	}
@@ -198,6 +210,8 @@ func (this *Liquidhandler) Simulate(request *LHRequest) error {
 		return err
 	}
 
+	request.Timeline = vlh.GetTimeline()
+
 	//if there were no errors or warnings
 	numErrors := vlh.CountErrors()
 	if numErrors == 0 {
@@ -289,6 +303,12 @@ func (this *Liquidhandler) Execute(request *LHRequest) error {
 		}
 	}
 
+	// prefer the physical simulation's estimate where available
+	if len(request.Timeline) != 0 {
+		last := request.Timeline[len(request.Timeline)-1]
+		d = last.Start + last.Duration
+	}
+
 	fmt.Printf("Total time estimate: %s\n", d.String())
 	request.TimeEstimate = d.Round(time.Second).Seconds()
 
@@ -386,7 +406,10 @@ func (this *Liquidhandler) shrinkVolumes(rq *LHRequest) error {
 				// case but still we should ideally be stricter
 				//
 				rv := rawVols[initialWell]
-				if rv.LessThan(initialWell.MaxVolume()) || rv.EqualTo(initialWell.MaxVolume()) {
+				if rq.refilledReservoir(initialWell) {
+					// the remainder is supplied by refills prompted during the run
+					initialVolume = initialWell.MaxVolume()
+				} else if rv.LessThan(initialWell.MaxVolume()) || rv.EqualTo(initialWell.MaxVolume()) {
 					// don't exceed the well maximum by a trivial amount
 					initialVolume = initialWell.MaxVolume()
 				} else {
@@ -626,6 +649,9 @@ func (this *Liquidhandler) Plan(ctx context.Context, request *LHRequest) error {
 		}
 	}
 
+	// hold bulk components in reservoirs if requested
+	request.chooseReservoirs(this.Properties.GetLoadedAdaptors())
+
 	// define the input plates
 	if err := request.inputPlateSetup(ctx, this.Properties.CarryVolume()); err != nil {
 		return errors.WithMessage(err, "while setting up input plates")
@@ -642,22 +668,33 @@ func (this *Liquidhandler) Plan(ctx context.Context, request *LHRequest) error {
 	// remove dummy mix-in-place instructions
 	request.removeDummyInstructions()
 
+	// prompt for reservoirs to be refilled when they would run out
+	if err := request.addRefillPrompts(this.Properties.CarryVolume()); err != nil {
+		return err
+	}
+
 	//set the well targets
 	if err := this.addWellTargets(); err != nil {
 		return err
 	}
 
 	// make the instructions for executing this request by first building the ITree root, then generating the lower level instructions
-	if root, err := liquidhandling.NewITreeRoot(request.InstructionChain); err != nil {
-		return err
-	} else if final, err := root.Build(ctx, request.Policies(), this.Properties); err != nil {
+	root, final, err := this.buildInstructionTree(ctx, request)
+	if err != nil {
 		return err
-	} else {
-		request.InstructionTree = root
-		request.Instructions = root.Leaves()
-		this.FinalProperties = final
 	}
 
+	// optionally reorder the transfers to save tips and time
+	if request.Options.OptimizeTransferOrder {
+		if root, final, err = this.reorderTransfers(ctx, request, root, final); err != nil {
+			return err
+		}
+	}
+
+	request.InstructionTree = root
+	request.Instructions = root.Leaves()
+	this.FinalProperties = final
+
 	// tipboxes are added during the tree building, so only exist in the final state
 	// copy them accross to the initial properties
 	for pos, tb := range this.FinalProperties.Tipboxes {
@@ -733,7 +770,15 @@ func (this *Liquidhandler) GetPlates(ctx context.Context, plates map[string]*wty
 func (this *Liquidhandler) Setup(ctx context.Context, request *LHRequest) error {
 	// assign the plates to positions
 	// this needs to be parameterizable
-	return this.SetupAgent(ctx, request, this.Properties)
+	if err := this.SetupAgent(ctx, request, this.Properties); err != nil {
+		return err
+	}
+
+	// then move them to reduce head travel
+	if request.Options.OptimizeLayout {
+		return OptimizeLayout(request, this.Properties)
+	}
+	return nil
 }
 
 // generate the output layout
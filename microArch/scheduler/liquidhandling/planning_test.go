@@ -79,6 +79,20 @@ func (test *PlanningTest) checkSummaryGeneration(t *testing.T, request *LHReques
 		fmt.Printf("Invalid Actions:\n%s\n", string(bs))
 		t.Error(err)
 	}
+
+	replay, err := NewSimulationInput(test.Liquidhandler.Properties, request.Instructions).NewReplay()
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	// stepping backwards and forwards should produce valid summaries
+	for _, step := range []int{replay.Len(), 0, replay.Len() / 2} {
+		if _, err := SummarizeStep(replay, step); err != nil {
+			t.Error(errors.WithMessage(err, fmt.Sprintf("step %d", step)))
+		} else if replay.Step() != step {
+			t.Errorf("expected replay at step %d, got %d", step, replay.Step())
+		}
+	}
 }
 
 func (test *PlanningTest) checkPlateIDMap(t *testing.T) {
@@ -0,0 +1,210 @@
+package liquidhandling
+
+import (
+	"encoding/json"
+
+	"github.com/pkg/errors"
+
+	"github.com/antha-lang/antha/antha/anthalib/wtype"
+	driver "github.com/antha-lang/antha/microArch/driver/liquidhandling"
+	simulator "github.com/antha-lang/antha/microArch/simulator/liquidhandling"
+)
+
+// SimulationInput is the initial state of the liquid handler and the
+// instructions sent to it during a mix, in a form which can be serialized so
+// that the mix can be replayed in the simulator later
+type SimulationInput struct {
+	Properties   *driver.LHProperties
+	Instructions driver.SetOfRobotInstructions
+}
+
+// NewSimulationInput create the input to replay the given instructions from
+// the initial state props
+func NewSimulationInput(props *driver.LHProperties, instructions []driver.TerminalRobotInstruction) *SimulationInput {
+	ris := make([]driver.RobotInstruction, 0, len(instructions))
+	for _, ins := range instructions {
+		ris = append(ris, ins)
+	}
+	return &SimulationInput{
+		Properties:   props,
+		Instructions: driver.SetOfRobotInstructions{RobotInstructions: ris},
+	}
+}
+
+// NewReplay create a replay of the instructions starting from the initial
+// state, using the same simulator settings as planning. The labware added to the deck by setup instructions does not survive
+// serialization, so it is taken from the matching position in Properties.
+func (si *SimulationInput) NewReplay() (*simulator.Replay, error) {
+	if si.Properties == nil {
+		return nil, errors.New("cannot replay mix: no initial state")
+	}
+
+	setup := make(map[string]driver.TerminalRobotInstruction)
+	for _, ins := range si.Properties.GetSetupInstructions() {
+		if apt, ok := ins.(*driver.AddPlateToInstruction); ok {
+			setup[apt.Position] = apt
+		}
+	}
+
+	tris := make([]driver.TerminalRobotInstruction, 0, len(si.Instructions.RobotInstructions))
+	for i, ins := range si.Instructions.RobotInstructions {
+		if apt, ok := ins.(*driver.AddPlateToInstruction); ok {
+			if _, isObj := apt.Plate.(wtype.LHObject); !isObj {
+				if ins, ok = setup[apt.Position]; !ok {
+					return nil, errors.Errorf("cannot replay mix: instruction %d adds %q to position %s which is empty in the initial state", i, apt.Name, apt.Position)
+				}
+			}
+		}
+		tri, ok := ins.(driver.TerminalRobotInstruction)
+		if !ok {
+			return nil, errors.Errorf("cannot replay mix: instruction %d not terminal", i)
+		}
+		tris = append(tris, tri)
+	}
+
+	return simulator.NewReplay(si.Properties, newSimulatorSettings(), tris)
+}
+
+// SummarizeStep produce a description of the state of the robot after the
+// given number of instructions in the replay have been simulated, leaving the
+// replay at that step.
+// The deck layouts before and after the last simulated instruction are given
+// in the format of ./schemas/layout.schema.json, and the returned JSON
+// validates against that schema. In addition the summary contains the
+// instruction, any errors it raised and the position and tips of each
+// adaptor.
+func SummarizeStep(replay *simulator.Replay, step int) ([]byte, error) {
+	ss := &stepSummary{Step: step}
+
+	if step > 0 {
+		if err := replay.Seek(step - 1); err != nil {
+			return nil, err
+		}
+		before := newDeckSummaryFromSimulator(replay.Simulator())
+		if err := replay.Forward(); err != nil {
+			return nil, err
+		}
+		ss.Before = before
+		if bs, err := json.Marshal(replay.LastInstruction()); err != nil {
+			return nil, err
+		} else {
+			ss.Instruction = bs
+		}
+		for _, err := range replay.LastErrors() {
+			ss.Errors = append(ss.Errors, errorSummary{Severity: err.Severity().String(), Message: err.Error()})
+		}
+	} else if err := replay.Seek(0); err != nil {
+		return nil, err
+	}
+
+	ss.After = newDeckSummaryFromSimulator(replay.Simulator())
+	if ss.Before == nil {
+		ss.Before = ss.After
+	}
+	ss.Adaptors = newAdaptorSummaries(replay.Simulator().GetState())
+
+	// object IDs are preserved by the simulator
+	ss.IDMap = make(map[string]string)
+	for _, pos := range ss.After.Positions {
+		if pos.Item != nil {
+			ss.IDMap[pos.Item.ID] = pos.Item.ID
+		}
+	}
+
+	if bs, err := json.Marshal(ss); err != nil {
+		return nil, err
+	} else if err := validateJSON("layout.schema.json", bs); err != nil {
+		return nil, errors.WithMessage(err, "generated an invalid step summary")
+	} else {
+		return bs, nil
+	}
+}
+
+// stepSummary summarize the state of the robot during a replay
+type stepSummary struct {
+	Before      *deckSummary      `json:"before"`                // the layout before the last instruction
+	After       *deckSummary      `json:"after"`                 // the layout after the last instruction
+	IDMap       map[string]string `json:"new_ids"`               // maps from ids in "before" to ids in "after"
+	Step        int               `json:"step"`                  // number of instructions simulated
+	Instruction json.RawMessage   `json:"instruction,omitempty"` // the last instruction simulated
+	Errors      []errorSummary    `json:"errors,omitempty"`      // errors and warnings raised by the last instruction
+	Adaptors    []*adaptorSummary `json:"adaptors"`
+}
+
+func (ss *stepSummary) MarshalJSON() ([]byte, error) {
+	type StepSummaryAlias stepSummary
+	return json.Marshal(struct {
+		*StepSummaryAlias
+		Version string `json:"version"`
+	}{
+		StepSummaryAlias: (*StepSummaryAlias)(ss),
+		Version:          LayoutSummaryVersion,
+	})
+}
+
+// errorSummary summarize an error raised by the simulator
+type errorSummary struct {
+	Severity string `json:"severity"`
+	Message  string `json:"message"`
+}
+
+// adaptorSummary summarize the state of an adaptor
+type adaptorSummary struct {
+	Name     string            `json:"name"`
+	Position coordinates       `json:"position"`
+	Channels []*channelSummary `json:"channels"`
+}
+
+// channelSummary summarize the state of a single channel of an adaptor
+type channelSummary struct {
+	Position coordinates    `json:"position"`
+	Tip      string         `json:"tip,omitempty"`      // type of the loaded tip
+	Contents *liquidSummary `json:"contents,omitempty"` // contents of the loaded tip
+}
+
+func newAdaptorSummaries(state *simulator.RobotState) []*adaptorSummary {
+	adaptors := state.GetAdaptors()
+	ret := make([]*adaptorSummary, 0, len(adaptors))
+	for _, adaptor := range adaptors {
+		as := &adaptorSummary{
+			Name:     adaptor.GetName(),
+			Position: newCoordinates3D(adaptor.GetPosition()),
+			Channels: make([]*channelSummary, 0, adaptor.GetChannelCount()),
+		}
+		for i := 0; i < adaptor.GetChannelCount(); i++ {
+			ch := adaptor.GetChannel(i)
+			cs := &channelSummary{
+				Position: newCoordinates3D(ch.GetAbsolutePosition()),
+			}
+			if ch.HasTip() {
+				cs.Tip = ch.GetTip().Type
+				if c := ch.GetContents(); c != nil && !c.IsZero() {
+					cs.Contents = newLiquidSummary(c)
+				}
+			}
+			as.Channels = append(as.Channels, cs)
+		}
+		ret = append(ret, as)
+	}
+	return ret
+}
+
+// newDeckSummaryFromSimulator create the deck layout from the current state
+// of the simulator
+func newDeckSummaryFromSimulator(vlh *simulator.VirtualLiquidHandler) *deckSummary {
+	deck := vlh.GetState().GetDeck()
+	names := deck.GetSlotNames()
+	positions := make(map[string]*deckPosition, len(names))
+	for _, name := range names {
+		dp := &deckPosition{
+			Position: newCoordinates3D(deck.GetSlotPosition(name)),
+			Size:     newCoordinates2D(deck.GetSlotSize(name)),
+		}
+		switch obj := vlh.GetObjectAt(name).(type) {
+		case *wtype.Plate, *wtype.LHTipbox, *wtype.LHTipwaste:
+			dp.Item = newItemSummary(obj)
+		}
+		positions[name] = dp
+	}
+	return &deckSummary{Positions: positions}
+}
@@ -0,0 +1,302 @@
+package liquidhandling
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/antha-lang/antha/antha/anthalib/wtype"
+	"github.com/antha-lang/antha/antha/anthalib/wunit"
+)
+
+// Reservoir is a bulk component held in a single well of a trough or
+// reservoir, from which several channels may aspirate at once. If more is
+// drawn than the well holds, the user is prompted to refill it during the run.
+type Reservoir struct {
+	Component string         // the kind of component held
+	Platetype *wtype.Plate   // the type of reservoir chosen
+	Location  string         // plate ID and well, once the input plates are set up
+	Required  wunit.Volume   // the total volume drawn, including carry volumes
+	Refills   []wunit.Volume // the volume added at each refill prompt
+}
+
+// Capacity returns the volume which can be drawn from the reservoir between
+// refills, i.e. its maximum volume less its dead volume
+func (r *Reservoir) Capacity() wunit.Volume {
+	return r.Platetype.Welltype.MaxWorkingVolume()
+}
+
+func (r *Reservoir) String() string {
+	return fmt.Sprintf("%s in %s at %s: %s required, %d refills", r.Component, r.Platetype.Type, r.Location, r.Required, len(r.Refills))
+}
+
+// isReservoirFor returns true if the plate is a reservoir in which every
+// channel of each adaptor can reach the liquid at once
+func isReservoirFor(plate *wtype.Plate, adaptors []*wtype.LHAdaptor) bool {
+	if !plate.IsReservoir() {
+		return false
+	}
+	for _, adaptor := range adaptors {
+		if !plate.AreWellTargetsEnabled(adaptor.Params.Multi, adaptorSpacing) {
+			return false
+		}
+	}
+	return true
+}
+
+// chooseReservoirs assigns bulk components to reservoirs chosen from the
+// input plate types, if requested. A component is bulk if it must be
+// autoallocated in full and needs more than the largest well of the other
+// input plate types can hold. Each bulk component is held in a single
+// reservoir well, preferring the type with the least dead volume which holds
+// everything required, otherwise the type needing the fewest refills.
+func (rq *LHRequest) chooseReservoirs(adaptors []*wtype.LHAdaptor) {
+	rq.Reservoirs = nil
+
+	if !rq.Options.UseReservoirs || rq.InputSolutions == nil {
+		return
+	}
+
+	var reservoirs []*wtype.Plate
+	var wellMax wunit.Volume
+	for _, p := range rq.InputPlatetypes {
+		if isReservoirFor(p, adaptors) {
+			reservoirs = append(reservoirs, p)
+		} else if v := p.Welltype.MaxWorkingVolume(); wellMax.IsNil() || v.GreaterThan(wellMax) {
+			wellMax = v
+		}
+	}
+
+	if len(reservoirs) == 0 {
+		return
+	}
+
+	names := make([]string, 0, len(rq.InputSolutions.VolumesWanting))
+	for name := range rq.InputSolutions.VolumesWanting {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		vol := rq.InputSolutions.VolumesWanting[name]
+		if isInstance(name) || rq.InputSolutions.VolumesSupplied[name].IsPositive() {
+			continue
+		} else if !wellMax.IsNil() && !vol.GreaterThan(wellMax) {
+			continue
+		}
+
+		if rq.Reservoirs == nil {
+			rq.Reservoirs = make(map[string]*Reservoir)
+		}
+		rq.Reservoirs[name] = &Reservoir{
+			Component: name,
+			Platetype: chooseReservoirType(vol, reservoirs),
+			Required:  vol.Dup(),
+		}
+	}
+}
+
+// chooseReservoirType returns the reservoir type with the least dead volume
+// which can hold vol, or the one which holds the most if none can
+func chooseReservoirType(vol wunit.Volume, reservoirs []*wtype.Plate) *wtype.Plate {
+	fits := func(p *wtype.Plate) bool {
+		return !vol.GreaterThan(p.Welltype.MaxWorkingVolume())
+	}
+
+	better := func(a, b *wtype.Plate) bool {
+		if fa, fb := fits(a), fits(b); fa != fb {
+			return fa
+		} else if !fa {
+			if va, vb := a.Welltype.MaxWorkingVolume(), b.Welltype.MaxWorkingVolume(); !va.EqualTo(vb) {
+				return va.GreaterThan(vb)
+			}
+		}
+		if ra, rb := a.Welltype.ResidualVolume(), b.Welltype.ResidualVolume(); !ra.EqualTo(rb) {
+			return ra.LessThan(rb)
+		}
+		return a.Type < b.Type
+	}
+
+	var best *wtype.Plate
+	for _, p := range reservoirs {
+		if best == nil || better(p, best) {
+			best = p
+		}
+	}
+	return best
+}
+
+// reservoirDraws sums the volumes drawn from each reservoir by a mix,
+// counting the carry volume once per transfer as getInputs does
+func (rq *LHRequest) reservoirDraws(ins *wtype.LHInstruction, carryVolume wunit.Volume) map[string]wunit.Volume {
+	draws := make(map[string]wunit.Volume)
+	if ins.Type != wtype.LHIMIX {
+		return draws
+	}
+	for ix, cmp := range ins.Inputs {
+		if cmp.IsInstance() || ix == 0 && !cmp.IsSample() {
+			continue
+		}
+		if _, ok := rq.Reservoirs[cmp.Kind()]; !ok {
+			continue
+		}
+		v, ok := draws[cmp.Kind()]
+		if !ok {
+			v = wunit.NewVolume(0.0, "ul")
+			draws[cmp.Kind()] = v
+		}
+		v.Add(cmp.Volume())
+		v.Add(carryVolume)
+	}
+	return draws
+}
+
+// addRefillPrompts adds up the volume drawn from each reservoir by the mixes
+// in the order of the instruction chain, and inserts a prompt to refill the
+// reservoirs before any mix which would draw more than remains in them,
+// splitting links of the chain where necessary. Each refill tops the
+// reservoir up by the volume drawn since it was last filled.
+func (rq *LHRequest) addRefillPrompts(carryVolume wunit.Volume) error {
+	if len(rq.Reservoirs) == 0 {
+		return nil
+	}
+
+	// first find the mixes which need a refill beforehand
+	drawn := make(map[string]wunit.Volume, len(rq.Reservoirs))
+	prompts := make(map[*wtype.LHInstruction]*wtype.LHInstruction)
+	for _, ins := range rq.InstructionChain.GetOrderedLHInstructions() {
+		draws := rq.reservoirDraws(ins, carryVolume)
+
+		names := make([]string, 0, len(draws))
+		for name := range draws {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		var messages []string
+		var refills []wtype.WellRefill
+		for _, name := range names {
+			res := rq.Reservoirs[name]
+			draw := draws[name]
+			if draw.GreaterThan(res.Capacity()) {
+				return wtype.LHErrorf(wtype.LH_ERR_VOL, "cannot draw %s of %s from reservoir %s at once, it holds at most %s", draw, name, res.Location, res.Capacity())
+			}
+
+			sofar, ok := drawn[name]
+			if !ok {
+				sofar = wunit.ZeroVolume()
+			}
+
+			total := wunit.AddVolumes(sofar, draw)
+			if total.GreaterThan(res.Capacity()) {
+				res.Refills = append(res.Refills, sofar)
+				messages = append(messages, fmt.Sprintf("add %s of %s to %s", sofar, name, rq.describeLocation(res.Location)))
+				tx := strings.Split(res.Location, ":")
+				refills = append(refills, wtype.WellRefill{PlateID: tx[0], Well: tx[1], Volume: sofar})
+				total = draw.Dup()
+			}
+			drawn[name] = total
+		}
+
+		if len(refills) != 0 {
+			prompt := wtype.NewLHPromptInstruction()
+			prompt.Message = "Refill reservoirs: " + strings.Join(messages, ", ")
+			prompt.Refills = refills
+			prompts[ins] = prompt
+		}
+	}
+
+	// then insert the prompts, starting a new link at each mix which needs one
+	for link := rq.InstructionChain; link != nil; link = link.Child {
+		for i := 0; i < len(link.Values); i++ {
+			prompt, ok := prompts[link.Values[i]]
+			if !ok {
+				continue
+			}
+			if i != 0 {
+				link = splitLink(link, i)
+				i = 0
+			}
+
+			rq.LHInstructions[prompt.ID] = prompt
+			if prev := insertLinkBefore(link, prompt); prev.Parent == nil {
+				rq.InstructionChain = prev
+			}
+		}
+	}
+
+	depth := 0
+	for link := rq.InstructionChain; link != nil; link = link.Child {
+		link.Depth = depth
+		depth++
+	}
+
+	rq.OutputOrder = rq.InstructionChain.FlattenInstructionIDs()
+
+	return nil
+}
+
+// describeLocation returns a human readable description of a plate ID and
+// well location
+func (rq *LHRequest) describeLocation(loc string) string {
+	tx := strings.Split(loc, ":")
+	if len(tx) != 2 {
+		return loc
+	}
+	if p, ok := rq.InputPlates[tx[0]]; ok {
+		return fmt.Sprintf("well %s of plate %s", tx[1], p.PlateName)
+	}
+	return fmt.Sprintf("well %s of plate %s", tx[1], tx[0])
+}
+
+// refilledReservoir returns true if the well holds a reservoir which is
+// refilled during the run
+func (rq *LHRequest) refilledReservoir(well *wtype.LHWell) bool {
+	loc := wtype.IDOf(well.GetParent()) + ":" + well.Crds.FormatA1()
+	for _, res := range rq.Reservoirs {
+		if res.Location == loc {
+			return len(res.Refills) != 0
+		}
+	}
+	return false
+}
+
+// insertLinkBefore inserts a new link containing only ins before link,
+// returning the new link. Depths are not updated.
+func insertLinkBefore(link *wtype.IChain, ins *wtype.LHInstruction) *wtype.IChain {
+	prev := &wtype.IChain{
+		Parent: link.Parent,
+		Child:  link,
+		Values: []*wtype.LHInstruction{ins},
+	}
+	if link.Parent != nil {
+		link.Parent.Child = prev
+	}
+	link.Parent = prev
+	return prev
+}
+
+// splitLink moves the values of link from index i on into a new link
+// following it, returning the new link. Depths are not updated.
+func splitLink(link *wtype.IChain, i int) *wtype.IChain {
+	next := &wtype.IChain{
+		Parent: link,
+		Child:  link.Child,
+		Values: append([]*wtype.LHInstruction{}, link.Values[i:]...),
+	}
+	if link.Child != nil {
+		link.Child.Parent = next
+	}
+	link.Child = next
+	link.Values = link.Values[:i:i]
+	return next
+}
+
+func sortedReservoirNames(reservoirs map[string]*Reservoir) []string {
+	names := make([]string, 0, len(reservoirs))
+	for name := range reservoirs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,202 @@
+package liquidhandling
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/antha-lang/antha/antha/anthalib/mixer"
+	"github.com/antha-lang/antha/antha/anthalib/wtype"
+	"github.com/antha-lang/antha/antha/anthalib/wunit"
+	"github.com/antha-lang/antha/inventory"
+	"github.com/antha-lang/antha/inventory/testinventory"
+	"github.com/antha-lang/antha/microArch/driver/liquidhandling"
+	"github.com/antha-lang/antha/microArch/simulator"
+	simulator_lh "github.com/antha-lang/antha/microArch/simulator/liquidhandling"
+)
+
+func TestChooseReservoirType(t *testing.T) {
+	ctx := testinventory.NewContext(context.Background())
+
+	var reservoirs []*wtype.Plate
+	for _, ptype := range []string{"reservoir", "DWST12", "SWST12"} {
+		p, err := inventory.NewPlate(ctx, ptype)
+		if err != nil {
+			t.Fatal(err)
+		}
+		reservoirs = append(reservoirs, p)
+	}
+
+	for vol, expected := range map[float64]string{
+		2000.0:   "SWST12",    // fits in everything, least dead volume
+		8000.0:   "DWST12",    // too much for the shallow trough
+		500000.0: "reservoir", // too much for anything, holds the most
+	} {
+		if got := chooseReservoirType(wunit.NewVolume(vol, "ul"), reservoirs); got.Type != expected {
+			t.Errorf("%f ul: expected %s, got %s", vol, expected, got.Type)
+		}
+	}
+}
+
+// makeReservoirRequest returns a request which draws more water than fits in
+// a single well of a shallow trough
+func makeReservoirRequest(ctx context.Context, t *testing.T, useReservoirs bool) *LHRequest {
+	rq := GetLHRequestForTest()
+
+	trough, err := inventory.NewPlate(ctx, "SWST12")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 40 transfers of 150 ul need more than the 4000 ul the trough holds
+	water := GetComponentForTest(ctx, "water", wunit.NewVolume(10000.0, "ul"))
+	for k := 0; k < 40; k++ {
+		ins := wtype.NewLHMixInstruction()
+		ins.AddInput(mixer.Sample(water, wunit.NewVolume(150.0, "ul")))
+		ins.AddOutput(GetComponentForTest(ctx, "water", wunit.NewVolume(150.0, "ul")))
+		rq.Add_instruction(ins)
+	}
+
+	rq.InputPlatetypes = append(rq.InputPlatetypes, GetPlateForTest(), trough)
+	rq.OutputPlatetypes = append(rq.OutputPlatetypes, GetPlateForTest())
+	rq.Options.UseReservoirs = useReservoirs
+
+	return rq
+}
+
+func planWithReservoirs(t *testing.T, useReservoirs bool) (*Liquidhandler, *LHRequest) {
+	ctx := GetContextForTest()
+	lh := GetLiquidHandlerForTest(ctx)
+	rq := makeReservoirRequest(ctx, t, useReservoirs)
+
+	if err := lh.Plan(ctx, rq); err != nil {
+		t.Fatal(err)
+	}
+
+	return lh, rq
+}
+
+func TestPlanWithReservoirs(t *testing.T) {
+	lh, rq := planWithReservoirs(t, true)
+
+	res, ok := rq.Reservoirs["water"]
+	if !ok {
+		t.Fatalf("expected water to be held in a reservoir, got %v", rq.Reservoirs)
+	}
+	if res.Platetype.Type != "SWST12" {
+		t.Errorf("expected water in SWST12, got %s", res.Platetype.Type)
+	}
+	if len(res.Refills) == 0 {
+		t.Fatalf("expected reservoir to be refilled: %s", res)
+	}
+	if summary := rq.PlanSummary(); len(summary) != 1 || summary[0] != "Reservoir: "+res.String() {
+		t.Errorf("expected reservoir in plan summary, got %q", summary)
+	}
+
+	// the water should be in a single well of the trough
+	if locs := rq.InputAssignments["water"]; len(locs) != 1 || locs[0] != res.Location {
+		t.Errorf("expected water only at %s, got %v", res.Location, locs)
+	}
+
+	tx := strings.Split(res.Location, ":")
+	pos, ok := lh.Properties.PlateIDLookup[tx[0]]
+	if !ok {
+		t.Fatalf("reservoir plate %s not on deck", tx[0])
+	}
+	well := lh.Properties.Plates[pos].Wellcoords[tx[1]]
+	if !well.CurrentVolume().EqualTo(well.MaxVolume()) {
+		t.Errorf("expected refilled reservoir to start full, got %s", well.CurrentVolume())
+	}
+
+	// one prompt per refill, each alone in its link
+	var prompts int
+	for link := rq.InstructionChain; link != nil; link = link.Child {
+		for _, ins := range link.Values {
+			if ins.Type == wtype.LHIPRM {
+				prompts++
+				if len(link.Values) != 1 {
+					t.Errorf("expected prompt to be alone in its link, got %d instructions", len(link.Values))
+				}
+			}
+		}
+	}
+	if prompts != len(res.Refills) {
+		t.Errorf("expected %d refill prompts, got %d", len(res.Refills), prompts)
+	}
+
+	var messages int
+	for _, ins := range rq.Instructions {
+		ins.Visit(liquidhandling.RobotInstructionBaseVisitor{
+			HandleMessage: func(msg *liquidhandling.MessageInstruction) {
+				if strings.HasPrefix(msg.Message, "Refill reservoirs") {
+					messages++
+				}
+			},
+		})
+	}
+	if messages != len(res.Refills) {
+		t.Errorf("expected %d refill messages, got %d", len(res.Refills), messages)
+	}
+}
+
+func TestPlanWithoutReservoirs(t *testing.T) {
+	_, rq := planWithReservoirs(t, false)
+
+	if len(rq.Reservoirs) != 0 {
+		t.Errorf("didn't expect reservoirs to be used, got %v", rq.Reservoirs)
+	}
+}
+
+// makeSingleHeadGilson returns the test Gilson with only the high volume head,
+// since the physical simulation detects collisions between tips loaded by one
+// head and the other
+func makeSingleHeadGilson(ctx context.Context) *liquidhandling.LHProperties {
+	lhp := makeGilson(ctx)
+
+	ha := wtype.NewLHHeadAssembly(nil)
+	ha.AddPosition(wtype.Coordinates3D{})
+	if err := ha.LoadHead(lhp.Heads[0]); err != nil {
+		panic(err)
+	}
+
+	lhp.Heads = lhp.Heads[:1]
+	lhp.Adaptors = lhp.Adaptors[:1]
+	lhp.HeadAssemblies = []*wtype.LHHeadAssembly{ha}
+
+	return lhp
+}
+
+func TestMakeSolutionsWithReservoirs(t *testing.T) {
+	ctx := GetContextForTest()
+	props := makeSingleHeadGilson(ctx)
+
+	// a second simulator stands in for the robot when executing
+	vlh, err := simulator_lh.NewVirtualLiquidHandler(props.Dup(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	props.Driver = vlh
+
+	lh := Init(props)
+	rq := makeReservoirRequest(ctx, t, true)
+
+	// the physical simulation must see the refills
+	if err := lh.MakeSolutions(ctx, rq); err != nil {
+		t.Fatal(err)
+	}
+	var errs int
+	for _, err := range vlh.GetErrors() {
+		if err.Severity() >= simulator.SeverityError {
+			errs++
+		}
+	}
+	if errs != 0 {
+		t.Errorf("expected no errors executing, got %d", errs)
+	}
+
+	if res, ok := rq.Reservoirs["water"]; !ok {
+		t.Fatal("expected water to be held in a reservoir")
+	} else if len(res.Refills) == 0 {
+		t.Fatalf("expected reservoir to be refilled: %s", res)
+	}
+}
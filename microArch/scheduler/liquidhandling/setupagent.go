@@ -256,6 +256,8 @@ func BasicSetupAgent(ctx context.Context, request *LHRequest, params *liquidhand
 				waste, err = inventory.NewTipwaste(ctx, "Manualtipwaste")
 			case "Evo":
 				waste, err = inventory.NewTipwaste(ctx, "Tecantipwaste")
+			case "OT-2":
+				waste, err = inventory.NewTipwaste(ctx, "OpentronsFixedTrash")
 			default:
 				return wtype.LHError(wtype.LH_ERR_OTHER, fmt.Sprintf("tip waste not handled for type: %s", params.Model))
 			}
@@ -0,0 +1,168 @@
+package liquidhandling
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/antha-lang/antha/antha/anthalib/wtype"
+	driver "github.com/antha-lang/antha/microArch/driver/liquidhandling"
+)
+
+// TransferOrderReport compares the tips used and estimated time taken by
+// the instructions generated for the mixes in their original order with
+// those after reordering
+type TransferOrderReport struct {
+	TipsBefore int
+	TipsAfter  int
+	TimeBefore time.Duration
+	TimeAfter  time.Duration
+}
+
+// TipsSaved returns the number of tips saved by reordering
+func (tor TransferOrderReport) TipsSaved() int {
+	return tor.TipsBefore - tor.TipsAfter
+}
+
+// TimeSaved returns the estimated time saved by reordering
+func (tor TransferOrderReport) TimeSaved() time.Duration {
+	return tor.TimeBefore - tor.TimeAfter
+}
+
+func (tor TransferOrderReport) String() string {
+	return fmt.Sprintf("transfer reordering saved %d tips (%d before, %d after) and an estimated %s (%s before, %s after)",
+		tor.TipsSaved(), tor.TipsBefore, tor.TipsAfter, tor.TimeSaved(), tor.TimeBefore, tor.TimeAfter)
+}
+
+// buildInstructionTree generates the robot instructions for the request's
+// instruction chain, returning the tree and the final state of the robot
+func (this *Liquidhandler) buildInstructionTree(ctx context.Context, request *LHRequest) (*driver.ITree, *driver.LHProperties, error) {
+	if root, err := driver.NewITreeRoot(request.InstructionChain); err != nil {
+		return nil, nil, err
+	} else if final, err := root.Build(ctx, request.Policies(), this.Properties); err != nil {
+		return nil, nil, err
+	} else {
+		return root, final, nil
+	}
+}
+
+// reorderTransfers groups the mixes within each link of the instruction
+// chain such that those moving the same components with the same policy
+// are made together and in column order on each destination plate, allowing
+// tips to be reused and channels to be used together. Since the effect of
+// reordering depends on the policies and the robot, the instructions are
+// regenerated and the new order kept only if it uses fewer tips, or as many
+// tips in less time, than the instructions already generated in root.
+// The comparison is recorded in request.TransferOrder.
+func (this *Liquidhandler) reorderTransfers(ctx context.Context, request *LHRequest, root *driver.ITree, final *driver.LHProperties) (*driver.ITree, *driver.LHProperties, error) {
+	timer := this.Properties.GetTimer()
+
+	report := &TransferOrderReport{}
+	report.TipsBefore, report.TimeBefore = instructionCosts(root.Leaves(), timer)
+	report.TipsAfter, report.TimeAfter = report.TipsBefore, report.TimeBefore
+	request.TransferOrder = report
+
+	original := make(map[*wtype.IChain][]*wtype.LHInstruction)
+	for link := request.InstructionChain; link != nil; link = link.Child {
+		if reordered, changed := groupTransfers(link.Values); changed {
+			original[link] = link.Values
+			link.Values = reordered
+		}
+	}
+
+	if len(original) == 0 {
+		return root, final, nil
+	}
+
+	restore := func() {
+		for link, values := range original {
+			link.Values = values
+		}
+	}
+
+	newRoot, newFinal, err := this.buildInstructionTree(ctx, request)
+	if err != nil {
+		restore()
+		return nil, nil, err
+	}
+
+	tips, dur := instructionCosts(newRoot.Leaves(), timer)
+	if tips > report.TipsBefore || tips == report.TipsBefore && dur >= report.TimeBefore {
+		restore()
+		return root, final, nil
+	}
+
+	report.TipsAfter, report.TimeAfter = tips, dur
+	request.OutputOrder = request.InstructionChain.FlattenInstructionIDs()
+
+	return newRoot, newFinal, nil
+}
+
+// instructionCosts counts the tips loaded by the instructions and estimates
+// the time they take
+func instructionCosts(leaves []driver.TerminalRobotInstruction, timer driver.LHTimer) (int, time.Duration) {
+	var tips int
+	var dur time.Duration
+	for _, ins := range leaves {
+		ins.Visit(driver.RobotInstructionBaseVisitor{
+			HandleLoadTips: func(ins *driver.LoadTipsInstruction) {
+				for _, pos := range ins.Pos {
+					if pos != "" {
+						tips++
+					}
+				}
+			},
+		})
+		dur += timer.TimeFor(ins)
+	}
+	return tips, dur
+}
+
+// groupTransfers returns the mixes ordered into groups which move the same
+// components of the same liquid types, in order of each group's first
+// appearance. Within each group mixes are ordered by destination plate and
+// down the columns of each plate. Links containing anything other than
+// mixes are left unchanged. Returns true if the order was changed.
+func groupTransfers(values []*wtype.LHInstruction) ([]*wtype.LHInstruction, bool) {
+	var keys []string
+	groups := make(map[string][]*wtype.LHInstruction)
+
+	for _, ins := range values {
+		if ins.Type != wtype.LHIMIX {
+			return values, false
+		}
+
+		moving := ins.ComponentsMoving()
+		parts := make([]string, 0, len(moving))
+		for _, cmp := range moving {
+			parts = append(parts, cmp.CName+"|"+cmp.TypeName())
+		}
+		key := strings.Join(parts, ";")
+
+		if _, ok := groups[key]; !ok {
+			keys = append(keys, key)
+		}
+		groups[key] = append(groups[key], ins)
+	}
+
+	ret := make([]*wtype.LHInstruction, 0, len(values))
+	for _, key := range keys {
+		group := groups[key]
+		sort.SliceStable(group, func(i, j int) bool {
+			if c := strings.Compare(group[i].PlateName, group[j].PlateName); c != 0 {
+				return c < 0
+			}
+			return wtype.CompareStringWellCoordsCol(group[i].Welladdress, group[j].Welladdress) < 0
+		})
+		ret = append(ret, group...)
+	}
+
+	for i := range values {
+		if values[i] != ret[i] {
+			return ret, true
+		}
+	}
+	return values, false
+}
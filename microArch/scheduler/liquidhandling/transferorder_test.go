@@ -0,0 +1,135 @@
+package liquidhandling
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/antha-lang/antha/antha/anthalib/mixer"
+	"github.com/antha-lang/antha/antha/anthalib/wtype"
+	"github.com/antha-lang/antha/antha/anthalib/wunit"
+)
+
+func makeGroupTestMix(name, plate, well string) *wtype.LHInstruction {
+	ins := wtype.NewLHMixInstruction()
+	smp := getComponentWithNameVolume(name, 10.0)
+	smp.SetSample(true)
+	ins.AddInput(smp)
+	ins.PlateName = plate
+	ins.Welladdress = well
+	return ins
+}
+
+func TestGroupTransfers(t *testing.T) {
+	values := []*wtype.LHInstruction{
+		makeGroupTestMix("water", "out", "B1"),
+		makeGroupTestMix("dna", "out", "A2"),
+		makeGroupTestMix("water", "out", "A1"),
+		makeGroupTestMix("dna", "out", "A1"),
+		makeGroupTestMix("water", "another", "C1"),
+	}
+
+	expected := []*wtype.LHInstruction{values[4], values[2], values[0], values[3], values[1]}
+
+	got, changed := groupTransfers(values)
+	if !changed {
+		t.Fatal("expected order to change")
+	}
+	for i := range expected {
+		if got[i] != expected[i] {
+			t.Errorf("%d: expected %s %s:%s, got %s %s:%s", i,
+				expected[i].Inputs[0].CName, expected[i].PlateName, expected[i].Welladdress,
+				got[i].Inputs[0].CName, got[i].PlateName, got[i].Welladdress)
+		}
+	}
+
+	if _, changed := groupTransfers(got); changed {
+		t.Error("expected grouping to be idempotent")
+	}
+
+	// links with anything other than mixes are left alone
+	prompt := wtype.NewLHPromptInstruction()
+	withPrompt := append([]*wtype.LHInstruction{prompt}, values...)
+	if got, changed := groupTransfers(withPrompt); changed || got[0] != prompt {
+		t.Error("expected links containing prompts to be unchanged")
+	}
+}
+
+// configureInterleavedRequest adds mixes alternating between two
+// components with the same policy
+func configureInterleavedRequest(ctx context.Context, rq *LHRequest, n int) {
+	water := GetComponentForTest(ctx, "water", wunit.NewVolume(5000.0, "ul"))
+	buffer := GetComponentForTest(ctx, "water", wunit.NewVolume(5000.0, "ul"))
+	buffer.CName = "buffer"
+
+	for k := 0; k < n; k++ {
+		src := water
+		if k%2 == 1 {
+			src = buffer
+		}
+		ins := wtype.NewLHMixInstruction()
+		ins.AddInput(mixer.Sample(src, wunit.NewVolume(10.0, "ul")))
+		out := GetComponentForTest(ctx, "water", wunit.NewVolume(10.0, "ul"))
+		out.CName = src.CName
+		ins.AddOutput(out)
+		rq.Add_instruction(ins)
+	}
+}
+
+func planInterleaved(t *testing.T, reorder bool) *LHRequest {
+	ctx := GetContextForTest()
+	lh := GetLiquidHandlerForTest(ctx)
+	rq := GetLHRequestForTest()
+	configureInterleavedRequest(ctx, rq, 12)
+	rq.InputPlatetypes = append(rq.InputPlatetypes, GetPlateForTest())
+	rq.OutputPlatetypes = append(rq.OutputPlatetypes, GetPlateForTest())
+	rq.Options.OptimizeTransferOrder = reorder
+
+	if err := lh.Plan(ctx, rq); err != nil {
+		t.Fatal(err)
+	}
+	return rq
+}
+
+func TestReorderTransfers(t *testing.T) {
+	rq := planInterleaved(t, true)
+
+	report := rq.TransferOrder
+	if report == nil {
+		t.Fatal("expected a transfer order report")
+	}
+	// a new order is only kept if it is no worse
+	if report.TipsSaved() < 0 {
+		t.Errorf("expected reordering not to use more tips: %s", report)
+	}
+	if report.TipsSaved() == 0 && report.TimeSaved() < 0 {
+		t.Errorf("expected reordering not to take longer: %s", report)
+	}
+	if summary := rq.PlanSummary(); len(summary) != 1 || !strings.HasPrefix(summary[0], "Transfer order: ") {
+		t.Errorf("expected transfer order in plan summary, got %q", summary)
+	}
+
+	var tips int
+	for _, te := range rq.TipsUsed {
+		tips += te.NTips
+	}
+	if tips != report.TipsAfter {
+		t.Errorf("expected %d tips used after reordering, got %d", report.TipsAfter, tips)
+	}
+
+	// the output order must follow the instruction chain
+	ids := rq.InstructionChain.FlattenInstructionIDs()
+	for i, id := range rq.OutputOrder {
+		if ids[i] != id {
+			t.Fatalf("output order differs from instruction chain at %d", i)
+		}
+	}
+}
+
+func TestReorderTransfersDisabled(t *testing.T) {
+	rq := planInterleaved(t, false)
+
+	if rq.TransferOrder != nil {
+		t.Errorf("didn't expect transfers to be reordered: %s", rq.TransferOrder)
+	}
+}
@@ -0,0 +1,129 @@
+package liquidhandling
+
+import (
+	"github.com/pkg/errors"
+
+	"github.com/antha-lang/antha/microArch/driver/liquidhandling"
+	"github.com/antha-lang/antha/microArch/simulator"
+)
+
+// Replay steps a simulated liquid handler forwards and backwards through a
+// list of instructions, so that the state of the robot can be inspected after
+// any instruction. The simulator state cannot be copied, so stepping
+// backwards replays the instructions from the initial state.
+type Replay struct {
+	props        *liquidhandling.LHProperties
+	settings     SimulatorSettings
+	instructions []liquidhandling.TerminalRobotInstruction
+	vlh          *VirtualLiquidHandler
+}
+
+// NewReplay returns a replay of the given instructions which starts before
+// the first instruction. props is the initial state of the liquid handler and
+// is not modified, settings may be nil for the default settings.
+func NewReplay(props *liquidhandling.LHProperties, settings *SimulatorSettings, instructions []liquidhandling.TerminalRobotInstruction) (*Replay, error) {
+	if settings == nil {
+		settings = DefaultSimulatorSettings()
+	}
+	r := &Replay{
+		props:        props,
+		settings:     *settings,
+		instructions: instructions,
+	}
+	if err := r.reset(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// reset returns the replay to before the first instruction
+func (r *Replay) reset() error {
+	// settings are modified during simulation, e.g. by WarnOnce
+	settings := r.settings
+	vlh, err := NewVirtualLiquidHandler(r.props.DupKeepIDs(), &settings)
+	if err != nil {
+		return err
+	}
+	vlh.resetState()
+	r.vlh = vlh
+	return nil
+}
+
+// Len returns the number of instructions in the replay
+func (r *Replay) Len() int {
+	return len(r.instructions)
+}
+
+// Step returns the number of instructions which have been simulated
+func (r *Replay) Step() int {
+	return len(r.vlh.instructionHistory)
+}
+
+// Forward simulates the next instruction
+func (r *Replay) Forward() error {
+	step := r.Step()
+	if step >= r.Len() {
+		return errors.Errorf("cannot step forward: all %d instructions have been simulated", r.Len())
+	}
+	return errors.Wrapf(r.vlh.simulateInstruction(r.instructions[step]), "instruction %d", step)
+}
+
+// Back returns to the state before the last simulated instruction
+func (r *Replay) Back() error {
+	if r.Step() == 0 {
+		return errors.New("cannot step back: no instructions have been simulated")
+	}
+	return r.Seek(r.Step() - 1)
+}
+
+// Seek simulates exactly the first step instructions
+func (r *Replay) Seek(step int) error {
+	if step < 0 || step > r.Len() {
+		return errors.Errorf("cannot seek to step %d: steps must be between 0 and %d", step, r.Len())
+	}
+	if step < r.Step() {
+		if err := r.reset(); err != nil {
+			return err
+		}
+	}
+	for r.Step() < step {
+		if err := r.Forward(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Instruction returns the i'th instruction of the replay
+func (r *Replay) Instruction(i int) liquidhandling.TerminalRobotInstruction {
+	return r.instructions[i]
+}
+
+// LastInstruction returns the last simulated instruction, or nil if no
+// instructions have been simulated
+func (r *Replay) LastInstruction() liquidhandling.TerminalRobotInstruction {
+	if r.Step() == 0 {
+		return nil
+	}
+	return r.instructions[r.Step()-1]
+}
+
+// LastErrors returns the errors and warnings raised by the last simulated
+// instruction
+func (r *Replay) LastErrors() []simulator.SimulationError {
+	if r.Step() == 0 {
+		return nil
+	}
+	errs := r.vlh.errorHistory[r.Step()-1]
+	ret := make([]simulator.SimulationError, 0, len(errs))
+	for _, err := range errs {
+		ret = append(ret, err)
+	}
+	return ret
+}
+
+// Simulator returns the simulated liquid handler in its current state. The
+// simulator should not be modified, and is replaced when stepping backwards.
+func (r *Replay) Simulator() *VirtualLiquidHandler {
+	return r.vlh
+}
@@ -0,0 +1,104 @@
+package liquidhandling
+
+import (
+	"testing"
+
+	"github.com/antha-lang/antha/microArch/driver/liquidhandling"
+)
+
+func replayInstructions() []liquidhandling.TerminalRobotInstruction {
+	column := func(col string) []string {
+		return []string{"A" + col, "B" + col, "C" + col, "D" + col, "E" + col, "F" + col, "G" + col, "H" + col}
+	}
+	eight := func(s string) []string {
+		return []string{s, s, s, s, s, s, s, s}
+	}
+	move := &Move{
+		deckposition: eight("tipbox_1"),
+		wellcoords:   column("1"),
+		reference:    []int{1, 1, 1, 1, 1, 1, 1, 1},
+		offsetX:      []float64{0., 0., 0., 0., 0., 0., 0., 0.},
+		offsetY:      []float64{0., 0., 0., 0., 0., 0., 0., 0.},
+		offsetZ:      []float64{5., 5., 5., 5., 5., 5., 5., 5.},
+		plate_type:   eight("tipbox"),
+		head:         0,
+	}
+	load := &LoadTips{
+		channels:  []int{0, 1, 2, 3, 4, 5, 6, 7},
+		head:      0,
+		multi:     8,
+		platetype: eight("tipbox"),
+		position:  eight("tipbox_1"),
+		well:      column("1"),
+	}
+
+	return []liquidhandling.TerminalRobotInstruction{
+		(&Initialize{}).Convert(),
+		(&AddPlateTo{"tipbox_1", defaultLHTipbox("tipbox1"), "tipbox1"}).Convert(),
+		move.Convert(),
+		load.Convert(),
+	}
+}
+
+func TestReplay(t *testing.T) {
+	instructions := replayInstructions()
+	replay, err := NewReplay(defaultLHProperties(), nil, instructions)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert := func(step int, assertions ...*AssertionFn) {
+		if g := replay.Step(); g != step {
+			t.Fatalf("expected replay at step %d, got %d", step, g)
+		}
+		for _, a := range assertions {
+			(*a)(t, replay.Simulator())
+		}
+	}
+
+	assert(0)
+	if replay.LastInstruction() != nil {
+		t.Error("expected no instruction before the first step")
+	}
+	if err := replay.Back(); err == nil {
+		t.Error("expected error stepping back from the first step")
+	}
+
+	if err := replay.Seek(replay.Len()); err != nil {
+		t.Fatal(err)
+	}
+	assert(len(instructions), tipboxAssertion("tipbox_1", []string{"A1", "B1", "C1", "D1", "E1", "F1", "G1", "H1"}), adaptorAssertion(0, []tipDesc{
+		{0, "", 0}, {1, "", 0}, {2, "", 0}, {3, "", 0}, {4, "", 0}, {5, "", 0}, {6, "", 0}, {7, "", 0},
+	}))
+	if replay.LastInstruction() != instructions[3] {
+		t.Errorf("expected last instruction %v, got %v", instructions[3], replay.LastInstruction())
+	}
+	if errs := replay.LastErrors(); len(errs) != 0 {
+		t.Errorf("unexpected errors loading tips: %v", errs)
+	}
+	if err := replay.Forward(); err == nil {
+		t.Error("expected error stepping forward from the last step")
+	}
+
+	// stepping back replays the earlier instructions into a new simulator
+	if err := replay.Back(); err != nil {
+		t.Fatal(err)
+	}
+	assert(len(instructions)-1, tipboxAssertion("tipbox_1", []string{}), adaptorAssertion(0, []tipDesc{}))
+
+	if err := replay.Forward(); err != nil {
+		t.Fatal(err)
+	}
+	assert(len(instructions), tipboxAssertion("tipbox_1", []string{"A1", "B1", "C1", "D1", "E1", "F1", "G1", "H1"}))
+
+	if err := replay.Seek(-1); err == nil {
+		t.Error("expected error seeking before the first step")
+	}
+	if err := replay.Seek(0); err != nil {
+		t.Fatal(err)
+	}
+	assert(0)
+	if obj := replay.Simulator().GetObjectAt("tipbox_1"); obj != nil {
+		t.Errorf("expected empty deck at step 0, found %v", obj)
+	}
+}
@@ -44,6 +44,7 @@ type ChannelState struct {
 	position wtype.Coordinates3D //position relative to the adaptor
 	adaptor  *AdaptorState       //the channel's adaptor
 	radius   float64
+	speed    float64 //pipette speed in ml/min, zero if not set
 }
 
 func NewChannelState(number int, adaptor *AdaptorState, position wtype.Coordinates3D, radius float64) *ChannelState {
@@ -112,6 +113,16 @@ func (self *ChannelState) GetAbsolutePosition() wtype.Coordinates3D {
 	return self.position.Add(self.adaptor.GetPosition())
 }
 
+//GetPipetteSpeed get the pipette speed in ml/min, or zero if it hasn't been set
+func (self *ChannelState) GetPipetteSpeed() float64 {
+	return self.speed
+}
+
+//SetPipetteSpeed set the pipette speed in ml/min
+func (self *ChannelState) SetPipetteSpeed(rate float64) {
+	self.speed = rate
+}
+
 //GetTarget get the LHObject below the adaptor
 func (self *ChannelState) GetTarget() wtype.LHObject {
 	return self.adaptor.GetGroup().GetRobot().GetDeck().GetChildBelow(self.GetAbsolutePosition())
@@ -516,6 +527,7 @@ type AdaptorGroup struct {
 	velocityRange *wtype.VelocityRange
 	position      wtype.Coordinates3D
 	robot         *RobotState
+	independentZ  bool
 }
 
 // NewAdaptorGroup convert a HeadAssembly into an AdaptorGroup for simulation
@@ -532,6 +544,7 @@ func NewAdaptorGroup(assembly *wtype.LHHeadAssembly) *AdaptorGroup {
 		motionLimits:  assembly.MotionLimits.Dup(),
 		velocity:      &wunit.Velocity3D{},
 		velocityRange: assembly.VelocityLimits.Dup(),
+		independentZ:  assembly.IndependentZ,
 	}
 
 	for i, pos := range assembly.Positions {
@@ -567,6 +580,12 @@ func (self *AdaptorGroup) GetAdaptors() []*AdaptorState {
 	return self.adaptors
 }
 
+// IsIndependentZ returns whether adaptors are raised and lowered
+// independently, so that only the adaptor in use can collide
+func (self *AdaptorGroup) IsIndependentZ() bool {
+	return self.independentZ
+}
+
 //CountAdaptors count the adaptors
 func (self *AdaptorGroup) NumAdaptors() int {
 	return len(self.adaptors)
@@ -31,13 +31,14 @@ const (
 )
 
 type SimulatorSettings struct {
-	enable_tipbox_collision bool      //Whether or not to complain if the head hits a tipbox
-	enable_tipbox_check     bool      //detect tipboxes which are taller that the tips, and disable tipbox_collisions
-	enable_tipload_override bool      //allow the adaptor to override the tip loading behaviour
-	warn_auto_channels      Frequency //Display warnings for load/unload tips
-	max_dispense_height     float64   //maximum height to dispense from in mm
-	warnPipetteSpeed        Frequency //Raise warnings for pipette speed out of range
-	warnLiquidType          Frequency //raise warnings when liquid types don't match
+	enable_tipbox_collision bool         //Whether or not to complain if the head hits a tipbox
+	enable_tipbox_check     bool         //detect tipboxes which are taller that the tips, and disable tipbox_collisions
+	enable_tipload_override bool         //allow the adaptor to override the tip loading behaviour
+	warn_auto_channels      Frequency    //Display warnings for load/unload tips
+	max_dispense_height     float64      //maximum height to dispense from in mm
+	warnPipetteSpeed        Frequency    //Raise warnings for pipette speed out of range
+	warnLiquidType          Frequency    //raise warnings when liquid types don't match
+	timingModel             *TimingModel //physical parameters used to estimate execution time
 }
 
 func DefaultSimulatorSettings() *SimulatorSettings {
@@ -49,6 +50,7 @@ func DefaultSimulatorSettings() *SimulatorSettings {
 		max_dispense_height:     5.,
 		warnPipetteSpeed:        WarnAlways,
 		warnLiquidType:          WarnNever,
+		timingModel:             DefaultTimingModel(),
 	}
 	return &ss
 }
@@ -128,3 +130,11 @@ func (self *SimulatorSettings) IsLiquidTypeWarningEnabled() bool {
 func (self *SimulatorSettings) EnableLiquidTypeWarning(f Frequency) {
 	self.warnLiquidType = f
 }
+
+func (self *SimulatorSettings) GetTimingModel() *TimingModel {
+	return self.timingModel
+}
+
+func (self *SimulatorSettings) SetTimingModel(m *TimingModel) {
+	self.timingModel = m
+}
@@ -27,6 +27,7 @@ import (
 	"math"
 	"sort"
 	"strings"
+	"time"
 
 	"github.com/pkg/errors"
 
@@ -51,6 +52,8 @@ type VirtualLiquidHandler struct {
 	lastTarget         wtype.LHObject
 	properties         *liquidhandling.LHProperties
 	objectByID         map[string]wtype.LHObject // map from object ID to the object used internally
+	timeline           []liquidhandling.TimedInstruction
+	elapsed            time.Duration // estimated duration of the instruction being simulated
 }
 
 //coneRadius hardcoded radius to assume for cones
@@ -115,14 +118,21 @@ func (self *VirtualLiquidHandler) Simulate(instructions []liquidhandling.Termina
 	self.resetState()
 
 	for _, ins := range instructions {
-		err := ins.(liquidhandling.TerminalRobotInstruction).OutputTo(self)
-		if err != nil {
-			return errors.Wrap(err, "while writing instructions to virtual device")
+		if err := self.simulateInstruction(ins); err != nil {
+			return err
 		}
+	}
+
+	return nil
+}
 
-		self.saveState(ins)
+//simulateInstruction simulate a single instruction, adding it to the history
+func (self *VirtualLiquidHandler) simulateInstruction(ins liquidhandling.TerminalRobotInstruction) error {
+	if err := ins.OutputTo(self); err != nil {
+		return errors.Wrap(err, "while writing instructions to virtual device")
 	}
 
+	self.saveState(ins)
 	return nil
 }
 
@@ -133,6 +143,11 @@ func (self *VirtualLiquidHandler) getState() *RobotState {
 	return self.state
 }
 
+//GetState get the current state of the simulated robot
+func (self *VirtualLiquidHandler) GetState() *RobotState {
+	return self.getState()
+}
+
 func (self *VirtualLiquidHandler) GetLastMove() string {
 	if self == nil {
 		return ""
@@ -198,6 +213,8 @@ func (self *VirtualLiquidHandler) resetState() {
 	self.errorHistory = make([][]LiquidhandlingError, 0)
 	self.instructionHistory = make([]liquidhandling.TerminalRobotInstruction, 0)
 	self.errors = make([]LiquidhandlingError, 0)
+	self.timeline = make([]liquidhandling.TimedInstruction, 0)
+	self.elapsed = 0
 }
 
 func (self *VirtualLiquidHandler) popLastState() (liquidhandling.TerminalRobotInstruction, []LiquidhandlingError) {
@@ -209,6 +226,9 @@ func (self *VirtualLiquidHandler) popLastState() (liquidhandling.TerminalRobotIn
 	ins := self.instructionHistory[len(self.instructionHistory)-1]
 	self.errorHistory = self.errorHistory[:len(self.errorHistory)-1]
 	self.instructionHistory = self.instructionHistory[:len(self.instructionHistory)-1]
+	if len(self.timeline) > 0 {
+		self.timeline = self.timeline[:len(self.timeline)-1]
+	}
 
 	return ins, err
 }
@@ -222,6 +242,12 @@ func (self *VirtualLiquidHandler) saveState(ins liquidhandling.TerminalRobotInst
 	self.instructionHistory = append(self.instructionHistory, ins)
 	self.errorHistory = append(self.errorHistory, self.errors)
 	self.errors = make([]LiquidhandlingError, 0)
+	self.timeline = append(self.timeline, liquidhandling.TimedInstruction{
+		Instruction: ins,
+		Start:       self.GetTotalTime(),
+		Duration:    self.elapsed,
+	})
+	self.elapsed = 0
 }
 
 func (self *VirtualLiquidHandler) addLHError(err LiquidhandlingError) {
@@ -687,7 +713,9 @@ func (self *VirtualLiquidHandler) Move(deckpositionS []string, wellcoords []stri
 	}
 
 	//move the head to the new position
+	from := adaptor.GetPosition()
 	err = adaptor.SetPosition(origin)
+	self.addTime(adaptor.GetGroup().timeForMove(from, adaptor.GetPosition(), self.settings.GetTimingModel()))
 	if err != nil {
 		self.AddErrorf("%s: %s", describe(), err.Error())
 	}
@@ -734,6 +762,7 @@ func (self *VirtualLiquidHandler) Aspirate(volume []float64, overstroke []bool,
 		self.AddErrorf("invalid Arguments: %s", err.Error())
 		return ret
 	}
+	self.addTime(timeForPipetting(arg.adaptor, arg.channels, arg.volumes, 1, self.settings.GetTimingModel()))
 
 	describe := func() string {
 		return fmt.Sprintf("%s of %s to head %d %s",
@@ -886,6 +915,7 @@ func (self *VirtualLiquidHandler) Dispense(volume []float64, blowout []bool, hea
 		self.AddErrorf("invalid arguments: %s", err.Error())
 		return ret
 	}
+	self.addTime(timeForPipetting(arg.adaptor, arg.channels, arg.volumes, 1, self.settings.GetTimingModel()))
 
 	//find the position of each tip
 	wells := self.getWellsBelow(self.settings.MaxDispenseHeight(), arg.adaptor)
@@ -1045,6 +1075,7 @@ func (self *VirtualLiquidHandler) LoadTips(channels []int, head, multi int,
 	if !self.testTipArgs(channels, head, platetypeS, positionS, well) {
 		return ret
 	}
+	self.addTime(self.settings.GetTimingModel().LoadTipsTime)
 
 	//get the individual position
 	position, err := getSingle(positionS)
@@ -1321,6 +1352,7 @@ func (self *VirtualLiquidHandler) UnloadTips(channels []int, head, multi int,
 	if !self.testTipArgs(channels, head, platetype, position, well) {
 		return ret
 	}
+	self.addTime(self.settings.GetTimingModel().UnloadTipsTime)
 
 	if multi != len(channels) {
 		self.AddWarningf("While unloading %s from %s, multi should equal %d, not %d",
@@ -1460,7 +1492,8 @@ func (self *VirtualLiquidHandler) SetPipetteSpeed(head, channel int, rate float6
 	tRate := wunit.NewFlowRate(rate, "ml/min")
 	minRate := make([]wunit.FlowRate, 0, len(channels))
 	maxRate := make([]wunit.FlowRate, 0, len(channels))
-	for ch := range channels {
+	for _, ch := range channels {
+		adaptor.GetChannel(ch).SetPipetteSpeed(rate)
 		p := adaptor.GetParamsForChannel(ch)
 		if tRate.GreaterThan(p.Maxspd) || tRate.LessThan(p.Minspd) {
 			outOfRange = append(outOfRange, ch)
@@ -1524,6 +1557,7 @@ func (self *VirtualLiquidHandler) Initialize() driver.CommandStatus {
 		self.AddWarning("Call to initialize when robot is already initialized")
 	}
 	self.state.Initialize()
+	self.addTime(self.settings.GetTimingModel().InitializeTime)
 	return driver.CommandOk()
 }
 
@@ -1543,6 +1577,8 @@ func (self *VirtualLiquidHandler) Finalize() driver.CommandStatus {
 func (self *VirtualLiquidHandler) Wait(time float64) driver.CommandStatus {
 	if time < 0.0 {
 		self.AddWarning("waiting for negative time")
+	} else {
+		self.addTime(seconds(time))
 	}
 	return driver.CommandOk()
 }
@@ -1569,6 +1605,14 @@ func (self *VirtualLiquidHandler) Mix(head int, volume []float64, platetype []st
 		self.AddErrorf("Invalid arguments - %s", err.Error())
 		return ret
 	}
+	maxCycles := 0
+	for _, ch := range arg.channels {
+		if cycles[ch] > maxCycles {
+			maxCycles = cycles[ch]
+		}
+	}
+	//each cycle is an aspirate and a dispense
+	self.addTime(timeForPipetting(arg.adaptor, arg.channels, arg.volumes, 2*maxCycles, self.settings.GetTimingModel()))
 
 	wells := self.getWellsBelow(0., arg.adaptor)
 
@@ -1836,6 +1880,18 @@ func (self *VirtualLiquidHandler) Message(level int, title, text string, showcan
 	return driver.CommandOk()
 }
 
+//Refill - used, tops up a well as the user is asked to in a message
+func (self *VirtualLiquidHandler) Refill(plateID, well string, volume float64) driver.CommandStatus {
+	if plate, ok := self.objectByID[plateID].(*wtype.Plate); !ok {
+		self.AddErrorf("cannot refill well %s: no plate with ID %s", well, plateID)
+	} else if w, ok := plate.Wellcoords[well]; !ok {
+		self.AddErrorf("cannot refill well %s: no such well in plate \"%s\"", well, plate.GetName())
+	} else if err := w.TopUp(wunit.NewVolume(volume, "ul")); err != nil {
+		self.AddError(err.Error())
+	}
+	return driver.CommandOk()
+}
+
 //GetOutputFile - used, but not in instruction stream
 func (self *VirtualLiquidHandler) GetOutputFile() ([]byte, driver.CommandStatus) {
 	self.AddWarning("not yet implemented")
@@ -151,6 +151,9 @@ func makeLHProperties(p *LHPropertiesParams) *liquidhandling.LHProperties {
 		lhp.HeadAssemblies = append(lhp.HeadAssemblies, makeLHHeadAssembly(ha))
 	}
 	lhp.Heads = lhp.GetLoadedHeads()
+	for _, head := range lhp.Heads {
+		lhp.Adaptors = append(lhp.Adaptors, head.Adaptor)
+	}
 
 	lhp.Preferences = &liquidhandling.LayoutOpt{
 		Tipboxes:  p.TipPreferences,
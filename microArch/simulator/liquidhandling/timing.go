@@ -0,0 +1,128 @@
+package liquidhandling
+
+import (
+	"math"
+	"time"
+
+	"github.com/antha-lang/antha/antha/anthalib/wtype"
+	"github.com/antha-lang/antha/antha/anthalib/wunit"
+	"github.com/antha-lang/antha/microArch/driver/liquidhandling"
+)
+
+// TimingModel holds the physical parameters used to estimate how long each
+// instruction takes. Head moves are timed from the distance travelled and
+// the drive speed, and liquid handling from the volume and the pipette speed.
+// Drive and pipette speeds set by instructions take precedence over the
+// defaults given here.
+type TimingModel struct {
+	DriveSpeedX     float64       // default head speed in the X direction in mm/s
+	DriveSpeedY     float64       // default head speed in the Y direction in mm/s
+	DriveSpeedZ     float64       // default head speed in the Z direction in mm/s
+	PipetteSpeed    float64       // default pipette speed in ml/min
+	MoveOverhead    time.Duration // acceleration and settling time for each move
+	PipetteOverhead time.Duration // time for each aspirate or dispense stroke in addition to moving the liquid
+	LoadTipsTime    time.Duration
+	UnloadTipsTime  time.Duration
+	InitializeTime  time.Duration
+}
+
+// DefaultTimingModel returns a timing model with typical speeds for an SBS
+// format liquid handler
+func DefaultTimingModel() *TimingModel {
+	return &TimingModel{
+		DriveSpeedX:     100.,
+		DriveSpeedY:     100.,
+		DriveSpeedZ:     50.,
+		PipetteSpeed:    3.7,
+		MoveOverhead:    500 * time.Millisecond,
+		PipetteOverhead: 500 * time.Millisecond,
+		LoadTipsTime:    5600 * time.Millisecond,
+		UnloadTipsTime:  5400 * time.Millisecond,
+		InitializeTime:  5 * time.Second,
+	}
+}
+
+func seconds(s float64) time.Duration {
+	return time.Duration(s * float64(time.Second))
+}
+
+// driveSpeed returns the head speed in mm/s along the given axis: the speed
+// last set by SetDriveSpeed, otherwise the maximum speed of the head, otherwise
+// the default
+func (self *AdaptorGroup) driveSpeed(a wunit.Axis, model *TimingModel) float64 {
+	if v := self.velocity.GetAxis(a); v.ConcreteMeasurement != nil {
+		return v.ConvertToString("mm/s")
+	}
+	if self.velocityRange != nil {
+		if v := self.velocityRange.Max.GetAxis(a); v.ConcreteMeasurement != nil {
+			return v.ConvertToString("mm/s")
+		}
+	}
+	switch a {
+	case wunit.XAxis:
+		return model.DriveSpeedX
+	case wunit.YAxis:
+		return model.DriveSpeedY
+	default:
+		return model.DriveSpeedZ
+	}
+}
+
+// timeForMove estimates the time for the head group to move between two
+// positions. The X and Y drives move together, the Z drive separately.
+func (self *AdaptorGroup) timeForMove(from, to wtype.Coordinates3D, model *TimingModel) time.Duration {
+	axisTime := func(a wunit.Axis, distance float64) float64 {
+		if distance == 0. {
+			return 0.
+		}
+		if speed := self.driveSpeed(a, model); speed > 0. {
+			return math.Abs(distance) / speed
+		}
+		return 0.
+	}
+
+	t := math.Max(axisTime(wunit.XAxis, to.X-from.X), axisTime(wunit.YAxis, to.Y-from.Y))
+	t += axisTime(wunit.ZAxis, to.Z-from.Z)
+	if t == 0. {
+		return 0
+	}
+	return seconds(t) + model.MoveOverhead
+}
+
+// timeForPipetting estimates the time for the given channels to move the
+// given volumes (in ul) strokes times. Channels move liquid simultaneously.
+func timeForPipetting(adaptor *AdaptorState, channels []int, volumes []float64, strokes int, model *TimingModel) time.Duration {
+	var t float64
+	for i, ch := range channels {
+		speed := adaptor.GetChannel(ch).GetPipetteSpeed()
+		if speed <= 0. {
+			speed = model.PipetteSpeed
+		}
+		if speed > 0. && i < len(volumes) {
+			// ml/min to ul/s
+			t = math.Max(t, volumes[i]/(speed*1000./60.))
+		}
+	}
+	return time.Duration(strokes) * (seconds(t) + model.PipetteOverhead)
+}
+
+// addTime adds to the duration of the instruction currently being simulated
+func (self *VirtualLiquidHandler) addTime(d time.Duration) {
+	self.elapsed += d
+}
+
+// GetTimeline returns the estimated start time and duration of each
+// instruction passed to the last call of Simulate
+func (self *VirtualLiquidHandler) GetTimeline() []liquidhandling.TimedInstruction {
+	return self.timeline
+}
+
+// GetTotalTime returns the estimated time to run the instructions passed to
+// the last call of Simulate
+func (self *VirtualLiquidHandler) GetTotalTime() time.Duration {
+	if len(self.timeline) == 0 {
+		return 0
+	}
+	last := self.timeline[len(self.timeline)-1]
+	return last.Start + last.Duration
+}
@@ -0,0 +1,118 @@
+package liquidhandling
+
+import (
+	"testing"
+	"time"
+
+	"github.com/antha-lang/antha/microArch/driver/liquidhandling"
+)
+
+// durationAssertion assert the estimated duration of the i'th instruction to within a millisecond
+func durationAssertion(i int, expected time.Duration) *AssertionFn {
+	var ret AssertionFn = func(t *testing.T, vlh *VirtualLiquidHandler) {
+		timeline := vlh.GetTimeline()
+		if i >= len(timeline) {
+			t.Fatalf("DurationAssertion failed: expected at least %d instructions in timeline, got %d", i+1, len(timeline))
+		}
+		if d := timeline[i].Duration - expected; d > time.Millisecond || d < -time.Millisecond {
+			t.Errorf("DurationAssertion failed: instruction %d should take %v, took %v", i, expected, timeline[i].Duration)
+		}
+	}
+	return &ret
+}
+
+func moveToColumn(column string) *Move {
+	return &Move{
+		deckposition: []string{"tipbox_2", "tipbox_2", "tipbox_2", "tipbox_2", "tipbox_2", "tipbox_2", "tipbox_2", "tipbox_2"},
+		wellcoords:   []string{"A" + column, "B" + column, "C" + column, "D" + column, "E" + column, "F" + column, "G" + column, "H" + column},
+		reference:    []int{1, 1, 1, 1, 1, 1, 1, 1},
+		offsetX:      []float64{0., 0., 0., 0., 0., 0., 0., 0.},
+		offsetY:      []float64{0., 0., 0., 0., 0., 0., 0., 0.},
+		offsetZ:      []float64{1., 1., 1., 1., 1., 1., 1., 1.},
+		plate_type:   []string{"tipbox", "tipbox", "tipbox", "tipbox", "tipbox", "tipbox", "tipbox", "tipbox"},
+		head:         0,
+	}
+}
+
+func TestTimeline(t *testing.T) {
+	overhead := DefaultTimingModel().MoveOverhead
+	SimulatorTests{
+		{
+			Name: "move one column",
+			Setup: []*SetupFn{
+				testLayout(),
+			},
+			Instructions: []TestRobotInstruction{
+				&SetDriveSpeed{drive: "X", speed: 10.},
+				moveToColumn("1"),
+				moveToColumn("2"),
+				moveToColumn("2"),
+			},
+			Assertions: []*AssertionFn{
+				durationAssertion(0, 0),
+				//9mm at 10mm/s
+				durationAssertion(2, 900*time.Millisecond+overhead),
+				//not moving takes no time
+				durationAssertion(3, 0),
+			},
+		},
+		{
+			Name: "initialize",
+			Instructions: []TestRobotInstruction{
+				&Initialize{},
+			},
+			Assertions: []*AssertionFn{
+				durationAssertion(0, DefaultTimingModel().InitializeTime),
+			},
+		},
+	}.Run(t)
+}
+
+func TestTimelineStart(t *testing.T) {
+	vlh, err := NewVirtualLiquidHandler(defaultLHProperties(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	(*testLayout())(vlh)
+
+	instructions := []liquidhandling.TerminalRobotInstruction{
+		moveToColumn("1").Convert(),
+		moveToColumn("3").Convert(),
+		moveToColumn("1").Convert(),
+	}
+	if err := vlh.Simulate(instructions); err != nil {
+		t.Fatal(err)
+	}
+
+	var total time.Duration
+	for i, ti := range vlh.GetTimeline() {
+		if ti.Start != total {
+			t.Errorf("instruction %d should start at %v, starts at %v", i, total, ti.Start)
+		}
+		total += ti.Duration
+	}
+	if g := vlh.GetTotalTime(); g != total {
+		t.Errorf("expected total time %v, got %v", total, g)
+	}
+	if n := len(vlh.GetTimeline()); n != len(instructions) {
+		t.Errorf("expected %d instructions in timeline, got %d", len(instructions), n)
+	}
+}
+
+func TestTimeForPipetting(t *testing.T) {
+	vlh, err := NewVirtualLiquidHandler(defaultLHProperties(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	adaptor, err := vlh.GetAdaptorState(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	model := DefaultTimingModel()
+
+	//6 ml/min is 100 ul/s, and the slowest channel determines the time
+	vlh.SetPipetteSpeed(0, -1, 6.)
+	if e, g := 2*(500*time.Millisecond+model.PipetteOverhead), timeForPipetting(adaptor, []int{0, 1}, []float64{20., 50.}, 2, model); e != g {
+		t.Errorf("expected %v, got %v", e, g)
+	}
+}
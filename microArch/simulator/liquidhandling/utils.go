@@ -188,6 +188,7 @@ func assertNoTipsOnOthersInGroup(adaptor *AdaptorState) error {
 }
 
 //assertNoCollisionsInGroup check that there are no collisions, ignoring the specified channels on the given adaptor
+//and the other adaptors in the group if they are raised and lowered independently
 func assertNoCollisionsInGroup(settings *SimulatorSettings, adaptor *AdaptorState, channelsToIgnore []int, channelClearance float64) *CollisionError {
 
 	var maxChannels int
@@ -205,6 +206,9 @@ func assertNoCollisionsInGroup(settings *SimulatorSettings, adaptor *AdaptorStat
 	channelMap := make(map[int][]int)
 	objectMap := make(map[wtype.LHObject]bool)
 	for _, ad := range adaptor.GetGroup().GetAdaptors() {
+		if ad != adaptor && adaptor.GetGroup().IsIndependentZ() {
+			continue
+		}
 		channels := make([]int, 0, ad.GetChannelCount())
 		for i := 0; i < ad.GetChannelCount(); i++ {
 			if ad == adaptor && ignore[i] {
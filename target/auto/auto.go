@@ -9,6 +9,7 @@ import (
 	runner "github.com/antha-lang/antha/driver/antha_runner_v1"
 	"github.com/antha-lang/antha/target"
 	"github.com/antha-lang/antha/target/human"
+	"github.com/antha-lang/antha/target/mixer"
 	"google.golang.org/grpc"
 )
 
@@ -46,8 +47,9 @@ func (a *Auto) Close() error {
 }
 
 // New makes target by inspecting a set of gRPC network services for a list
-// of drivers.
-func New(opt Opt) (ret *Auto, err error) {
+// of drivers. If the mixer options configure an OT-2, a mixer generating
+// protocols for it is added using tipboxes from the inventory of the context.
+func New(ctx context.Context, opt Opt) (ret *Auto, err error) {
 	ret = &Auto{
 		Target:  target.New(),
 		runners: make(map[string][]runner.RunnerClient),
@@ -67,7 +69,6 @@ func New(opt Opt) (ret *Auto, err error) {
 		HumanOpt:  human.Opt{CanMix: true, CanIncubate: true},
 	}
 
-	ctx := context.Background()
 	for _, ep := range opt.Endpoints {
 		var conn *grpc.ClientConn
 		conn, err = grpc.Dial(ep.URI, grpc.WithInsecure())
@@ -81,6 +82,16 @@ func New(opt Opt) (ret *Auto, err error) {
 		}
 	}
 
+	if mopt := getMixerOpt(opt.MaybeArgs); mopt.OT2 != nil {
+		var d *mixer.Mixer
+		d, err = mixer.NewOT2(ctx, mopt)
+		if err != nil {
+			return
+		}
+		tryer.HumanOpt.CanMix = false
+		ret.Target.AddDevice(d)
+	}
+
 	ret.Target.AddDevice(human.New(tryer.HumanOpt))
 
 	return
@@ -24,6 +24,9 @@ type Finalizer interface {
 type TimeEstimator interface {
 	// GetTimeEstimate returns a time estimate for this instruction in seconds
 	GetTimeEstimate() float64
+	// GetTimeline returns the estimated start time and duration of each
+	// low level instruction, or nil if they are unknown
+	GetTimeline() []liquidhandling.TimedInstruction
 }
 
 // A TipEstimator is an instruction that uses tips and provides information on how many
@@ -118,6 +121,14 @@ func (a *Mix) GetTimeEstimate() float64 {
 	return est
 }
 
+// GetTimeline implements a TimeEstimator
+func (a *Mix) GetTimeline() []liquidhandling.TimedInstruction {
+	if a.Request == nil {
+		return nil
+	}
+	return a.Request.Timeline
+}
+
 // GetTipEstimates implements a TipEstimator
 func (a *Mix) GetTipEstimates() []wtype.TipEstimate {
 	ret := []wtype.TipEstimate{}
@@ -155,6 +166,21 @@ func (a *Mix) SummarizeActions() ([]byte, error) {
 	return lh.SummarizeActions(a.Properties, a.Request.InstructionTree)
 }
 
+// SummarizePlan helper function to get a human readable description of the effect of the optimizations
+// made in planning the mix, one line each
+func (a *Mix) SummarizePlan() []string {
+	if a.Request == nil {
+		return nil
+	}
+	return a.Request.PlanSummary()
+}
+
+// SimulationInput helper function to get the initial state of the liquid handler and the instructions
+// sent to it, which can be serialized and later replayed instruction by instruction in the simulator
+func (a *Mix) SimulationInput() *lh.SimulationInput {
+	return lh.NewSimulationInput(a.Properties, a.Request.Instructions)
+}
+
 // A Manual is human-aided interaction
 type Manual struct {
 	dependsMixin
@@ -14,6 +14,8 @@ import (
 	"github.com/antha-lang/antha/ast"
 	"github.com/antha-lang/antha/inventory"
 	driver "github.com/antha-lang/antha/microArch/driver/liquidhandling"
+	"github.com/antha-lang/antha/microArch/driver/liquidhandling/opentrons"
+	"github.com/antha-lang/antha/microArch/driver/liquidhandling/worklist"
 	"github.com/antha-lang/antha/microArch/sampletracker"
 	planner "github.com/antha-lang/antha/microArch/scheduler/liquidhandling"
 	"github.com/antha-lang/antha/target"
@@ -51,6 +53,9 @@ func (a *Mixer) CanCompile(req ast.Request) bool {
 
 // FileType returns the file type for generated files
 func (a *Mixer) FileType() (ftype string) {
+	if wl, ok := a.driver.(*worklist.Worklist); ok {
+		return wl.Format().ContentType()
+	}
 	if m := a.properties.Mnfr; len(m) != 0 {
 		ftype = fmt.Sprintf("application/%s", strings.ToLower(m))
 	}
@@ -209,6 +214,18 @@ func (a *Mixer) makeLhreq(ctx context.Context) (*lhreq, error) {
 
 	req.Options.IgnorePhysicalSimulation = a.opt.IgnorePhysicalSimulation
 
+	// layout optimization
+
+	req.Options.OptimizeLayout = a.opt.OptimizeLayout
+
+	// transfer reordering
+
+	req.Options.OptimizeTransferOrder = a.opt.OptimizeTransferOrder
+
+	// reagent reservoirs
+
+	req.Options.UseReservoirs = a.opt.UseReservoirs
+
 	return &lhreq{
 		LHRequest:     req,
 		LHProperties:  prop,
@@ -431,7 +448,14 @@ func (a *Mixer) makeMix(ctx context.Context, mixes []*wtype.LHInstruction) (*tar
 		// TODO: Desired filename not exposed in current driver interface, so pick
 		// a name. So far, at least Gilson software cares what the filename is, so
 		// use .sqlite for compatibility
-		name = strings.Replace(fmt.Sprintf("%s.sqlite", time.Now().Format(time.RFC3339)), ":", "_", -1)
+		ext := ".sqlite"
+		switch d := a.driver.(type) {
+		case *worklist.Worklist:
+			ext = d.Format().Extension()
+		case *opentrons.OT2:
+			ext = ".py"
+		}
+		name = strings.Replace(fmt.Sprintf("%s%s", time.Now().Format(time.RFC3339), ext), ":", "_", -1)
 	}
 
 	tarball, err := a.saveFile(name)
@@ -462,6 +486,14 @@ func New(opt Opt, d driver.LiquidhandlingDriver) (*Mixer, error) {
 		Washes:    driver.Addresses(opt.DriverSpecificWashPreferences),
 	}
 
+	if f := opt.WorklistFormat; len(f) != 0 {
+		wl, err := newWorklist(f, d)
+		if err != nil {
+			return nil, err
+		}
+		d = wl
+	}
+
 	if p, status := d.GetCapabilities(); !status.Ok() {
 		return nil, status.GetError()
 	} else if err := p.ApplyUserPreferences(userPreferences); err != nil {
@@ -471,3 +503,30 @@ func New(opt Opt, d driver.LiquidhandlingDriver) (*Mixer, error) {
 		return &Mixer{driver: d, properties: &p, opt: opt}, nil
 	}
 }
+
+// NewOT2 creates a new Mixer which generates a Python protocol for the
+// Opentrons OT-2 configured by opt.OT2, using tipboxes from the inventory of
+// the context
+func NewOT2(ctx context.Context, opt Opt) (*Mixer, error) {
+	if opt.OT2 == nil {
+		return nil, fmt.Errorf("no OT-2 configuration given")
+	}
+	d, err := opentrons.New(ctx, *opt.OT2)
+	if err != nil {
+		return nil, err
+	}
+	return New(opt, d)
+}
+
+// newWorklist returns a driver writing worklists for the device of d
+func newWorklist(format string, d driver.LiquidhandlingDriver) (*worklist.Worklist, error) {
+	f, err := worklist.ParseFormat(format)
+	if err != nil {
+		return nil, err
+	}
+	p, status := d.GetCapabilities()
+	if !status.Ok() {
+		return nil, status.GetError()
+	}
+	return worklist.New(f, &p)
+}
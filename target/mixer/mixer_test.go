@@ -0,0 +1,111 @@
+package mixer
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"io/ioutil"
+	"path"
+	"strings"
+	"testing"
+
+	anthamixer "github.com/antha-lang/antha/antha/anthalib/mixer"
+	"github.com/antha-lang/antha/antha/anthalib/wtype"
+	"github.com/antha-lang/antha/antha/anthalib/wunit"
+	"github.com/antha-lang/antha/ast"
+	"github.com/antha-lang/antha/inventory"
+	"github.com/antha-lang/antha/inventory/testinventory"
+	"github.com/antha-lang/antha/microArch/driver/liquidhandling/opentrons"
+	"github.com/antha-lang/antha/microArch/sampletracker"
+	"github.com/antha-lang/antha/target"
+)
+
+func TestOT2Mix(t *testing.T) {
+	ctx := sampletracker.NewContext(testinventory.NewContext(context.Background()))
+
+	if _, err := NewOT2(ctx, DefaultOpt); err == nil {
+		t.Error("expected error creating OT-2 mixer without configuration")
+	}
+
+	config := opentrons.DefaultConfig()
+	config.ProtocolName = "Mix test"
+	opt := DefaultOpt
+	opt.OT2 = &config
+	opt.InputPlateTypes = []string{"DWST12"}
+	m, err := NewOT2(ctx, opt)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	water, err := inventory.NewComponent(ctx, "water")
+	if err != nil {
+		t.Fatal(err)
+	}
+	water.SetVolume(wunit.NewVolume(1000.0, "ul"))
+	out, err := inventory.NewPlate(ctx, "pcrplate_skirted")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// volumes for each pipette
+	var nodes []ast.Node
+	for well, vol := range map[string]float64{"A1": 50.0, "B1": 10.0} {
+		nodes = append(nodes, &ast.Command{
+			Inst: anthamixer.GenericMix(anthamixer.MixOptions{
+				Inputs:      []*wtype.Liquid{anthamixer.Sample(water, wunit.NewVolume(vol, "ul"))},
+				Destination: out,
+				Address:     well,
+			}),
+		})
+	}
+
+	insts, err := m.Compile(ctx, nodes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mix, ok := insts[0].(*target.Mix)
+	if !ok {
+		t.Fatalf("expected mix, got %T", insts[0])
+	}
+
+	protocol, status := m.driver.GetOutputFile()
+	if !status.Ok() {
+		t.Fatal(status)
+	}
+	for _, expected := range []string{
+		"from opentrons import protocol_api",
+		`"protocolName": "Mix test"`,
+		"def run(protocol: protocol_api.ProtocolContext):",
+		"left.pick_up_tip(",
+		"right.pick_up_tip(",
+		".aspirate(",
+		".dispense(",
+	} {
+		if !bytes.Contains(protocol, []byte(expected)) {
+			t.Errorf("expected protocol to contain %q, got:\n%s", expected, protocol)
+		}
+	}
+
+	// the mix carries the protocol as a Python file
+	gr, err := gzip.NewReader(bytes.NewReader(mix.Files.Tarball))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tr := tar.NewReader(gr)
+	hdr, err := tr.Next()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ext := path.Ext(hdr.Name); ext != ".py" {
+		t.Errorf("expected python file, got %s", hdr.Name)
+	}
+	if bs, err := ioutil.ReadAll(tr); err != nil {
+		t.Fatal(err)
+	} else if string(bs) != string(protocol) {
+		t.Errorf("expected tarball to hold protocol, got:\n%s", bs)
+	}
+	if !strings.Contains(mix.Files.Type, "opentrons") {
+		t.Errorf("expected opentrons file type, got %s", mix.Files.Type)
+	}
+}
@@ -3,6 +3,7 @@ package mixer
 import (
 	"github.com/antha-lang/antha/antha/anthalib/wtype"
 	"github.com/antha-lang/antha/meta"
+	"github.com/antha-lang/antha/microArch/driver/liquidhandling/opentrons"
 )
 
 var (
@@ -41,6 +42,14 @@ type Opt struct {
 	// Specify file name in the instruction stream of any driver generated file
 	DriverOutputFileName string `json:"driverOutputFileName,omitempty"`
 
+	// Write transfers as a worklist in this format (e.g., tecan-gwl,
+	// hamilton-csv or echo-picklist) rather than using the driver
+	WorklistFormat string `json:"worklistFormat,omitempty"`
+
+	// Generate a Python protocol for an Opentrons OT-2 with this
+	// configuration rather than using a driver
+	OT2 *opentrons.Config `json:"ot2,omitempty"`
+
 	// Driver specific options. Semantics are not stable. Will need to be
 	// revised when multi device execution is supported.
 	DriverSpecificInputPreferences    []string `json:"driverSpecificInputPreferences,omitempty"`
@@ -56,6 +65,9 @@ type Opt struct {
 	LegacyVolume             bool `json:"legacyVolume"`             // Don't track volumes for intermediates
 	FixVolumes               bool `json:"fixVolumes"`               // Aim to revise requested volumes to service requirements
 	IgnorePhysicalSimulation bool `json:"ignorePhysicalSimulation"` //ignore errors in physical simulation
+	OptimizeLayout           bool `json:"optimizeLayout"`           // Move plates to reduce head travel
+	OptimizeTransferOrder    bool `json:"optimizeTransferOrder"`    // Reorder transfers to save tips and time
+	UseReservoirs            bool `json:"useReservoirs"`            // Hold bulk reagents in troughs, prompting for refills
 
 	// Two ways to set user liquid policies rule set
 	CustomPolicyData    map[string]wtype.LHPolicy `json:"customPolicyData,omitempty"`    // Set rule set from policies
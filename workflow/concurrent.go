@@ -0,0 +1,129 @@
+package workflow
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/antha-lang/antha/inject"
+)
+
+// A ProcessError is an error returned by running a process
+type ProcessError struct {
+	Process string
+	Err     error
+}
+
+// Error satisfies the error interface
+func (a *ProcessError) Error() string {
+	return fmt.Sprintf("cannot run process %q: %s", a.Process, a.Err)
+}
+
+// Errors are the errors returned by the processes of a workflow in
+// scheduling order
+type Errors []*ProcessError
+
+// Error satisfies the error interface
+func (a Errors) Error() string {
+	var msgs []string
+	for _, err := range a {
+		msgs = append(msgs, err.Error())
+	}
+	return strings.Join(msgs, "\n")
+}
+
+// A PanicError records a panic raised by a process that was run
+// concurrently with other processes
+type PanicError struct {
+	Value interface{} // Value passed to panic
+	Stack string      // Stack trace of the panicking process
+}
+
+// Error satisfies the error interface
+func (a *PanicError) Error() string {
+	return fmt.Sprintf("%v\n%s", a.Value, a.Stack)
+}
+
+type runResult struct {
+	Node     *node
+	MoreWork []*node
+	Err      error
+}
+
+// runRecover runs a process, turning any panic into a PanicError so that
+// it can be reported from the goroutine calling Run
+func (a *Workflow) runRecover(ctx context.Context, n *node) (moreWork []*node, err error) {
+	defer func() {
+		if res := recover(); res != nil {
+			err = &PanicError{Value: res, Stack: inject.ElementStackTrace()}
+		}
+	}()
+	return a.run(ctx, n)
+}
+
+// runConcurrent runs ready processes with a pool of at most
+// maxConcurrency workers. Ready processes are started in the order given
+// by order. The first error cancels the context of all running processes
+// and no more processes are started.
+func (a *Workflow) runConcurrent(parent context.Context, order []*node) error {
+	rank := make(map[string]int)
+	for idx, n := range order {
+		rank[n.Process] = idx
+	}
+
+	ctx, cancel := context.WithCancel(parent)
+	defer cancel()
+
+	worklist, err := makeRoots(a.nodes)
+	if err != nil {
+		return err
+	}
+
+	results := make(chan runResult)
+	running := 0
+	var errs Errors
+	for len(worklist) > 0 || running > 0 {
+		for len(errs) == 0 && running < a.maxConcurrency && len(worklist) > 0 {
+			n := worklist[0]
+			worklist = worklist[1:]
+			running++
+			go func(n *node) {
+				moreWork, err := a.runRecover(ctx, n)
+				results <- runResult{Node: n, MoreWork: moreWork, Err: err}
+			}(n)
+		}
+
+		if running == 0 {
+			break
+		}
+
+		res := <-results
+		running--
+
+		if res.Err != nil {
+			// Processes stopped by our own cancellation are not the
+			// cause of the failure
+			if len(errs) > 0 && res.Err == context.Canceled {
+				continue
+			}
+			errs = append(errs, &ProcessError{Process: res.Node.Process, Err: res.Err})
+			cancel()
+			continue
+		}
+
+		worklist = append(worklist, res.MoreWork...)
+		sort.Slice(worklist, func(i, j int) bool {
+			return rank[worklist[i].Process] < rank[worklist[j].Process]
+		})
+	}
+
+	if len(errs) > 0 {
+		sort.Slice(errs, func(i, j int) bool {
+			return rank[errs[i].Process] < rank[errs[j].Process]
+		})
+		return errs
+	}
+
+	return nil
+}
@@ -0,0 +1,159 @@
+package workflow
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+
+	"github.com/antha-lang/antha/inject"
+)
+
+// Kinds of control construct
+const (
+	// MapControl runs the component of a process once for each element of
+	// the slice inputs named by Over. Every output is gathered into a
+	// slice with one element per run.
+	MapControl = "map"
+	// CondControl routes its In input to its True output if its Cond input
+	// is true and to its False output otherwise. Processes that depend on
+	// the output that is not taken are skipped.
+	CondControl = "cond"
+)
+
+// Ports of a CondControl process
+const (
+	CondPort  = "Cond"
+	InPort    = "In"
+	TruePort  = "True"
+	FalsePort = "False"
+)
+
+var errNotSlice = errors.New("not a slice")
+
+// A Control makes a process a control construct rather than a plain call of
+// its component
+type Control struct {
+	Kind string   `json:"kind"`
+	Over []string `json:"over,omitempty"` // Inputs iterated over by MapControl
+}
+
+func (a *Control) validate(funcName string) error {
+	switch a.Kind {
+	case MapControl:
+		if funcName == "" {
+			return fmt.Errorf("%s control requires a component", a.Kind)
+		} else if len(a.Over) == 0 {
+			return fmt.Errorf("%s control requires at least one input to iterate over", a.Kind)
+		}
+	case CondControl:
+		if funcName != "" {
+			return fmt.Errorf("%s control cannot have a component", a.Kind)
+		}
+	default:
+		return fmt.Errorf("unknown control %q", a.Kind)
+	}
+	return nil
+}
+
+func callCond(n *node) (inject.Value, map[string]bool, error) {
+	cond, ok := n.Params[CondPort].(bool)
+	if !ok {
+		return nil, nil, fmt.Errorf("cannot read parameter %s", CondPort)
+	}
+	value, ok := n.Params[InPort]
+	if !ok {
+		return nil, nil, fmt.Errorf("cannot read parameter %s", InPort)
+	}
+
+	taken, notTaken := TruePort, FalsePort
+	if !cond {
+		taken, notTaken = notTaken, taken
+	}
+	return inject.Value{taken: value}, map[string]bool{notTaken: true}, nil
+}
+
+// outputTypes returns the types of the outputs of the function in query if
+// they are known
+func outputTypes(ctx context.Context, query inject.NameQuery) map[string]reflect.Type {
+	types := make(map[string]reflect.Type)
+	runner, err := inject.Find(ctx, query)
+	if err != nil {
+		return types
+	}
+	if tr, ok := runner.(inject.TypedRunner); ok {
+		for name, v := range inject.MakeValue(tr.Output()) {
+			if v != nil {
+				types[name] = reflect.TypeOf(v)
+			}
+		}
+	}
+	return types
+}
+
+func (a *Workflow) callMap(ctx context.Context, n *node, query inject.NameQuery) (inject.Value, error) {
+	length := -1
+	over := make(map[string]reflect.Value)
+	for _, name := range n.Control.Over {
+		v := reflect.ValueOf(n.Params[name])
+		if v.Kind() != reflect.Slice {
+			return nil, fmt.Errorf("parameter %s: %s", name, errNotSlice)
+		} else if length >= 0 && v.Len() != length {
+			return nil, fmt.Errorf("parameter %s has length %d but expecting %d", name, v.Len(), length)
+		}
+		length = v.Len()
+		over[name] = v
+	}
+
+	gathered := make(map[string]reflect.Value)
+	for name, typ := range outputTypes(ctx, query) {
+		gathered[name] = reflect.MakeSlice(reflect.SliceOf(typ), 0, length)
+	}
+
+	for i := 0; i < length; i++ {
+		params := make(inject.Value)
+		for k, v := range n.Params {
+			params[k] = v
+		}
+		for name, v := range over {
+			params[name] = v.Index(i).Interface()
+		}
+
+		out, err := a.call(ctx, n, query, params)
+		if err != nil {
+			return nil, fmt.Errorf("element %d: %s", i, err)
+		}
+
+		for name, v := range out {
+			g, ok := gathered[name]
+			if !ok {
+				typ := reflect.TypeOf((*interface{})(nil)).Elem()
+				if v != nil {
+					typ = reflect.TypeOf(v)
+				}
+				g = reflect.MakeSlice(reflect.SliceOf(typ), 0, length)
+			}
+			elem := reflect.Zero(g.Type().Elem())
+			if v != nil {
+				elem = reflect.ValueOf(v)
+			}
+			if !elem.Type().AssignableTo(g.Type().Elem()) {
+				return nil, fmt.Errorf("element %d: output %s of type %s not assignable to type %s", i, name, elem.Type(), g.Type().Elem())
+			}
+			gathered[name] = reflect.Append(g, elem)
+		}
+	}
+
+	out := make(inject.Value)
+	for name, g := range gathered {
+		out[name] = g.Interface()
+	}
+	// Without type information, connected outputs of an empty map are
+	// still given a value
+	for name := range n.Outs {
+		if _, seen := out[name]; !seen {
+			out[name] = []interface{}{}
+		}
+	}
+	return out, nil
+}
@@ -0,0 +1,133 @@
+package workflow
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+var controlJSON = `
+{
+    "processes": {
+        "Equals": { "component": "Equals" },
+        "Branch": { "control": { "kind": "cond" } },
+        "OnTrue": { "component": "Copy" },
+        "OnFalse": { "component": "Copy" },
+        "AfterFalse": { "component": "Copy" },
+        "Map": { "component": "Copy", "control": { "kind": "map", "over": [ "In" ] } }
+    },
+    "connections": [
+        {
+            "source": { "process": "Equals", "port": "Out" },
+            "target": { "process": "Branch", "port": "Cond" }
+        },
+        {
+            "source": { "process": "Branch", "port": "True" },
+            "target": { "process": "OnTrue", "port": "In" }
+        },
+        {
+            "source": { "process": "Branch", "port": "False" },
+            "target": { "process": "OnFalse", "port": "In" }
+        },
+        {
+            "source": { "process": "OnFalse", "port": "Out" },
+            "target": { "process": "AfterFalse", "port": "In" }
+        }
+    ]
+}
+`
+
+func TestRunControl(t *testing.T) {
+	ctx, err := createContext()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, same := range []bool{true, false} {
+		var desc *Desc
+		if err := json.Unmarshal([]byte(controlJSON), &desc); err != nil {
+			t.Fatal(err)
+		}
+
+		w, err := New(Opt{FromDesc: desc})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		b := "B"
+		if same {
+			b = "A"
+		}
+		if err := w.SetParam(Port{Process: "Equals", Port: "A"}, "A"); err != nil {
+			t.Error(err)
+		}
+		if err := w.SetParam(Port{Process: "Equals", Port: "B"}, b); err != nil {
+			t.Error(err)
+		}
+		if err := w.SetParam(Port{Process: "Branch", Port: "In"}, "value"); err != nil {
+			t.Error(err)
+		}
+		if err := w.SetParam(Port{Process: "Map", Port: "In"}, []string{"x", "y", "z"}); err != nil {
+			t.Error(err)
+		}
+
+		if err := w.Run(ctx); err != nil {
+			t.Fatal(err)
+		}
+
+		_, trueRan := w.Outputs[Port{Process: "OnTrue", Port: "Out"}]
+		_, falseRan := w.Outputs[Port{Process: "AfterFalse", Port: "Out"}]
+		if trueRan != same || falseRan == same {
+			t.Errorf("cond %t: expecting only one branch to run but got true branch %t and false branch %t", same, trueRan, falseRan)
+		}
+
+		if out, ok := w.Outputs[Port{Process: "Map", Port: "Out"}].([]string); !ok {
+			t.Errorf("cannot read parameter Out of type []string: %v", w.Outputs[Port{Process: "Map", Port: "Out"}])
+		} else if !reflect.DeepEqual(out, []string{"x", "y", "z"}) {
+			t.Errorf("expecting %v but got %v", []string{"x", "y", "z"}, out)
+		}
+	}
+}
+
+func TestControlErrors(t *testing.T) {
+	w, err := New(Opt{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := w.AddControlNode("A", "", Control{Kind: MapControl, Over: []string{"In"}}); err == nil {
+		t.Error("expecting error for map without a component")
+	}
+	if err := w.AddControlNode("B", "Copy", Control{Kind: CondControl}); err == nil {
+		t.Error("expecting error for cond with a component")
+	}
+	if err := w.AddControlNode("C", "Copy", Control{Kind: "loop"}); err == nil {
+		t.Error("expecting error for unknown control")
+	}
+}
+
+func TestCyclicControl(t *testing.T) {
+	w, err := New(Opt{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := w.AddControlNode("Branch", "", Control{Kind: CondControl}); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.AddNode("Copy", "Copy"); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.AddEdge(Port{Process: "Branch", Port: "True"}, Port{Process: "Copy", Port: "In"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.AddEdge(Port{Process: "Copy", Port: "Out"}, Port{Process: "Branch", Port: "In"}); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, err := createContext()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Run(ctx); err != errCyclicWorkflow {
+		t.Errorf("expecting %q but got %v", errCyclicWorkflow, err)
+	}
+}
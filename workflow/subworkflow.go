@@ -0,0 +1,236 @@
+package workflow
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Separator between the name of a sub-workflow process and the names of
+// the processes within it
+const namespaceSep = "/"
+
+// A SubWorkflow is a workflow that can be used as a process of another
+// workflow. Inputs and Outputs map the port names of the enclosing process
+// to the ports of the processes within the sub-workflow that they expose. An
+// input may expose several inner ports, each of which receives its value.
+type SubWorkflow struct {
+	Desc
+	Inputs  map[string][]Port `json:"inputs"`
+	Outputs map[string]Port   `json:"outputs"`
+}
+
+func namespaced(process, inner string) string {
+	return process + namespaceSep + inner
+}
+
+// validate checks that each port within the sub-workflow is exposed at most
+// once and that exposed ports are not also connected within the
+// sub-workflow, since they would then be assigned twice
+func (a *SubWorkflow) validate() error {
+	connected := make(map[Port]bool)
+	for _, c := range a.Connections {
+		connected[c.Src] = true
+		connected[c.Tgt] = true
+	}
+
+	exposed := make(map[Port]string)
+	expose := func(name string, p Port) error {
+		if connected[p] {
+			return fmt.Errorf("exposed port %q is connected within the sub-workflow", p)
+		} else if other, seen := exposed[p]; seen {
+			return fmt.Errorf("port %q exposed by both %q and %q", p, other, name)
+		}
+		exposed[p] = name
+		return nil
+	}
+
+	for name, ps := range a.Inputs {
+		if len(ps) == 0 {
+			return fmt.Errorf("input %q exposes no ports", name)
+		}
+		for _, p := range ps {
+			if err := expose(name, p); err != nil {
+				return err
+			}
+		}
+	}
+	for name, p := range a.Outputs {
+		if err := expose(name, p); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// resolveInput returns the ports of the processes, relative to this
+// sub-workflow, that are exposed by the given input port
+func (a *SubWorkflow) resolveInput(port string) ([]Port, error) {
+	ps, ok := a.Inputs[port]
+	if !ok {
+		return nil, errUnknownPort
+	}
+
+	var ret []Port
+	for _, p := range ps {
+		inner, ok := a.Processes[p.Process]
+		if !ok {
+			return nil, fmt.Errorf("exposed port %q: %s", p, errUnknownProcess)
+		}
+		if inner.Workflow == nil {
+			ret = append(ret, p)
+			continue
+		}
+
+		ips, err := inner.Workflow.resolveInput(p.Port)
+		if err != nil {
+			return nil, fmt.Errorf("exposed port %q: %s", p, err)
+		}
+		for _, ip := range ips {
+			ret = append(ret, Port{Process: namespaced(p.Process, ip.Process), Port: ip.Port})
+		}
+	}
+	return ret, nil
+}
+
+// resolveOutput returns the port of the process, relative to this
+// sub-workflow, that is exposed by the given output port
+func (a *SubWorkflow) resolveOutput(port string) (Port, error) {
+	p, ok := a.Outputs[port]
+	if !ok {
+		return Port{}, errUnknownPort
+	}
+	inner, ok := a.Processes[p.Process]
+	if !ok {
+		return Port{}, fmt.Errorf("exposed port %q: %s", p, errUnknownProcess)
+	}
+	if inner.Workflow == nil {
+		return p, nil
+	}
+
+	ip, err := inner.Workflow.resolveOutput(p.Port)
+	if err != nil {
+		return Port{}, fmt.Errorf("exposed port %q: %s", p, err)
+	}
+	return Port{Process: namespaced(p.Process, ip.Process), Port: ip.Port}, nil
+}
+
+// resolveInputs returns the ports of component processes for an input port
+// of a process of desc, which may be a sub-workflow
+func (a *Desc) resolveInputs(port Port) ([]Port, error) {
+	p, ok := a.Processes[port.Process]
+	if !ok || p.Workflow == nil {
+		return []Port{port}, nil
+	}
+	ips, err := p.Workflow.resolveInput(port.Port)
+	if err != nil {
+		return nil, fmt.Errorf("port %q of sub-workflow: %s", port, err)
+	}
+	ret := make([]Port, 0, len(ips))
+	for _, ip := range ips {
+		ret = append(ret, Port{Process: namespaced(port.Process, ip.Process), Port: ip.Port})
+	}
+	return ret, nil
+}
+
+// resolveOutput returns the port of a component process for an output port
+// of a process of desc, which may be a sub-workflow
+func (a *Desc) resolveOutput(port Port) (Port, error) {
+	p, ok := a.Processes[port.Process]
+	if !ok || p.Workflow == nil {
+		return port, nil
+	}
+	ip, err := p.Workflow.resolveOutput(port.Port)
+	if err != nil {
+		return Port{}, fmt.Errorf("port %q of sub-workflow: %s", port, err)
+	}
+	return Port{Process: namespaced(port.Process, ip.Process), Port: ip.Port}, nil
+}
+
+// exposedOutputs returns the outputs of the sub-workflow processes of desc
+// keyed by the port of the component process that they expose
+func (a *Desc) exposedOutputs() (map[Port]Port, error) {
+	ret := make(map[Port]Port)
+	for name, p := range a.Processes {
+		if p.Workflow == nil {
+			continue
+		}
+		for out := range p.Workflow.Outputs {
+			port := Port{Process: name, Port: out}
+			inner, err := a.resolveOutput(port)
+			if err != nil {
+				return nil, err
+			}
+			ret[inner] = port
+		}
+	}
+	return ret, nil
+}
+
+// flatten returns an equivalent workflow description where every
+// sub-workflow process is replaced by the processes within it. Inner
+// processes are named by joining the name of the sub-workflow process and
+// their own name with "/".
+func (a *Desc) flatten() (*Desc, error) {
+	flat := &Desc{
+		Processes: make(map[string]Process),
+	}
+
+	add := func(name string, p Process) error {
+		if _, seen := flat.Processes[name]; seen {
+			return fmt.Errorf("process %q already defined", name)
+		}
+		flat.Processes[name] = p
+		return nil
+	}
+
+	for name, p := range a.Processes {
+		if p.Workflow == nil {
+			if err := add(name, p); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		if p.Component != "" {
+			return nil, fmt.Errorf("process %q has both a component and a workflow", name)
+		}
+		if strings.Contains(name, namespaceSep) {
+			return nil, fmt.Errorf("sub-workflow process %q cannot contain %q", name, namespaceSep)
+		}
+		if err := p.Workflow.validate(); err != nil {
+			return nil, fmt.Errorf("sub-workflow %q: %s", name, err)
+		}
+
+		inner, err := p.Workflow.Desc.flatten()
+		if err != nil {
+			return nil, fmt.Errorf("sub-workflow %q: %s", name, err)
+		}
+		for iname, ip := range inner.Processes {
+			if err := add(namespaced(name, iname), ip); err != nil {
+				return nil, err
+			}
+		}
+		for _, c := range inner.Connections {
+			flat.Connections = append(flat.Connections, Connection{
+				Src: Port{Process: namespaced(name, c.Src.Process), Port: c.Src.Port},
+				Tgt: Port{Process: namespaced(name, c.Tgt.Process), Port: c.Tgt.Port},
+			})
+		}
+	}
+
+	for _, c := range a.Connections {
+		src, err := a.resolveOutput(c.Src)
+		if err != nil {
+			return nil, err
+		}
+		tgts, err := a.resolveInputs(c.Tgt)
+		if err != nil {
+			return nil, err
+		}
+		for _, tgt := range tgts {
+			flat.Connections = append(flat.Connections, Connection{Src: src, Tgt: tgt})
+		}
+	}
+
+	return flat, nil
+}
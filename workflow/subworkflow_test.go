@@ -0,0 +1,268 @@
+package workflow
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+var nestedJSON = `
+{
+    "processes": {
+        "Equals": { "component": "Equals" },
+        "Choose": {
+            "workflow": {
+                "processes": {
+                    "Cond": { "component": "Cond" },
+                    "Copy": { "component": "Copy" }
+                },
+                "connections": [
+                    {
+                        "source": { "process": "Cond", "port": "Out" },
+                        "target": { "process": "Copy", "port": "In" }
+                    }
+                ],
+                "inputs": {
+                    "Cond": [{ "process": "Cond", "port": "Cond" }],
+                    "IfTrue": [{ "process": "Cond", "port": "True" }],
+                    "IfFalse": [{ "process": "Cond", "port": "False" }]
+                },
+                "outputs": {
+                    "Result": { "process": "Copy", "port": "Out" }
+                }
+            }
+        },
+        "Final": { "component": "Copy" }
+    },
+    "connections": [
+        {
+            "source": { "process": "Equals", "port": "Out" },
+            "target": { "process": "Choose", "port": "Cond" }
+        },
+        {
+            "source": { "process": "Choose", "port": "Result" },
+            "target": { "process": "Final", "port": "In" }
+        }
+    ]
+}
+`
+
+func TestRunSubWorkflow(t *testing.T) {
+	var desc *Desc
+	if err := json.Unmarshal([]byte(nestedJSON), &desc); err != nil {
+		t.Fatal(err)
+	}
+
+	w, err := New(Opt{FromDesc: desc})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if fn, err := w.FuncName("Choose/Cond"); err != nil {
+		t.Error(err)
+	} else if fn != "Cond" {
+		t.Errorf("expecting component %q but got %q", "Cond", fn)
+	}
+
+	ctx, err := createContext()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := w.SetParam(Port{Process: "Equals", Port: "A"}, "A"); err != nil {
+		t.Error(err)
+	}
+	if err := w.SetParam(Port{Process: "Equals", Port: "B"}, "A"); err != nil {
+		t.Error(err)
+	}
+	if err := w.SetParam(Port{Process: "Choose", Port: "IfTrue"}, "True"); err != nil {
+		t.Error(err)
+	}
+	if err := w.SetParam(Port{Process: "Choose", Port: "IfFalse"}, "False"); err != nil {
+		t.Error(err)
+	}
+	if err := w.SetParam(Port{Process: "Choose", Port: "Missing"}, "False"); err == nil {
+		t.Error("expecting error setting unexposed port")
+	}
+
+	if err := w.Run(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	if out, ok := w.Outputs[Port{Process: "Final", Port: "Out"}].(string); !ok {
+		t.Errorf("cannot read parameter Out")
+	} else if out != "True" {
+		t.Errorf("expecting output %q but got %q", "True", out)
+	}
+}
+
+func TestRunSubWorkflowFanOut(t *testing.T) {
+	// Both exposes a single input to two processes and is nested in Outer
+	both := &SubWorkflow{
+		Desc: Desc{
+			Processes: map[string]Process{
+				"A": {Component: "Copy"},
+				"B": {Component: "Copy"},
+			},
+		},
+		Inputs: map[string][]Port{
+			"In": {{Process: "A", Port: "In"}, {Process: "B", Port: "In"}},
+		},
+		Outputs: map[string]Port{
+			"A": {Process: "A", Port: "Out"},
+			"B": {Process: "B", Port: "Out"},
+		},
+	}
+	outer := &SubWorkflow{
+		Desc: Desc{
+			Processes: map[string]Process{
+				"Both": {Workflow: both},
+				"C":    {Component: "Copy"},
+			},
+		},
+		Inputs: map[string][]Port{
+			"In": {{Process: "Both", Port: "In"}, {Process: "C", Port: "In"}},
+		},
+		Outputs: map[string]Port{
+			"A": {Process: "Both", Port: "A"},
+			"B": {Process: "Both", Port: "B"},
+			"C": {Process: "C", Port: "Out"},
+		},
+	}
+	desc := &Desc{
+		Processes: map[string]Process{
+			"Source": {Component: "Copy"},
+			"Outer":  {Workflow: outer},
+			"Pair":   {Workflow: both},
+		},
+		Connections: []Connection{
+			{Src: Port{Process: "Source", Port: "Out"}, Tgt: Port{Process: "Outer", Port: "In"}},
+		},
+	}
+
+	w, err := New(Opt{FromDesc: desc})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, err := createContext()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := w.SetParam(Port{Process: "Source", Port: "In"}, "Connected"); err != nil {
+		t.Error(err)
+	}
+	if err := w.SetParam(Port{Process: "Pair", Port: "In"}, "Set"); err != nil {
+		t.Error(err)
+	}
+
+	if err := w.Run(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	// outputs are named by the sub-workflow processes that expose them
+	for port, expected := range map[Port]string{
+		{Process: "Outer", Port: "A"}: "Connected",
+		{Process: "Outer", Port: "B"}: "Connected",
+		{Process: "Outer", Port: "C"}: "Connected",
+		{Process: "Pair", Port: "A"}:  "Set",
+		{Process: "Pair", Port: "B"}:  "Set",
+	} {
+		if out, ok := w.Outputs[port].(string); !ok {
+			t.Errorf("cannot read output %q", port)
+		} else if out != expected {
+			t.Errorf("expecting output %q of %q but got %q", expected, port, out)
+		}
+	}
+	if _, found := w.Outputs[Port{Process: "Outer/C", Port: "Out"}]; found {
+		t.Error("expecting exposed output under the name of the sub-workflow process only")
+	}
+}
+
+func TestFlattenErrors(t *testing.T) {
+	both := &Desc{
+		Processes: map[string]Process{
+			"A": {Component: "Copy", Workflow: &SubWorkflow{}},
+		},
+	}
+	if _, err := New(Opt{FromDesc: both}); err == nil {
+		t.Error("expecting error for process with component and workflow")
+	}
+
+	unknown := &Desc{
+		Processes: map[string]Process{
+			"A": {Workflow: &SubWorkflow{}},
+			"B": {Component: "Copy"},
+		},
+		Connections: []Connection{
+			{Src: Port{Process: "A", Port: "Out"}, Tgt: Port{Process: "B", Port: "In"}},
+		},
+	}
+	if _, err := New(Opt{FromDesc: unknown}); err == nil {
+		t.Error("expecting error for unexposed port")
+	}
+
+	// Copy.In is already the target of a connection within the sub-workflow
+	connectedIn := &Desc{
+		Processes: map[string]Process{
+			"A": {Workflow: &SubWorkflow{
+				Desc: Desc{
+					Processes: map[string]Process{
+						"First": {Component: "Copy"},
+						"Copy":  {Component: "Copy"},
+					},
+					Connections: []Connection{
+						{Src: Port{Process: "First", Port: "Out"}, Tgt: Port{Process: "Copy", Port: "In"}},
+					},
+				},
+				Inputs: map[string][]Port{
+					"In": {{Process: "First", Port: "In"}, {Process: "Copy", Port: "In"}},
+				},
+			}},
+		},
+	}
+	if _, err := New(Opt{FromDesc: connectedIn}); err == nil {
+		t.Error("expecting error for exposed input connected within sub-workflow")
+	}
+
+	connectedOut := &Desc{
+		Processes: map[string]Process{
+			"A": {Workflow: &SubWorkflow{
+				Desc: Desc{
+					Processes: map[string]Process{
+						"First": {Component: "Copy"},
+						"Copy":  {Component: "Copy"},
+					},
+					Connections: []Connection{
+						{Src: Port{Process: "First", Port: "Out"}, Tgt: Port{Process: "Copy", Port: "In"}},
+					},
+				},
+				Outputs: map[string]Port{
+					"Out": {Process: "First", Port: "Out"},
+				},
+			}},
+		},
+	}
+	if _, err := New(Opt{FromDesc: connectedOut}); err == nil {
+		t.Error("expecting error for exposed output connected within sub-workflow")
+	}
+
+	twice := &Desc{
+		Processes: map[string]Process{
+			"A": {Workflow: &SubWorkflow{
+				Desc: Desc{
+					Processes: map[string]Process{
+						"Copy": {Component: "Copy"},
+					},
+				},
+				Inputs: map[string][]Port{
+					"In":    {{Process: "Copy", Port: "In"}},
+					"Other": {{Process: "Copy", Port: "In"}},
+				},
+			}},
+		},
+	}
+	if _, err := New(Opt{FromDesc: twice}); err == nil {
+		t.Error("expecting error for port exposed by two inputs")
+	}
+}
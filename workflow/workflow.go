@@ -7,14 +7,13 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"sort"
 	"sync"
 
 	api "github.com/antha-lang/antha/api/v1"
 	"github.com/antha-lang/antha/inject"
 )
 
-// TODO: deterministic node name/order
-
 var (
 	errCyclicWorkflow  = errors.New("cyclic workflow")
 	errUnknownPort     = errors.New("unknown port")
@@ -34,9 +33,12 @@ func (a Port) String() string {
 	return fmt.Sprintf("%s.%s", a.Process, a.Port)
 }
 
-// A Process is an instance of a component / element execution
+// A Process is an instance of a component / element execution, of a
+// sub-workflow or of a control construct
 type Process struct {
 	Component string         `json:"component"`
+	Workflow  *SubWorkflow   `json:"workflow,omitempty"`
+	Control   *Control       `json:"control,omitempty"`
 	Metadata  screenPosition `json:"metadata"`
 }
 
@@ -67,12 +69,14 @@ func (a endpoint) String() string {
 }
 
 type node struct {
-	lock     sync.Mutex            // Lock on Params and Ins during Execute
+	lock     sync.Mutex            // Lock on Params, Ins and Skipped during Execute
 	Process  string                // Name of this instance
 	FuncName string                // Function that should be called
+	Control  *Control              // Control construct, if any
 	Params   inject.Value          // Parameters to this function
 	Outs     map[string][]endpoint // Out edges
 	Ins      map[string]bool       // In edges
+	Skipped  bool                  // On a branch not taken by a conditional
 }
 
 func (a *node) removeIn(port string) error {
@@ -85,6 +89,12 @@ func (a *node) removeIn(port string) error {
 	return nil
 }
 
+func (a *node) skip() {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	a.Skipped = true
+}
+
 func (a *node) hasIns() bool {
 	return len(a.Ins) > 0
 }
@@ -101,8 +111,25 @@ func (a *node) setParam(port string, value interface{}) error {
 
 // Workflow is the state to execute a workflow
 type Workflow struct {
-	nodes   map[string]*node
-	Outputs map[Port]interface{} // Values generated that were not connected to another process
+	lock           sync.Mutex // Lock on nodes and Outputs during Run
+	desc           *Desc      // Description before flattening sub-workflows
+	nodes          map[string]*node
+	order          []string
+	maxConcurrency int
+	processContext func(context.Context, string) context.Context
+	checkpointer   Checkpointer
+	exposed        map[Port]Port // Sub-workflow outputs by the inner port exposing them
+	// Values generated that were not connected to another process. Values of
+	// sub-workflow outputs are keyed by the output of the sub-workflow
+	// process, other values from within sub-workflows by the inner port.
+	Outputs map[Port]interface{}
+}
+
+// Order returns the names of the processes in the order they were
+// scheduled by the last call to Run. The order depends only on the
+// structure of the workflow and not on how long each process takes to run.
+func (a *Workflow) Order() []string {
+	return a.order
 }
 
 // FuncName gets the function to be called for the given process name
@@ -114,24 +141,44 @@ func (a *Workflow) FuncName(process string) (string, error) {
 	return n.FuncName, nil
 }
 
+// ResolvePorts returns the ports of the processes that are run for the
+// given input port. Ports of sub-workflow processes resolve to the ports of
+// the inner processes that they expose; other ports are returned unchanged.
+func (a *Workflow) ResolvePorts(port Port) ([]Port, error) {
+	return a.desc.resolveInputs(port)
+}
+
 // SetParam sets initial parameter values before executing
 func (a *Workflow) SetParam(port Port, value interface{}) error {
-	n := a.nodes[port.Process]
-	if n == nil {
-		return errUnknownPort
-	} else if n.Ins[port.Port] {
-		return errAlreadyAssigned
-	} else {
-		return n.setParam(port.Port, value)
+	ports, err := a.ResolvePorts(port)
+	if err != nil {
+		return err
 	}
+	for _, port := range ports {
+		n := a.nodes[port.Process]
+		if n == nil {
+			return errUnknownPort
+		} else if n.Ins[port.Port] {
+			return errAlreadyAssigned
+		} else if err := n.setParam(port.Port, value); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
-func updateOutParams(n *node, out inject.Value, unmatched map[Port]interface{}) error {
+func updateOutParams(n *node, out inject.Value, skipped map[string]bool, unmatched map[Port]interface{}, exposed map[Port]Port) error {
 	seen := make(map[string]bool)
+	for name := range skipped {
+		seen[name] = true
+	}
 	for name, value := range out {
 		seen[name] = true
 		if eps := n.Outs[name]; len(eps) == 0 {
 			port := Port{Port: name, Process: n.Process}
+			if ep, ok := exposed[port]; ok {
+				port = ep
+			}
 			if _, seen := unmatched[port]; seen {
 				return fmt.Errorf("%q already assigned", endpoint{Port: name, Node: n})
 			}
@@ -157,19 +204,46 @@ func (a *Workflow) run(ctx context.Context, n *node) ([]*node, error) {
 		Repo:  n.FuncName,
 		Stage: api.ElementStage_STEPS,
 	}
-	out, err := inject.Call(ctx, query, n.Params)
+	if a.processContext != nil {
+		ctx = a.processContext(ctx, n.Process)
+	}
+
+	var out inject.Value
+	var skipped map[string]bool
+	var err error
+	switch {
+	case n.Skipped:
+		skipped = make(map[string]bool)
+		for name := range n.Outs {
+			skipped[name] = true
+		}
+	case n.Control == nil:
+		out, err = a.call(ctx, n, query, n.Params)
+	case n.Control.Kind == MapControl:
+		out, err = a.callMap(ctx, n, query)
+	case n.Control.Kind == CondControl:
+		out, skipped, err = callCond(n)
+	default:
+		err = fmt.Errorf("unknown control %q", n.Control.Kind)
+	}
 
 	if err != nil {
 		return nil, err
 	}
 
-	if err := updateOutParams(n, out, a.Outputs); err != nil {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+
+	if err := updateOutParams(n, out, skipped, a.Outputs, a.exposed); err != nil {
 		return nil, err
 	}
 
 	var roots []*node
-	for _, eps := range n.Outs {
+	for name, eps := range n.Outs {
 		for _, ep := range eps {
+			if skipped[name] {
+				ep.Node.skip()
+			}
 			if err := ep.Node.removeIn(ep.Port); err != nil {
 				return nil, fmt.Errorf("error removing in edge on %q: %s", ep, err)
 			} else if !ep.Node.hasIns() {
@@ -178,9 +252,40 @@ func (a *Workflow) run(ctx context.Context, n *node) ([]*node, error) {
 		}
 	}
 	delete(a.nodes, n.Process)
+	sortNodes(roots)
 	return roots, nil
 }
 
+func sortNodes(nodes []*node) {
+	sort.Slice(nodes, func(i, j int) bool {
+		return nodes[i].Process < nodes[j].Process
+	})
+}
+
+// call runs the function of a process, reusing the outputs saved by the
+// checkpointer if there are any
+func (a *Workflow) call(ctx context.Context, n *node, query inject.NameQuery, params inject.Value) (inject.Value, error) {
+	if a.checkpointer == nil {
+		return inject.Call(ctx, query, params)
+	}
+
+	if out, found, err := a.checkpointer.Load(ctx, n.Process, n.FuncName, params); err != nil {
+		return nil, fmt.Errorf("cannot load checkpoint: %s", err)
+	} else if found {
+		return out, nil
+	}
+
+	out, err := inject.Call(ctx, query, params)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := a.checkpointer.Save(ctx, n.Process, n.FuncName, params, out); err != nil {
+		return nil, fmt.Errorf("cannot save checkpoint: %s", err)
+	}
+	return out, nil
+}
+
 func makeRoots(nodes map[string]*node) ([]*node, error) {
 	var roots []*node
 	for _, n := range nodes {
@@ -191,28 +296,69 @@ func makeRoots(nodes map[string]*node) ([]*node, error) {
 	if len(roots) == 0 && len(nodes) > 0 {
 		return nil, errCyclicWorkflow
 	}
+	sortNodes(roots)
 	return roots, nil
 }
 
-// Run a workflow
-func (a *Workflow) Run(ctx context.Context) error {
+// schedule returns the order in which processes would be run one at a time
+// without running them. Ready processes are taken in the order they become
+// ready, with ties broken by process name.
+func (a *Workflow) schedule() ([]*node, error) {
 	worklist, err := makeRoots(a.nodes)
 	if err != nil {
-		return err
+		return nil, err
+	}
+
+	ins := make(map[*node]int)
+	for _, n := range a.nodes {
+		ins[n] = len(n.Ins)
 	}
 
+	var order []*node
 	for len(worklist) > 0 {
 		n := worklist[0]
 		worklist = worklist[1:]
+		order = append(order, n)
 
-		if moreWork, err := a.run(ctx, n); err != nil {
-			return fmt.Errorf("cannot run process %q: %s", n.Process, err)
-		} else {
-			worklist = append(worklist, moreWork...)
+		var moreWork []*node
+		for _, eps := range n.Outs {
+			for _, ep := range eps {
+				ins[ep.Node]--
+				if ins[ep.Node] == 0 {
+					moreWork = append(moreWork, ep.Node)
+				}
+			}
 		}
+		sortNodes(moreWork)
+		worklist = append(worklist, moreWork...)
+	}
+	if len(order) != len(a.nodes) {
+		return nil, errCyclicWorkflow
+	}
+
+	return order, nil
+}
+
+// Run a workflow
+func (a *Workflow) Run(ctx context.Context) error {
+	order, err := a.schedule()
+	if err != nil {
+		return err
+	}
+
+	a.order = nil
+	for _, n := range order {
+		a.order = append(a.order, n.Process)
+	}
+
+	if a.maxConcurrency > 1 {
+		return a.runConcurrent(ctx, order)
 	}
-	if len(a.nodes) > 0 { // by definition, len(worklist) == 0
-		return errCyclicWorkflow
+
+	for _, n := range order {
+		if _, err := a.run(ctx, n); err != nil {
+			return Errors{&ProcessError{Process: n.Process, Err: err}}
+		}
 	}
 
 	return nil
@@ -234,6 +380,19 @@ func (a *Workflow) AddNode(process, funcName string) error {
 	return nil
 }
 
+// AddControlNode adds a process to a workflow that executes a control
+// construct. For MapControl, funcName is the function run for each element.
+func (a *Workflow) AddControlNode(process, funcName string, control Control) error {
+	if err := control.validate(funcName); err != nil {
+		return fmt.Errorf("process %q: %s", process, err)
+	}
+	if err := a.AddNode(process, funcName); err != nil {
+		return err
+	}
+	a.nodes[process].Control = &control
+	return nil
+}
+
 // AddEdge connects an output of one process to an input of another
 func (a *Workflow) AddEdge(src, tgt Port) error {
 	snode := a.nodes[src.Process]
@@ -258,24 +417,57 @@ func (a *Workflow) AddEdge(src, tgt Port) error {
 // Opt are options for creating a new Workflow
 type Opt struct {
 	FromDesc *Desc
+	// MaxConcurrency is the maximum number of processes to run at the same
+	// time. Values less than two run processes one at a time.
+	MaxConcurrency int
+	// ProcessContext, if not nil, is called to create the context each
+	// process is run with.
+	ProcessContext func(ctx context.Context, process string) context.Context
+	// Checkpointer, if not nil, saves the outputs of each process and
+	// supplies them again when a process is run with the same parameters.
+	Checkpointer Checkpointer
+}
+
+// A Checkpointer stores the outputs of processes so that a later run can
+// reuse them instead of calling the process function again
+type Checkpointer interface {
+	// Load returns the outputs saved for a process run with the given
+	// parameters. The boolean result is false if nothing was saved.
+	Load(ctx context.Context, process, funcName string, params inject.Value) (inject.Value, bool, error)
+	// Save records the outputs of a process run with the given parameters
+	Save(ctx context.Context, process, funcName string, params, out inject.Value) error
 }
 
 // New creates a new Workflow
 func New(opt Opt) (*Workflow, error) {
 	w := &Workflow{
-		nodes:   make(map[string]*node),
-		Outputs: make(map[Port]interface{}),
+		nodes:          make(map[string]*node),
+		maxConcurrency: opt.MaxConcurrency,
+		processContext: opt.ProcessContext,
+		checkpointer:   opt.Checkpointer,
+		Outputs:        make(map[Port]interface{}),
 	}
 
-	var desc *Desc
 	if opt.FromDesc != nil {
-		desc = opt.FromDesc
+		w.desc = opt.FromDesc
 	} else {
-		desc = &Desc{}
+		w.desc = &Desc{}
+	}
+
+	desc, err := w.desc.flatten()
+	if err != nil {
+		return nil, err
+	}
+	if w.exposed, err = w.desc.exposedOutputs(); err != nil {
+		return nil, err
 	}
 
 	for name, process := range desc.Processes {
-		if err := w.AddNode(name, process.Component); err != nil {
+		if process.Control != nil {
+			if err := w.AddControlNode(name, process.Component, *process.Control); err != nil {
+				return nil, err
+			}
+		} else if err := w.AddNode(name, process.Component); err != nil {
 			return nil, err
 		}
 	}
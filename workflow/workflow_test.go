@@ -168,3 +168,90 @@ func TestDuplicateIns(t *testing.T) {
 		t.Errorf("expecting error setting in port")
 	}
 }
+
+func makeFanOut(t *testing.T, opt Opt, n int) *Workflow {
+	w, err := New(opt)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := w.AddNode("Equals", "Equals"); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < n; i++ {
+		cond := fmt.Sprintf("Cond%d", i)
+		if err := w.AddNode(cond, "Cond"); err != nil {
+			t.Fatal(err)
+		}
+		if err := w.AddEdge(Port{Process: "Equals", Port: "Out"}, Port{Process: cond, Port: "Cond"}); err != nil {
+			t.Fatal(err)
+		}
+		if err := w.SetParam(Port{Process: cond, Port: "True"}, "True"); err != nil {
+			t.Fatal(err)
+		}
+		if err := w.SetParam(Port{Process: cond, Port: "False"}, cond); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := w.SetParam(Port{Process: "Equals", Port: "A"}, "A"); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.SetParam(Port{Process: "Equals", Port: "B"}, "B"); err != nil {
+		t.Fatal(err)
+	}
+	return w
+}
+
+func TestRunConcurrent(t *testing.T) {
+	ctx, err := createContext()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var serialOrder []string
+	for _, c := range []int{0, 4} {
+		w := makeFanOut(t, Opt{MaxConcurrency: c}, 10)
+		if err := w.Run(ctx); err != nil {
+			t.Fatal(err)
+		}
+
+		for i := 0; i < 10; i++ {
+			cond := fmt.Sprintf("Cond%d", i)
+			if out, ok := w.Outputs[Port{Process: cond, Port: "Out"}].(string); !ok {
+				t.Errorf("cannot read parameter Out of %s", cond)
+			} else if out != cond {
+				t.Errorf("expecting output %q but got %q", cond, out)
+			}
+		}
+
+		if serialOrder == nil {
+			serialOrder = w.Order()
+		} else if fmt.Sprint(serialOrder) != fmt.Sprint(w.Order()) {
+			t.Errorf("expecting order %v but got %v", serialOrder, w.Order())
+		}
+	}
+	if len(serialOrder) != 11 || serialOrder[0] != "Equals" {
+		t.Errorf("unexpected order %v", serialOrder)
+	}
+}
+
+func TestRunConcurrentError(t *testing.T) {
+	ctx, err := createContext()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w := makeFanOut(t, Opt{MaxConcurrency: 4}, 3)
+	// Cond1 is missing its True parameter
+	w.nodes["Cond1"].Params = inject.Value{"False": "False"}
+
+	err = w.Run(ctx)
+	errs, ok := err.(Errors)
+	if !ok {
+		t.Fatalf("expecting Errors but got %v", err)
+	}
+	if len(errs) != 1 || errs[0].Process != "Cond1" {
+		t.Errorf("expecting error from Cond1 but got %v", errs)
+	}
+}